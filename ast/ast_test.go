@@ -117,6 +117,36 @@ func TestASTByTable(t *testing.T) {
 			ast.Permit().ResourceIsIn("T", types.NewEntityUID("T", "42")),
 			internalast.Permit().ResourceIsIn("T", types.NewEntityUID("T", "42")),
 		},
+		{
+			"scopePrincipalEqSlot",
+			ast.Permit().PrincipalEqSlot(),
+			internalast.Permit().PrincipalEqSlot(),
+		},
+		{
+			"scopePrincipalInSlot",
+			ast.Permit().PrincipalInSlot(),
+			internalast.Permit().PrincipalInSlot(),
+		},
+		{
+			"scopePrincipalIsInSlot",
+			ast.Permit().PrincipalIsInSlot("T"),
+			internalast.Permit().PrincipalIsInSlot("T"),
+		},
+		{
+			"scopeResourceEqSlot",
+			ast.Permit().ResourceEqSlot(),
+			internalast.Permit().ResourceEqSlot(),
+		},
+		{
+			"scopeResourceInSlot",
+			ast.Permit().ResourceInSlot(),
+			internalast.Permit().ResourceInSlot(),
+		},
+		{
+			"scopeResourceIsInSlot",
+			ast.Permit().ResourceIsInSlot("T"),
+			internalast.Permit().ResourceIsInSlot("T"),
+		},
 		{
 			"variablePrincipal",
 			ast.Permit().When(ast.Principal()),
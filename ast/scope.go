@@ -24,6 +24,22 @@ func (p *Policy) PrincipalIsIn(entityType types.EntityType, entity types.EntityU
 	return wrapPolicy(p.unwrap().PrincipalIsIn(entityType, entity))
 }
 
+// PrincipalEqSlot replaces the principal scope condition with an equality test against the ?principal slot.
+func (p *Policy) PrincipalEqSlot() *Policy {
+	return wrapPolicy(p.unwrap().PrincipalEqSlot())
+}
+
+// PrincipalInSlot replaces the principal scope condition with a membership test against the ?principal slot.
+func (p *Policy) PrincipalInSlot() *Policy {
+	return wrapPolicy(p.unwrap().PrincipalInSlot())
+}
+
+// PrincipalIsInSlot replaces the principal scope condition with a type and membership test against the ?principal
+// slot.
+func (p *Policy) PrincipalIsInSlot(entityType types.EntityType) *Policy {
+	return wrapPolicy(p.unwrap().PrincipalIsInSlot(entityType))
+}
+
 // ActionEq replaces the action scope condition.
 func (p *Policy) ActionEq(entity types.EntityUID) *Policy {
 	return wrapPolicy(p.unwrap().ActionEq(entity))
@@ -58,3 +74,18 @@ func (p *Policy) ResourceIs(entityType types.EntityType) *Policy {
 func (p *Policy) ResourceIsIn(entityType types.EntityType, entity types.EntityUID) *Policy {
 	return wrapPolicy(p.unwrap().ResourceIsIn(entityType, entity))
 }
+
+// ResourceEqSlot replaces the resource scope condition with an equality test against the ?resource slot.
+func (p *Policy) ResourceEqSlot() *Policy {
+	return wrapPolicy(p.unwrap().ResourceEqSlot())
+}
+
+// ResourceInSlot replaces the resource scope condition with a membership test against the ?resource slot.
+func (p *Policy) ResourceInSlot() *Policy {
+	return wrapPolicy(p.unwrap().ResourceInSlot())
+}
+
+// ResourceIsInSlot replaces the resource scope condition with a type and membership test against the ?resource slot.
+func (p *Policy) ResourceIsInSlot(entityType types.EntityType) *Policy {
+	return wrapPolicy(p.unwrap().ResourceIsInSlot(entityType))
+}
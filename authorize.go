@@ -1,6 +1,7 @@
 package cedar
 
 import (
+	"context"
 	"iter"
 
 	"github.com/cedar-policy/cedar-go/internal/eval"
@@ -13,20 +14,84 @@ type PolicyIterator interface {
 	All() iter.Seq2[PolicyID, *Policy]
 }
 
+// candidatePolicies is implemented by policy sets which can select the policies that may apply to a request.
+type candidatePolicies interface {
+	candidates(entities types.EntityGetter, req Request) iter.Seq2[PolicyID, *Policy]
+}
+
 // Authorize uses the combination of the PolicySet and Entities to determine
 // if the given Request to determine Decision and Diagnostic.
 func Authorize(policies PolicyIterator, entities types.EntityGetter, req Request) (Decision, Diagnostic) {
+	return authorize(context.Background(), policies, entities, req, nil)
+}
+
+// A Budget limits the work done by AuthorizeContext, across all of the policies which it evaluates.  A zero limit
+// means no limit.
+type Budget struct {
+	// MaxSteps limits the number of expressions evaluated.
+	MaxSteps int
+	// MaxEntityLookups limits the number of times an entity is looked up in the EntityGetter.
+	MaxEntityLookups int
+	// MaxSetSize limits the number of elements of each set produced during evaluation.
+	MaxSetSize int
+}
+
+// ErrBudgetExceeded is wrapped by the Aborted error of the Diagnostic returned by AuthorizeContext when authorization
+// exceeds its Budget.
+var ErrBudgetExceeded = eval.ErrBudgetExceeded
+
+// AuthorizeContext is like Authorize, but stops evaluating policies once the context is done or the Budget is exceeded.
+// The context is checked between policies.  A zero Budget imposes no limits, and avoids the cost of counting the work
+// done by evaluation.
+//
+// When authorization is stopped, AuthorizeContext returns Deny and a Diagnostic without reasons whose Aborted error is
+// either the error of the context or wraps ErrBudgetExceeded.  The errors of the Diagnostic are those of the policies
+// evaluated so far, including the policy whose evaluation exceeded the Budget.
+//
+// If the entities implement EntityLoader, they are loaded with the context.
+func AuthorizeContext(ctx context.Context, policies PolicyIterator, entities types.EntityGetter, req Request, budget Budget) (Decision, Diagnostic) {
+	if budget == (Budget{}) {
+		return authorize(ctx, policies, entities, req, nil)
+	}
+	return authorize(ctx, policies, entities, req, &eval.Budget{
+		MaxSteps:         budget.MaxSteps,
+		MaxEntityLookups: budget.MaxEntityLookups,
+		MaxSetSize:       budget.MaxSetSize,
+	})
+}
+
+func authorize(ctx context.Context, policies PolicyIterator, entities types.EntityGetter, req Request, budget *eval.Budget) (Decision, Diagnostic) {
+	env, loaded := newEnv(ctx, entities, req, budget)
+	return authorizeEnv(ctx, policies, env, loaded, req)
+}
+
+// newEnv returns the Env in which to evaluate policies for the request.  If the entities implement EntityLoader, it
+// also returns the loadedEntities through which they are loaded.
+func newEnv(ctx context.Context, entities types.EntityGetter, req Request, budget *eval.Budget) (eval.Env, *loadedEntities) {
 	if entities == nil {
 		var zero types.EntityMap
 		entities = zero
 	}
-	env := eval.Env{
+	var loaded *loadedEntities
+	if loader, ok := entities.(types.EntityLoader); ok {
+		loaded = newLoadedEntities(ctx, loader, req)
+		entities = loaded
+	}
+	if budget != nil {
+		entities = budget.Entities(entities)
+	}
+	return eval.Env{
 		Entities:  entities,
 		Principal: req.Principal,
 		Action:    req.Action,
 		Resource:  req.Resource,
 		Context:   req.Context,
-	}
+		Budget:    budget,
+	}, loaded
+}
+
+func authorizeEnv(ctx context.Context, policies PolicyIterator, env eval.Env, loaded *loadedEntities, req Request) (Decision, Diagnostic) {
+	budget := env.Budget
 	var diag Diagnostic
 	var forbids []DiagnosticReason
 	var permits []DiagnosticReason
@@ -35,8 +100,36 @@ func Authorize(policies PolicyIterator, entities types.EntityGetter, req Request
 	// - All policy should be run to collect errors
 	// - For permit, all permits must be run to collect annotations
 	// - For forbid, forbids must be run to collect annotations
-	for id, po := range policies.All() {
-		result, err := po.eval.Eval(env)
+	all := policies.All()
+	if c, ok := policies.(candidatePolicies); ok {
+		all = c.candidates(env.Entities, req)
+		// If entities failed to load, the candidates may be missing policies which would match the request, so
+		// evaluate all of them instead, each reporting the error if it needs the entities.
+		if loaded != nil && loaded.takeErr() != nil {
+			all = policies.All()
+		}
+	}
+	for id, po := range all {
+		if err := abortError(ctx, budget); err != nil {
+			diag.Aborted = err
+			return Deny, diag
+		}
+		var result types.Boolean
+		var err error
+		if budget != nil {
+			result, err = po.evalWithBudget().Eval(env)
+			if budget.Err() != nil {
+				err = budget.Err()
+			}
+		} else {
+			result, err = po.eval.Eval(env)
+		}
+		if loaded != nil {
+			if lerr := loaded.takeErr(); lerr != nil {
+				diag.Errors = append(diag.Errors, DiagnosticError{PolicyID: id, Position: po.Position(), Message: lerr.Error(), Kind: EntityLoadError})
+				continue
+			}
+		}
 		if err != nil {
 			diag.Errors = append(diag.Errors, DiagnosticError{PolicyID: id, Position: po.Position(), Message: err.Error()})
 			continue
@@ -50,6 +143,10 @@ func Authorize(policies PolicyIterator, entities types.EntityGetter, req Request
 			permits = append(permits, DiagnosticReason{PolicyID: id, Position: po.Position()})
 		}
 	}
+	if err := abortError(ctx, budget); err != nil {
+		diag.Aborted = err
+		return Deny, diag
+	}
 	if len(forbids) > 0 {
 		diag.Reasons = forbids
 		return Deny, diag
@@ -60,3 +157,14 @@ func Authorize(policies PolicyIterator, entities types.EntityGetter, req Request
 	}
 	return Deny, diag
 }
+
+// abortError returns the error which should stop authorization, if any.
+func abortError(ctx context.Context, budget *eval.Budget) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if budget != nil {
+		return budget.Err()
+	}
+	return nil
+}
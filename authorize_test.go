@@ -1,6 +1,7 @@
 package cedar_test
 
 import (
+	"context"
 	"testing"
 
 	"github.com/cedar-policy/cedar-go"
@@ -844,6 +845,98 @@ func TestIsAuthorized(t *testing.T) {
 			})
 			testutil.Equals(t, len(diag.Errors), tt.DiagErr)
 			testutil.Equals(t, ok, tt.Want)
+
+			ok, diag = cedar.Authorize(cedar.NewIndexedPolicySet(ps), tt.Entities, cedar.Request{
+				Principal: tt.Principal,
+				Action:    tt.Action,
+				Resource:  tt.Resource,
+				Context:   tt.Context,
+			})
+			testutil.Equals(t, len(diag.Errors), tt.DiagErr)
+			testutil.Equals(t, ok, tt.Want)
+		})
+	}
+}
+
+func TestAuthorizeContext(t *testing.T) {
+	t.Parallel()
+	ps, err := cedar.NewPolicySetFromBytes("policy.cedar", []byte(`
+permit(principal, action, resource) when { context.groups.contains(principal) };
+permit(principal in Group::"staff", action, resource);
+forbid(principal, action, resource) when { principal.locked };
+`))
+	testutil.OK(t, err)
+	alice := cedar.NewEntityUID("User", "alice")
+	staff := cedar.NewEntityUID("Group", "staff")
+	entities := cedar.EntityMap{alice: {
+		UID:        alice,
+		Parents:    cedar.NewEntityUIDSet(staff),
+		Attributes: cedar.NewRecord(cedar.RecordMap{"locked": cedar.False}),
+	}}
+	req := cedar.Request{
+		Principal: alice,
+		Context:   cedar.NewRecord(cedar.RecordMap{"groups": cedar.NewSet(alice, staff)}),
+	}
+	cancelled, cancel := context.WithCancel(context.Background())
+	cancel()
+	tests := []struct {
+		name        string
+		ctx         context.Context
+		budget      cedar.Budget
+		want        cedar.Decision
+		wantReasons int
+		wantErrors  []string
+		wantAborted error
+		plainOnly   bool // the index looks up entities itself
+	}{
+		{"unlimited", context.Background(), cedar.Budget{}, cedar.Allow, 2, nil, nil, false},
+		{"enough", context.Background(), cedar.Budget{MaxSteps: 100, MaxEntityLookups: 10, MaxSetSize: 2}, cedar.Allow, 2, nil, nil, false},
+		{"cancelled", cancelled, cedar.Budget{}, cedar.Deny, 0, nil, context.Canceled, false},
+		{"steps", context.Background(), cedar.Budget{MaxSteps: 3}, cedar.Deny, 0,
+			[]string{"evaluation budget exceeded: more than 3 steps"}, cedar.ErrBudgetExceeded, false},
+		{"lookups", context.Background(), cedar.Budget{MaxEntityLookups: 1}, cedar.Deny, 0,
+			[]string{"evaluation budget exceeded: more than 1 entity lookups"}, cedar.ErrBudgetExceeded, true},
+		{"setSize", context.Background(), cedar.Budget{MaxSetSize: 1}, cedar.Deny, 0,
+			[]string{"evaluation budget exceeded: set of 2 elements is larger than 1"}, cedar.ErrBudgetExceeded, false},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			policySets := []cedar.PolicyIterator{ps, cedar.NewIndexedPolicySet(ps)}
+			if tt.plainOnly {
+				policySets = policySets[:1]
+			}
+			for _, policies := range policySets {
+				got, diag := cedar.AuthorizeContext(tt.ctx, policies, entities, req, tt.budget)
+				testutil.Equals(t, got, tt.want)
+				testutil.Equals(t, len(diag.Reasons), tt.wantReasons)
+				var errs []string
+				for _, e := range diag.Errors {
+					errs = append(errs, e.Message)
+				}
+				testutil.Equals(t, errs, tt.wantErrors)
+				if tt.wantAborted == nil {
+					testutil.OK(t, diag.Aborted)
+				} else {
+					testutil.ErrorIs(t, diag.Aborted, tt.wantAborted)
+				}
+			}
 		})
 	}
 }
+
+func TestAuthorizeContextIndexLookups(t *testing.T) {
+	t.Parallel()
+	ps, err := cedar.NewPolicySetFromBytes("policy.cedar", []byte(`permit(principal in Group::"staff", action, resource);`))
+	testutil.OK(t, err)
+	alice := cedar.NewEntityUID("User", "alice")
+	entities := cedar.EntityMap{alice: {UID: alice}}
+
+	// The lookups of the index exceed the budget before any policy is evaluated.
+	got, diag := cedar.AuthorizeContext(context.Background(), cedar.NewIndexedPolicySet(ps), entities,
+		cedar.Request{Principal: alice}, cedar.Budget{MaxEntityLookups: 1})
+	testutil.Equals(t, got, cedar.Deny)
+	testutil.Equals(t, diag.Errors, nil)
+	testutil.ErrorIs(t, diag.Aborted, cedar.ErrBudgetExceeded)
+}
@@ -0,0 +1,5 @@
+// Package example holds the code which cedar-gen generates for an example schema.  It is used to test the command and
+// the generated code.
+package example
+
+//go:generate go run github.com/cedar-policy/cedar-go/cmd/cedar-gen -package example -o entities.go schema.json
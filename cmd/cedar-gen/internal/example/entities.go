@@ -0,0 +1,213 @@
+// Code generated from a Cedar schema. DO NOT EDIT.
+
+package example
+
+import "github.com/cedar-policy/cedar-go/types"
+
+// GroupType is the Cedar entity type PhotoApp::Group.
+const GroupType types.EntityType = "PhotoApp::Group"
+
+// NewGroupUID returns the UID of the PhotoApp::Group entity with the given ID.
+func NewGroupUID(id types.String) types.EntityUID {
+	return types.NewEntityUID(GroupType, id)
+}
+
+// Group is an entity of type PhotoApp::Group.
+type Group struct {
+	ID types.String
+}
+
+// UID returns the UID of the entity.
+func (e Group) UID() types.EntityUID {
+	return NewGroupUID(e.ID)
+}
+
+// Record returns the attributes of the entity.
+func (e Group) Record() types.Record {
+	return types.Record{}
+}
+
+// Entity returns the entity.
+func (e Group) Entity() types.Entity {
+	return types.Entity{
+		UID:        e.UID(),
+		Parents:    types.NewEntityUIDSet(),
+		Attributes: e.Record(),
+	}
+}
+
+// UserType is the Cedar entity type PhotoApp::User.
+const UserType types.EntityType = "PhotoApp::User"
+
+// NewUserUID returns the UID of the PhotoApp::User entity with the given ID.
+func NewUserUID(id types.String) types.EntityUID {
+	return types.NewEntityUID(UserType, id)
+}
+
+// User is an entity of type PhotoApp::User.
+type User struct {
+	ID              types.String
+	Parents         []types.EntityUID
+	Department      types.String
+	Age             *types.Long
+	Address         UserAddress
+	PreviousAddress *UserAddress
+	Manager         *types.EntityUID
+	Roles           []types.String
+	Logins          []UserLogins
+	Tags            map[types.String]types.String
+}
+
+// UID returns the UID of the entity.
+func (e User) UID() types.EntityUID {
+	return NewUserUID(e.ID)
+}
+
+// Record returns the attributes of the entity.
+func (e User) Record() types.Record {
+	m := types.RecordMap{
+		"department": e.Department,
+		"address":    e.Address.Record(),
+		"roles":      newSet(e.Roles, func(v types.String) types.Value { return v }),
+		"logins":     newSet(e.Logins, func(v UserLogins) types.Value { return v.Record() }),
+	}
+	if e.Age != nil {
+		m["age"] = *e.Age
+	}
+	if e.PreviousAddress != nil {
+		m["previousAddress"] = e.PreviousAddress.Record()
+	}
+	if e.Manager != nil {
+		m["manager"] = *e.Manager
+	}
+	return types.NewRecord(m)
+}
+
+// Entity returns the entity.
+func (e User) Entity() types.Entity {
+	tags := make(types.RecordMap, len(e.Tags))
+	for k, v := range e.Tags {
+		tags[k] = v
+	}
+	return types.Entity{
+		UID:        e.UID(),
+		Parents:    types.NewEntityUIDSet(e.Parents...),
+		Attributes: e.Record(),
+		Tags:       types.NewRecord(tags),
+	}
+}
+
+// PhotoType is the Cedar entity type PhotoApp::Photo.
+const PhotoType types.EntityType = "PhotoApp::Photo"
+
+// NewPhotoUID returns the UID of the PhotoApp::Photo entity with the given ID.
+func NewPhotoUID(id types.String) types.EntityUID {
+	return types.NewEntityUID(PhotoType, id)
+}
+
+// Photo is an entity of type PhotoApp::Photo.
+type Photo struct {
+	ID      types.String
+	Owner   types.EntityUID
+	Private types.Boolean
+}
+
+// UID returns the UID of the entity.
+func (e Photo) UID() types.EntityUID {
+	return NewPhotoUID(e.ID)
+}
+
+// Record returns the attributes of the entity.
+func (e Photo) Record() types.Record {
+	m := types.RecordMap{
+		"owner":   e.Owner,
+		"private": e.Private,
+	}
+	return types.NewRecord(m)
+}
+
+// Entity returns the entity.
+func (e Photo) Entity() types.Entity {
+	return types.Entity{
+		UID:        e.UID(),
+		Parents:    types.NewEntityUIDSet(),
+		Attributes: e.Record(),
+	}
+}
+
+// ColorType is the Cedar entity type PhotoApp::Color.
+const ColorType types.EntityType = "PhotoApp::Color"
+
+// NewColorUID returns the UID of the PhotoApp::Color entity with the given ID.
+func NewColorUID(id types.String) types.EntityUID {
+	return types.NewEntityUID(ColorType, id)
+}
+
+// The entities of the enumerated entity type PhotoApp::Color.
+var (
+	ColorRed       = NewColorUID("red")
+	ColorDarkGreen = NewColorUID("dark green")
+)
+
+// ActionView is the UID of the action PhotoApp::Action::"view".
+var ActionView = types.NewEntityUID("PhotoApp::Action", "view")
+
+// ActionEditPhoto is the UID of the action PhotoApp::Action::"edit photo".
+var ActionEditPhoto = types.NewEntityUID("PhotoApp::Action", "edit photo")
+
+// UserAddress is a record type used by User.
+type UserAddress struct {
+	Street types.String
+	City   *types.String
+}
+
+// Record returns the record.
+func (r UserAddress) Record() types.Record {
+	m := types.RecordMap{
+		"street": r.Street,
+	}
+	if r.City != nil {
+		m["city"] = *r.City
+	}
+	return types.NewRecord(m)
+}
+
+// UserLogins is a record type used by User.
+type UserLogins struct {
+	At types.Datetime
+	IP types.IPAddr
+}
+
+// Record returns the record.
+func (r UserLogins) Record() types.Record {
+	m := types.RecordMap{
+		"at": r.At,
+		"ip": r.IP,
+	}
+	return types.NewRecord(m)
+}
+
+// ViewContext is the context of a request for the action PhotoApp::Action::"view".
+type ViewContext struct {
+	IP  types.IPAddr
+	Mfa *types.Boolean
+}
+
+// Record returns the record.
+func (r ViewContext) Record() types.Record {
+	m := types.RecordMap{
+		"ip": r.IP,
+	}
+	if r.Mfa != nil {
+		m["mfa"] = *r.Mfa
+	}
+	return types.NewRecord(m)
+}
+
+func newSet[T any](vs []T, f func(T) types.Value) types.Set {
+	vals := make([]types.Value, len(vs))
+	for i, v := range vs {
+		vals[i] = f(v)
+	}
+	return types.NewSet(vals...)
+}
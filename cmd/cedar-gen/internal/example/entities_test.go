@@ -0,0 +1,99 @@
+package example_test
+
+import (
+	"os"
+	"testing"
+
+	"github.com/cedar-policy/cedar-go/cmd/cedar-gen/internal/example"
+	"github.com/cedar-policy/cedar-go/internal/testutil"
+	"github.com/cedar-policy/cedar-go/types"
+	"github.com/cedar-policy/cedar-go/x/exp/schema"
+)
+
+func loadSchema(t *testing.T) *schema.Schema {
+	t.Helper()
+	b, err := os.ReadFile("schema.json")
+	testutil.OK(t, err)
+	var s schema.Schema
+	testutil.OK(t, s.UnmarshalJSON(b))
+	return &s
+}
+
+func TestEntities(t *testing.T) {
+	t.Parallel()
+	age := types.Long(42)
+	admins := example.NewGroupUID("admins")
+	alice := example.User{
+		ID:         "alice",
+		Parents:    []types.EntityUID{admins},
+		Department: "engineering",
+		Age:        &age,
+		Address:    example.UserAddress{Street: "1 Main St"},
+		Roles:      []types.String{"admin"},
+		Logins: []example.UserLogins{
+			{At: types.NewDatetimeFromMillis(0), IP: testutil.Must(types.ParseIPAddr("10.0.0.1"))},
+		},
+		Tags: map[types.String]types.String{"team": "infra"},
+	}
+	photo := example.Photo{ID: "p", Owner: alice.UID(), Private: true}
+	entities := types.EntityMap{
+		admins:      example.Group{ID: "admins"}.Entity(),
+		alice.UID(): alice.Entity(),
+		photo.UID(): photo.Entity(),
+	}
+	testutil.Equals(t, entities[alice.UID()], types.Entity{
+		UID:     types.NewEntityUID("PhotoApp::User", "alice"),
+		Parents: types.NewEntityUIDSet(admins),
+		Attributes: types.NewRecord(types.RecordMap{
+			"department": types.String("engineering"),
+			"age":        types.Long(42),
+			"address":    types.NewRecord(types.RecordMap{"street": types.String("1 Main St")}),
+			"roles":      types.NewSet(types.String("admin")),
+			"logins": types.NewSet(types.NewRecord(types.RecordMap{
+				"at": types.NewDatetimeFromMillis(0),
+				"ip": testutil.Must(types.ParseIPAddr("10.0.0.1")),
+			})),
+		}),
+		Tags: types.NewRecord(types.RecordMap{"team": types.String("infra")}),
+	})
+
+	errs, err := schema.ValidateEntities(loadSchema(t), entities)
+	testutil.OK(t, err)
+	testutil.Equals(t, errs, nil)
+}
+
+func TestOptionalAttributes(t *testing.T) {
+	t.Parallel()
+	manager := example.NewUserUID("bob")
+	city := types.String("Seattle")
+	alice := example.User{
+		ID:              "alice",
+		Address:         example.UserAddress{Street: "1 Main St", City: &city},
+		PreviousAddress: &example.UserAddress{Street: "2 High St"},
+		Manager:         &manager,
+	}
+	testutil.Equals(t, alice.Record(), types.NewRecord(types.RecordMap{
+		"department":      types.String(""),
+		"address":         types.NewRecord(types.RecordMap{"street": types.String("1 Main St"), "city": city}),
+		"previousAddress": types.NewRecord(types.RecordMap{"street": types.String("2 High St")}),
+		"manager":         manager,
+		"roles":           types.NewSet([]types.Value{}...),
+		"logins":          types.NewSet([]types.Value{}...),
+	}))
+}
+
+func TestActions(t *testing.T) {
+	t.Parallel()
+	testutil.Equals(t, example.ActionView, types.NewEntityUID("PhotoApp::Action", "view"))
+	testutil.Equals(t, example.ActionEditPhoto, types.NewEntityUID("PhotoApp::Action", "edit photo"))
+	testutil.Equals(t, example.ColorDarkGreen, types.NewEntityUID("PhotoApp::Color", "dark green"))
+
+	mfa := types.True
+	context := example.ViewContext{IP: testutil.Must(types.ParseIPAddr("10.0.0.1")), Mfa: &mfa}
+	testutil.OK(t, schema.ValidateRequest(loadSchema(t), types.Request{
+		Principal: example.NewUserUID("alice"),
+		Action:    example.ActionView,
+		Resource:  example.NewPhotoUID("p"),
+		Context:   context.Record(),
+	}))
+}
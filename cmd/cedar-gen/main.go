@@ -0,0 +1,78 @@
+// Command cedar-gen generates Go types for the entities and actions declared by a Cedar schema.
+//
+// Usage:
+//
+//	cedar-gen -package name [-o file] schema
+//
+// Schemas whose file names end in ".json" are read in the JSON schema format, and others in the human-readable
+// format.  The generated source is written to the -o file, or to standard output.  See schema.GenerateGo for a
+// description of the declarations which are generated.
+//
+// The exit status is 0 if the source was generated and 2 if an error occurred.  As for the other commands, 1 is kept
+// for findings, of which cedar-gen reports none.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/cedar-policy/cedar-go/x/exp/schema"
+)
+
+func main() {
+	os.Exit(run(os.Args[1:], os.Stdout, os.Stderr))
+}
+
+func run(args []string, stdout, stderr io.Writer) int {
+	flags := flag.NewFlagSet("cedar-gen", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	packageName := flags.String("package", "", "the name of the generated package")
+	output := flags.String("o", "", "the file to write, instead of standard output")
+	flags.Usage = func() {
+		fmt.Fprintln(stderr, "usage: cedar-gen -package name [-o file] schema")
+		flags.PrintDefaults()
+	}
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+	if flags.NArg() != 1 || *packageName == "" {
+		flags.Usage()
+		return 2
+	}
+	src, err := generate(flags.Arg(0), *packageName)
+	if err != nil {
+		fmt.Fprintln(stderr, err)
+		return 2
+	}
+	if *output == "" {
+		_, err = stdout.Write(src)
+	} else {
+		err = os.WriteFile(*output, src, 0o644)
+	}
+	if err != nil {
+		fmt.Fprintln(stderr, err)
+		return 2
+	}
+	return 0
+}
+
+func generate(fileName, packageName string) ([]byte, error) {
+	b, err := os.ReadFile(fileName)
+	if err != nil {
+		return nil, err
+	}
+	var s schema.Schema
+	if strings.HasSuffix(fileName, ".json") {
+		err = s.UnmarshalJSON(b)
+	} else {
+		err = s.UnmarshalCedar(b)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%v: %w", fileName, err)
+	}
+	s.SetFilename(fileName)
+	return schema.GenerateGo(&s, packageName)
+}
@@ -0,0 +1,72 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/cedar-policy/cedar-go/internal/testutil"
+)
+
+func TestRunGeneratesExample(t *testing.T) {
+	t.Parallel()
+	want, err := os.ReadFile(filepath.Join("internal", "example", "entities.go"))
+	testutil.OK(t, err)
+
+	var stdout, stderr bytes.Buffer
+	testutil.Equals(t, run([]string{"-package", "example", filepath.Join("internal", "example", "schema.json")}, &stdout, &stderr), 0)
+	testutil.Equals(t, stdout.String(), string(want))
+
+	out := filepath.Join(t.TempDir(), "entities.go")
+	testutil.Equals(t, run([]string{"-package", "example", "-o", out, filepath.Join("internal", "example", "schema.json")}, &stdout, &stderr), 0)
+	got, err := os.ReadFile(out)
+	testutil.OK(t, err)
+	testutil.Equals(t, string(got), string(want))
+}
+
+func TestRunCedarFormat(t *testing.T) {
+	t.Parallel()
+	dir := t.TempDir()
+	schemaFile := filepath.Join(dir, "schema.cedarschema")
+	testutil.OK(t, os.WriteFile(schemaFile, []byte(`entity User;`), 0o600))
+	var stdout, stderr bytes.Buffer
+	testutil.Equals(t, run([]string{"-package", "p", schemaFile}, &stdout, &stderr), 0)
+	testutil.Equals(t, bytes.Contains(stdout.Bytes(), []byte("type User struct")), true)
+}
+
+func TestRunErrors(t *testing.T) {
+	t.Parallel()
+	dir := t.TempDir()
+	files := map[string]string{
+		"bad.cedarschema":        `entity`,
+		"unresolved.cedarschema": `entity User in [Missing];`,
+		"ok.cedarschema":         `entity User;`,
+	}
+	for name, content := range files {
+		testutil.OK(t, os.WriteFile(filepath.Join(dir, name), []byte(content), 0o600))
+	}
+	path := func(name string) string { return filepath.Join(dir, name) }
+	tests := []struct {
+		name   string
+		args   []string
+		status int
+	}{
+		{"noPackage", []string{path("ok.cedarschema")}, 2},
+		{"noSchema", []string{"-package", "p"}, 2},
+		{"badFlag", []string{"-nope"}, 2},
+		{"missing", []string{"-package", "p", path("missing")}, 2},
+		{"badSchema", []string{"-package", "p", path("bad.cedarschema")}, 2},
+		{"unresolved", []string{"-package", "p", path("unresolved.cedarschema")}, 2},
+		{"badOutput", []string{"-package", "p", "-o", dir, path("ok.cedarschema")}, 2},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			var stdout, stderr bytes.Buffer
+			testutil.Equals(t, run(tt.args, &stdout, &stderr), tt.status)
+			testutil.Equals(t, stderr.Len() > 0, true)
+		})
+	}
+}
@@ -0,0 +1,81 @@
+// Command cedar-lint reports common mistakes in Cedar policies.
+//
+// Usage:
+//
+//	cedar-lint [-json] policy-file...
+//
+// Each finding is printed on its own line, giving the position of the offending condition or expression, or of the
+// policy if the finding is about the policy as a whole, the severity of the finding, its message and the rule which
+// found it.  With -json, the findings are printed as a JSON array instead.
+//
+// The exit status is 0 if there are no findings, 1 if there are any and 2 if an error occurred.
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/cedar-policy/cedar-go"
+	"github.com/cedar-policy/cedar-go/x/exp/lint"
+)
+
+func main() {
+	os.Exit(run(os.Args[1:], os.Stdout, os.Stderr))
+}
+
+func run(args []string, stdout, stderr io.Writer) int {
+	flags := flag.NewFlagSet("cedar-lint", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	jsonOutput := flags.Bool("json", false, "print the findings as JSON")
+	flags.Usage = func() {
+		fmt.Fprintln(stderr, "usage: cedar-lint [-json] policy-file...")
+		flags.PrintDefaults()
+	}
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+	if flags.NArg() == 0 {
+		flags.Usage()
+		return 2
+	}
+	findings := []lint.Finding{}
+	for _, fileName := range flags.Args() {
+		f, err := lintFile(fileName)
+		if err != nil {
+			fmt.Fprintln(stderr, err)
+			return 2
+		}
+		findings = append(findings, f...)
+	}
+	if *jsonOutput {
+		b, err := json.MarshalIndent(findings, "", "  ")
+		if err != nil {
+			fmt.Fprintln(stderr, err)
+			return 2
+		}
+		fmt.Fprintln(stdout, string(b))
+	} else {
+		for _, f := range findings {
+			fmt.Fprintln(stdout, f)
+		}
+	}
+	if len(findings) > 0 {
+		return 1
+	}
+	return 0
+}
+
+func lintFile(fileName string) ([]lint.Finding, error) {
+	b, err := os.ReadFile(fileName)
+	if err != nil {
+		return nil, err
+	}
+	policies, err := cedar.NewPolicySetFromBytes(fileName, b)
+	if err != nil {
+		return nil, err
+	}
+	return lint.Policies(policies), nil
+}
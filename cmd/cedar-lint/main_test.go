@@ -0,0 +1,52 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/cedar-policy/cedar-go/internal/testutil"
+)
+
+func TestRun(t *testing.T) {
+	t.Parallel()
+	dir := t.TempDir()
+	for name, content := range map[string]string{
+		"clean.cedar": `permit(principal == User::"alice", action, resource);`,
+		"bad.cedar": `permit(principal == User::"alice", action, resource);
+permit(principal, action, resource) when { resource.name like "doc" };`,
+		"invalid.cedar": `permit(`,
+	} {
+		testutil.OK(t, os.WriteFile(filepath.Join(dir, name), []byte(content), 0o600))
+	}
+	path := func(name string) string { return filepath.Join(dir, name) }
+	tests := []struct {
+		name   string
+		args   []string
+		status int
+		stdout string
+	}{
+		{"clean", []string{path("clean.cedar")}, 0, ""},
+		{
+			"findings",
+			[]string{path("clean.cedar"), path("bad.cedar")},
+			1,
+			path("bad.cedar") + ":2:44: warning: policy `policy1`: `like \"doc\"` has no wildcards, and is better written with `==` (like-without-wildcard)\n",
+		},
+		{"jsonClean", []string{"-json", path("clean.cedar")}, 0, "[]\n"},
+		{"usage", nil, 2, ""},
+		{"badFlag", []string{"-nope"}, 2, ""},
+		{"missing", []string{path("missing.cedar")}, 2, ""},
+		{"invalid", []string{path("invalid.cedar")}, 2, ""},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			var stdout, stderr bytes.Buffer
+			testutil.Equals(t, run(tt.args, &stdout, &stderr), tt.status)
+			testutil.Equals(t, stdout.String(), tt.stdout)
+		})
+	}
+}
@@ -0,0 +1,113 @@
+// Command cedar-schema-diff reports the differences between two versions of a Cedar schema and whether they are
+// backward compatible.
+//
+// Usage:
+//
+//	cedar-schema-diff [-policies file] [-json] old-schema new-schema
+//
+// Schemas whose file names end in ".json" are read in the JSON schema format, and others in the human-readable
+// format.  Each change is printed on its own line, followed by the validation errors of any policies in the
+// -policies file which validate against the old schema but not against the new one.  With -json, the result is
+// printed as a JSON object instead.
+//
+// The exit status is 0 if the new schema is backward compatible with the old one, 1 if it is not and 2 if an error
+// occurred.
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/cedar-policy/cedar-go"
+	"github.com/cedar-policy/cedar-go/x/exp/schema"
+)
+
+func main() {
+	os.Exit(run(os.Args[1:], os.Stdout, os.Stderr))
+}
+
+func run(args []string, stdout, stderr io.Writer) int {
+	flags := flag.NewFlagSet("cedar-schema-diff", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	policiesFile := flags.String("policies", "", "a file of Cedar policies to validate against both schemas")
+	jsonOutput := flags.Bool("json", false, "print the result as JSON")
+	flags.Usage = func() {
+		fmt.Fprintln(stderr, "usage: cedar-schema-diff [-policies file] [-json] old-schema new-schema")
+		flags.PrintDefaults()
+	}
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+	if flags.NArg() != 2 {
+		flags.Usage()
+		return 2
+	}
+	diff, err := diffFiles(flags.Arg(0), flags.Arg(1), *policiesFile)
+	if err != nil {
+		fmt.Fprintln(stderr, err)
+		return 2
+	}
+	if *jsonOutput {
+		b, err := json.MarshalIndent(diff, "", "  ")
+		if err != nil {
+			fmt.Fprintln(stderr, err)
+			return 2
+		}
+		fmt.Fprintln(stdout, string(b))
+	} else {
+		for _, c := range diff.Changes {
+			fmt.Fprintln(stdout, c)
+		}
+		for _, e := range diff.BrokenPolicies {
+			fmt.Fprintln(stdout, e)
+		}
+	}
+	if diff.Breaking() {
+		return 1
+	}
+	return 0
+}
+
+func diffFiles(oldFile, newFile, policiesFile string) (schema.SchemaDiff, error) {
+	from, err := readSchema(oldFile)
+	if err != nil {
+		return schema.SchemaDiff{}, err
+	}
+	to, err := readSchema(newFile)
+	if err != nil {
+		return schema.SchemaDiff{}, err
+	}
+	var policies *cedar.PolicySet
+	if policiesFile != "" {
+		b, err := os.ReadFile(policiesFile)
+		if err != nil {
+			return schema.SchemaDiff{}, err
+		}
+		if policies, err = cedar.NewPolicySetFromBytes(policiesFile, b); err != nil {
+			return schema.SchemaDiff{}, err
+		}
+	}
+	return schema.DiffSchemas(from, to, policies)
+}
+
+func readSchema(fileName string) (*schema.Schema, error) {
+	b, err := os.ReadFile(fileName)
+	if err != nil {
+		return nil, err
+	}
+	var s schema.Schema
+	if strings.HasSuffix(fileName, ".json") {
+		err = s.UnmarshalJSON(b)
+	} else {
+		err = s.UnmarshalCedar(b)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%v: %w", fileName, err)
+	}
+	s.SetFilename(fileName)
+	return &s, nil
+}
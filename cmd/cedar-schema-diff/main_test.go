@@ -0,0 +1,74 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/cedar-policy/cedar-go/internal/testutil"
+)
+
+func writeFiles(t *testing.T, files map[string]string) string {
+	t.Helper()
+	dir := t.TempDir()
+	for name, content := range files {
+		testutil.OK(t, os.WriteFile(filepath.Join(dir, name), []byte(content), 0o600))
+	}
+	return dir
+}
+
+func TestRun(t *testing.T) {
+	t.Parallel()
+	dir := writeFiles(t, map[string]string{
+		"old.cedarschema": `entity User = { age: Long }; action view appliesTo { principal: User, resource: User };`,
+		"new.cedarschema": `entity User = { age: String }; action view appliesTo { principal: User, resource: User };`,
+		"new.json":        `{"": {"entityTypes": {"User": {"shape": {"type": "Record", "attributes": {"age": {"type": "Long"}, "name": {"type": "String", "required": false}}}}}, "actions": {"view": {"appliesTo": {"principalTypes": ["User"], "resourceTypes": ["User"]}}}}}`,
+		"policies.cedar":  `permit(principal, action, resource) when { principal.age > 3 };`,
+		"bad.cedarschema": `entity`,
+		"bad.cedar":       `permit(`,
+	})
+	path := func(name string) string { return filepath.Join(dir, name) }
+	tests := []struct {
+		name   string
+		args   []string
+		status int
+		stdout string
+	}{
+		{
+			"breaking",
+			[]string{"-policies", path("policies.cedar"), path("old.cedarschema"), path("new.cedarschema")},
+			1,
+			"breaking change at `User.age`: type changed from Long to String\n" +
+				"while validating policy `policy0`: >: expected operands of type Long, datetime or duration, got String and Long\n",
+		},
+		{
+			"compatible",
+			[]string{path("old.cedarschema"), path("new.json")},
+			0,
+			"change at `User.name`: optional attribute added\n",
+		},
+		{
+			"json",
+			[]string{"-json", path("old.cedarschema"), path("old.cedarschema")},
+			0,
+			"{}\n",
+		},
+		{"usage", []string{path("old.cedarschema")}, 2, ""},
+		{"badFlag", []string{"-nope"}, 2, ""},
+		{"missingOld", []string{path("missing"), path("new.json")}, 2, ""},
+		{"missingNew", []string{path("old.cedarschema"), path("missing")}, 2, ""},
+		{"badSchema", []string{path("bad.cedarschema"), path("new.json")}, 2, ""},
+		{"missingPolicies", []string{"-policies", path("missing"), path("old.cedarschema"), path("new.json")}, 2, ""},
+		{"badPolicies", []string{"-policies", path("bad.cedar"), path("old.cedarschema"), path("new.json")}, 2, ""},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			var stdout, stderr bytes.Buffer
+			testutil.Equals(t, run(tt.args, &stdout, &stderr), tt.status)
+			testutil.Equals(t, stdout.String(), tt.stdout)
+		})
+	}
+}
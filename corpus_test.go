@@ -9,6 +9,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"io"
+	"slices"
 	"strings"
 	"testing"
 
@@ -16,6 +17,7 @@ import (
 	"github.com/cedar-policy/cedar-go/internal/testutil"
 	"github.com/cedar-policy/cedar-go/types"
 	"github.com/cedar-policy/cedar-go/x/exp/batch"
+	"github.com/cedar-policy/cedar-go/x/exp/schema"
 )
 
 // jsonEntity is not part of entityValue as I can find
@@ -137,6 +139,35 @@ func TestCorpus(t *testing.T) {
 				t.Fatal("error unmarshalling test", err)
 			}
 
+			schemaContent, err := fdm.GetFileData(tt.Schema)
+			if err != nil {
+				t.Fatal("error reading schema content", err)
+			}
+
+			var s schema.Schema
+			if err := s.UnmarshalCedar(schemaContent); err != nil {
+				t.Fatal("error parsing schema", err)
+			}
+			schemaText, err := s.MarshalCedar()
+			if err != nil {
+				t.Fatal("error marshaling schema", err)
+			}
+			var reparsed schema.Schema
+			if err := reparsed.UnmarshalCedar(schemaText); err != nil {
+				t.Fatal("error reparsing marshaled schema", err)
+			}
+			testutil.Equals(t, string(testutil.Must(reparsed.MarshalCedar())), string(schemaText))
+
+			schemaJSON, err := s.MarshalJSON()
+			if err != nil {
+				t.Fatal("error marshaling schema to JSON", err)
+			}
+			var fromJSON schema.Schema
+			if err := fromJSON.UnmarshalJSON(schemaJSON); err != nil {
+				t.Fatal("error unmarshaling JSON schema", err)
+			}
+			testutil.Equals(t, string(testutil.Must(fromJSON.MarshalCedar())), string(schemaText))
+
 			entitiesContent, err := fdm.GetFileData(tt.Entities)
 			if err != nil {
 				t.Fatal("error reading entities content", err)
@@ -157,6 +188,32 @@ func TestCorpus(t *testing.T) {
 				t.Fatal("error parsing policy set", err)
 			}
 
+			validation, err := schema.Validate(&s, policySet)
+			if err != nil {
+				t.Fatal("error validating policy set", err)
+			}
+			testutil.Equals(t, validation.Valid(), tt.ShouldValidate)
+			entityErrors, err := schema.ValidateEntities(&s, entities)
+			testutil.OK(t, err)
+			testutil.Equals(t, entityErrors, nil)
+			schemaEntities, err := schema.UnmarshalEntities(&s, entitiesContent)
+			testutil.OK(t, err)
+			testutil.Equals(t, schemaEntities, entities)
+			inferred, warnings := schema.InferSchema(entities, policySet)
+			if len(warnings) == 0 {
+				inferredErrors, err := schema.ValidateEntities(inferred, entities)
+				testutil.OK(t, err)
+				testutil.Equals(t, inferredErrors, nil)
+			}
+			if tt.ShouldValidate {
+				permissive, err := schema.ValidateWithMode(&s, policySet, schema.Permissive)
+				testutil.OK(t, err)
+				testutil.Equals(t, permissive.Errors, nil)
+			}
+
+			indexed := cedar.NewIndexedPolicySet(policySet)
+			store, err := cedar.NewEntityStore(entities)
+			testutil.OK(t, err)
 			for _, request := range tt.Requests {
 				if len(request.Reasons) == 0 && request.Reasons != nil {
 					request.Reasons = nil
@@ -167,14 +224,14 @@ func TestCorpus(t *testing.T) {
 
 				t.Run(request.Desc, func(t *testing.T) {
 					t.Parallel()
-					ok, diag := policySet.IsAuthorized(
-						entities,
-						cedar.Request{
-							Principal: cedar.EntityUID(request.Principal),
-							Action:    cedar.EntityUID(request.Action),
-							Resource:  cedar.EntityUID(request.Resource),
-							Context:   request.Context,
-						})
+					req := cedar.Request{
+						Principal: cedar.EntityUID(request.Principal),
+						Action:    cedar.EntityUID(request.Action),
+						Resource:  cedar.EntityUID(request.Resource),
+						Context:   request.Context,
+					}
+					testutil.OK(t, schema.ValidateRequest(&s, req))
+					ok, diag := policySet.IsAuthorized(entities, req)
 
 					testutil.Equals(t, ok, request.Decision)
 					var errors []cedar.PolicyID
@@ -189,6 +246,50 @@ func TestCorpus(t *testing.T) {
 					testutil.Equals(t, reasons, request.Reasons)
 				})
 
+				t.Run(request.Desc+"/indexed", func(t *testing.T) {
+					t.Parallel()
+					req := cedar.Request{
+						Principal: cedar.EntityUID(request.Principal),
+						Action:    cedar.EntityUID(request.Action),
+						Resource:  cedar.EntityUID(request.Resource),
+						Context:   request.Context,
+					}
+					ok, diag := cedar.Authorize(indexed, entities, req)
+					testutil.Equals(t, ok, request.Decision)
+					var errors []cedar.PolicyID
+					for _, n := range diag.Errors {
+						errors = append(errors, n.PolicyID)
+					}
+					testutil.Equals(t, errors, sortedIDs(request.Errors))
+					var reasons []cedar.PolicyID
+					for _, n := range diag.Reasons {
+						reasons = append(reasons, n.PolicyID)
+					}
+					testutil.Equals(t, reasons, sortedIDs(request.Reasons))
+				})
+
+				t.Run(request.Desc+"/store", func(t *testing.T) {
+					t.Parallel()
+					req := cedar.Request{
+						Principal: cedar.EntityUID(request.Principal),
+						Action:    cedar.EntityUID(request.Action),
+						Resource:  cedar.EntityUID(request.Resource),
+						Context:   request.Context,
+					}
+					ok, diag := policySet.IsAuthorized(store, req)
+					testutil.Equals(t, ok, request.Decision)
+					var errors []cedar.PolicyID
+					for _, n := range diag.Errors {
+						errors = append(errors, n.PolicyID)
+					}
+					testutil.Equals(t, errors, request.Errors)
+					var reasons []cedar.PolicyID
+					for _, n := range diag.Reasons {
+						reasons = append(reasons, n.PolicyID)
+					}
+					testutil.Equals(t, reasons, request.Reasons)
+				})
+
 				t.Run(request.Desc+"/batch", func(t *testing.T) {
 					t.Parallel()
 					ctx := context.Background()
@@ -239,6 +340,13 @@ func TestCorpus(t *testing.T) {
 	}
 }
 
+func sortedIDs(ids []cedar.PolicyID) []cedar.PolicyID {
+	if ids == nil {
+		return nil
+	}
+	return slices.Sorted(slices.Values(ids))
+}
+
 // Specific corpus tests that have been extracted for easy regression testing purposes
 func TestCorpusRelated(t *testing.T) {
 	t.Parallel()
@@ -0,0 +1,63 @@
+package cedar
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/cedar-policy/cedar-go/types"
+)
+
+// loadedEntities is the EntityGetter used to evaluate policies with an EntityLoader.  It remembers the entities which
+// it has loaded, or failed to load, during one authorization, so that each is loaded at most once, and the first error
+// since the error was last taken.
+type loadedEntities struct {
+	ctx    context.Context
+	loader types.EntityLoader
+	cache  map[EntityUID]loadedEntity
+	err    error
+}
+
+type loadedEntity struct {
+	entity Entity
+	found  bool
+	err    error
+}
+
+// newLoadedEntities returns loadedEntities for the request.  If the loader is an EntityBatchLoader, the principal,
+// action and resource of the request are loaded at once; should that fail, they are loaded one by one when needed.
+func newLoadedEntities(ctx context.Context, loader types.EntityLoader, req Request) *loadedEntities {
+	l := &loadedEntities{ctx: ctx, loader: loader, cache: map[EntityUID]loadedEntity{}}
+	if bl, ok := loader.(types.EntityBatchLoader); ok {
+		uids := []EntityUID{req.Principal, req.Action, req.Resource}
+		if entities, err := bl.LoadMany(ctx, uids); err == nil {
+			for _, uid := range uids {
+				e, found := entities[uid]
+				l.cache[uid] = loadedEntity{entity: e, found: found}
+			}
+		}
+	}
+	return l
+}
+
+func (l *loadedEntities) Get(uid EntityUID) (Entity, bool) {
+	e, ok := l.cache[uid]
+	if !ok {
+		var err error
+		e.entity, e.found, err = l.loader.Load(l.ctx, uid)
+		if err != nil {
+			e = loadedEntity{err: fmt.Errorf("failed to load entity %v: %w", uid, err)}
+		}
+		l.cache[uid] = e
+	}
+	if e.err != nil && l.err == nil {
+		l.err = e.err
+	}
+	return e.entity, e.found
+}
+
+// takeErr returns the first error since it was last called, if any.
+func (l *loadedEntities) takeErr() error {
+	err := l.err
+	l.err = nil
+	return err
+}
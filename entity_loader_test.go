@@ -0,0 +1,155 @@
+package cedar_test
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/cedar-policy/cedar-go"
+	"github.com/cedar-policy/cedar-go/internal/testutil"
+)
+
+var errOutage = errors.New("database outage")
+
+// testLoader is an EntityLoader which records the entities it loads, and fails to load some of them.
+type testLoader struct {
+	entities cedar.EntityMap
+	failing  []cedar.EntityUID
+
+	mu     sync.Mutex
+	ctx    context.Context
+	loaded []cedar.EntityUID
+}
+
+func (l *testLoader) Get(cedar.EntityUID) (cedar.Entity, bool) {
+	panic("Get must not be used when Load is available")
+}
+
+func (l *testLoader) Load(ctx context.Context, uid cedar.EntityUID) (cedar.Entity, bool, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.ctx = ctx
+	l.loaded = append(l.loaded, uid)
+	if slices.Contains(l.failing, uid) {
+		return cedar.Entity{}, false, errOutage
+	}
+	e, ok := l.entities[uid]
+	return e, ok, nil
+}
+
+// testBatchLoader is a testLoader which can also load many entities at once.
+type testBatchLoader struct {
+	*testLoader
+	batchErr error
+	batches  [][]cedar.EntityUID
+}
+
+func (l *testBatchLoader) LoadMany(_ context.Context, uids []cedar.EntityUID) (cedar.EntityMap, error) {
+	l.batches = append(l.batches, uids)
+	if l.batchErr != nil {
+		return nil, l.batchErr
+	}
+	res := cedar.EntityMap{}
+	for _, uid := range uids {
+		if e, ok := l.entities[uid]; ok {
+			res[uid] = e
+		}
+	}
+	return res, nil
+}
+
+func TestEntityLoader(t *testing.T) {
+	t.Parallel()
+	ps, err := cedar.NewPolicySetFromBytes("policy.cedar", []byte(`
+permit(principal in Group::"staff", action, resource);
+permit(principal, action, resource) when { resource.public };
+forbid(principal, action, resource) when { principal.locked };
+`))
+	testutil.OK(t, err)
+	alice := cedar.NewEntityUID("User", "alice")
+	staff := cedar.NewEntityUID("Group", "staff")
+	view := cedar.NewEntityUID("Action", "view")
+	doc := cedar.NewEntityUID("Doc", "readme")
+	entities := cedar.EntityMap{
+		alice: {UID: alice, Parents: cedar.NewEntityUIDSet(staff), Attributes: cedar.NewRecord(cedar.RecordMap{"locked": cedar.False})},
+		doc:   {UID: doc, Attributes: cedar.NewRecord(cedar.RecordMap{"public": cedar.False})},
+	}
+	req := cedar.Request{Principal: alice, Action: view, Resource: doc}
+	type ctxKey struct{}
+	ctx := context.WithValue(context.Background(), ctxKey{}, true)
+
+	for _, policies := range []cedar.PolicyIterator{ps, cedar.NewIndexedPolicySet(ps)} {
+		t.Run("Load", func(t *testing.T) {
+			l := &testLoader{entities: entities}
+			got, diag := cedar.AuthorizeContext(ctx, policies, l, req, cedar.Budget{})
+			testutil.Equals(t, got, cedar.Allow)
+			testutil.Equals(t, diag.Errors, nil)
+			testutil.Equals(t, len(diag.Reasons), 1)
+			testutil.Equals(t, l.ctx, ctx)
+			// Each entity is loaded once.
+			slices.SortFunc(l.loaded, func(a, b cedar.EntityUID) int { return strings.Compare(a.String(), b.String()) })
+			testutil.Equals(t, slices.Compact(slices.Clone(l.loaded)), l.loaded)
+		})
+
+		t.Run("LoadError", func(t *testing.T) {
+			l := &testLoader{entities: entities, failing: []cedar.EntityUID{alice}}
+			got, diag := cedar.AuthorizeContext(ctx, policies, l, req, cedar.Budget{})
+			testutil.Equals(t, got, cedar.Deny)
+			testutil.Equals(t, diag.Reasons, nil)
+			sortDiagnostic(&diag)
+			testutil.Equals(t, diag.Errors, []cedar.DiagnosticError{
+				{PolicyID: "policy0", Position: ps.Get("policy0").Position(), Message: `failed to load entity User::"alice": database outage`, Kind: cedar.EntityLoadError},
+				{PolicyID: "policy2", Position: ps.Get("policy2").Position(), Message: `failed to load entity User::"alice": database outage`, Kind: cedar.EntityLoadError},
+			})
+		})
+
+		t.Run("LoadMany", func(t *testing.T) {
+			l := &testBatchLoader{testLoader: &testLoader{entities: entities}}
+			got, _ := cedar.AuthorizeContext(ctx, policies, l, req, cedar.Budget{})
+			testutil.Equals(t, got, cedar.Allow)
+			testutil.Equals(t, l.batches, [][]cedar.EntityUID{{alice, view, doc}})
+			// The entities of the request are not loaded again.
+			testutil.Equals(t, slices.ContainsFunc(l.loaded, func(uid cedar.EntityUID) bool {
+				return uid == alice || uid == view || uid == doc
+			}), false)
+		})
+
+		t.Run("LoadManyError", func(t *testing.T) {
+			l := &testBatchLoader{testLoader: &testLoader{entities: entities}, batchErr: errOutage}
+			got, diag := cedar.AuthorizeContext(ctx, policies, l, req, cedar.Budget{})
+			testutil.Equals(t, got, cedar.Allow)
+			testutil.Equals(t, diag.Errors, nil)
+			testutil.Equals(t, len(l.batches), 1)
+			testutil.Equals(t, slices.Contains(l.loaded, alice), true)
+		})
+	}
+
+	t.Run("Authorize", func(t *testing.T) {
+		t.Parallel()
+		l := &testLoader{entities: entities, failing: []cedar.EntityUID{doc}}
+		got, diag := cedar.Authorize(ps, l, req)
+		testutil.Equals(t, got, cedar.Allow)
+		testutil.Equals(t, len(diag.Errors), 1)
+		testutil.Equals(t, diag.Errors[0].PolicyID, "policy1")
+		testutil.Equals(t, diag.Errors[0].Kind, cedar.EntityLoadError)
+		testutil.Equals(t, l.ctx, context.Background())
+	})
+
+	t.Run("AuthorizeWithTrace", func(t *testing.T) {
+		t.Parallel()
+		l := &testLoader{entities: entities, failing: []cedar.EntityUID{doc}}
+		got, diag, trace := cedar.AuthorizeWithTrace(ps, l, req)
+		wantDecision, wantDiag := cedar.Authorize(ps, &testLoader{entities: entities, failing: []cedar.EntityUID{doc}}, req)
+		testutil.Equals(t, got, wantDecision)
+		testutil.Equals(t, diag, wantDiag)
+		testutil.Equals(t, trace.Policies[0].Satisfied, true)
+		testutil.Equals(t, trace.Policies[1].Error, `failed to load entity Doc::"readme": database outage`)
+		testutil.Equals(t, trace.Policies[2].Error, "")
+		// Each entity is loaded once, even though tracing evaluates the policies again.
+		slices.SortFunc(l.loaded, func(a, b cedar.EntityUID) int { return strings.Compare(a.String(), b.String()) })
+		testutil.Equals(t, slices.Compact(slices.Clone(l.loaded)), l.loaded)
+	})
+}
@@ -0,0 +1,120 @@
+package eval
+
+import (
+	"fmt"
+
+	"github.com/cedar-policy/cedar-go/types"
+)
+
+var ErrBudgetExceeded = fmt.Errorf("evaluation budget exceeded")
+
+// A Budget limits the work done by evaluation, across all of the policies which are evaluated with it.  A zero limit
+// means no limit.  Steps and set sizes are only checked by Evalers compiled with CompileBudgeted, and entity lookups
+// are only counted through the EntityGetter returned by Entities.
+type Budget struct {
+	MaxSteps         int
+	MaxEntityLookups int
+	MaxSetSize       int
+
+	steps   int
+	lookups int
+	err     error
+}
+
+// Err returns the error wrapping ErrBudgetExceeded once any of the limits has been exceeded, and nil before.
+func (b *Budget) Err() error {
+	return b.err
+}
+
+func (b *Budget) exceeded(format string, args ...any) error {
+	if b.err == nil {
+		b.err = fmt.Errorf("%w: "+format, append([]any{ErrBudgetExceeded}, args...)...)
+	}
+	return b.err
+}
+
+func (b *Budget) step() error {
+	if b.err != nil {
+		return b.err
+	}
+	b.steps++
+	if b.MaxSteps > 0 && b.steps > b.MaxSteps {
+		return b.exceeded("more than %d steps", b.MaxSteps)
+	}
+	return nil
+}
+
+func (b *Budget) lookup() bool {
+	b.lookups++
+	if b.MaxEntityLookups > 0 && b.lookups > b.MaxEntityLookups {
+		_ = b.exceeded("more than %d entity lookups", b.MaxEntityLookups)
+		return false
+	}
+	return true
+}
+
+func (b *Budget) checkSet(v types.Value) error {
+	if s, ok := v.(types.Set); ok && b.MaxSetSize > 0 && s.Len() > b.MaxSetSize {
+		return b.exceeded("set of %d elements is larger than %d", s.Len(), b.MaxSetSize)
+	}
+	return nil
+}
+
+// Entities returns an EntityGetter which counts each lookup in entities against the budget.  Once the limit on entity
+// lookups has been exceeded, no entity is found.  The EntityGetter is a types.AncestorChecker if entities is.
+func (b *Budget) Entities(entities types.EntityGetter) types.EntityGetter {
+	if ac, ok := entities.(types.AncestorChecker); ok {
+		return budgetAncestorChecker{budgetEntities{b, entities}, ac}
+	}
+	return budgetEntities{b, entities}
+}
+
+type budgetEntities struct {
+	budget   *Budget
+	entities types.EntityGetter
+}
+
+func (e budgetEntities) Get(uid types.EntityUID) (types.Entity, bool) {
+	if !e.budget.lookup() {
+		return types.Entity{}, false
+	}
+	return e.entities.Get(uid)
+}
+
+type budgetAncestorChecker struct {
+	budgetEntities
+	checker types.AncestorChecker
+}
+
+func (e budgetAncestorChecker) IsAncestor(entity, ancestor types.EntityUID) bool {
+	if !e.budget.lookup() {
+		return false
+	}
+	return e.checker.IsAncestor(entity, ancestor)
+}
+
+// A budgetEval counts its evaluation as a step against the Budget of the Env, if there is one.
+type budgetEval struct {
+	eval Evaler
+}
+
+func newBudgetEval(eval Evaler) *budgetEval {
+	return &budgetEval{eval: eval}
+}
+
+func (n *budgetEval) Eval(env Env) (types.Value, error) {
+	if env.Budget == nil {
+		return n.eval.Eval(env)
+	}
+	if err := env.Budget.step(); err != nil {
+		return zeroValue(), err
+	}
+	v, err := n.eval.Eval(env)
+	if err != nil {
+		return zeroValue(), err
+	}
+	if err := env.Budget.checkSet(v); err != nil {
+		return zeroValue(), err
+	}
+	return v, nil
+}
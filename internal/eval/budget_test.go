@@ -0,0 +1,106 @@
+package eval
+
+import (
+	"testing"
+
+	"github.com/cedar-policy/cedar-go/internal/testutil"
+	"github.com/cedar-policy/cedar-go/types"
+	"github.com/cedar-policy/cedar-go/x/exp/ast"
+)
+
+func TestBudget(t *testing.T) {
+	t.Parallel()
+	alice := types.NewEntityUID("User", "alice")
+	staff := types.NewEntityUID("Group", "staff")
+	policy := ast.Permit().
+		When(ast.Context().Access("groups").Contains(ast.Principal())).
+		When(ast.Principal().In(ast.Value(staff)))
+	entities := types.EntityMap{alice: {UID: alice, Parents: types.NewEntityUIDSet(staff)}}
+	env := func(b *Budget) Env {
+		return Env{
+			Entities:  b.Entities(entities),
+			Principal: alice,
+			Context:   types.NewRecord(types.RecordMap{"groups": types.NewSet(alice, staff)}),
+			Budget:    b,
+		}
+	}
+	tests := []struct {
+		name    string
+		budget  Budget
+		want    types.Boolean
+		wantErr string
+	}{
+		{"unlimited", Budget{}, true, ""},
+		{"enough", Budget{MaxSteps: 10, MaxEntityLookups: 1, MaxSetSize: 2}, true, ""},
+		{"steps", Budget{MaxSteps: 9}, false, "evaluation budget exceeded: more than 9 steps"},
+		{"setSize", Budget{MaxSetSize: 1}, false, "evaluation budget exceeded: set of 2 elements is larger than 1"},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			b := tt.budget
+			e := CompileBudgeted(policy)
+			got, err := e.Eval(env(&b))
+			testutil.Equals(t, got, tt.want)
+			if tt.wantErr == "" {
+				testutil.OK(t, err)
+				testutil.OK(t, b.Err())
+				return
+			}
+			testutil.ErrorIs(t, err, ErrBudgetExceeded)
+			testutil.Equals(t, err.Error(), tt.wantErr)
+			testutil.Equals(t, b.Err(), err)
+
+			// Once exceeded, the budget stops any further evaluation.
+			got, err = e.Eval(env(&b))
+			testutil.Equals(t, got, false)
+			testutil.Equals(t, err, b.Err())
+		})
+	}
+
+	t.Run("lookupsExceeded", func(t *testing.T) {
+		t.Parallel()
+		b := Budget{MaxEntityLookups: 1}
+		es := b.Entities(entities)
+		_, ok := es.Get(alice)
+		testutil.Equals(t, ok, true)
+		testutil.OK(t, b.Err())
+		_, ok = es.Get(alice)
+		testutil.Equals(t, ok, false)
+		testutil.ErrorIs(t, b.Err(), ErrBudgetExceeded)
+		testutil.Equals(t, b.Err().Error(), "evaluation budget exceeded: more than 1 entity lookups")
+		_, isChecker := es.(types.AncestorChecker)
+		testutil.Equals(t, isChecker, false)
+	})
+
+	t.Run("ancestorChecker", func(t *testing.T) {
+		t.Parallel()
+		b := Budget{MaxEntityLookups: 1}
+		es := b.Entities(ancestorChecker{alice: staff}).(types.AncestorChecker)
+		testutil.Equals(t, es.IsAncestor(alice, staff), true)
+		testutil.Equals(t, es.IsAncestor(alice, staff), false)
+		testutil.ErrorIs(t, b.Err(), ErrBudgetExceeded)
+	})
+
+	t.Run("noBudget", func(t *testing.T) {
+		t.Parallel()
+		e := CompileBudgeted(policy)
+		got, err := e.Eval(Env{
+			Entities:  entities,
+			Principal: alice,
+			Context:   types.NewRecord(types.RecordMap{"groups": types.NewSet(alice)}),
+		})
+		testutil.OK(t, err)
+		testutil.Equals(t, got, true)
+	})
+
+	t.Run("error", func(t *testing.T) {
+		t.Parallel()
+		b := Budget{MaxSteps: 100}
+		e := CompileBudgeted(ast.Permit().When(ast.Context().Access("missing")))
+		_, err := e.Eval(Env{Entities: entities, Context: types.Record{}, Budget: &b})
+		testutil.Error(t, err)
+		testutil.OK(t, b.Err())
+	})
+}
@@ -24,9 +24,19 @@ func (e *BoolEvaler) Eval(env Env) (types.Boolean, error) {
 }
 
 func Compile(p *ast.Policy) BoolEvaler {
+	return compile(p, converter{})
+}
+
+// CompileBudgeted is like Compile, but the returned BoolEvaler counts each step of evaluation against the Budget of the
+// Env, and checks the size of each set which evaluation produces.
+func CompileBudgeted(p *ast.Policy) BoolEvaler {
+	return compile(p, converter{budgeted: true})
+}
+
+func compile(p *ast.Policy, c converter) BoolEvaler {
 	p = foldPolicy(p)
 	node := policyToNode(p).AsIsNode()
-	return BoolEvaler{eval: toEval(node)}
+	return BoolEvaler{eval: c.toEval(node)}
 }
 
 func policyToNode(p *ast.Policy) ast.Node {
@@ -80,6 +90,9 @@ func scopeToNode(varNode ast.NodeTypeVariable, in ast.IsScopeNode) ast.Node {
 
 	case ast.ScopeTypeIsIn:
 		return ast.NewNode(varNode).IsIn(t.Type, ast.Value(t.Entity))
+	case ast.ScopeTypeSlotEq, ast.ScopeTypeSlotIn, ast.ScopeTypeSlotIsIn:
+		// A slot which has not been linked to an entity matches nothing.
+		return ast.False()
 	default:
 		panic(fmt.Sprintf("unknown scope type %T", t))
 	}
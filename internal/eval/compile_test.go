@@ -137,6 +137,18 @@ func TestScopeToNode(t *testing.T) {
 			ast.ScopeTypeIsIn{Type: "T", Entity: types.NewEntityUID("T", "42")},
 			ast.Resource().IsIn("T", ast.EntityUID("T", "42")),
 		},
+		{
+			"slotEq",
+			ast.NewPrincipalNode(),
+			ast.ScopeTypeSlotEq{Slot: types.PrincipalSlot},
+			ast.False(),
+		},
+		{
+			"slotIsIn",
+			ast.NewResourceNode(),
+			ast.ScopeTypeSlotIsIn{Type: "T", Slot: types.ResourceSlot},
+			ast.False(),
+		},
 	}
 	for _, tt := range tests {
 		tt := tt
@@ -9,27 +9,45 @@ import (
 )
 
 func toEval(n ast.IsNode) Evaler {
+	return converter{}.toEval(n)
+}
+
+// A converter converts nodes to Evalers.  A budgeted converter wraps each Evaler to count the steps of evaluation
+// against the Budget of the Env.
+type converter struct {
+	budgeted bool
+}
+
+func (c converter) toEval(n ast.IsNode) Evaler {
+	e := c.convert(n)
+	if c.budgeted {
+		return newBudgetEval(e)
+	}
+	return e
+}
+
+func (c converter) convert(n ast.IsNode) Evaler {
 	switch v := n.(type) {
 	case ast.NodeTypeAccess:
-		return newAttributeAccessEval(toEval(v.Arg), v.Value)
+		return newAttributeAccessEval(c.toEval(v.Arg), v.Value)
 	case ast.NodeTypeHas:
-		return newHasEval(toEval(v.Arg), v.Value)
+		return newHasEval(c.toEval(v.Arg), v.Value)
 	case ast.NodeTypeGetTag:
-		return newGetTagEval(toEval(v.Left), toEval(v.Right))
+		return newGetTagEval(c.toEval(v.Left), c.toEval(v.Right))
 	case ast.NodeTypeHasTag:
-		return newHasTagEval(toEval(v.Left), toEval(v.Right))
+		return newHasTagEval(c.toEval(v.Left), c.toEval(v.Right))
 	case ast.NodeTypeLike:
-		return newLikeEval(toEval(v.Arg), v.Value)
+		return newLikeEval(c.toEval(v.Arg), v.Value)
 	case ast.NodeTypeIfThenElse:
-		return newIfThenElseEval(toEval(v.If), toEval(v.Then), toEval(v.Else))
+		return newIfThenElseEval(c.toEval(v.If), c.toEval(v.Then), c.toEval(v.Else))
 	case ast.NodeTypeIs:
-		return newIsEval(toEval(v.Left), v.EntityType)
+		return newIsEval(c.toEval(v.Left), v.EntityType)
 	case ast.NodeTypeIsIn:
-		return newIsInEval(toEval(v.Left), v.EntityType, toEval(v.Entity))
+		return newIsInEval(c.toEval(v.Left), v.EntityType, c.toEval(v.Entity))
 	case ast.NodeTypeExtensionCall:
 		args := make([]Evaler, len(v.Args))
 		for i, a := range v.Args {
-			args[i] = toEval(a)
+			args[i] = c.toEval(a)
 		}
 		return newExtensionEval(v.Name, args)
 	case ast.NodeValue:
@@ -37,19 +55,19 @@ func toEval(n ast.IsNode) Evaler {
 	case ast.NodeTypeRecord:
 		m := make(map[types.String]Evaler, len(v.Elements))
 		for _, e := range v.Elements {
-			m[e.Key] = toEval(e.Value)
+			m[e.Key] = c.toEval(e.Value)
 		}
 		return newRecordLiteralEval(m)
 	case ast.NodeTypeSet:
 		s := make([]Evaler, len(v.Elements))
 		for i, e := range v.Elements {
-			s[i] = toEval(e)
+			s[i] = c.toEval(e)
 		}
 		return newSetLiteralEval(s)
 	case ast.NodeTypeNegate:
-		return newNegateEval(toEval(v.Arg))
+		return newNegateEval(c.toEval(v.Arg))
 	case ast.NodeTypeNot:
-		return newNotEval(toEval(v.Arg))
+		return newNotEval(c.toEval(v.Arg))
 	case ast.NodeTypeVariable:
 		switch v.Name {
 		case consts.Principal, consts.Action, consts.Resource, consts.Context:
@@ -58,37 +76,37 @@ func toEval(n ast.IsNode) Evaler {
 			panic(fmt.Errorf("unknown variable: %v", v.Name))
 		}
 	case ast.NodeTypeIn:
-		return newInEval(toEval(v.Left), toEval(v.Right))
+		return newInEval(c.toEval(v.Left), c.toEval(v.Right))
 	case ast.NodeTypeAnd:
-		return newAndEval(toEval(v.Left), toEval(v.Right))
+		return newAndEval(c.toEval(v.Left), c.toEval(v.Right))
 	case ast.NodeTypeOr:
-		return newOrEval(toEval(v.Left), toEval(v.Right))
+		return newOrEval(c.toEval(v.Left), c.toEval(v.Right))
 	case ast.NodeTypeEquals:
-		return newEqualEval(toEval(v.Left), toEval(v.Right))
+		return newEqualEval(c.toEval(v.Left), c.toEval(v.Right))
 	case ast.NodeTypeNotEquals:
-		return newNotEqualEval(toEval(v.Left), toEval(v.Right))
+		return newNotEqualEval(c.toEval(v.Left), c.toEval(v.Right))
 	case ast.NodeTypeGreaterThan:
-		return newComparableValueGreaterThanEval(toEval(v.Left), toEval(v.Right))
+		return newComparableValueGreaterThanEval(c.toEval(v.Left), c.toEval(v.Right))
 	case ast.NodeTypeGreaterThanOrEqual:
-		return newComparableValueGreaterThanOrEqualEval(toEval(v.Left), toEval(v.Right))
+		return newComparableValueGreaterThanOrEqualEval(c.toEval(v.Left), c.toEval(v.Right))
 	case ast.NodeTypeLessThan:
-		return newComparableValueLessThanEval(toEval(v.Left), toEval(v.Right))
+		return newComparableValueLessThanEval(c.toEval(v.Left), c.toEval(v.Right))
 	case ast.NodeTypeLessThanOrEqual:
-		return newComparableValueLessThanOrEqualEval(toEval(v.Left), toEval(v.Right))
+		return newComparableValueLessThanOrEqualEval(c.toEval(v.Left), c.toEval(v.Right))
 	case ast.NodeTypeSub:
-		return newSubtractEval(toEval(v.Left), toEval(v.Right))
+		return newSubtractEval(c.toEval(v.Left), c.toEval(v.Right))
 	case ast.NodeTypeAdd:
-		return newAddEval(toEval(v.Left), toEval(v.Right))
+		return newAddEval(c.toEval(v.Left), c.toEval(v.Right))
 	case ast.NodeTypeMult:
-		return newMultiplyEval(toEval(v.Left), toEval(v.Right))
+		return newMultiplyEval(c.toEval(v.Left), c.toEval(v.Right))
 	case ast.NodeTypeContains:
-		return newContainsEval(toEval(v.Left), toEval(v.Right))
+		return newContainsEval(c.toEval(v.Left), c.toEval(v.Right))
 	case ast.NodeTypeContainsAll:
-		return newContainsAllEval(toEval(v.Left), toEval(v.Right))
+		return newContainsAllEval(c.toEval(v.Left), c.toEval(v.Right))
 	case ast.NodeTypeContainsAny:
-		return newContainsAnyEval(toEval(v.Left), toEval(v.Right))
+		return newContainsAnyEval(c.toEval(v.Left), c.toEval(v.Right))
 	case ast.NodeTypeIsEmpty:
-		return newIsEmptyEval(toEval(v.Arg))
+		return newIsEmptyEval(c.toEval(v.Arg))
 	default:
 		panic(fmt.Sprintf("unknown node type %T", v))
 	}
@@ -3,6 +3,7 @@ package eval
 import (
 	"errors"
 	"fmt"
+	"iter"
 
 	"github.com/cedar-policy/cedar-go/internal/consts"
 	"github.com/cedar-policy/cedar-go/internal/extensions"
@@ -26,6 +27,10 @@ type Env struct {
 	Entities                    types.EntityGetter
 	Principal, Action, Resource types.Value
 	Context                     types.Value
+	// UnknownEntities are the entities whose attributes, tags and ancestors are unknown during partial evaluation.
+	UnknownEntities types.EntityUIDSet
+	// Budget, if not nil, limits the work done by evaluation.
+	Budget *Budget
 }
 
 type Evaler interface {
@@ -936,6 +941,9 @@ func entityInOne(env Env, entity types.EntityUID, parent types.EntityUID) bool {
 	if entity == parent {
 		return true
 	}
+	if ac, ok := env.Entities.(types.AncestorChecker); ok {
+		return ac.IsAncestor(entity, parent)
+	}
 	var known mapset.MapSet[types.EntityUID]
 	var todo []types.EntityUID
 	var candidate = entity
@@ -960,10 +968,24 @@ func entityInOne(env Env, entity types.EntityUID, parent types.EntityUID) bool {
 	}
 }
 
-func entityInSet(env Env, entity types.EntityUID, parents mapset.Container[types.EntityUID]) bool {
+// An entitySet is a set of entities which can be both queried and iterated over.
+type entitySet interface {
+	mapset.Container[types.EntityUID]
+	All() iter.Seq[types.EntityUID]
+}
+
+func entityInSet(env Env, entity types.EntityUID, parents entitySet) bool {
 	if parents.Contains(entity) {
 		return true
 	}
+	if ac, ok := env.Entities.(types.AncestorChecker); ok {
+		for p := range parents.All() {
+			if ac.IsAncestor(entity, p) {
+				return true
+			}
+		}
+		return false
+	}
 	var known mapset.MapSet[types.EntityUID]
 	var todo []types.EntityUID
 	var candidate = entity
@@ -1859,8 +1859,32 @@ func TestEntityIn(t *testing.T) {
 			}
 			res := entityInSet(Env{Entities: entityMap}, strEnt(tt.lhs), types.NewEntityUIDSet(rhs...))
 			testutil.Equals(t, res, tt.result)
+			if len(rhs) == 1 {
+				testutil.Equals(t, entityInOne(Env{Entities: entityMap}, strEnt(tt.lhs), rhs[0]), tt.result)
+			}
+
+			store, err := types.NewEntityStore(entityMap)
+			if err != nil {
+				testutil.ErrorIs(t, err, types.ErrEntityCycle)
+				return
+			}
+			res = entityInSet(Env{Entities: store}, strEnt(tt.lhs), types.NewEntityUIDSet(rhs...))
+			testutil.Equals(t, res, tt.result)
+			if len(rhs) == 1 {
+				testutil.Equals(t, entityInOne(Env{Entities: store}, strEnt(tt.lhs), rhs[0]), tt.result)
+			}
 		})
 	}
+	t.Run("ancestorChecker", func(t *testing.T) {
+		t.Parallel()
+		amy := strEnt(`person::"amy"`)
+		rowing := strEnt(`club::"rowing"`)
+		env := Env{Entities: ancestorChecker{amy: rowing}}
+		testutil.Equals(t, entityInOne(env, amy, rowing), true)
+		testutil.Equals(t, entityInOne(env, rowing, amy), false)
+		testutil.Equals(t, entityInSet(env, amy, types.NewEntityUIDSet(strEnt(`club::"chess"`), rowing)), true)
+		testutil.Equals(t, entityInSet(env, rowing, types.NewEntityUIDSet(amy)), false)
+	})
 	// This test will run for a very long time (O(2^100)) if there isn't caching.
 	t.Run("exponentialWithoutCaching", func(t *testing.T) {
 		t.Parallel()
@@ -1891,6 +1915,17 @@ func TestEntityIn(t *testing.T) {
 	})
 }
 
+// An ancestorChecker holds the only parent of each entity, and does not hold the entities themselves.
+type ancestorChecker map[types.EntityUID]types.EntityUID
+
+func (c ancestorChecker) Get(types.EntityUID) (types.Entity, bool) {
+	return types.Entity{}, false
+}
+
+func (c ancestorChecker) IsAncestor(entity, ancestor types.EntityUID) bool {
+	return c[entity] == ancestor
+}
+
 func TestIsNode(t *testing.T) {
 	t.Parallel()
 	tests := []struct {
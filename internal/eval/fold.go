@@ -80,6 +80,12 @@ func tryFoldUnary(v ast.UnaryNode, mkEval func(a Evaler) Evaler, wrap func(b ast
 	)
 }
 
+// Fold returns the expression with as much constant folding as is possible given no PARC data, as for the conditions
+// of a policy by foldPolicy.
+func Fold(n ast.IsNode) ast.IsNode {
+	return fold(n)
+}
+
 // fold takes in an ast.Node and finds does as much constant folding as is possible given no PARC data.
 //
 //nolint:revive
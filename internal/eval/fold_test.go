@@ -51,6 +51,12 @@ func TestFoldNode(t *testing.T) {
 	}
 }
 
+func TestFold(t *testing.T) {
+	t.Parallel()
+	testutil.Equals(t, Fold(ast.Long(6).Multiply(ast.Long(7)).AsIsNode()), ast.Long(42).AsIsNode())
+	testutil.Equals(t, Fold(ast.Context().Access("x").AsIsNode()), ast.Context().Access("x").AsIsNode())
+}
+
 func TestFoldPolicy(t *testing.T) {
 	t.Parallel()
 	tests := []struct {
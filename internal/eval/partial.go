@@ -29,6 +29,26 @@ func IsVariable(v types.Value) bool {
 	return false
 }
 
+// containsVariable reports whether v is a variable, or a record or set containing one, whose value is therefore not
+// known.
+func containsVariable(v types.Value) bool {
+	switch v := v.(type) {
+	case types.Record:
+		for _, e := range v.All() {
+			if containsVariable(e) {
+				return true
+			}
+		}
+	case types.Set:
+		for e := range v.All() {
+			if containsVariable(e) {
+				return true
+			}
+		}
+	}
+	return IsVariable(v)
+}
+
 func ToVariable(ent types.EntityUID) (types.String, bool) {
 	if ent.Type == variableEntityType {
 		return ent.ID, true
@@ -124,6 +144,11 @@ func partialResourceScope(env Env, ent types.Value, scope ast.IsResourceScopeNod
 }
 
 func partialScopeEval(env Env, ent types.Value, in ast.IsScopeNode) (evaled bool, result bool) {
+	switch in.(type) {
+	case ast.ScopeTypeSlotEq, ast.ScopeTypeSlotIn, ast.ScopeTypeSlotIsIn:
+		// A slot which has not been linked to an entity matches nothing.
+		return true, false
+	}
 	if IsVariable(ent) {
 		return false, false
 	} else if IsIgnore(ent) {
@@ -139,14 +164,20 @@ func partialScopeEval(env Env, ent types.Value, in ast.IsScopeNode) (evaled bool
 	case ast.ScopeTypeEq:
 		return true, e == t.Entity
 	case ast.ScopeTypeIn:
-		return true, entityInOne(env, e, t.Entity)
+		result = entityInOne(env, e, t.Entity)
+		return result || !reachesUnknown(env, e), result
 	case ast.ScopeTypeInSet:
 		set := mapset.Immutable(t.Entities...)
-		return true, entityInSet(env, e, set)
+		result = entityInSet(env, e, set)
+		return result || !reachesUnknown(env, e), result
 	case ast.ScopeTypeIs:
 		return true, e.Type == t.Type
 	case ast.ScopeTypeIsIn:
-		return true, e.Type == t.Type && entityInOne(env, e, t.Entity)
+		if e.Type != t.Type {
+			return true, false
+		}
+		result = entityInOne(env, e, t.Entity)
+		return result || !reachesUnknown(env, e), result
 	default:
 		panic(fmt.Sprintf("unknown scope type %T", t))
 	}
@@ -159,6 +190,16 @@ var errIgnore = fmt.Errorf("ignore")
 func tryPartial(env Env, nodes []ast.IsNode,
 	mkEval func(values []types.Value) Evaler,
 	mkNode func(nodes []ast.IsNode) ast.IsNode,
+) (ast.IsNode, error) {
+	return tryPartialOperands(env, nodes, false, mkEval, mkNode)
+}
+
+// tryPartialOperands is like tryPartial, but for an operation which only looks up attributes if attributes is set.  A
+// record or set which contains a variable is not known, so an operand which evaluates to one is left as it was, except
+// by such an operation: the attributes of the record which are not variables are known.
+func tryPartialOperands(env Env, nodes []ast.IsNode, attributes bool,
+	mkEval func(values []types.Value) Evaler,
+	mkNode func(nodes []ast.IsNode) ast.IsNode,
 ) (ast.IsNode, error) {
 	var values []types.Value
 	ok := true
@@ -170,6 +211,10 @@ func tryPartial(env Env, nodes []ast.IsNode,
 		} else if err != nil {
 			return nil, err
 		}
+		if v, vok := n.(ast.NodeValue); vok && !attributes && containsVariable(v.Value) {
+			ok = false
+			continue
+		}
 		nodes[i] = n
 		if !ok {
 			continue
@@ -213,20 +258,20 @@ func tryPartialUnary(env Env, v ast.UnaryNode, mkEval func(a Evaler) Evaler, wra
 func partial(env Env, n ast.IsNode) (ast.IsNode, error) {
 	switch v := n.(type) {
 	case ast.NodeTypeAccess:
-		return tryPartial(env,
-			[]ast.IsNode{v.Arg},
+		return tryPartialOperands(env,
+			[]ast.IsNode{v.Arg}, true,
 			func(values []types.Value) Evaler {
-				return newAttributeAccessEval(newLiteralEval(values[0]), v.Value)
+				return derefEval(env, values[0], newAttributeAccessEval(newLiteralEval(values[0]), v.Value))
 			},
 			func(nodes []ast.IsNode) ast.IsNode {
 				return ast.NodeTypeAccess{StrOpNode: ast.StrOpNode{Arg: nodes[0], Value: v.Value}}
 			},
 		)
 	case ast.NodeTypeHas:
-		return tryPartial(env,
-			[]ast.IsNode{v.Arg},
+		return tryPartialOperands(env,
+			[]ast.IsNode{v.Arg}, true,
 			func(values []types.Value) Evaler {
-				return newPartialHasEval(newLiteralEval(values[0]), v.Value)
+				return derefEval(env, values[0], newPartialHasEval(newLiteralEval(values[0]), v.Value))
 			},
 			func(nodes []ast.IsNode) ast.IsNode {
 				return ast.NodeTypeHas{StrOpNode: ast.StrOpNode{Arg: nodes[0], Value: v.Value}}
@@ -236,7 +281,7 @@ func partial(env Env, n ast.IsNode) (ast.IsNode, error) {
 		return tryPartial(env,
 			[]ast.IsNode{v.Left, v.Right},
 			func(values []types.Value) Evaler {
-				return newGetTagEval(newLiteralEval(values[0]), newLiteralEval(values[1]))
+				return derefEval(env, values[0], newGetTagEval(newLiteralEval(values[0]), newLiteralEval(values[1])))
 			},
 			func(nodes []ast.IsNode) ast.IsNode {
 				return ast.NodeTypeGetTag{BinaryNode: ast.BinaryNode{Left: nodes[0], Right: nodes[1]}}
@@ -246,7 +291,7 @@ func partial(env Env, n ast.IsNode) (ast.IsNode, error) {
 		return tryPartial(env,
 			[]ast.IsNode{v.Left, v.Right},
 			func(values []types.Value) Evaler {
-				return newHasTagEval(newLiteralEval(values[0]), newLiteralEval(values[1]))
+				return derefEval(env, values[0], newHasTagEval(newLiteralEval(values[0]), newLiteralEval(values[1])))
 			},
 			func(nodes []ast.IsNode) ast.IsNode {
 				return ast.NodeTypeHasTag{BinaryNode: ast.BinaryNode{Left: nodes[0], Right: nodes[1]}}
@@ -278,7 +323,7 @@ func partial(env Env, n ast.IsNode) (ast.IsNode, error) {
 		return tryPartial(env,
 			[]ast.IsNode{v.Left, v.Entity},
 			func(values []types.Value) Evaler {
-				return newIsInEval(newLiteralEval(values[0]), v.EntityType, newLiteralEval(values[1]))
+				return newPartialInEval(values[0], newIsInEval(newLiteralEval(values[0]), v.EntityType, newLiteralEval(values[1])))
 			},
 			func(nodes []ast.IsNode) ast.IsNode {
 				return ast.NodeTypeIsIn{NodeTypeIs: ast.NodeTypeIs{Left: nodes[0], EntityType: v.EntityType}, Entity: nodes[1]}
@@ -353,7 +398,15 @@ func partial(env Env, n ast.IsNode) (ast.IsNode, error) {
 			},
 		)
 	case ast.NodeTypeIn:
-		return tryPartialBinary(env, v.BinaryNode, newInEval, func(b ast.BinaryNode) ast.IsNode { return ast.NodeTypeIn{BinaryNode: b} })
+		return tryPartial(env,
+			[]ast.IsNode{v.Left, v.Right},
+			func(values []types.Value) Evaler {
+				return newPartialInEval(values[0], newInEval(newLiteralEval(values[0]), newLiteralEval(values[1])))
+			},
+			func(nodes []ast.IsNode) ast.IsNode {
+				return ast.NodeTypeIn{BinaryNode: ast.BinaryNode{Left: nodes[0], Right: nodes[1]}}
+			},
+		)
 	case ast.NodeTypeAnd:
 		return partialAnd(env, v)
 	case ast.NodeTypeOr:
@@ -508,6 +561,16 @@ func extError(err error) ast.NodeTypeExtensionCall {
 	return ast.NodeTypeExtensionCall{Name: partialErrorName, Args: []ast.IsNode{ast.NodeValue{Value: types.String(err.Error())}}}
 }
 
+// ToPartialError returns the error message of a node which partial evaluation produced in place of an expression that
+// fails to evaluate.
+func ToPartialError(n ast.IsNode) (string, bool) {
+	e, ok := n.(ast.NodeTypeExtensionCall)
+	if !ok || e.Name != partialErrorName {
+		return "", false
+	}
+	return string(e.Args[0].(ast.NodeValue).Value.(types.String)), true
+}
+
 // partialHasEval
 type partialHasEval struct {
 	object    Evaler
@@ -541,6 +604,61 @@ func (n *partialHasEval) Eval(env Env) (types.Value, error) {
 	return types.Boolean(ok), nil
 }
 
+// derefEval returns an Evaler which produces a variable if entity is unknown, since evaluating an operation which
+// dereferences it requires its data, and which otherwise evaluates e.
+func derefEval(env Env, entity types.Value, e Evaler) Evaler {
+	if uid, ok := entity.(types.EntityUID); ok && env.UnknownEntities.Contains(uid) {
+		return newLiteralEval(Variable(""))
+	}
+	return e
+}
+
+// reachesUnknown reports whether an unknown entity is found while traversing the ancestors of entity, so that whether
+// entity is in another entity cannot be decided unless a path to it is found through known entities.
+func reachesUnknown(env Env, entity types.EntityUID) bool {
+	if env.UnknownEntities.Len() == 0 {
+		return false
+	}
+	var known mapset.MapSet[types.EntityUID]
+	todo := []types.EntityUID{entity}
+	for len(todo) > 0 {
+		var candidate types.EntityUID
+		candidate, todo = todo[len(todo)-1], todo[:len(todo)-1]
+		if env.UnknownEntities.Contains(candidate) {
+			return true
+		}
+		if known.Contains(candidate) {
+			continue
+		}
+		known.Add(candidate)
+		if fe, ok := env.Entities.Get(candidate); ok {
+			todo = slices.AppendSeq(todo, fe.Parents.All())
+		}
+	}
+	return false
+}
+
+// partialInEval
+type partialInEval struct {
+	entity types.Value
+	in     Evaler
+}
+
+func newPartialInEval(entity types.Value, in Evaler) *partialInEval {
+	return &partialInEval{entity: entity, in: in}
+}
+
+func (n *partialInEval) Eval(env Env) (types.Value, error) {
+	v, err := n.in.Eval(env)
+	if err != nil || v != types.False {
+		return v, err
+	}
+	if e, ok := n.entity.(types.EntityUID); ok && reachesUnknown(env, e) {
+		return Variable(""), nil
+	}
+	return v, nil
+}
+
 // partialErrorEval
 type partialErrorEval struct {
 	arg Evaler
@@ -137,6 +137,13 @@ func TestPartialScopeEval(t *testing.T) {
 			ast.ScopeTypeIsIn{Type: "T", Entity: types.NewEntityUID("T", "FAIL")},
 			true, false,
 		},
+		{
+			"scopeTypeSlotIn",
+			Env{},
+			Variable("principal"),
+			ast.ScopeTypeSlotIn{Slot: types.PrincipalSlot},
+			true, false,
+		},
 	}
 
 	for _, tt := range tests {
@@ -443,6 +450,37 @@ func TestPartialPolicy(t *testing.T) {
 			ast.Permit().When(ast.True().Equal(ast.False().Equal(ast.Context()))),
 			true,
 		},
+		{"keepRecordWithVariable",
+			ast.Permit().When(ast.Context().Equal(ast.Value(types.NewRecord(types.RecordMap{"a": types.Long(1), "b": types.Long(2)})))),
+			Env{
+				Context: types.NewRecord(types.RecordMap{"a": Variable("a"), "b": types.Long(2)}),
+			},
+			ast.Permit().When(ast.Context().Equal(ast.Value(types.NewRecord(types.RecordMap{"a": types.Long(1), "b": types.Long(2)})))),
+			true,
+		},
+		{"keepSetWithVariable",
+			ast.Permit().When(ast.Context().Access("s").Contains(ast.Long(1)).And(ast.Context().Access("t").IsEmpty())),
+			Env{
+				Context: types.NewRecord(types.RecordMap{
+					"s": types.NewSet(types.Long(2), Variable("x")),
+					"t": types.NewSet(types.NewRecord(types.RecordMap{"v": Variable("v")})),
+				}),
+			},
+			ast.Permit().When(ast.Context().Access("s").Contains(ast.Long(1)).And(ast.Context().Access("t").IsEmpty())),
+			true,
+		},
+		{"knownAttributeOfRecordWithVariable",
+			ast.Permit().When(ast.Context().Access("b").Equal(ast.Long(2)).And(ast.Context().Access("c").Contains(ast.Long(3)))),
+			Env{
+				Context: types.NewRecord(types.RecordMap{
+					"a": Variable("a"),
+					"b": types.Long(2),
+					"c": types.NewSet(types.Long(3)),
+				}),
+			},
+			ast.Permit(),
+			true,
+		},
 	}
 	for _, tt := range tests {
 		tt := tt
@@ -1355,6 +1393,123 @@ func TestPartialWithContext(t *testing.T) {
 	}
 }
 
+func TestPartialUnknownEntities(t *testing.T) {
+	t.Parallel()
+	alice := types.NewEntityUID("User", "alice")
+	staff := types.NewEntityUID("Group", "staff")
+	admins := types.NewEntityUID("Group", "admins")
+	doc := types.NewEntityUID("Doc", "readme")
+	env := Env{
+		Entities: types.EntityMap{
+			alice: types.Entity{UID: alice, Parents: types.NewEntityUIDSet(staff, alice)},
+			doc:   types.Entity{UID: doc, Attributes: types.NewRecord(types.RecordMap{"owner": alice})},
+		},
+		Principal:       alice,
+		Action:          types.NewEntityUID("Action", "view"),
+		Resource:        doc,
+		Context:         types.Record{},
+		UnknownEntities: types.NewEntityUIDSet(staff, doc),
+	}
+	tests := []struct {
+		name string
+		in   *ast.Policy
+		out  *ast.Policy
+		keep bool
+	}{
+		{"scopeInKnownPath",
+			ast.Permit().PrincipalIn(staff),
+			ast.Permit(),
+			true,
+		},
+		{"scopeInUnknownPath",
+			ast.Permit().PrincipalIn(admins),
+			ast.Permit().PrincipalIn(admins),
+			true,
+		},
+		{"scopeInSetKnownPath",
+			ast.Permit().ActionInSet(admins),
+			nil,
+			false,
+		},
+		{"scopeInSetUnknownResource",
+			ast.Permit().ResourceIn(admins),
+			ast.Permit().ResourceIn(admins),
+			true,
+		},
+		{"scopeIsInWrongType",
+			ast.Permit().PrincipalIsIn("Group", admins),
+			nil,
+			false,
+		},
+		{"scopeIsInUnknownPath",
+			ast.Permit().PrincipalIsIn("User", admins),
+			ast.Permit().PrincipalIsIn("User", admins),
+			true,
+		},
+		{"scopeInSetUnknownPrincipal",
+			ast.Permit().PrincipalIn(alice).When(ast.Principal().In(ast.Set(ast.EntityUID("Group", "admins")))),
+			ast.Permit().When(ast.Principal().In(ast.Set(ast.EntityUID("Group", "admins")))),
+			true,
+		},
+		{"access",
+			ast.Permit().When(ast.Resource().Access("owner").Equal(ast.Principal())),
+			ast.Permit().When(ast.Resource().Access("owner").Equal(ast.EntityUID("User", "alice"))),
+			true,
+		},
+		{"has",
+			ast.Permit().When(ast.Resource().Has("owner")),
+			ast.Permit().When(ast.Resource().Has("owner")),
+			true,
+		},
+		{"tags",
+			ast.Permit().When(ast.Resource().HasTag(ast.String("a")).And(ast.Resource().GetTag(ast.String("a")))),
+			ast.Permit().When(ast.EntityUID("Doc", "readme").HasTag(ast.String("a")).And(ast.EntityUID("Doc", "readme").GetTag(ast.String("a")))),
+			true,
+		},
+		{"knownAccess",
+			ast.Permit().When(ast.Principal().Has("owner")),
+			nil,
+			false,
+		},
+		{"inKnownPath",
+			ast.Permit().When(ast.Principal().In(ast.EntityUID("Group", "staff"))),
+			ast.Permit(),
+			true,
+		},
+		{"inUnknownPath",
+			ast.Permit().When(ast.Principal().In(ast.EntityUID("Group", "admins"))),
+			ast.Permit().When(ast.Principal().In(ast.EntityUID("Group", "admins"))),
+			true,
+		},
+		{"isInUnknownPath",
+			ast.Permit().When(ast.Principal().IsIn("User", ast.EntityUID("Group", "admins"))),
+			ast.Permit().When(ast.Principal().IsIn("User", ast.EntityUID("Group", "admins"))),
+			true,
+		},
+		{"inKnownEntity",
+			ast.Permit().When(ast.EntityUID("Group", "admins").In(ast.EntityUID("Group", "staff"))),
+			nil,
+			false,
+		},
+		{"inError",
+			ast.Permit().When(ast.String("a").In(ast.EntityUID("Group", "staff"))),
+			ast.Permit().When(ast.NewNode(extError(errors.New("type error: expected (entity of type `any_entity_type`), got string")))),
+			true,
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			out, keep := PartialPolicy(env, tt.in)
+			testutil.Equals(t, keep, tt.keep)
+			if keep {
+				testutil.Equals(t, out, tt.out)
+			}
+		})
+	}
+}
+
 func TestPartialPanic(t *testing.T) {
 	t.Parallel()
 	testutil.Panic(t, func() {
@@ -1568,3 +1723,14 @@ func TestToVariable(t *testing.T) {
 		})
 	}
 }
+
+func TestToPartialError(t *testing.T) {
+	t.Parallel()
+	msg, ok := ToPartialError(extError(errors.New("oops")))
+	testutil.Equals(t, ok, true)
+	testutil.Equals(t, msg, "oops")
+	_, ok = ToPartialError(ast.NodeTypeExtensionCall{Name: "ip"})
+	testutil.Equals(t, ok, false)
+	_, ok = ToPartialError(ast.NodeValue{Value: types.True})
+	testutil.Equals(t, ok, false)
+}
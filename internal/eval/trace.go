@@ -0,0 +1,242 @@
+package eval
+
+import (
+	"github.com/cedar-policy/cedar-go/types"
+	"github.com/cedar-policy/cedar-go/x/exp/ast"
+)
+
+// A NodeResult is the result of evaluating an expression.  Position is the source position of the expression, which is
+// valid only if it is known.
+type NodeResult struct {
+	Node     ast.IsNode
+	Position ast.Position
+	Value    types.Value
+	Err      error
+}
+
+// EvalNode evaluates the expression.
+func EvalNode(env Env, n ast.IsNode) NodeResult {
+	v, err := toEval(n).Eval(env)
+	if err != nil {
+		return NodeResult{Node: n, Err: err}
+	}
+	return NodeResult{Node: n, Value: v}
+}
+
+// TraceScope evaluates the constraints of the scope of the policy on the principal, action and resource, leaving out
+// those which match any entity.
+func TraceScope(env Env, p *ast.Policy) []NodeResult {
+	var res []NodeResult
+	for _, n := range ScopeNodes(p) {
+		res = append(res, EvalNode(env, n))
+	}
+	return res
+}
+
+// ScopeNodes returns the constraints of the scope of the policy on the principal, action and resource as expressions,
+// leaving out those which match any entity.
+func ScopeNodes(p *ast.Policy) []ast.IsNode {
+	var res []ast.IsNode
+	if _, ok := p.Principal.(ast.ScopeTypeAll); !ok {
+		res = append(res, scopeToNode(ast.NewPrincipalNode(), p.Principal).AsIsNode())
+	}
+	if _, ok := p.Action.(ast.ScopeTypeAll); !ok {
+		res = append(res, scopeToNode(ast.NewActionNode(), p.Action).AsIsNode())
+	}
+	if _, ok := p.Resource.(ast.ScopeTypeAll); !ok {
+		res = append(res, scopeToNode(ast.NewResourceNode(), p.Resource).AsIsNode())
+	}
+	return res
+}
+
+// ConditionPosition returns the source position of the body of a condition, which is valid only if the parser recorded
+// it.
+func ConditionPosition(c ast.ConditionType) ast.Position {
+	return locateCondition(c).position()
+}
+
+// WalkCondition calls f for each node of the body of a condition, before its descendants, along with its source
+// position, which is valid only if the parser recorded it.
+func WalkCondition(c ast.ConditionType, f func(ast.IsNode, ast.Position)) {
+	locateCondition(c).walk(f)
+}
+
+// KeySubexpressions evaluates the key subexpressions of the body of a condition: the operands of `&&`, `||` and `!`,
+// down to the first expression which is none of those, and the operands of that expression which are not literal
+// values.  The results hold the source positions of the subexpressions if the parser recorded them.
+func KeySubexpressions(env Env, c ast.ConditionType) []NodeResult {
+	var res []NodeResult
+	for _, k := range keySubexpressions(locateCondition(c), false) {
+		res = append(res, k.eval(env))
+	}
+	return res
+}
+
+func keySubexpressions(n locatedNode, include bool) []locatedNode {
+	var res []locatedNode
+	switch n.node.(type) {
+	case ast.NodeTypeAnd, ast.NodeTypeOr:
+		c := n.children()
+		return append(keySubexpressions(c[0], true), keySubexpressions(c[1], true)...)
+	case ast.NodeTypeNot:
+		return keySubexpressions(n.children()[0], true)
+	}
+	if include {
+		res = append(res, n)
+	}
+	if !testsOperands(n.node) {
+		return res
+	}
+	for _, o := range n.children() {
+		if _, ok := o.node.(ast.NodeValue); !ok {
+			res = append(res, o)
+		}
+	}
+	return res
+}
+
+// testsOperands reports whether an expression compares or tests the values of its operands.
+func testsOperands(n ast.IsNode) bool {
+	switch n.(type) {
+	case ast.NodeTypeEquals, ast.NodeTypeNotEquals, ast.NodeTypeLessThan, ast.NodeTypeLessThanOrEqual,
+		ast.NodeTypeGreaterThan, ast.NodeTypeGreaterThanOrEqual, ast.NodeTypeIn, ast.NodeTypeContains,
+		ast.NodeTypeContainsAll, ast.NodeTypeContainsAny, ast.NodeTypeHasTag, ast.NodeTypeIsIn, ast.NodeTypeIs,
+		ast.NodeTypeHas, ast.NodeTypeLike, ast.NodeTypeIsEmpty:
+		return true
+	}
+	return false
+}
+
+// A locatedNode is a node of the body of a condition, along with the source positions of it and its descendants in
+// post-order, if the parser recorded them.
+type locatedNode struct {
+	node      ast.IsNode
+	positions []ast.Position
+}
+
+// locateCondition returns the body of the condition, with its positions if there is one for each of its nodes.
+func locateCondition(c ast.ConditionType) locatedNode {
+	if len(c.Positions) != nodeCount(c.Body) {
+		return locatedNode{node: c.Body}
+	}
+	return locatedNode{node: c.Body, positions: c.Positions}
+}
+
+func (n locatedNode) position() ast.Position {
+	if len(n.positions) == 0 {
+		return ast.Position{}
+	}
+	return n.positions[len(n.positions)-1]
+}
+
+func (n locatedNode) walk(f func(ast.IsNode, ast.Position)) {
+	f(n.node, n.position())
+	for _, c := range n.children() {
+		c.walk(f)
+	}
+}
+
+func (n locatedNode) eval(env Env) NodeResult {
+	res := EvalNode(env, n.node)
+	res.Position = n.position()
+	return res
+}
+
+// children returns the operands of the node, in the order in which they appear in the source, each followed in
+// post-order by the next.
+func (n locatedNode) children() []locatedNode {
+	var res []locatedNode
+	start := 0
+	for _, c := range children(n.node) {
+		end := start + nodeCount(c)
+		l := locatedNode{node: c}
+		if n.positions != nil {
+			l.positions = n.positions[start:end]
+		}
+		res = append(res, l)
+		start = end
+	}
+	return res
+}
+
+// nodeCount returns the number of nodes in the expression.
+func nodeCount(n ast.IsNode) int {
+	res := 1
+	for _, c := range children(n) {
+		res += nodeCount(c)
+	}
+	return res
+}
+
+func binaryChildren(n ast.BinaryNode) []ast.IsNode {
+	return []ast.IsNode{n.Left, n.Right}
+}
+
+// children returns the operands of n, in the order in which they appear in the source.
+func children(n ast.IsNode) []ast.IsNode {
+	switch n := n.(type) {
+	case ast.NodeTypeAccess:
+		return []ast.IsNode{n.Arg}
+	case ast.NodeTypeHas:
+		return []ast.IsNode{n.Arg}
+	case ast.NodeTypeLike:
+		return []ast.IsNode{n.Arg}
+	case ast.NodeTypeIs:
+		return []ast.IsNode{n.Left}
+	case ast.NodeTypeIsIn:
+		return []ast.IsNode{n.Left, n.Entity}
+	case ast.NodeTypeIfThenElse:
+		return []ast.IsNode{n.If, n.Then, n.Else}
+	case ast.NodeTypeExtensionCall:
+		return n.Args
+	case ast.NodeTypeRecord:
+		res := make([]ast.IsNode, len(n.Elements))
+		for i, e := range n.Elements {
+			res[i] = e.Value
+		}
+		return res
+	case ast.NodeTypeSet:
+		return n.Elements
+	case ast.NodeTypeNegate:
+		return []ast.IsNode{n.Arg}
+	case ast.NodeTypeNot:
+		return []ast.IsNode{n.Arg}
+	case ast.NodeTypeIsEmpty:
+		return []ast.IsNode{n.Arg}
+	case ast.NodeTypeGetTag:
+		return binaryChildren(n.BinaryNode)
+	case ast.NodeTypeHasTag:
+		return binaryChildren(n.BinaryNode)
+	case ast.NodeTypeAnd:
+		return binaryChildren(n.BinaryNode)
+	case ast.NodeTypeOr:
+		return binaryChildren(n.BinaryNode)
+	case ast.NodeTypeEquals:
+		return binaryChildren(n.BinaryNode)
+	case ast.NodeTypeNotEquals:
+		return binaryChildren(n.BinaryNode)
+	case ast.NodeTypeLessThan:
+		return binaryChildren(n.BinaryNode)
+	case ast.NodeTypeLessThanOrEqual:
+		return binaryChildren(n.BinaryNode)
+	case ast.NodeTypeGreaterThan:
+		return binaryChildren(n.BinaryNode)
+	case ast.NodeTypeGreaterThanOrEqual:
+		return binaryChildren(n.BinaryNode)
+	case ast.NodeTypeIn:
+		return binaryChildren(n.BinaryNode)
+	case ast.NodeTypeAdd:
+		return binaryChildren(n.BinaryNode)
+	case ast.NodeTypeSub:
+		return binaryChildren(n.BinaryNode)
+	case ast.NodeTypeMult:
+		return binaryChildren(n.BinaryNode)
+	case ast.NodeTypeContains:
+		return binaryChildren(n.BinaryNode)
+	case ast.NodeTypeContainsAll:
+		return binaryChildren(n.BinaryNode)
+	case ast.NodeTypeContainsAny:
+		return binaryChildren(n.BinaryNode)
+	}
+	return nil
+}
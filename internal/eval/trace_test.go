@@ -0,0 +1,171 @@
+package eval
+
+import (
+	"testing"
+
+	"github.com/cedar-policy/cedar-go/internal/testutil"
+	"github.com/cedar-policy/cedar-go/types"
+	"github.com/cedar-policy/cedar-go/x/exp/ast"
+)
+
+func TestTraceScope(t *testing.T) {
+	t.Parallel()
+	alice := types.NewEntityUID("User", "alice")
+	env := Env{Entities: types.EntityMap{}, Principal: alice, Action: types.NewEntityUID("Action", "view"), Resource: alice}
+	testutil.Equals(t, TraceScope(env, ast.Permit()), nil)
+
+	p := ast.Permit().PrincipalEq(alice).ActionEq(types.NewEntityUID("Action", "edit")).ResourceIs("Doc")
+	res := TraceScope(env, p)
+	testutil.Equals(t, len(res), 3)
+	testutil.Equals(t, res[0].Value, types.Value(types.True))
+	testutil.Equals(t, res[1].Value, types.Value(types.False))
+	testutil.Equals(t, res[2].Value, types.Value(types.False))
+	testutil.Equals(t, res[2].Node, ast.Resource().Is("Doc").AsIsNode())
+	testutil.Equals(t, ScopeNodes(p)[2], ast.Resource().Is("Doc").AsIsNode())
+}
+
+func TestKeySubexpressions(t *testing.T) {
+	t.Parallel()
+	x := ast.Context().Access("x")
+	y := ast.Context().Access("y")
+	tests := []struct {
+		name string
+		in   ast.Node
+		out  []ast.Node
+	}{
+		{"value", ast.True(), nil},
+		{"access", x, nil},
+		{"equals", x.Equal(ast.Long(1)), []ast.Node{x}},
+		{"notEquals", x.NotEqual(y), []ast.Node{x, y}},
+		{"lessThan", x.LessThan(y), []ast.Node{x, y}},
+		{"lessThanOrEqual", x.LessThanOrEqual(y), []ast.Node{x, y}},
+		{"greaterThan", x.GreaterThan(y), []ast.Node{x, y}},
+		{"greaterThanOrEqual", x.GreaterThanOrEqual(y), []ast.Node{x, y}},
+		{"in", x.In(y), []ast.Node{x, y}},
+		{"contains", x.Contains(y), []ast.Node{x, y}},
+		{"containsAll", x.ContainsAll(y), []ast.Node{x, y}},
+		{"containsAny", x.ContainsAny(y), []ast.Node{x, y}},
+		{"hasTag", x.HasTag(y), []ast.Node{x, y}},
+		{"isIn", x.IsIn("User", y), []ast.Node{x, y}},
+		{"is", x.Is("User"), []ast.Node{x}},
+		{"has", x.Has("z"), []ast.Node{x}},
+		{"like", x.Like(types.NewPattern("a", types.Wildcard{})), []ast.Node{x}},
+		{"isEmpty", x.IsEmpty(), []ast.Node{x}},
+		{"and", x.Equal(y).And(ast.Not(x.Has("z"))).Or(y), []ast.Node{x.Equal(y), x, y, x.Has("z"), x, y}},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			var want []ast.IsNode
+			for _, n := range tt.out {
+				want = append(want, n.AsIsNode())
+			}
+			var got []ast.IsNode
+			for _, r := range KeySubexpressions(Env{Context: types.Record{}}, ast.ConditionType{Body: tt.in.AsIsNode()}) {
+				got = append(got, r.Node)
+				testutil.Error(t, r.Err)
+			}
+			testutil.Equals(t, got, want)
+		})
+	}
+	t.Run("values", func(t *testing.T) {
+		t.Parallel()
+		env := Env{Context: types.NewRecord(types.RecordMap{"x": types.Long(1)})}
+		res := KeySubexpressions(env, ast.ConditionType{Body: x.Equal(ast.Long(1)).AsIsNode()})
+		testutil.Equals(t, res, []NodeResult{{Node: x.AsIsNode(), Value: types.Long(1)}})
+	})
+	t.Run("positions", func(t *testing.T) {
+		t.Parallel()
+		// context.x == 1 || !context.y, with a position for each node in post-order
+		pos := func(column int) ast.Position { return ast.Position{Offset: column - 1, Line: 1, Column: column} }
+		c := ast.ConditionType{
+			Body:      x.Equal(ast.Long(1)).Or(ast.Not(y)).AsIsNode(),
+			Positions: []ast.Position{pos(1), pos(1), pos(14), pos(1), pos(20), pos(20), pos(19), pos(1)},
+		}
+		env := Env{Context: types.NewRecord(types.RecordMap{"x": types.Long(1), "y": types.False})}
+		testutil.Equals(t, KeySubexpressions(env, c), []NodeResult{
+			{Node: x.Equal(ast.Long(1)).AsIsNode(), Position: pos(1), Value: types.True},
+			{Node: x.AsIsNode(), Position: pos(1), Value: types.Long(1)},
+			{Node: y.AsIsNode(), Position: pos(20), Value: types.False},
+		})
+		testutil.Equals(t, ConditionPosition(c), pos(1))
+
+		c.Positions = c.Positions[1:]
+		testutil.Equals(t, KeySubexpressions(env, c)[2].Position, ast.Position{})
+		testutil.Equals(t, ConditionPosition(c), ast.Position{})
+	})
+}
+
+func TestWalkCondition(t *testing.T) {
+	t.Parallel()
+	x := ast.Context().Access("x")
+	pos := func(column int) ast.Position { return ast.Position{Offset: column - 1, Line: 1, Column: column} }
+	// !context.x, with a position for each node in post-order
+	c := ast.ConditionType{
+		Body:      ast.Not(x).AsIsNode(),
+		Positions: []ast.Position{pos(2), pos(2), pos(1)},
+	}
+	var nodes []ast.IsNode
+	var positions []ast.Position
+	WalkCondition(c, func(n ast.IsNode, p ast.Position) {
+		nodes = append(nodes, n)
+		positions = append(positions, p)
+	})
+	testutil.Equals(t, nodes, []ast.IsNode{ast.Not(x).AsIsNode(), x.AsIsNode(), ast.Context().AsIsNode()})
+	testutil.Equals(t, positions, []ast.Position{pos(1), pos(2), pos(2)})
+
+	c.Positions = nil
+	positions = nil
+	WalkCondition(c, func(_ ast.IsNode, p ast.Position) { positions = append(positions, p) })
+	testutil.Equals(t, positions, []ast.Position{{}, {}, {}})
+}
+
+func TestNodeCount(t *testing.T) {
+	t.Parallel()
+	x := ast.Context().Access("x")
+	tests := []struct {
+		name string
+		in   ast.Node
+		out  int
+	}{
+		{"value", ast.Long(1), 1},
+		{"variable", ast.Context(), 1},
+		{"access", x, 2},
+		{"has", ast.Context().Has("x"), 2},
+		{"like", x.Like(types.NewPattern("a")), 3},
+		{"is", x.Is("User"), 3},
+		{"isIn", x.IsIn("User", x), 5},
+		{"ifThenElse", ast.IfThenElse(ast.True(), x, ast.False()), 5},
+		{"extensionCall", ast.ExtensionCall("ip", ast.String("::1")), 2},
+		{"record", ast.Record(ast.Pairs{{Key: "a", Value: x}, {Key: "b", Value: ast.Long(1)}}), 4},
+		{"set", ast.Set(x, ast.Long(1)), 4},
+		{"negate", ast.Negate(x), 3},
+		{"not", ast.Not(x), 3},
+		{"isEmpty", x.IsEmpty(), 3},
+		{"getTag", x.GetTag(ast.String("a")), 4},
+		{"hasTag", x.HasTag(ast.String("a")), 4},
+		{"and", x.And(x), 5},
+		{"or", x.Or(x), 5},
+		{"equals", x.Equal(x), 5},
+		{"notEquals", x.NotEqual(x), 5},
+		{"lessThan", x.LessThan(x), 5},
+		{"lessThanOrEqual", x.LessThanOrEqual(x), 5},
+		{"greaterThan", x.GreaterThan(x), 5},
+		{"greaterThanOrEqual", x.GreaterThanOrEqual(x), 5},
+		{"in", x.In(x), 5},
+		{"add", x.Add(x), 5},
+		{"sub", x.Subtract(x), 5},
+		{"mult", x.Multiply(x), 5},
+		{"contains", x.Contains(x), 5},
+		{"containsAll", x.ContainsAll(x), 5},
+		{"containsAny", x.ContainsAny(x), 5},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			testutil.Equals(t, nodeCount(tt.in.AsIsNode()), tt.out)
+		})
+	}
+}
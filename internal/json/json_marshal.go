@@ -36,10 +36,26 @@ func (s *scopeJSON) FromNode(src ast.IsScopeNode) {
 		s.EntityType = string(t.Type)
 		return
 	case ast.ScopeTypeIsIn:
+		s.Op = "is"
+		s.EntityType = string(t.Type)
+		e := types.ImplicitlyMarshaledEntityUID(t.Entity)
+		s.In = &scopeInJSON{
+			Entity: &e,
+		}
+		return
+	case ast.ScopeTypeSlotEq:
+		s.Op = "=="
+		s.Slot = string(t.Slot)
+		return
+	case ast.ScopeTypeSlotIn:
+		s.Op = "in"
+		s.Slot = string(t.Slot)
+		return
+	case ast.ScopeTypeSlotIsIn:
 		s.Op = "is"
 		s.EntityType = string(t.Type)
 		s.In = &scopeInJSON{
-			Entity: types.ImplicitlyMarshaledEntityUID(t.Entity),
+			Slot: string(t.Slot),
 		}
 		return
 	default:
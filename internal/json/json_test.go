@@ -170,6 +170,42 @@ func TestUnmarshalJSON(t *testing.T) {
 			ast.Permit().ResourceIsIn(types.EntityType("T"), types.NewEntityUID("P", "42")),
 			testutil.OK,
 		},
+		{
+			"principalEqSlot",
+			`{"effect":"permit","principal":{"op":"==","slot":"?principal"},"action":{"op":"All"},"resource":{"op":"All"}}`,
+			ast.Permit().PrincipalEqSlot(),
+			testutil.OK,
+		},
+		{
+			"principalInSlot",
+			`{"effect":"permit","principal":{"op":"in","slot":"?principal"},"action":{"op":"All"},"resource":{"op":"All"}}`,
+			ast.Permit().PrincipalInSlot(),
+			testutil.OK,
+		},
+		{
+			"principalIsInSlot",
+			`{"effect":"permit","principal":{"op":"is","entity_type":"T","in":{"slot":"?principal"}},"action":{"op":"All"},"resource":{"op":"All"}}`,
+			ast.Permit().PrincipalIsInSlot(types.EntityType("T")),
+			testutil.OK,
+		},
+		{
+			"resourceEqSlot",
+			`{"effect":"permit","principal":{"op":"All"},"action":{"op":"All"},"resource":{"op":"==","slot":"?resource"}}`,
+			ast.Permit().ResourceEqSlot(),
+			testutil.OK,
+		},
+		{
+			"resourceInSlot",
+			`{"effect":"permit","principal":{"op":"All"},"action":{"op":"All"},"resource":{"op":"in","slot":"?resource"}}`,
+			ast.Permit().ResourceInSlot(),
+			testutil.OK,
+		},
+		{
+			"resourceIsInSlot",
+			`{"effect":"permit","principal":{"op":"All"},"action":{"op":"All"},"resource":{"op":"is","entity_type":"T","in":{"slot":"?resource"}}}`,
+			ast.Permit().ResourceIsInSlot(types.EntityType("T")),
+			testutil.OK,
+		},
 		{
 			"when",
 			`{"effect":"permit","principal":{"op":"All"},"action":{"op":"All"},"resource":{"op":"All"},
@@ -606,6 +642,22 @@ func TestUnmarshalErrors(t *testing.T) {
 			"principalScopeInMissingEntity",
 			`{"effect":"permit","principal":{"op":"in"},"action":{"op":"All"},"resource":{"op":"All"}}`,
 		},
+		{
+			"principalScopeIsInMissingEntity",
+			`{"effect":"permit","principal":{"op":"is","entity_type":"T","in":{}},"action":{"op":"All"},"resource":{"op":"All"}}`,
+		},
+		{
+			"principalScopeEqWrongSlot",
+			`{"effect":"permit","principal":{"op":"==","slot":"?resource"},"action":{"op":"All"},"resource":{"op":"All"}}`,
+		},
+		{
+			"principalScopeInWrongSlot",
+			`{"effect":"permit","principal":{"op":"in","slot":"?p"},"action":{"op":"All"},"resource":{"op":"All"}}`,
+		},
+		{
+			"resourceScopeIsInWrongSlot",
+			`{"effect":"permit","principal":{"op":"All"},"action":{"op":"All"},"resource":{"op":"is","entity_type":"T","in":{"slot":"?principal"}}}`,
+		},
 		{
 			"actionScopeEqMissingEntity",
 			`{"effect":"permit","principal":{"op":"All"},"action":{"op":"All"},"action":{"op":"=="}}`,
@@ -17,16 +17,22 @@ type isPrincipalResourceScopeNode interface {
 	ast.IsResourceScopeNode
 }
 
-func (s *scopeJSON) ToPrincipalResourceNode() (isPrincipalResourceScopeNode, error) {
+func (s *scopeJSON) ToPrincipalResourceNode(slot types.SlotID) (isPrincipalResourceScopeNode, error) {
 	switch s.Op {
 	case "All":
 		return ast.Scope{}.All(), nil
 	case "==":
+		if s.Slot != "" {
+			return ast.Scope{}.EqSlot(slot), checkSlot(s.Slot, slot)
+		}
 		if s.Entity == nil {
 			return nil, fmt.Errorf("missing entity")
 		}
 		return ast.Scope{}.Eq(types.EntityUID(*s.Entity)), nil
 	case "in":
+		if s.Slot != "" {
+			return ast.Scope{}.InSlot(slot), checkSlot(s.Slot, slot)
+		}
 		if s.Entity == nil {
 			return nil, fmt.Errorf("missing entity")
 		}
@@ -35,11 +41,24 @@ func (s *scopeJSON) ToPrincipalResourceNode() (isPrincipalResourceScopeNode, err
 		if s.In == nil {
 			return ast.Scope{}.Is(types.EntityType(s.EntityType)), nil
 		}
-		return ast.Scope{}.IsIn(types.EntityType(s.EntityType), types.EntityUID(s.In.Entity)), nil
+		if s.In.Slot != "" {
+			return ast.Scope{}.IsInSlot(types.EntityType(s.EntityType), slot), checkSlot(s.In.Slot, slot)
+		}
+		if s.In.Entity == nil {
+			return nil, fmt.Errorf("missing entity")
+		}
+		return ast.Scope{}.IsIn(types.EntityType(s.EntityType), types.EntityUID(*s.In.Entity)), nil
 	}
 	return nil, fmt.Errorf("unknown op: %v", s.Op)
 }
 
+func checkSlot(got string, want types.SlotID) error {
+	if types.SlotID(got) != want {
+		return fmt.Errorf("invalid slot: %v", got)
+	}
+	return nil
+}
+
 func (s *scopeJSON) ToActionNode() (ast.IsActionScopeNode, error) {
 	switch s.Op {
 	case "All":
@@ -307,7 +326,7 @@ func (p *Policy) UnmarshalJSON(b []byte) error {
 		p.unwrap().Annotate(types.Ident(k), types.String(v))
 	}
 	var err error
-	p.Principal, err = j.Principal.ToPrincipalResourceNode()
+	p.Principal, err = j.Principal.ToPrincipalResourceNode(types.PrincipalSlot)
 	if err != nil {
 		return fmt.Errorf("error in principal: %w", err)
 	}
@@ -315,7 +334,7 @@ func (p *Policy) UnmarshalJSON(b []byte) error {
 	if err != nil {
 		return fmt.Errorf("error in action: %w", err)
 	}
-	p.Resource, err = j.Resource.ToPrincipalResourceNode()
+	p.Resource, err = j.Resource.ToPrincipalResourceNode(types.ResourceSlot)
 	if err != nil {
 		return fmt.Errorf("error in resource: %w", err)
 	}
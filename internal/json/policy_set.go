@@ -1,7 +1,17 @@
 package json
 
+import "github.com/cedar-policy/cedar-go/types"
+
 type PolicySet map[string]*Policy
 
 type PolicySetJSON struct {
-	StaticPolicies PolicySet `json:"staticPolicies"`
+	StaticPolicies PolicySet          `json:"staticPolicies"`
+	Templates      PolicySet          `json:"templates,omitempty"`
+	TemplateLinks  []TemplateLinkJSON `json:"templateLinks,omitempty"`
+}
+
+type TemplateLinkJSON struct {
+	TemplateID string                                        `json:"templateId"`
+	NewID      string                                        `json:"newId"`
+	Values     map[string]types.ImplicitlyMarshaledEntityUID `json:"values"`
 }
@@ -0,0 +1,148 @@
+package json
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+)
+
+// Schema JSON type names, as used in the "type" field of a type object.
+const (
+	schemaTypeBoolean        = "Boolean"
+	schemaTypeLong           = "Long"
+	schemaTypeString         = "String"
+	schemaTypeSet            = "Set"
+	schemaTypeRecord         = "Record"
+	schemaTypeEntity         = "Entity"
+	schemaTypeExtension      = "Extension"
+	schemaTypeEntityOrCommon = "EntityOrCommon"
+)
+
+type schemaJSON = orderedJSON[schemaNamespaceJSON]
+
+type schemaNamespaceJSON struct {
+	Annotations *orderedJSON[string]               `json:"annotations,omitempty"`
+	EntityTypes orderedJSON[schemaEntityTypeJSON]  `json:"entityTypes"`
+	Actions     orderedJSON[schemaActionJSON]      `json:"actions"`
+	CommonTypes *orderedJSON[schemaCommonTypeJSON] `json:"commonTypes,omitempty"`
+}
+
+type schemaEntityTypeJSON struct {
+	Annotations   *orderedJSON[string] `json:"annotations,omitempty"`
+	MemberOfTypes []string             `json:"memberOfTypes,omitempty"`
+	Shape         *schemaTypeJSON      `json:"shape,omitempty"`
+	Tags          *schemaTypeJSON      `json:"tags,omitempty"`
+	Enum          []string             `json:"enum,omitempty"`
+}
+
+type schemaActionJSON struct {
+	Annotations *orderedJSON[string]  `json:"annotations,omitempty"`
+	MemberOf    []schemaActionRefJSON `json:"memberOf,omitempty"`
+	AppliesTo   *schemaAppliesToJSON  `json:"appliesTo,omitempty"`
+	Attributes  json.RawMessage       `json:"attributes,omitempty"`
+}
+
+type schemaActionRefJSON struct {
+	ID   string `json:"id"`
+	Type string `json:"type,omitempty"`
+}
+
+type schemaAppliesToJSON struct {
+	PrincipalTypes []string        `json:"principalTypes"`
+	ResourceTypes  []string        `json:"resourceTypes"`
+	Context        *schemaTypeJSON `json:"context,omitempty"`
+}
+
+// schemaTypeJSON is the union of all of the forms a type may take in a JSON schema.
+type schemaTypeJSON struct {
+	Type                 string                            `json:"type"`
+	Element              *schemaTypeJSON                   `json:"element,omitempty"`
+	Attributes           *orderedJSON[schemaAttributeJSON] `json:"attributes,omitempty"`
+	AdditionalAttributes bool                              `json:"additionalAttributes,omitempty"`
+	Name                 string                            `json:"name,omitempty"`
+}
+
+type schemaCommonTypeJSON struct {
+	schemaTypeJSON
+	Annotations *orderedJSON[string] `json:"annotations,omitempty"`
+}
+
+type schemaAttributeJSON struct {
+	schemaTypeJSON
+	Required    *bool                `json:"required,omitempty"`
+	Annotations *orderedJSON[string] `json:"annotations,omitempty"`
+}
+
+type orderedJSONEntry[T any] struct {
+	Key   string
+	Value T
+}
+
+// orderedJSON is a JSON object which retains the order of its keys.  This allows declarations to survive a round trip
+// through the JSON format in the order in which they were written.
+type orderedJSON[T any] []orderedJSONEntry[T]
+
+func (o orderedJSON[T]) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+	for i, e := range o {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		k, err := json.Marshal(e.Key)
+		if err != nil {
+			return nil, err
+		}
+		buf.Write(k)
+		buf.WriteByte(':')
+		v, err := json.Marshal(e.Value)
+		if err != nil {
+			return nil, err
+		}
+		buf.Write(v)
+	}
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
+
+func (o *orderedJSON[T]) UnmarshalJSON(b []byte) error {
+	dec := json.NewDecoder(bytes.NewReader(b))
+	t, err := dec.Token()
+	if err != nil {
+		return err
+	}
+	if t != json.Delim('{') {
+		return fmt.Errorf("expected object, got %v", t)
+	}
+	res := orderedJSON[T]{}
+	known := map[string]bool{}
+	for dec.More() {
+		t, err := dec.Token()
+		if err != nil {
+			return err
+		}
+		key := t.(string)
+		if known[key] {
+			return fmt.Errorf("duplicate key %q", key)
+		}
+		known[key] = true
+		var raw json.RawMessage
+		if err := dec.Decode(&raw); err != nil {
+			return err
+		}
+		var v T
+		if err := unmarshalStrict(raw, &v); err != nil {
+			return fmt.Errorf("%q: %w", key, err)
+		}
+		res = append(res, orderedJSONEntry[T]{Key: key, Value: v})
+	}
+	*o = res
+	return nil
+}
+
+// unmarshalStrict decodes b into v, rejecting any fields which v does not declare.
+func unmarshalStrict(b []byte, v any) error {
+	dec := json.NewDecoder(bytes.NewReader(b))
+	dec.DisallowUnknownFields()
+	return dec.Decode(v)
+}
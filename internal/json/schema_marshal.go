@@ -0,0 +1,197 @@
+package json
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/cedar-policy/cedar-go/types"
+	schemaast "github.com/cedar-policy/cedar-go/x/exp/schema/ast"
+)
+
+type Schema schemaast.Schema
+
+// MarshalJSON encodes the schema in the JSON schema format.  Declarations are emitted in the order in which they
+// appear in the schema.
+func (s *Schema) MarshalJSON() ([]byte, error) {
+	m := schemaJSONMarshaler{schema: (*schemaast.Schema)(s)}
+	res := schemaJSON{}
+	for _, ns := range s.Namespaces {
+		m.ns = ns
+		nsJSON, err := m.namespace(ns)
+		if err != nil {
+			return nil, err
+		}
+		res = append(res, orderedJSONEntry[schemaNamespaceJSON]{Key: string(ns.Name), Value: nsJSON})
+	}
+	return json.Marshal(res)
+}
+
+type schemaJSONMarshaler struct {
+	schema *schemaast.Schema
+	ns     *schemaast.Namespace
+}
+
+func (m *schemaJSONMarshaler) namespace(ns *schemaast.Namespace) (schemaNamespaceJSON, error) {
+	res := schemaNamespaceJSON{
+		Annotations: annotationsToJSON(ns.Annotations),
+		EntityTypes: orderedJSON[schemaEntityTypeJSON]{},
+		Actions:     orderedJSON[schemaActionJSON]{},
+	}
+	for _, e := range ns.Entities {
+		ej, err := m.entity(e)
+		if err != nil {
+			return res, fmt.Errorf("entity type %v: %w", e.Name, err)
+		}
+		res.EntityTypes = append(res.EntityTypes, orderedJSONEntry[schemaEntityTypeJSON]{Key: string(e.Name), Value: ej})
+	}
+	for _, a := range ns.Actions {
+		aj, err := m.action(a)
+		if err != nil {
+			return res, fmt.Errorf("action %q: %w", a.Name, err)
+		}
+		res.Actions = append(res.Actions, orderedJSONEntry[schemaActionJSON]{Key: string(a.Name), Value: aj})
+	}
+	if len(ns.CommonTypes) > 0 {
+		commonTypes := orderedJSON[schemaCommonTypeJSON]{}
+		for _, c := range ns.CommonTypes {
+			t, err := m.typ(c.Type)
+			if err != nil {
+				return res, fmt.Errorf("common type %v: %w", c.Name, err)
+			}
+			cj := schemaCommonTypeJSON{schemaTypeJSON: *t, Annotations: annotationsToJSON(c.Annotations)}
+			commonTypes = append(commonTypes, orderedJSONEntry[schemaCommonTypeJSON]{Key: string(c.Name), Value: cj})
+		}
+		res.CommonTypes = &commonTypes
+	}
+	return res, nil
+}
+
+func annotationsToJSON(annotations []schemaast.Annotation) *orderedJSON[string] {
+	if len(annotations) == 0 {
+		return nil
+	}
+	res := make(orderedJSON[string], len(annotations))
+	for i, a := range annotations {
+		res[i] = orderedJSONEntry[string]{Key: string(a.Key), Value: string(a.Value)}
+	}
+	return &res
+}
+
+func (m *schemaJSONMarshaler) entity(e *schemaast.Entity) (schemaEntityTypeJSON, error) {
+	res := schemaEntityTypeJSON{Annotations: annotationsToJSON(e.Annotations)}
+	if e.Enum != nil {
+		res.Enum = make([]string, len(e.Enum))
+		for i, v := range e.Enum {
+			res.Enum[i] = string(v)
+		}
+		return res, nil
+	}
+	res.MemberOfTypes = pathsToJSON(e.MemberOf)
+	var err error
+	if e.Shape != nil {
+		if res.Shape, err = m.typ(e.Shape); err != nil {
+			return res, err
+		}
+	}
+	if e.Tags != nil {
+		if res.Tags, err = m.typ(e.Tags); err != nil {
+			return res, err
+		}
+	}
+	return res, nil
+}
+
+func pathsToJSON(paths []types.Path) []string {
+	if paths == nil {
+		return nil
+	}
+	res := make([]string, len(paths))
+	for i, p := range paths {
+		res[i] = string(p)
+	}
+	return res
+}
+
+func (m *schemaJSONMarshaler) action(a *schemaast.Action) (schemaActionJSON, error) {
+	res := schemaActionJSON{Annotations: annotationsToJSON(a.Annotations)}
+	for _, ref := range a.MemberOf {
+		res.MemberOf = append(res.MemberOf, schemaActionRefJSON{ID: string(ref.ID), Type: string(ref.Type)})
+	}
+	if a.AppliesTo != nil {
+		res.AppliesTo = &schemaAppliesToJSON{
+			PrincipalTypes: pathsToJSON(a.AppliesTo.Principals),
+			ResourceTypes:  pathsToJSON(a.AppliesTo.Resources),
+		}
+		if res.AppliesTo.PrincipalTypes == nil {
+			res.AppliesTo.PrincipalTypes = []string{}
+		}
+		if res.AppliesTo.ResourceTypes == nil {
+			res.AppliesTo.ResourceTypes = []string{}
+		}
+		if a.AppliesTo.Context != nil {
+			var err error
+			if res.AppliesTo.Context, err = m.typ(a.AppliesTo.Context); err != nil {
+				return res, err
+			}
+		}
+	}
+	return res, nil
+}
+
+func (m *schemaJSONMarshaler) typ(t schemaast.IsType) (*schemaTypeJSON, error) {
+	switch t := t.(type) {
+	case schemaast.BooleanType:
+		return &schemaTypeJSON{Type: schemaTypeBoolean}, nil
+	case schemaast.LongType:
+		return &schemaTypeJSON{Type: schemaTypeLong}, nil
+	case schemaast.StringType:
+		return &schemaTypeJSON{Type: schemaTypeString}, nil
+	case schemaast.ExtensionType:
+		return &schemaTypeJSON{Type: schemaTypeExtension, Name: string(t.Name)}, nil
+	case schemaast.SetType:
+		element, err := m.typ(t.Element)
+		if err != nil {
+			return nil, err
+		}
+		return &schemaTypeJSON{Type: schemaTypeSet, Element: element}, nil
+	case schemaast.RecordType:
+		attrs := orderedJSON[schemaAttributeJSON]{}
+		for _, a := range t.Attributes {
+			at, err := m.typ(a.Type)
+			if err != nil {
+				return nil, err
+			}
+			aj := schemaAttributeJSON{schemaTypeJSON: *at, Annotations: annotationsToJSON(a.Annotations)}
+			if a.Optional {
+				required := false
+				aj.Required = &required
+			}
+			attrs = append(attrs, orderedJSONEntry[schemaAttributeJSON]{Key: string(a.Name), Value: aj})
+		}
+		return &schemaTypeJSON{Type: schemaTypeRecord, Attributes: &attrs}, nil
+	case schemaast.EntityTypeRef:
+		return &schemaTypeJSON{Type: schemaTypeEntity, Name: string(t.Name)}, nil
+	case schemaast.TypeRef:
+		// A reference to a built-in type which is not shadowed by a declaration is written in its more familiar
+		// built-in form, e.g. {"type": "Long"} rather than {"type": "EntityOrCommon", "name": "Long"}.
+		if b, ok := m.builtin(t.Name); ok {
+			return m.typ(b)
+		}
+		return &schemaTypeJSON{Type: schemaTypeEntityOrCommon, Name: string(t.Name)}, nil
+	}
+	return nil, fmt.Errorf("unknown schema type %T", t)
+}
+
+func (m *schemaJSONMarshaler) builtin(name types.Path) (schemaast.IsType, bool) {
+	if strings.Contains(string(name), "::") {
+		return nil, false
+	}
+	ident := types.Ident(name)
+	for _, ns := range []*schemaast.Namespace{m.ns, m.schema.Namespace("")} {
+		if ns != nil && (ns.CommonType(ident) != nil || ns.Entity(ident) != nil) {
+			return nil, false
+		}
+	}
+	return schemaast.BuiltinType(ident)
+}
@@ -0,0 +1,267 @@
+package json
+
+import (
+	"testing"
+
+	"github.com/cedar-policy/cedar-go/internal/testutil"
+	"github.com/cedar-policy/cedar-go/types"
+	schemaast "github.com/cedar-policy/cedar-go/x/exp/schema/ast"
+)
+
+func TestSchemaUnmarshalJSON(t *testing.T) {
+	t.Parallel()
+	in := `{
+		"PhotoApp": {
+			"annotations": {"doc": "photos", "internal": ""},
+			"commonTypes": {
+				"PersonType": {
+					"type": "Record",
+					"annotations": {"doc": "a person"},
+					"attributes": {
+						"age": {"type": "Long"},
+						"name": {"type": "String", "required": false, "annotations": {"doc": "the name"}}
+					}
+				},
+				"Alias": {"type": "PersonType"}
+			},
+			"entityTypes": {
+				"User": {
+					"memberOfTypes": ["UserGroup"],
+					"shape": {"type": "PersonType"},
+					"tags": {"type": "Set", "element": {"type": "Extension", "name": "ipaddr"}}
+				},
+				"UserGroup": {
+					"shape": {"type": "Record", "attributes": {
+						"owner": {"type": "Entity", "name": "User"},
+						"flag": {"type": "Boolean"},
+						"other": {"type": "EntityOrCommon", "name": "Alias"}
+					}}
+				},
+				"Color": {"enum": ["red", "blue"], "annotations": {"doc": "colors"}}
+			},
+			"actions": {
+				"view": {
+					"annotations": {"doc": "view"},
+					"memberOf": [{"id": "read"}, {"id": "all", "type": "Other::Action"}],
+					"appliesTo": {
+						"principalTypes": ["User"],
+						"resourceTypes": [],
+						"context": {"type": "Record", "attributes": {}}
+					}
+				},
+				"read": {}
+			}
+		},
+		"": {"entityTypes": {}, "actions": {}}
+	}`
+	want := Schema{Namespaces: []*schemaast.Namespace{
+		{
+			Name:        "PhotoApp",
+			Annotations: []schemaast.Annotation{{Key: "doc", Value: "photos"}, {Key: "internal", Value: ""}},
+			CommonTypes: []*schemaast.CommonType{
+				{
+					Name:        "PersonType",
+					Annotations: []schemaast.Annotation{{Key: "doc", Value: "a person"}},
+					Type: schemaast.RecordType{Attributes: []schemaast.Attribute{
+						{Name: "age", Type: schemaast.LongType{}},
+						{
+							Name:        "name",
+							Type:        schemaast.StringType{},
+							Optional:    true,
+							Annotations: []schemaast.Annotation{{Key: "doc", Value: "the name"}},
+						},
+					}},
+				},
+				{Name: "Alias", Type: schemaast.TypeRef{Name: "PersonType"}},
+			},
+			Entities: []*schemaast.Entity{
+				{
+					Name:     "User",
+					MemberOf: []types.Path{"UserGroup"},
+					Shape:    schemaast.TypeRef{Name: "PersonType"},
+					Tags:     schemaast.SetType{Element: schemaast.ExtensionType{Name: "ipaddr"}},
+				},
+				{
+					Name: "UserGroup",
+					Shape: schemaast.RecordType{Attributes: []schemaast.Attribute{
+						{Name: "owner", Type: schemaast.EntityTypeRef{Name: "User"}},
+						{Name: "flag", Type: schemaast.BooleanType{}},
+						{Name: "other", Type: schemaast.TypeRef{Name: "Alias"}},
+					}},
+				},
+				{
+					Name:        "Color",
+					Annotations: []schemaast.Annotation{{Key: "doc", Value: "colors"}},
+					Enum:        []types.String{"red", "blue"},
+				},
+			},
+			Actions: []*schemaast.Action{
+				{
+					Name:        "view",
+					Annotations: []schemaast.Annotation{{Key: "doc", Value: "view"}},
+					MemberOf:    []schemaast.ActionRef{{ID: "read"}, {Type: "Other::Action", ID: "all"}},
+					AppliesTo: &schemaast.AppliesTo{
+						Principals: []types.Path{"User"},
+						Context:    schemaast.RecordType{},
+					},
+				},
+				{Name: "read"},
+			},
+		},
+		{},
+	}}
+	var got Schema
+	testutil.OK(t, got.UnmarshalJSON([]byte(in)))
+	testutil.Equals(t, got, want)
+}
+
+func TestSchemaUnmarshalJSONErrors(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name  string
+		input string
+	}{
+		{"not an object", `[]`},
+		{"bad json", `{"": `},
+		{"duplicate namespace", `{"A": {"entityTypes": {}, "actions": {}}, "A": {"entityTypes": {}, "actions": {}}}`},
+		{"unknown field", `{"": {"entityTypes": {}, "actions": {}, "other": 1}}`},
+		{"bad namespace name", `{"A::": {"entityTypes": {}, "actions": {}}}`},
+		{"bad entity name", `{"": {"entityTypes": {"A B": {}}, "actions": {}}}`},
+		{"duplicate entity", `{"": {"entityTypes": {"A": {}, "A": {}}, "actions": {}}}`},
+		{"bad parent", `{"": {"entityTypes": {"A": {"memberOfTypes": ["1"]}}, "actions": {}}}`},
+		{"enum with shape", `{"": {"entityTypes": {"A": {"enum": ["a"], "shape": {"type": "Record"}}}, "actions": {}}}`},
+		{"empty enum", `{"": {"entityTypes": {"A": {"enum": []}}, "actions": {}}}`},
+		{"bad shape", `{"": {"entityTypes": {"A": {"shape": {"type": "Set"}}}, "actions": {}}}`},
+		{"bad tags", `{"": {"entityTypes": {"A": {"tags": {"type": "Entity"}}}, "actions": {}}}`},
+		{"unknown extension", `{"": {"commonTypes": {"A": {"type": "Extension", "name": "nope"}}, "entityTypes": {}, "actions": {}}}`},
+		{"bad common type name", `{"": {"commonTypes": {"": {"type": "Long"}}, "entityTypes": {}, "actions": {}}}`},
+		{"builtin common type name", `{"": {"commonTypes": {"Long": {"type": "String"}}, "entityTypes": {}, "actions": {}}}`},
+		{"bad type name", `{"": {"commonTypes": {"A": {"type": "1"}}, "entityTypes": {}, "actions": {}}}`},
+		{"bad entity or common", `{"": {"commonTypes": {"A": {"type": "EntityOrCommon"}}, "entityTypes": {}, "actions": {}}}`},
+		{"bad set element", `{"": {"commonTypes": {"A": {"type": "Set", "element": {"type": "Set"}}}, "entityTypes": {}, "actions": {}}}`},
+		{"bad attribute", `{"": {"commonTypes": {"A": {"type": "Record", "attributes": {"a": {"type": "Set"}}}}, "entityTypes": {}, "actions": {}}}`},
+		{"additional attributes", `{"": {"commonTypes": {"A": {"type": "Record", "additionalAttributes": true}}, "entityTypes": {}, "actions": {}}}`},
+		{"action attributes", `{"": {"entityTypes": {}, "actions": {"a": {"attributes": {}}}}}`},
+		{"bad action ref", `{"": {"entityTypes": {}, "actions": {"a": {"memberOf": [{"id": "b", "type": "::"}]}}}}`},
+		{"bad principal type", `{"": {"entityTypes": {}, "actions": {"a": {"appliesTo": {"principalTypes": [""]}}}}}`},
+		{"bad resource type", `{"": {"entityTypes": {}, "actions": {"a": {"appliesTo": {"resourceTypes": [""]}}}}}`},
+		{"bad context", `{"": {"entityTypes": {}, "actions": {"a": {"appliesTo": {"context": {"type": "Set"}}}}}}`},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			var s Schema
+			testutil.Error(t, s.UnmarshalJSON([]byte(tt.input)))
+		})
+	}
+}
+
+func TestSchemaMarshalJSON(t *testing.T) {
+	t.Parallel()
+	s := Schema{Namespaces: []*schemaast.Namespace{
+		{
+			Entities: []*schemaast.Entity{{Name: "String"}},
+		},
+		{
+			Name:        "NS",
+			Annotations: []schemaast.Annotation{{Key: "doc", Value: "ns"}},
+			CommonTypes: []*schemaast.CommonType{{
+				Name:        "Ctx",
+				Annotations: []schemaast.Annotation{{Key: "doc", Value: "ctx"}},
+				Type: schemaast.RecordType{Attributes: []schemaast.Attribute{
+					{Name: "z", Type: schemaast.TypeRef{Name: "Long"}},
+					{Name: "a", Type: schemaast.TypeRef{Name: "String"}, Optional: true},
+					{Name: "b", Type: schemaast.TypeRef{Name: "NS::Other"}},
+				}},
+			}},
+			Entities: []*schemaast.Entity{
+				{
+					Name:     "User",
+					MemberOf: []types.Path{"Group"},
+					Shape:    schemaast.RecordType{},
+					Tags:     schemaast.SetType{Element: schemaast.ExtensionType{Name: "decimal"}},
+				},
+				{Name: "Color", Enum: []types.String{"red"}},
+			},
+			Actions: []*schemaast.Action{{
+				Name:     "view",
+				MemberOf: []schemaast.ActionRef{{ID: "all"}, {Type: "NS::Action", ID: "read"}},
+				AppliesTo: &schemaast.AppliesTo{
+					Context: schemaast.TypeRef{Name: "Ctx"},
+				},
+			}},
+		},
+	}}
+	testutil.JSONMarshalsTo(t, &s, `{
+		"": {"entityTypes": {"String": {}}, "actions": {}},
+		"NS": {
+			"annotations": {"doc": "ns"},
+			"entityTypes": {
+				"User": {
+					"memberOfTypes": ["Group"],
+					"shape": {"type": "Record", "attributes": {}},
+					"tags": {"type": "Set", "element": {"type": "Extension", "name": "decimal"}}
+				},
+				"Color": {"enum": ["red"]}
+			},
+			"actions": {
+				"view": {
+					"memberOf": [{"id": "all"}, {"id": "read", "type": "NS::Action"}],
+					"appliesTo": {
+						"principalTypes": [],
+						"resourceTypes": [],
+						"context": {"type": "EntityOrCommon", "name": "Ctx"}
+					}
+				}
+			},
+			"commonTypes": {
+				"Ctx": {
+					"type": "Record",
+					"attributes": {
+						"z": {"type": "Long"},
+						"a": {"type": "EntityOrCommon", "name": "String", "required": false},
+						"b": {"type": "EntityOrCommon", "name": "NS::Other"}
+					},
+					"annotations": {"doc": "ctx"}
+				}
+			}
+		}
+	}`)
+}
+
+func TestSchemaMarshalJSONRoundTrip(t *testing.T) {
+	t.Parallel()
+	in := `{"A::B":{"annotations":{"z":"1","a":"2"},"entityTypes":{"Z":{"shape":{"type":"Record","attributes":{"y":{"type":"Entity","name":"A::B::Z"},"x":{"type":"Boolean","annotations":{"doc":""}}}}},"Y":{}},"actions":{"b":{},"a":{"appliesTo":{"principalTypes":["Z"],"resourceTypes":["Y"]}}}}}`
+	var s Schema
+	testutil.OK(t, s.UnmarshalJSON([]byte(in)))
+	out, err := s.MarshalJSON()
+	testutil.OK(t, err)
+	testutil.Equals(t, string(out), in)
+}
+
+func TestSchemaMarshalJSONErrors(t *testing.T) {
+	t.Parallel()
+	bad := schemaast.SetType{}
+	tests := []struct {
+		name string
+		ns   *schemaast.Namespace
+	}{
+		{"common type", &schemaast.Namespace{CommonTypes: []*schemaast.CommonType{{Name: "A", Type: bad}}}},
+		{"shape", &schemaast.Namespace{Entities: []*schemaast.Entity{{Name: "A", Shape: bad}}}},
+		{"tags", &schemaast.Namespace{Entities: []*schemaast.Entity{{Name: "A", Tags: bad}}}},
+		{"context", &schemaast.Namespace{Actions: []*schemaast.Action{{Name: "a", AppliesTo: &schemaast.AppliesTo{Context: bad}}}}},
+		{"attribute", &schemaast.Namespace{CommonTypes: []*schemaast.CommonType{{Name: "A", Type: schemaast.RecordType{
+			Attributes: []schemaast.Attribute{{Name: "a", Type: bad}},
+		}}}}},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			s := Schema{Namespaces: []*schemaast.Namespace{tt.ns}}
+			_, err := s.MarshalJSON()
+			testutil.Error(t, err)
+		})
+	}
+}
@@ -0,0 +1,241 @@
+package json
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/cedar-policy/cedar-go/types"
+	schemaast "github.com/cedar-policy/cedar-go/x/exp/schema/ast"
+)
+
+// UnmarshalJSON parses a schema in the JSON schema format.  The order of namespaces and declarations is retained.
+func (s *Schema) UnmarshalJSON(b []byte) error {
+	var in schemaJSON
+	if err := in.UnmarshalJSON(b); err != nil {
+		return err
+	}
+	var res Schema
+	for _, e := range in {
+		ns, err := namespaceFromJSON(e.Key, e.Value)
+		if err != nil {
+			return err
+		}
+		res.Namespaces = append(res.Namespaces, ns)
+	}
+	*s = res
+	return nil
+}
+
+func namespaceFromJSON(name string, in schemaNamespaceJSON) (*schemaast.Namespace, error) {
+	if name != "" && !isPath(name) {
+		return nil, fmt.Errorf("invalid namespace name %q", name)
+	}
+	ns := &schemaast.Namespace{Name: types.Path(name), Annotations: annotationsFromJSON(in.Annotations)}
+	if in.CommonTypes != nil {
+		for _, e := range *in.CommonTypes {
+			if !isIdent(e.Key) {
+				return nil, fmt.Errorf("invalid common type name %q", e.Key)
+			}
+			if _, ok := schemaast.BuiltinType(types.Ident(e.Key)); ok {
+				return nil, fmt.Errorf("common type name shadows a built-in type: %v", e.Key)
+			}
+			t, err := typeFromJSON(&e.Value.schemaTypeJSON)
+			if err != nil {
+				return nil, fmt.Errorf("common type %v: %w", e.Key, err)
+			}
+			ns.CommonTypes = append(ns.CommonTypes, &schemaast.CommonType{
+				Name:        types.Ident(e.Key),
+				Annotations: annotationsFromJSON(e.Value.Annotations),
+				Type:        t,
+			})
+		}
+	}
+	for _, e := range in.EntityTypes {
+		entity, err := entityFromJSON(e.Key, e.Value)
+		if err != nil {
+			return nil, fmt.Errorf("entity type %v: %w", e.Key, err)
+		}
+		ns.Entities = append(ns.Entities, entity)
+	}
+	for _, e := range in.Actions {
+		action, err := actionFromJSON(e.Key, e.Value)
+		if err != nil {
+			return nil, fmt.Errorf("action %q: %w", e.Key, err)
+		}
+		ns.Actions = append(ns.Actions, action)
+	}
+	return ns, nil
+}
+
+func annotationsFromJSON(in *orderedJSON[string]) []schemaast.Annotation {
+	if in == nil || len(*in) == 0 {
+		return nil
+	}
+	res := make([]schemaast.Annotation, len(*in))
+	for i, e := range *in {
+		res[i] = schemaast.Annotation{Key: types.Ident(e.Key), Value: types.String(e.Value)}
+	}
+	return res
+}
+
+func entityFromJSON(name string, in schemaEntityTypeJSON) (*schemaast.Entity, error) {
+	if !isIdent(name) {
+		return nil, fmt.Errorf("invalid entity type name")
+	}
+	res := &schemaast.Entity{Name: types.Ident(name), Annotations: annotationsFromJSON(in.Annotations)}
+	if in.Enum != nil {
+		if in.MemberOfTypes != nil || in.Shape != nil || in.Tags != nil {
+			return nil, fmt.Errorf("enumerated entity types cannot declare parents, a shape or tags")
+		}
+		if len(in.Enum) == 0 {
+			return nil, fmt.Errorf("enum must not be empty")
+		}
+		res.Enum = make([]types.String, len(in.Enum))
+		for i, v := range in.Enum {
+			res.Enum[i] = types.String(v)
+		}
+		return res, nil
+	}
+	var err error
+	if res.MemberOf, err = pathsFromJSON(in.MemberOfTypes); err != nil {
+		return nil, err
+	}
+	if in.Shape != nil {
+		if res.Shape, err = typeFromJSON(in.Shape); err != nil {
+			return nil, err
+		}
+	}
+	if in.Tags != nil {
+		if res.Tags, err = typeFromJSON(in.Tags); err != nil {
+			return nil, err
+		}
+	}
+	return res, nil
+}
+
+func pathsFromJSON(in []string) ([]types.Path, error) {
+	if len(in) == 0 {
+		return nil, nil
+	}
+	res := make([]types.Path, len(in))
+	for i, p := range in {
+		if !isPath(p) {
+			return nil, fmt.Errorf("invalid entity type name %q", p)
+		}
+		res[i] = types.Path(p)
+	}
+	return res, nil
+}
+
+func actionFromJSON(name string, in schemaActionJSON) (*schemaast.Action, error) {
+	if in.Attributes != nil {
+		return nil, fmt.Errorf("action attributes are not supported")
+	}
+	res := &schemaast.Action{Name: types.String(name), Annotations: annotationsFromJSON(in.Annotations)}
+	for _, ref := range in.MemberOf {
+		if ref.Type != "" && !isPath(ref.Type) {
+			return nil, fmt.Errorf("invalid action type %q", ref.Type)
+		}
+		res.MemberOf = append(res.MemberOf, schemaast.ActionRef{Type: types.Path(ref.Type), ID: types.String(ref.ID)})
+	}
+	if in.AppliesTo != nil {
+		var appliesTo schemaast.AppliesTo
+		var err error
+		if appliesTo.Principals, err = pathsFromJSON(in.AppliesTo.PrincipalTypes); err != nil {
+			return nil, err
+		}
+		if appliesTo.Resources, err = pathsFromJSON(in.AppliesTo.ResourceTypes); err != nil {
+			return nil, err
+		}
+		if in.AppliesTo.Context != nil {
+			if appliesTo.Context, err = typeFromJSON(in.AppliesTo.Context); err != nil {
+				return nil, err
+			}
+		}
+		res.AppliesTo = &appliesTo
+	}
+	return res, nil
+}
+
+func typeFromJSON(in *schemaTypeJSON) (schemaast.IsType, error) {
+	switch in.Type {
+	case schemaTypeBoolean:
+		return schemaast.BooleanType{}, nil
+	case schemaTypeLong:
+		return schemaast.LongType{}, nil
+	case schemaTypeString:
+		return schemaast.StringType{}, nil
+	case schemaTypeSet:
+		if in.Element == nil {
+			return nil, fmt.Errorf("set type is missing an element type")
+		}
+		element, err := typeFromJSON(in.Element)
+		if err != nil {
+			return nil, err
+		}
+		return schemaast.SetType{Element: element}, nil
+	case schemaTypeRecord:
+		if in.AdditionalAttributes {
+			return nil, fmt.Errorf("additionalAttributes is not supported")
+		}
+		var res schemaast.RecordType
+		if in.Attributes == nil {
+			return res, nil
+		}
+		for _, e := range *in.Attributes {
+			t, err := typeFromJSON(&e.Value.schemaTypeJSON)
+			if err != nil {
+				return nil, fmt.Errorf("attribute %q: %w", e.Key, err)
+			}
+			res.Attributes = append(res.Attributes, schemaast.Attribute{
+				Name:        types.String(e.Key),
+				Type:        t,
+				Optional:    e.Value.Required != nil && !*e.Value.Required,
+				Annotations: annotationsFromJSON(e.Value.Annotations),
+			})
+		}
+		return res, nil
+	case schemaTypeEntity:
+		if !isPath(in.Name) {
+			return nil, fmt.Errorf("invalid entity type name %q", in.Name)
+		}
+		return schemaast.EntityTypeRef{Name: types.Path(in.Name)}, nil
+	case schemaTypeExtension:
+		t, ok := schemaast.BuiltinType(types.Ident(in.Name))
+		if _, isExtension := t.(schemaast.ExtensionType); !ok || !isExtension {
+			return nil, fmt.Errorf("unknown extension type %q", in.Name)
+		}
+		return t, nil
+	case schemaTypeEntityOrCommon:
+		if !isPath(in.Name) {
+			return nil, fmt.Errorf("invalid type name %q", in.Name)
+		}
+		return schemaast.TypeRef{Name: types.Path(in.Name)}, nil
+	}
+	// Any other type name is a reference to a common type.
+	if !isPath(in.Type) {
+		return nil, fmt.Errorf("invalid type %q", in.Type)
+	}
+	return schemaast.TypeRef{Name: types.Path(in.Type)}, nil
+}
+
+func isIdent(s string) bool {
+	if s == "" {
+		return false
+	}
+	for i, r := range s {
+		if !(r == '_' || (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z') || (i > 0 && r >= '0' && r <= '9')) {
+			return false
+		}
+	}
+	return true
+}
+
+func isPath(s string) bool {
+	for _, part := range strings.Split(s, "::") {
+		if !isIdent(part) {
+			return false
+		}
+	}
+	return true
+}
@@ -62,26 +62,32 @@ func (p *Policy) marshalScope(buf *bytes.Buffer) {
 	}
 
 	buf.WriteString("(\n    ")
-	if principalAll {
-		buf.WriteString(consts.Principal)
-	} else {
-		astNodeToMarshalNode(scopeToNode(ast.NewPrincipalNode(), p.Principal).AsIsNode()).marshalCedar(buf)
-	}
+	marshalScopeConstraint(ast.NewPrincipalNode(), p.Principal, buf)
 	buf.WriteString(",\n    ")
-	if actionAll {
-		buf.WriteString(consts.Action)
-	} else {
-		astNodeToMarshalNode(scopeToNode(ast.NewActionNode(), p.Action).AsIsNode()).marshalCedar(buf)
-	}
+	marshalScopeConstraint(ast.NewActionNode(), p.Action, buf)
 	buf.WriteString(",\n    ")
-	if resourceAll {
-		buf.WriteString(consts.Resource)
-	} else {
-		astNodeToMarshalNode(scopeToNode(ast.NewResourceNode(), p.Resource).AsIsNode()).marshalCedar(buf)
-	}
+	marshalScopeConstraint(ast.NewResourceNode(), p.Resource, buf)
 	buf.WriteString("\n)")
 }
 
+func marshalScopeConstraint(varNode ast.NodeTypeVariable, in ast.IsScopeNode, buf *bytes.Buffer) {
+	name := string(varNode.Name)
+	switch t := in.(type) {
+	case ast.ScopeTypeAll:
+		buf.WriteString(name)
+	case ast.ScopeTypeSlotEq:
+		buf.WriteString(name + " == " + string(t.Slot))
+	case ast.ScopeTypeSlotIn:
+		buf.WriteString(name + " in " + string(t.Slot))
+	case ast.ScopeTypeSlotIsIn:
+		buf.WriteString(name + " is ")
+		buf.WriteString(string(t.Type))
+		buf.WriteString(" in " + string(t.Slot))
+	default:
+		astNodeToMarshalNode(scopeToNode(varNode, in).AsIsNode()).marshalCedar(buf)
+	}
+}
+
 func marshalAnnotation(n ast.AnnotationType, buf *bytes.Buffer) {
 	buf.WriteRune('@')
 	buf.WriteString(string(n.Key))
@@ -118,6 +124,11 @@ func (n NodeValue) marshalCedar(buf *bytes.Buffer) {
 	buf.Write(n.Value.MarshalCedar())
 }
 
+// MarshalNode encodes a single expression in the human-readable format.
+func MarshalNode(n ast.IsNode, buf *bytes.Buffer) {
+	astNodeToMarshalNode(n).marshalCedar(buf)
+}
+
 func marshalChildNode(thisNodePrecedence nodePrecedenceLevel, childAstNode ast.IsNode, buf *bytes.Buffer) {
 	childNode := astNodeToMarshalNode(childAstNode)
 	if thisNodePrecedence > childNode.precedenceLevel() {
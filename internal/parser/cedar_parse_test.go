@@ -333,6 +333,7 @@ func TestParse(t *testing.T) {
 			// these tests (see test "ex1")
 			for _, pp := range policies {
 				pp.Position = ast.Position{Offset: 0, Line: 1, Column: 1}
+				clearNodePositions(pp)
 
 				var buf bytes.Buffer
 				pp.MarshalCedar(&buf)
@@ -340,6 +341,7 @@ func TestParse(t *testing.T) {
 				var p2 parser.PolicySlice
 				err = p2.UnmarshalCedar(buf.Bytes())
 				testutil.OK(t, err)
+				clearNodePositions(p2[0])
 
 				testutil.Equals(t, p2[0], pp)
 			}
@@ -347,6 +349,14 @@ func TestParse(t *testing.T) {
 	}
 }
 
+// clearNodePositions removes the positions of the nodes of the conditions of a parsed policy, so that it can be
+// compared with one built in code.
+func clearNodePositions(p *parser.Policy) {
+	for i := range p.Conditions {
+		p.Conditions[i].Positions = nil
+	}
+}
+
 func TestPolicyPositions(t *testing.T) {
 	t.Parallel()
 	in := `// idk a comment
@@ -369,3 +379,41 @@ permit( principal, action, resource );
 	testutil.Equals(t, out[1].Position, ast.Position{Offset: 86, Line: 7, Column: 3})
 	testutil.Equals(t, out[2].Position, ast.Position{Offset: 148, Line: 10, Column: 2})
 }
+
+func TestConditionPositions(t *testing.T) {
+	t.Parallel()
+	in := `permit(principal, action, resource)
+when { -principal.age + 1 > 2 }
+unless {
+  if context has a then [User::"x", ip("::1")] else {k: !(-9223372036854775808).isEmpty()}
+};`
+
+	var out parser.Policy
+	testutil.OK(t, out.UnmarshalCedar([]byte(in)))
+	testutil.Equals(t, len(out.Conditions), 2)
+	pos := func(offset, line, column int) ast.Position {
+		return ast.Position{Offset: offset, Line: line, Column: column}
+	}
+	testutil.Equals(t, out.Conditions[0].Positions, []ast.Position{
+		pos(44, 2, 9),  // principal
+		pos(44, 2, 9),  // principal.age
+		pos(43, 2, 8),  // -principal.age
+		pos(60, 2, 25), // 1
+		pos(43, 2, 8),  // -principal.age + 1
+		pos(64, 2, 29), // 2
+		pos(43, 2, 8),  // -principal.age + 1 > 2
+	})
+	testutil.Equals(t, out.Conditions[1].Positions, []ast.Position{
+		pos(82, 4, 6),   // context
+		pos(82, 4, 6),   // context has a
+		pos(102, 4, 26), // User::"x"
+		pos(116, 4, 40), // "::1"
+		pos(113, 4, 37), // ip("::1")
+		pos(101, 4, 25), // [User::"x", ip("::1")]
+		pos(135, 4, 59), // -9223372036854775808
+		pos(134, 4, 58), // (-9223372036854775808).isEmpty()
+		pos(133, 4, 57), // !(-9223372036854775808).isEmpty()
+		pos(129, 4, 53), // {k: ...}
+		pos(79, 4, 3),   // if ... then ... else ...
+	})
+}
@@ -91,6 +91,8 @@ func (p *Policy) fromCedar(parser *parser) error {
 type parser struct {
 	tokens []Token
 	pos    int
+	// positions holds the positions of the nodes of the condition being parsed, in the order in which they are created.
+	positions []ast.Position
 }
 
 func newParser(tokens []Token) parser {
@@ -117,6 +119,12 @@ func (p *parser) exact(tok string) error {
 	return nil
 }
 
+// node records the position of a node of a condition, which is created after the nodes of its operands, and returns it.
+func (p *parser) node(pos Position, n ast.Node) ast.Node {
+	p.positions = append(p.positions, ast.Position(pos))
+	return n
+}
+
 func (p *parser) errorf(s string, args ...interface{}) error {
 	var t Token
 	if p.pos < len(p.tokens) {
@@ -192,6 +200,13 @@ func (p *parser) principal(policy *ast.Policy) error {
 	switch p.peek().Text {
 	case "==":
 		p.advance()
+		if p.peek().Text == "?" {
+			if err := p.slot(types.PrincipalSlot); err != nil {
+				return err
+			}
+			policy.PrincipalEqSlot()
+			return nil
+		}
 		entity, err := p.entity()
 		if err != nil {
 			return err
@@ -206,6 +221,13 @@ func (p *parser) principal(policy *ast.Policy) error {
 		}
 		if p.peek().Text == "in" {
 			p.advance()
+			if p.peek().Text == "?" {
+				if err := p.slot(types.PrincipalSlot); err != nil {
+					return err
+				}
+				policy.PrincipalIsInSlot(path)
+				return nil
+			}
 			entity, err := p.entity()
 			if err != nil {
 				return err
@@ -218,6 +240,13 @@ func (p *parser) principal(policy *ast.Policy) error {
 		return nil
 	case "in":
 		p.advance()
+		if p.peek().Text == "?" {
+			if err := p.slot(types.PrincipalSlot); err != nil {
+				return err
+			}
+			policy.PrincipalInSlot()
+			return nil
+		}
 		entity, err := p.entity()
 		if err != nil {
 			return err
@@ -229,6 +258,16 @@ func (p *parser) principal(policy *ast.Policy) error {
 	return nil
 }
 
+// slot parses a reference to the given template slot, the only one which may appear in its scope constraint.  The
+// caller has peeked the leading "?", which must be immediately followed by the name of the slot.
+func (p *parser) slot(want types.SlotID) error {
+	q := p.advance()
+	if t := p.advance(); "?"+t.Text != string(want) || t.Pos.Offset != q.Pos.Offset+1 {
+		return p.errorf("expected %v", want)
+	}
+	return nil
+}
+
 func (p *parser) entity() (types.EntityUID, error) {
 	var res types.EntityUID
 	t := p.advance()
@@ -348,6 +387,13 @@ func (p *parser) resource(policy *ast.Policy) error {
 	switch p.peek().Text {
 	case "==":
 		p.advance()
+		if p.peek().Text == "?" {
+			if err := p.slot(types.ResourceSlot); err != nil {
+				return err
+			}
+			policy.ResourceEqSlot()
+			return nil
+		}
 		entity, err := p.entity()
 		if err != nil {
 			return err
@@ -362,6 +408,13 @@ func (p *parser) resource(policy *ast.Policy) error {
 		}
 		if p.peek().Text == "in" {
 			p.advance()
+			if p.peek().Text == "?" {
+				if err := p.slot(types.ResourceSlot); err != nil {
+					return err
+				}
+				policy.ResourceIsInSlot(path)
+				return nil
+			}
 			entity, err := p.entity()
 			if err != nil {
 				return err
@@ -374,6 +427,13 @@ func (p *parser) resource(policy *ast.Policy) error {
 		return nil
 	case "in":
 		p.advance()
+		if p.peek().Text == "?" {
+			if err := p.slot(types.ResourceSlot); err != nil {
+				return err
+			}
+			policy.ResourceInSlot()
+			return nil
+		}
 		entity, err := p.entity()
 		if err != nil {
 			return err
@@ -395,6 +455,7 @@ func (p *parser) conditions(policy *ast.Policy) error {
 				return err
 			}
 			policy.When(expr)
+			policy.Conditions[len(policy.Conditions)-1].Positions = p.positions
 		case "unless":
 			p.advance()
 			expr, err := p.condition()
@@ -402,6 +463,7 @@ func (p *parser) conditions(policy *ast.Policy) error {
 				return err
 			}
 			policy.Unless(expr)
+			policy.Conditions[len(policy.Conditions)-1].Positions = p.positions
 		default:
 			return nil
 		}
@@ -411,6 +473,7 @@ func (p *parser) conditions(policy *ast.Policy) error {
 func (p *parser) condition() (ast.Node, error) {
 	var res ast.Node
 	var err error
+	p.positions = nil
 	if err := p.exact("{"); err != nil {
 		return res, err
 	}
@@ -449,13 +512,14 @@ func (p *parser) expression() (ast.Node, error) {
 			return ast.Node{}, err
 		}
 
-		return ast.IfThenElse(condition, ifTrue, ifFalse), nil
+		return p.node(t.Pos, ast.IfThenElse(condition, ifTrue, ifFalse)), nil
 	}
 
 	return p.or()
 }
 
 func (p *parser) or() (ast.Node, error) {
+	start := p.peek().Pos
 	lhs, err := p.and()
 	if err != nil {
 		return ast.Node{}, err
@@ -467,13 +531,14 @@ func (p *parser) or() (ast.Node, error) {
 		if err != nil {
 			return ast.Node{}, err
 		}
-		lhs = lhs.Or(rhs)
+		lhs = p.node(start, lhs.Or(rhs))
 	}
 
 	return lhs, nil
 }
 
 func (p *parser) and() (ast.Node, error) {
+	start := p.peek().Pos
 	lhs, err := p.relation()
 	if err != nil {
 		return ast.Node{}, err
@@ -485,13 +550,14 @@ func (p *parser) and() (ast.Node, error) {
 		if err != nil {
 			return ast.Node{}, err
 		}
-		lhs = lhs.And(rhs)
+		lhs = p.node(start, lhs.And(rhs))
 	}
 
 	return lhs, nil
 }
 
 func (p *parser) relation() (ast.Node, error) {
+	start := p.peek().Pos
 	lhs, err := p.add()
 	if err != nil {
 		return ast.Node{}, err
@@ -502,13 +568,13 @@ func (p *parser) relation() (ast.Node, error) {
 	switch t.Text {
 	case "has":
 		p.advance()
-		return p.has(lhs)
+		return p.has(start, lhs)
 	case "like":
 		p.advance()
-		return p.like(lhs)
+		return p.like(start, lhs)
 	case "is":
 		p.advance()
-		return p.is(lhs)
+		return p.is(start, lhs)
 	}
 
 	// RELOP
@@ -538,24 +604,24 @@ func (p *parser) relation() (ast.Node, error) {
 	if err != nil {
 		return ast.Node{}, err
 	}
-	return operator(lhs, rhs), nil
+	return p.node(start, operator(lhs, rhs)), nil
 }
 
-func (p *parser) has(lhs ast.Node) (ast.Node, error) {
+func (p *parser) has(start Position, lhs ast.Node) (ast.Node, error) {
 	t := p.advance()
 	if t.isIdent() {
-		return lhs.Has(types.String(t.Text)), nil
+		return p.node(start, lhs.Has(types.String(t.Text))), nil
 	} else if t.isString() {
 		str, err := t.stringValue()
 		if err != nil {
 			return ast.Node{}, err
 		}
-		return lhs.Has(types.String(str)), nil
+		return p.node(start, lhs.Has(types.String(str))), nil
 	}
 	return ast.Node{}, p.errorf("expected ident or string")
 }
 
-func (p *parser) like(lhs ast.Node) (ast.Node, error) {
+func (p *parser) like(start Position, lhs ast.Node) (ast.Node, error) {
 	t := p.advance()
 	if !t.isString() {
 		return ast.Node{}, p.errorf("expected string literal")
@@ -567,10 +633,10 @@ func (p *parser) like(lhs ast.Node) (ast.Node, error) {
 	if err != nil {
 		return ast.Node{}, err
 	}
-	return lhs.Like(pattern), nil
+	return p.node(start, lhs.Like(pattern)), nil
 }
 
-func (p *parser) is(lhs ast.Node) (ast.Node, error) {
+func (p *parser) is(start Position, lhs ast.Node) (ast.Node, error) {
 	entityType, err := p.path()
 	if err != nil {
 		return ast.Node{}, err
@@ -581,12 +647,13 @@ func (p *parser) is(lhs ast.Node) (ast.Node, error) {
 		if err != nil {
 			return ast.Node{}, err
 		}
-		return lhs.IsIn(entityType, inEntity), nil
+		return p.node(start, lhs.IsIn(entityType, inEntity)), nil
 	}
-	return lhs.Is(entityType), nil
+	return p.node(start, lhs.Is(entityType)), nil
 }
 
 func (p *parser) add() (ast.Node, error) {
+	start := p.peek().Pos
 	lhs, err := p.mult()
 	if err != nil {
 		return ast.Node{}, err
@@ -611,13 +678,14 @@ func (p *parser) add() (ast.Node, error) {
 		if err != nil {
 			return ast.Node{}, err
 		}
-		lhs = operator(lhs, rhs)
+		lhs = p.node(start, operator(lhs, rhs))
 	}
 
 	return lhs, nil
 }
 
 func (p *parser) mult() (ast.Node, error) {
+	start := p.peek().Pos
 	lhs, err := p.unary()
 	if err != nil {
 		return ast.Node{}, err
@@ -629,7 +697,7 @@ func (p *parser) mult() (ast.Node, error) {
 		if err != nil {
 			return ast.Node{}, err
 		}
-		lhs = lhs.Multiply(rhs)
+		lhs = p.node(start, lhs.Multiply(rhs))
 	}
 
 	return lhs, nil
@@ -637,6 +705,7 @@ func (p *parser) mult() (ast.Node, error) {
 
 func (p *parser) unary() (ast.Node, error) {
 	var ops []bool
+	var opPositions []Position
 	for {
 		opToken := p.peek()
 		if opToken.Text != "-" && opToken.Text != "!" {
@@ -644,6 +713,7 @@ func (p *parser) unary() (ast.Node, error) {
 		}
 		p.advance()
 		ops = append(ops, opToken.Text == "-")
+		opPositions = append(opPositions, opToken.Pos)
 	}
 
 	var res ast.Node
@@ -656,7 +726,7 @@ func (p *parser) unary() (ast.Node, error) {
 		if err != nil {
 			return ast.Node{}, err
 		}
-		res = ast.Long(i)
+		res = p.node(opPositions[len(ops)-1], ast.Long(i))
 		ops = ops[:len(ops)-1]
 	} else {
 		var err error
@@ -668,22 +738,23 @@ func (p *parser) unary() (ast.Node, error) {
 
 	for i := len(ops) - 1; i >= 0; i-- {
 		if ops[i] {
-			res = ast.Negate(res)
+			res = p.node(opPositions[i], ast.Negate(res))
 		} else {
-			res = ast.Not(res)
+			res = p.node(opPositions[i], ast.Not(res))
 		}
 	}
 	return res, nil
 }
 
 func (p *parser) member() (ast.Node, error) {
+	start := p.peek().Pos
 	res, err := p.primary()
 	if err != nil {
 		return res, err
 	}
 	for {
 		var ok bool
-		res, ok, err = p.access(res)
+		res, ok, err = p.access(start, res)
 		if err != nil {
 			return ast.Node{}, err
 		}
@@ -719,7 +790,7 @@ func (p *parser) primary() (ast.Node, error) {
 		// look ahead one token to resolve it.
 		next := p.peek()
 		if next.Text == "::" || next.Text == "(" {
-			return p.entityOrExtFun(t.Text)
+			return p.entityOrExtFun(t.Pos, t.Text)
 		}
 		switch t.Text {
 		case consts.Principal:
@@ -741,7 +812,7 @@ func (p *parser) primary() (ast.Node, error) {
 		if err := p.exact(")"); err != nil {
 			return res, err
 		}
-		res = expr
+		return expr, nil
 	case t.Text == "[":
 		set, err := p.expressions("]")
 		if err != nil {
@@ -758,10 +829,10 @@ func (p *parser) primary() (ast.Node, error) {
 	default:
 		return res, p.errorf("invalid primary")
 	}
-	return res, nil
+	return p.node(t.Pos, res), nil
 }
 
-func (p *parser) entityOrExtFun(prefix string) (ast.Node, error) {
+func (p *parser) entityOrExtFun(start Position, prefix string) (ast.Node, error) {
 	for {
 		t := p.advance()
 		switch t.Text {
@@ -775,7 +846,7 @@ func (p *parser) entityOrExtFun(prefix string) (ast.Node, error) {
 				if err != nil {
 					return ast.Node{}, err
 				}
-				return ast.EntityUID(types.Ident(prefix), types.String(id)), nil
+				return p.node(start, ast.EntityUID(types.Ident(prefix), types.String(id))), nil
 			default:
 				return ast.Node{}, p.errorf("unexpected token")
 			}
@@ -795,7 +866,7 @@ func (p *parser) entityOrExtFun(prefix string) (ast.Node, error) {
 				return ast.Node{}, err
 			}
 			p.advance()
-			return ast.ExtensionCall(types.Path(prefix), args...), nil
+			return p.node(start, ast.ExtensionCall(types.Path(prefix), args...)), nil
 		default:
 			return ast.Node{}, p.errorf("unexpected token")
 		}
@@ -888,7 +959,7 @@ func (p *parser) parseOneArgMethodCall(methodName string, f func(ast.Node) ast.N
 	return f(args[0]), nil
 }
 
-func (p *parser) access(lhs ast.Node) (ast.Node, bool, error) {
+func (p *parser) access(start Position, lhs ast.Node) (ast.Node, bool, error) {
 	t := p.peek()
 	switch t.Text {
 	case ".":
@@ -937,10 +1008,10 @@ func (p *parser) access(lhs ast.Node) (ast.Node, bool, error) {
 				return ast.Node{}, false, err
 			}
 
-			return n, true, nil
+			return p.node(start, n), true, nil
 		}
 
-		return lhs.Access(types.String(t.Text)), true, nil
+		return p.node(start, lhs.Access(types.String(t.Text))), true, nil
 	case "[":
 		p.advance()
 		t := p.advance()
@@ -954,7 +1025,7 @@ func (p *parser) access(lhs ast.Node) (ast.Node, bool, error) {
 		if err := p.exact("]"); err != nil {
 			return ast.Node{}, false, err
 		}
-		return lhs.Access(types.String(name)), true, nil
+		return p.node(start, lhs.Access(types.String(name))), true, nil
 	default:
 		return lhs, false, nil
 	}
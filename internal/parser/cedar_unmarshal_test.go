@@ -121,6 +121,33 @@ permit ( principal, action, resource );`,
 );`,
 			ast.Permit().ActionInSet(farming, forestry),
 		},
+		{
+			"scope eq slots",
+			`permit (
+    principal == ?principal,
+    action,
+    resource == ?resource
+);`,
+			ast.Permit().PrincipalEqSlot().ResourceEqSlot(),
+		},
+		{
+			"scope in slots",
+			`permit (
+    principal in ?principal,
+    action == Action::"sow",
+    resource in ?resource
+);`,
+			ast.Permit().PrincipalInSlot().ActionEq(sow).ResourceInSlot(),
+		},
+		{
+			"scope is in slots",
+			`permit (
+    principal is User in ?principal,
+    action,
+    resource is Crop in ?resource
+);`,
+			ast.Permit().PrincipalIsInSlot("User").ResourceIsInSlot("Crop"),
+		},
 		{
 			"trivial conditions",
 			`permit ( principal, action, resource )
@@ -480,6 +507,7 @@ when { (if true then 2 else 3) * 4 == 8 };`,
 			var policy parser.Policy
 			testutil.OK(t, policy.UnmarshalCedar([]byte(tt.Text)))
 			policy.Position = ast.Position{}
+			clearNodePositions(&policy)
 			testutil.Equals(t, &policy, (*parser.Policy)(tt.ExpectedPolicy))
 
 			var buf bytes.Buffer
@@ -585,6 +613,14 @@ func TestParseApproximateErrors(t *testing.T) {
 		{"resourceBadIsIn1", `permit (principal, action, resource is "error");`, "expected ident"},
 		{"resourceBadIsIn1", `permit (principal, action, resource is T in error);`, "got ) want ::"},
 		{"resourceBadIn", `permit (principal, action, resource in error);`, "got ) want ::"},
+		{"principalWrongSlot", `permit (principal == ?resource, action, resource);`, "expected ?principal"},
+		{"principalSpacedSlot", `permit (principal in ? principal, action, resource);`, "expected ?principal"},
+		{"principalIsInWrongSlot", `permit (principal is T in ?action, action, resource);`, "expected ?principal"},
+		{"resourceWrongSlot", `permit (principal, action, resource in ?principal);`, "expected ?resource"},
+		{"resourceEqWrongSlot", `permit (principal, action, resource == ?principal);`, "expected ?resource"},
+		{"resourceIsInWrongSlot", `permit (principal, action, resource is T in ?);`, "expected ?resource"},
+		{"actionSlot", `permit (principal, action == ?action, resource);`, "expected ident"},
+		{"conditionSlot", `permit (principal, action, resource) when { ?principal };`, "invalid primary"},
 		{"unlessCondition", `permit (principal, action, resource) unless {`, "invalid primary"},
 		{"or", `permit (principal, action, resource) when { true ||`, "invalid primary"},
 		{"and", `permit (principal, action, resource) when { true &&`, "invalid primary"},
@@ -722,6 +758,7 @@ func TestReservedNamesInEntityPath(t *testing.T) {
 			var out parser.Policy
 			err := out.UnmarshalCedar([]byte(tt.in))
 			out.Position = ast.Position{}
+			clearNodePositions(&out)
 			tt.err(t, err)
 			if err == nil {
 				testutil.Equals(t, &out, (*parser.Policy)(tt.out))
@@ -0,0 +1,319 @@
+package parser
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"unicode"
+
+	"github.com/cedar-policy/cedar-go/types"
+	schemaast "github.com/cedar-policy/cedar-go/x/exp/schema/ast"
+)
+
+const schemaIndent = "  "
+
+// MarshalCedar emits the schema in the human-readable Cedar schema format.  Within each namespace common types are
+// emitted first, followed by entity types and then actions.
+func (s *Schema) MarshalCedar(buf *bytes.Buffer) error {
+	m := schemaMarshaler{buf: buf, schema: (*schemaast.Schema)(s)}
+	first := true
+	for _, ns := range s.Namespaces {
+		if !first {
+			buf.WriteRune('\n')
+		}
+		first = false
+		if err := m.namespace(ns); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+type schemaMarshaler struct {
+	buf    *bytes.Buffer
+	schema *schemaast.Schema
+	ns     *schemaast.Namespace
+	indent string
+}
+
+func (m *schemaMarshaler) namespace(ns *schemaast.Namespace) error {
+	m.ns = ns
+	m.indent = ""
+	if ns.Name != "" {
+		m.annotations(ns.Annotations)
+		m.buf.WriteString("namespace " + string(ns.Name) + " {\n")
+		m.indent = schemaIndent
+	}
+	for _, c := range ns.CommonTypes {
+		m.annotations(c.Annotations)
+		m.buf.WriteString(m.indent + "type " + string(c.Name) + " = ")
+		if err := m.typ(c.Type); err != nil {
+			return err
+		}
+		m.buf.WriteString(";\n")
+	}
+	for _, e := range ns.Entities {
+		if err := m.entity(e); err != nil {
+			return err
+		}
+	}
+	for _, a := range ns.Actions {
+		if err := m.action(a); err != nil {
+			return err
+		}
+	}
+	if ns.Name != "" {
+		m.buf.WriteString("}\n")
+	}
+	return nil
+}
+
+func (m *schemaMarshaler) annotations(annotations []schemaast.Annotation) {
+	for _, a := range annotations {
+		m.buf.WriteString(m.indent + "@" + string(a.Key) + "(")
+		m.str(a.Value)
+		m.buf.WriteString(")\n")
+	}
+}
+
+func (m *schemaMarshaler) entity(e *schemaast.Entity) error {
+	m.annotations(e.Annotations)
+	m.buf.WriteString(m.indent + "entity " + string(e.Name))
+	if e.Enum != nil {
+		m.buf.WriteString(" enum [")
+		for i, v := range e.Enum {
+			if i > 0 {
+				m.buf.WriteString(", ")
+			}
+			m.str(v)
+		}
+		m.buf.WriteString("];\n")
+		return nil
+	}
+	if len(e.MemberOf) > 0 {
+		m.buf.WriteString(" in ")
+		m.paths(e.MemberOf)
+	}
+	if e.Shape != nil {
+		shape, err := m.entityShape(e.Shape)
+		if err != nil {
+			return fmt.Errorf("entity type %v: %w", e.Name, err)
+		}
+		m.buf.WriteString(" = ")
+		if err := m.typ(shape); err != nil {
+			return err
+		}
+	}
+	if e.Tags != nil {
+		m.buf.WriteString(" tags ")
+		if err := m.typ(e.Tags); err != nil {
+			return err
+		}
+	}
+	m.buf.WriteString(";\n")
+	return nil
+}
+
+// entityShape returns the record type for an entity's shape.  The Cedar schema format has no syntax for declaring a
+// shape by referring to a common type, so such references are inlined.
+func (m *schemaMarshaler) entityShape(shape schemaast.IsType) (schemaast.RecordType, error) {
+	seen := map[types.Path]bool{}
+	for {
+		switch t := shape.(type) {
+		case schemaast.RecordType:
+			return t, nil
+		case schemaast.TypeRef:
+			if seen[t.Name] {
+				return schemaast.RecordType{}, fmt.Errorf("cycle in common type %v", t.Name)
+			}
+			seen[t.Name] = true
+			c := m.lookupCommonType(t.Name)
+			if c == nil {
+				return schemaast.RecordType{}, fmt.Errorf("shape refers to unknown common type %v", t.Name)
+			}
+			shape = c.Type
+		default:
+			return schemaast.RecordType{}, fmt.Errorf("shape must be a record type")
+		}
+	}
+}
+
+func (m *schemaMarshaler) lookupCommonType(name types.Path) *schemaast.CommonType {
+	nsName, ident := splitPath(name)
+	if nsName == "" {
+		if c := m.ns.CommonType(ident); c != nil {
+			return c
+		}
+	}
+	if ns := m.schema.Namespace(nsName); ns != nil {
+		return ns.CommonType(ident)
+	}
+	return nil
+}
+
+func splitPath(p types.Path) (types.Path, types.Ident) {
+	i := strings.LastIndex(string(p), "::")
+	if i < 0 {
+		return "", types.Ident(p)
+	}
+	return p[:i], types.Ident(p[i+2:])
+}
+
+func (m *schemaMarshaler) action(a *schemaast.Action) error {
+	m.annotations(a.Annotations)
+	m.buf.WriteString(m.indent + "action ")
+	m.str(a.Name)
+	if len(a.MemberOf) > 0 {
+		m.buf.WriteString(" in [")
+		for i, ref := range a.MemberOf {
+			if i > 0 {
+				m.buf.WriteString(", ")
+			}
+			if ref.Type != "" {
+				m.buf.WriteString(string(ref.Type) + "::")
+			}
+			m.str(ref.ID)
+		}
+		m.buf.WriteRune(']')
+	}
+	if a.AppliesTo != nil {
+		inner := m.indent + schemaIndent
+		m.buf.WriteString(" appliesTo {\n")
+		m.buf.WriteString(inner + "principal: ")
+		m.paths(a.AppliesTo.Principals)
+		m.buf.WriteString(",\n" + inner + "resource: ")
+		m.paths(a.AppliesTo.Resources)
+		m.buf.WriteString(",\n" + inner + "context: ")
+		context := a.AppliesTo.Context
+		if context == nil {
+			context = schemaast.RecordType{}
+		}
+		saved := m.indent
+		m.indent = inner
+		err := m.typ(context)
+		m.indent = saved
+		if err != nil {
+			return err
+		}
+		m.buf.WriteString("\n" + m.indent + "}")
+	}
+	m.buf.WriteString(";\n")
+	return nil
+}
+
+func (m *schemaMarshaler) paths(paths []types.Path) {
+	m.buf.WriteRune('[')
+	for i, p := range paths {
+		if i > 0 {
+			m.buf.WriteString(", ")
+		}
+		m.buf.WriteString(string(p))
+	}
+	m.buf.WriteRune(']')
+}
+
+func (m *schemaMarshaler) typ(t schemaast.IsType) error {
+	switch t := t.(type) {
+	case schemaast.BooleanType:
+		m.builtin("Bool")
+	case schemaast.LongType:
+		m.builtin("Long")
+	case schemaast.StringType:
+		m.builtin("String")
+	case schemaast.ExtensionType:
+		m.builtin(t.Name)
+	case schemaast.SetType:
+		m.buf.WriteString("Set<")
+		if err := m.typ(t.Element); err != nil {
+			return err
+		}
+		m.buf.WriteRune('>')
+	case schemaast.RecordType:
+		return m.record(t)
+	case schemaast.EntityTypeRef:
+		m.buf.WriteString(string(t.Name))
+	case schemaast.TypeRef:
+		m.buf.WriteString(string(t.Name))
+	default:
+		return fmt.Errorf("unknown schema type %T", t)
+	}
+	return nil
+}
+
+// builtin writes the name of a built-in type, qualifying it with the __cedar namespace if the bare name would refer
+// to a declaration in the schema.
+func (m *schemaMarshaler) builtin(name types.Ident) {
+	if m.shadowed(name) {
+		m.buf.WriteString(schemaast.CedarNamespace + "::")
+	}
+	m.buf.WriteString(string(name))
+}
+
+func (m *schemaMarshaler) shadowed(name types.Ident) bool {
+	for _, ns := range []*schemaast.Namespace{m.ns, m.schema.Namespace("")} {
+		if ns != nil && (ns.CommonType(name) != nil || ns.Entity(name) != nil) {
+			return true
+		}
+	}
+	return false
+}
+
+func (m *schemaMarshaler) record(r schemaast.RecordType) error {
+	if len(r.Attributes) == 0 {
+		m.buf.WriteString("{}")
+		return nil
+	}
+	saved := m.indent
+	m.indent += schemaIndent
+	m.buf.WriteString("{\n")
+	for i, a := range r.Attributes {
+		m.annotations(a.Annotations)
+		m.buf.WriteString(m.indent)
+		if a.Name != "" && canMarshalAsIdent(string(a.Name)) {
+			m.buf.WriteString(string(a.Name))
+		} else {
+			m.str(a.Name)
+		}
+		if a.Optional {
+			m.buf.WriteRune('?')
+		}
+		m.buf.WriteString(": ")
+		if err := m.typ(a.Type); err != nil {
+			return err
+		}
+		if i < len(r.Attributes)-1 {
+			m.buf.WriteRune(',')
+		}
+		m.buf.WriteRune('\n')
+	}
+	m.indent = saved
+	m.buf.WriteString(m.indent + "}")
+	return nil
+}
+
+// str writes a string literal using only the escape sequences accepted by the Cedar tokenizer.
+func (m *schemaMarshaler) str(s types.String) {
+	m.buf.WriteRune('"')
+	for _, r := range string(s) {
+		switch r {
+		case '\\', '"':
+			m.buf.WriteRune('\\')
+			m.buf.WriteRune(r)
+		case 0:
+			m.buf.WriteString(`\0`)
+		case '\n':
+			m.buf.WriteString(`\n`)
+		case '\r':
+			m.buf.WriteString(`\r`)
+		case '\t':
+			m.buf.WriteString(`\t`)
+		default:
+			if unicode.IsPrint(r) {
+				m.buf.WriteRune(r)
+			} else {
+				fmt.Fprintf(m.buf, `\u{%x}`, r)
+			}
+		}
+	}
+	m.buf.WriteRune('"')
+}
@@ -0,0 +1,350 @@
+package parser_test
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/cedar-policy/cedar-go/internal/parser"
+	"github.com/cedar-policy/cedar-go/internal/testutil"
+	"github.com/cedar-policy/cedar-go/types"
+	"github.com/cedar-policy/cedar-go/x/exp/schema/ast"
+)
+
+// stripPositions zeroes the positions recorded in the schema so that tests can compare declarations structurally.
+func stripPositions(s *parser.Schema) {
+	var stripType func(t ast.IsType)
+	stripType = func(t ast.IsType) {
+		switch t := t.(type) {
+		case ast.RecordType:
+			for i := range t.Attributes {
+				t.Attributes[i].Position = types.Position{}
+				stripType(t.Attributes[i].Type)
+			}
+		case ast.SetType:
+			stripType(t.Element)
+		}
+	}
+	for _, ns := range s.Namespaces {
+		ns.Position = types.Position{}
+		for _, e := range ns.Entities {
+			e.Position = types.Position{}
+			stripType(e.Shape)
+			stripType(e.Tags)
+		}
+		for _, a := range ns.Actions {
+			a.Position = types.Position{}
+			if a.AppliesTo != nil {
+				stripType(a.AppliesTo.Context)
+			}
+		}
+		for _, c := range ns.CommonTypes {
+			c.Position = types.Position{}
+			stripType(c.Type)
+		}
+	}
+}
+
+func TestSchemaUnmarshalCedar(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name string
+		in   string
+		want parser.Schema
+	}{
+		{
+			"empty",
+			``,
+			parser.Schema{},
+		},
+		{
+			"entity",
+			`entity User;`,
+			parser.Schema{Namespaces: []*ast.Namespace{{Entities: []*ast.Entity{{Name: "User"}}}}},
+		},
+		{
+			"multiple entities with parents, shape and tags",
+			`entity User, Admin in [Group, NS::Team] = { name: String, "a b"?: Set<Long> } tags String;`,
+			parser.Schema{Namespaces: []*ast.Namespace{{Entities: []*ast.Entity{
+				{
+					Name:     "User",
+					MemberOf: []types.Path{"Group", "NS::Team"},
+					Shape: ast.RecordType{Attributes: []ast.Attribute{
+						{Name: "name", Type: ast.TypeRef{Name: "String"}},
+						{Name: "a b", Type: ast.SetType{Element: ast.TypeRef{Name: "Long"}}, Optional: true},
+					}},
+					Tags: ast.TypeRef{Name: "String"},
+				},
+				{
+					Name:     "Admin",
+					MemberOf: []types.Path{"Group", "NS::Team"},
+					Shape: ast.RecordType{Attributes: []ast.Attribute{
+						{Name: "name", Type: ast.TypeRef{Name: "String"}},
+						{Name: "a b", Type: ast.SetType{Element: ast.TypeRef{Name: "Long"}}, Optional: true},
+					}},
+					Tags: ast.TypeRef{Name: "String"},
+				},
+			}}}},
+		},
+		{
+			"entity shape without equals and single parent",
+			`entity User in Group { in: __cedar::Bool };`,
+			parser.Schema{Namespaces: []*ast.Namespace{{Entities: []*ast.Entity{{
+				Name:     "User",
+				MemberOf: []types.Path{"Group"},
+				Shape:    ast.RecordType{Attributes: []ast.Attribute{{Name: "in", Type: ast.BooleanType{}}}},
+			}}}}},
+		},
+		{
+			"enum entity",
+			`entity Color enum ["red", "green",];`,
+			parser.Schema{Namespaces: []*ast.Namespace{{Entities: []*ast.Entity{{
+				Name: "Color",
+				Enum: []types.String{"red", "green"},
+			}}}}},
+		},
+		{
+			"common type",
+			`type Addr = { ip: __cedar::ipaddr, tags: Set<String> };`,
+			parser.Schema{Namespaces: []*ast.Namespace{{CommonTypes: []*ast.CommonType{{
+				Name: "Addr",
+				Type: ast.RecordType{Attributes: []ast.Attribute{
+					{Name: "ip", Type: ast.ExtensionType{Name: "ipaddr"}},
+					{Name: "tags", Type: ast.SetType{Element: ast.TypeRef{Name: "String"}}},
+				}},
+			}}}}},
+		},
+		{
+			"actions",
+			`action read, "write all" in ["group", NS::Action::"other"] appliesTo {
+				principal: User,
+				resource: [Doc, Folder],
+				context: Ctx,
+			};
+			action group;`,
+			parser.Schema{Namespaces: []*ast.Namespace{{Actions: []*ast.Action{
+				{
+					Name:     "read",
+					MemberOf: []ast.ActionRef{{ID: "group"}, {Type: "NS::Action", ID: "other"}},
+					AppliesTo: &ast.AppliesTo{
+						Principals: []types.Path{"User"},
+						Resources:  []types.Path{"Doc", "Folder"},
+						Context:    ast.TypeRef{Name: "Ctx"},
+					},
+				},
+				{
+					Name:     "write all",
+					MemberOf: []ast.ActionRef{{ID: "group"}, {Type: "NS::Action", ID: "other"}},
+					AppliesTo: &ast.AppliesTo{
+						Principals: []types.Path{"User"},
+						Resources:  []types.Path{"Doc", "Folder"},
+						Context:    ast.TypeRef{Name: "Ctx"},
+					},
+				},
+				{Name: "group"},
+			}}}},
+		},
+		{
+			"namespaces and annotations",
+			`@doc("app")
+			namespace App::Core {
+				@doc("user") @internal
+				entity User = {
+					@doc("the name")
+					name: String,
+				};
+			}
+			entity Root;`,
+			parser.Schema{Namespaces: []*ast.Namespace{
+				{
+					Name:        "App::Core",
+					Annotations: []ast.Annotation{{Key: "doc", Value: "app"}},
+					Entities: []*ast.Entity{{
+						Name:        "User",
+						Annotations: []ast.Annotation{{Key: "doc", Value: "user"}, {Key: "internal", Value: ""}},
+						Shape: ast.RecordType{Attributes: []ast.Attribute{{
+							Name:        "name",
+							Type:        ast.TypeRef{Name: "String"},
+							Annotations: []ast.Annotation{{Key: "doc", Value: "the name"}},
+						}}},
+					}},
+				},
+				{Entities: []*ast.Entity{{Name: "Root"}}},
+			}},
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			var got parser.Schema
+			testutil.OK(t, got.UnmarshalCedar([]byte(tt.in)))
+			stripPositions(&got)
+			testutil.Equals(t, got, tt.want)
+		})
+	}
+}
+
+func TestSchemaUnmarshalCedarPositions(t *testing.T) {
+	t.Parallel()
+	var s parser.Schema
+	testutil.OK(t, s.UnmarshalCedar([]byte("namespace NS {\n  entity User = {\n    name: String,\n  };\n}")))
+	ns := s.Namespaces[0]
+	testutil.Equals(t, ns.Position, types.Position{Offset: 0, Line: 1, Column: 1})
+	testutil.Equals(t, ns.Entities[0].Position, types.Position{Offset: 17, Line: 2, Column: 3})
+	attr, ok := ns.Entities[0].Shape.(ast.RecordType).Attribute("name")
+	testutil.Equals(t, ok, true)
+	testutil.Equals(t, attr.Position, types.Position{Offset: 37, Line: 3, Column: 5})
+}
+
+func TestSchemaUnmarshalCedarErrors(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name string
+		in   string
+	}{
+		{"bad token", `entity "`},
+		{"unknown declaration", `thing User;`},
+		{"missing semicolon", `entity User`},
+		{"duplicate entity", `entity User; entity User;`},
+		{"duplicate entity in one declaration", `entity User, User;`},
+		{"duplicate action", `action read; action "read";`},
+		{"duplicate common type", `type A = Long; type A = String;`},
+		{"builtin common type", `type Long = String;`},
+		{"duplicate namespace", `namespace A {} namespace A {}`},
+		{"unterminated namespace", `namespace A { entity User;`},
+		{"duplicate annotation", `@a @a entity User;`},
+		{"bad annotation value", `@a(b) entity User;`},
+		{"duplicate attribute", `entity User = { a: Long, a: Long };`},
+		{"shape not a record", `entity User = Long;`},
+		{"bad attribute name", `entity User = { 1: Long };`},
+		{"missing attribute type", `entity User = { a };`},
+		{"bad type", `type A = 1;`},
+		{"unterminated set", `type A = Set<Long;`},
+		{"duplicate appliesTo", `action a appliesTo { principal: A, principal: B };`},
+		{"unknown appliesTo", `action a appliesTo { subject: A };`},
+		{"action attributes", `action a attributes {};`},
+		{"bad action ref", `action a in [1];`},
+		{"bad enum", `entity Color enum [red];`},
+		{"bad entity name", `entity "User";`},
+		{"bad entity types", `entity User in [1];`},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			var s parser.Schema
+			testutil.Error(t, s.UnmarshalCedar([]byte(tt.in)))
+		})
+	}
+}
+
+func TestSchemaMarshalCedar(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name string
+		in   string
+		want string
+	}{
+		{
+			"entities",
+			`entity User in [Group] = {"name": String, "the age"?: Long} tags Set<String>; entity Group;`,
+			`entity User in [Group] = {
+  name: String,
+  "the age"?: Long
+} tags Set<String>;
+entity Group;
+`,
+		},
+		{
+			"enum and escapes",
+			`entity Color enum ["r\"ed", "gr\0een"];`,
+			`entity Color enum ["r\"ed", "gr\0een"];
+`,
+		},
+		{
+			"shadowed builtin",
+			`entity String; type T = { a: __cedar::String, b: __cedar::Long, c: __cedar::ipaddr };`,
+			`type T = {
+  a: __cedar::String,
+  b: Long,
+  c: ipaddr
+};
+entity String;
+`,
+		},
+		{
+			"actions and namespaces",
+			`@doc("ns") namespace NS { @doc("a") action "read file" in [NS::Action::"all", "other"] appliesTo { principal: [User], resource: Doc }; action all; }`,
+			`@doc("ns")
+namespace NS {
+  @doc("a")
+  action "read file" in [NS::Action::"all", "other"] appliesTo {
+    principal: [User],
+    resource: [Doc],
+    context: {}
+  };
+  action "all";
+}
+`,
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			var s parser.Schema
+			testutil.OK(t, s.UnmarshalCedar([]byte(tt.in)))
+			var buf bytes.Buffer
+			testutil.OK(t, s.MarshalCedar(&buf))
+			testutil.Equals(t, buf.String(), tt.want)
+		})
+	}
+}
+
+func TestSchemaMarshalCedarInlinesCommonShape(t *testing.T) {
+	t.Parallel()
+	s := parser.Schema{Namespaces: []*ast.Namespace{{
+		Name: "NS",
+		CommonTypes: []*ast.CommonType{
+			{Name: "Shape", Type: ast.TypeRef{Name: "Other"}},
+			{Name: "Other", Type: ast.RecordType{Attributes: []ast.Attribute{{Name: "a", Type: ast.LongType{}}}}},
+		},
+		Entities: []*ast.Entity{{Name: "User", Shape: ast.TypeRef{Name: "Shape"}}},
+	}}}
+	var buf bytes.Buffer
+	testutil.OK(t, s.MarshalCedar(&buf))
+	testutil.Equals(t, buf.String(), `namespace NS {
+  type Shape = Other;
+  type Other = {
+    a: Long
+  };
+  entity User = {
+    a: Long
+  };
+}
+`)
+}
+
+func TestSchemaMarshalCedarErrors(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name  string
+		shape ast.IsType
+	}{
+		{"unknown common type", ast.TypeRef{Name: "Missing"}},
+		{"not a record", ast.LongType{}},
+		{"cycle", ast.TypeRef{Name: "Loop"}},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			s := parser.Schema{Namespaces: []*ast.Namespace{{
+				CommonTypes: []*ast.CommonType{{Name: "Loop", Type: ast.TypeRef{Name: "Loop"}}},
+				Entities:    []*ast.Entity{{Name: "User", Shape: tt.shape}},
+			}}}
+			var buf bytes.Buffer
+			testutil.Error(t, s.MarshalCedar(&buf))
+		})
+	}
+}
@@ -0,0 +1,538 @@
+package parser
+
+import (
+	"slices"
+	"strings"
+
+	"github.com/cedar-policy/cedar-go/internal/consts"
+	"github.com/cedar-policy/cedar-go/internal/mapset"
+	"github.com/cedar-policy/cedar-go/types"
+	schemaast "github.com/cedar-policy/cedar-go/x/exp/schema/ast"
+)
+
+type Schema schemaast.Schema
+
+// UnmarshalCedar parses a schema in the human-readable Cedar schema format.  Declarations outside of a namespace
+// are collected into a single namespace with an empty name, regardless of where they appear in the document.
+func (s *Schema) UnmarshalCedar(b []byte) error {
+	tokens, err := Tokenize(b)
+	if err != nil {
+		return err
+	}
+
+	var res Schema
+	var anonymous *schemaast.Namespace
+	var known mapset.MapSet[types.Path]
+	parser := newParser(tokens)
+	for !parser.peek().isEOF() {
+		pos := parser.peek().Pos
+		annotations, err := parser.schemaAnnotations()
+		if err != nil {
+			return err
+		}
+		if parser.peek().Text == "namespace" {
+			ns, err := parser.namespace(annotations)
+			if err != nil {
+				return err
+			}
+			if known.Contains(ns.Name) {
+				return parser.errorf("duplicate namespace: %v", ns.Name)
+			}
+			known.Add(ns.Name)
+			ns.Position = types.Position(pos)
+			res.Namespaces = append(res.Namespaces, ns)
+			continue
+		}
+		if anonymous == nil {
+			anonymous = &schemaast.Namespace{Position: types.Position(pos)}
+			res.Namespaces = append(res.Namespaces, anonymous)
+		}
+		if err := parser.declaration(anonymous, annotations, pos); err != nil {
+			return err
+		}
+	}
+
+	*s = res
+	return nil
+}
+
+func (p *parser) schemaAnnotations() ([]schemaast.Annotation, error) {
+	var res []schemaast.Annotation
+	var known mapset.MapSet[types.Ident]
+	for p.peek().Text == "@" {
+		p.advance()
+		t := p.advance()
+		if !t.isIdent() && !t.isReservedKeyword() {
+			return nil, p.errorf("expected ident or reserved keyword")
+		}
+		key := types.Ident(t.Text)
+		if known.Contains(key) {
+			return nil, p.errorf("duplicate annotation: @%s", key)
+		}
+		known.Add(key)
+
+		// Unlike in policies, the value of a schema annotation is optional and defaults to the empty string.
+		var value string
+		if p.peek().Text == "(" {
+			p.advance()
+			t = p.advance()
+			if !t.isString() {
+				return nil, p.errorf("expected string")
+			}
+			var err error
+			if value, err = t.stringValue(); err != nil {
+				return nil, err
+			}
+			if err := p.exact(")"); err != nil {
+				return nil, err
+			}
+		}
+		res = append(res, schemaast.Annotation{Key: key, Value: types.String(value)})
+	}
+	return res, nil
+}
+
+func (p *parser) namespace(annotations []schemaast.Annotation) (*schemaast.Namespace, error) {
+	if err := p.exact("namespace"); err != nil {
+		return nil, err
+	}
+	name, err := p.path()
+	if err != nil {
+		return nil, err
+	}
+	ns := &schemaast.Namespace{Name: types.Path(name), Annotations: annotations}
+	if err := p.exact("{"); err != nil {
+		return nil, err
+	}
+	for p.peek().Text != "}" {
+		if p.peek().isEOF() {
+			return nil, p.errorf("unexpected end of namespace")
+		}
+		pos := p.peek().Pos
+		annotations, err := p.schemaAnnotations()
+		if err != nil {
+			return nil, err
+		}
+		if err := p.declaration(ns, annotations, pos); err != nil {
+			return nil, err
+		}
+	}
+	p.advance()
+	return ns, nil
+}
+
+func (p *parser) declaration(ns *schemaast.Namespace, annotations []schemaast.Annotation, pos Position) error {
+	switch p.peek().Text {
+	case "entity":
+		return p.entityDecl(ns, annotations, pos)
+	case "action":
+		return p.actionDecl(ns, annotations, pos)
+	case "type":
+		return p.commonTypeDecl(ns, annotations, pos)
+	}
+	return p.errorf("unexpected declaration: %v", p.peek().Text)
+}
+
+func (p *parser) entityDecl(ns *schemaast.Namespace, annotations []schemaast.Annotation, pos Position) error {
+	p.advance()
+	names, err := p.idents()
+	if err != nil {
+		return err
+	}
+	for i, n := range names {
+		if ns.Entity(n) != nil || slices.Contains(names[:i], n) {
+			return p.errorf("duplicate entity type: %v", n)
+		}
+	}
+
+	var decl schemaast.Entity
+	if p.peek().Text == "enum" {
+		p.advance()
+		if decl.Enum, err = p.enumValues(); err != nil {
+			return err
+		}
+	} else {
+		if p.peek().Text == "in" {
+			p.advance()
+			if decl.MemberOf, err = p.entityTypes(); err != nil {
+				return err
+			}
+		}
+		if p.peek().Text == "=" {
+			p.advance()
+			if p.peek().Text != "{" {
+				return p.errorf("expected record type")
+			}
+		}
+		if p.peek().Text == "{" {
+			if decl.Shape, err = p.recordType(); err != nil {
+				return err
+			}
+		}
+		if p.peek().Text == "tags" {
+			p.advance()
+			if decl.Tags, err = p.schemaType(); err != nil {
+				return err
+			}
+		}
+	}
+	if err := p.exact(";"); err != nil {
+		return err
+	}
+
+	for _, n := range names {
+		e := decl
+		e.Name = n
+		e.Annotations = annotations
+		e.Position = types.Position(pos)
+		ns.Entities = append(ns.Entities, &e)
+	}
+	return nil
+}
+
+func (p *parser) enumValues() ([]types.String, error) {
+	if err := p.exact("["); err != nil {
+		return nil, err
+	}
+	var res []types.String
+	for {
+		t := p.advance()
+		if !t.isString() {
+			return nil, p.errorf("expected string")
+		}
+		s, err := t.stringValue()
+		if err != nil {
+			return nil, err
+		}
+		res = append(res, types.String(s))
+		if p.peek().Text != "," {
+			break
+		}
+		p.advance()
+		if p.peek().Text == "]" {
+			break
+		}
+	}
+	if err := p.exact("]"); err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+func (p *parser) actionDecl(ns *schemaast.Namespace, annotations []schemaast.Annotation, pos Position) error {
+	p.advance()
+	var names []types.String
+	for {
+		name, err := p.name()
+		if err != nil {
+			return err
+		}
+		if ns.Action(name) != nil || slices.Contains(names, name) {
+			return p.errorf("duplicate action: %q", name)
+		}
+		names = append(names, name)
+		if p.peek().Text != "," {
+			break
+		}
+		p.advance()
+	}
+
+	var decl schemaast.Action
+	var err error
+	if p.peek().Text == "in" {
+		p.advance()
+		if decl.MemberOf, err = p.actionRefs(); err != nil {
+			return err
+		}
+	}
+	if p.peek().Text == "appliesTo" {
+		p.advance()
+		if decl.AppliesTo, err = p.appliesTo(); err != nil {
+			return err
+		}
+	}
+	if p.peek().Text == "attributes" {
+		return p.errorf("action attributes are not supported")
+	}
+	if err := p.exact(";"); err != nil {
+		return err
+	}
+
+	for _, n := range names {
+		a := decl
+		a.Name = n
+		a.Annotations = annotations
+		a.Position = types.Position(pos)
+		ns.Actions = append(ns.Actions, &a)
+	}
+	return nil
+}
+
+func (p *parser) actionRefs() ([]schemaast.ActionRef, error) {
+	if p.peek().Text != "[" {
+		ref, err := p.actionRef()
+		if err != nil {
+			return nil, err
+		}
+		return []schemaast.ActionRef{ref}, nil
+	}
+	p.advance()
+	var res []schemaast.ActionRef
+	for p.peek().Text != "]" {
+		ref, err := p.actionRef()
+		if err != nil {
+			return nil, err
+		}
+		res = append(res, ref)
+		if p.peek().Text != "," {
+			break
+		}
+		p.advance()
+	}
+	if err := p.exact("]"); err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+// actionRef parses either a bare action name or a fully qualified action entity, e.g. `"read"`, `read` or
+// `NS::Action::"read"`.
+func (p *parser) actionRef() (schemaast.ActionRef, error) {
+	t := p.advance()
+	switch {
+	case t.isString():
+		s, err := t.stringValue()
+		if err != nil {
+			return schemaast.ActionRef{}, err
+		}
+		return schemaast.ActionRef{ID: types.String(s)}, nil
+	case t.isIdent():
+		if p.peek().Text != "::" {
+			return schemaast.ActionRef{ID: types.String(t.Text)}, nil
+		}
+		uid, err := p.entityFirstPathPreread(types.EntityType(t.Text))
+		if err != nil {
+			return schemaast.ActionRef{}, err
+		}
+		return schemaast.ActionRef{Type: types.Path(uid.Type), ID: uid.ID}, nil
+	}
+	return schemaast.ActionRef{}, p.errorf("expected action name")
+}
+
+func (p *parser) appliesTo() (*schemaast.AppliesTo, error) {
+	if err := p.exact("{"); err != nil {
+		return nil, err
+	}
+	var res schemaast.AppliesTo
+	var known mapset.MapSet[string]
+	for p.peek().Text != "}" {
+		t := p.advance()
+		if known.Contains(t.Text) {
+			return nil, p.errorf("duplicate appliesTo element: %v", t.Text)
+		}
+		known.Add(t.Text)
+		if err := p.exact(":"); err != nil {
+			return nil, err
+		}
+		var err error
+		switch t.Text {
+		case consts.Principal:
+			res.Principals, err = p.entityTypes()
+		case consts.Resource:
+			res.Resources, err = p.entityTypes()
+		case consts.Context:
+			res.Context, err = p.schemaType()
+		default:
+			err = p.errorf("unexpected appliesTo element: %v", t.Text)
+		}
+		if err != nil {
+			return nil, err
+		}
+		if p.peek().Text != "," {
+			break
+		}
+		p.advance()
+	}
+	if err := p.exact("}"); err != nil {
+		return nil, err
+	}
+	return &res, nil
+}
+
+func (p *parser) commonTypeDecl(ns *schemaast.Namespace, annotations []schemaast.Annotation, pos Position) error {
+	p.advance()
+	t := p.advance()
+	if !t.isIdent() {
+		return p.errorf("expected ident")
+	}
+	name := types.Ident(t.Text)
+	if _, ok := schemaast.BuiltinType(name); ok {
+		return p.errorf("common type name shadows a built-in type: %v", name)
+	}
+	if ns.CommonType(name) != nil {
+		return p.errorf("duplicate common type: %v", name)
+	}
+	if err := p.exact("="); err != nil {
+		return err
+	}
+	typ, err := p.schemaType()
+	if err != nil {
+		return err
+	}
+	if err := p.exact(";"); err != nil {
+		return err
+	}
+	ns.CommonTypes = append(ns.CommonTypes, &schemaast.CommonType{
+		Name:        name,
+		Annotations: annotations,
+		Type:        typ,
+		Position:    types.Position(pos),
+	})
+	return nil
+}
+
+func (p *parser) schemaType() (schemaast.IsType, error) {
+	t := p.peek()
+	switch {
+	case t.Text == "{":
+		return p.recordType()
+	case t.Text == "Set" && p.tokens[p.pos+1].Text == "<":
+		p.advance()
+		p.advance()
+		element, err := p.schemaType()
+		if err != nil {
+			return nil, err
+		}
+		if err := p.exact(">"); err != nil {
+			return nil, err
+		}
+		return schemaast.SetType{Element: element}, nil
+	case t.isIdent():
+		path, err := p.path()
+		if err != nil {
+			return nil, err
+		}
+		return typeFromPath(types.Path(path)), nil
+	}
+	return nil, p.errorf("expected type")
+}
+
+// typeFromPath only resolves names in the __cedar namespace, which always refer to built-in types.  All other names
+// may be shadowed by declarations and so can only be resolved once the whole schema is known.
+func typeFromPath(path types.Path) schemaast.IsType {
+	if name, ok := strings.CutPrefix(string(path), schemaast.CedarNamespace+"::"); ok {
+		if t, ok := schemaast.BuiltinType(types.Ident(name)); ok {
+			return t
+		}
+	}
+	return schemaast.TypeRef{Name: path}
+}
+
+func (p *parser) recordType() (schemaast.RecordType, error) {
+	if err := p.exact("{"); err != nil {
+		return schemaast.RecordType{}, err
+	}
+	var res schemaast.RecordType
+	var known mapset.MapSet[types.String]
+	for p.peek().Text != "}" {
+		pos := p.peek().Pos
+		annotations, err := p.schemaAnnotations()
+		if err != nil {
+			return res, err
+		}
+		name, err := p.attributeName()
+		if err != nil {
+			return res, err
+		}
+		if known.Contains(name) {
+			return res, p.errorf("duplicate attribute: %q", name)
+		}
+		known.Add(name)
+		attr := schemaast.Attribute{Name: name, Annotations: annotations, Position: types.Position(pos)}
+		if p.peek().Text == "?" {
+			p.advance()
+			attr.Optional = true
+		}
+		if err := p.exact(":"); err != nil {
+			return res, err
+		}
+		if attr.Type, err = p.schemaType(); err != nil {
+			return res, err
+		}
+		res.Attributes = append(res.Attributes, attr)
+		if p.peek().Text != "," {
+			break
+		}
+		p.advance()
+	}
+	if err := p.exact("}"); err != nil {
+		return res, err
+	}
+	return res, nil
+}
+
+func (p *parser) attributeName() (types.String, error) {
+	t := p.advance()
+	switch {
+	case t.isIdent(), t.isReservedKeyword():
+		return types.String(t.Text), nil
+	case t.isString():
+		s, err := t.stringValue()
+		return types.String(s), err
+	}
+	return "", p.errorf("expected ident or string")
+}
+
+func (p *parser) name() (types.String, error) {
+	t := p.advance()
+	switch {
+	case t.isIdent():
+		return types.String(t.Text), nil
+	case t.isString():
+		s, err := t.stringValue()
+		return types.String(s), err
+	}
+	return "", p.errorf("expected ident or string")
+}
+
+func (p *parser) idents() ([]types.Ident, error) {
+	var res []types.Ident
+	for {
+		t := p.advance()
+		if !t.isIdent() {
+			return nil, p.errorf("expected ident")
+		}
+		res = append(res, types.Ident(t.Text))
+		if p.peek().Text != "," {
+			return res, nil
+		}
+		p.advance()
+	}
+}
+
+func (p *parser) entityTypes() ([]types.Path, error) {
+	if p.peek().Text != "[" {
+		path, err := p.path()
+		if err != nil {
+			return nil, err
+		}
+		return []types.Path{types.Path(path)}, nil
+	}
+	p.advance()
+	var res []types.Path
+	for p.peek().Text != "]" {
+		path, err := p.path()
+		if err != nil {
+			return nil, err
+		}
+		res = append(res, types.Path(path))
+		if p.peek().Text != "," {
+			break
+		}
+		p.advance()
+	}
+	if err := p.exact("]"); err != nil {
+		return nil, err
+	}
+	return res, nil
+}
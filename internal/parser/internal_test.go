@@ -1,6 +1,7 @@
 package parser
 
 import (
+	"bytes"
 	"testing"
 
 	"github.com/cedar-policy/cedar-go/internal/testutil"
@@ -31,3 +32,10 @@ func TestAstNodeToMarshalNode(t *testing.T) {
 		})
 	})
 }
+
+func TestMarshalNode(t *testing.T) {
+	t.Parallel()
+	var buf bytes.Buffer
+	MarshalNode(ast.Resource().Access("size").Add(ast.Long(1)).GreaterThan(ast.Long(2)).AsIsNode(), &buf)
+	testutil.Equals(t, buf.String(), "resource.size + 1 > 2")
+}
@@ -0,0 +1,124 @@
+package cedar
+
+import (
+	"github.com/cedar-policy/cedar-go/internal/eval"
+	"github.com/cedar-policy/cedar-go/types"
+	internalast "github.com/cedar-policy/cedar-go/x/exp/ast"
+)
+
+// Unknown returns a value which stands for a part of a PartialRequest that is not known: the principal, action,
+// resource or context, an attribute of the context, or an attribute or tag of an entity.
+func Unknown() Value {
+	return eval.Variable("")
+}
+
+// A PartialRequest is like a Request, except that any part of it may be Unknown, and the context may be a Record with
+// Unknown attributes.  A nil Context is an empty Record.
+type PartialRequest struct {
+	Principal Value
+	Action    Value
+	Resource  Value
+	Context   Value
+
+	// UnknownEntities lists the entities whose attributes, tags and ancestors are unknown, whether or not they are
+	// present in the entities passed to AuthorizePartial.
+	UnknownEntities []EntityUID
+}
+
+// A PartialResponse is the result of a partial authorization.
+type PartialResponse struct {
+	// Decided reports whether the decision is the same whatever the unknown parts of the request are.  If so,
+	// Decision holds it and Diagnostic holds the policies which determine it.
+	Decided  bool
+	Decision Decision
+
+	// Residuals holds each policy which may apply to the request, evaluated as far as the known parts of the request
+	// allow.  A policy which definitely applies has no scope constraints or conditions.  Authorizing a request using
+	// the residuals gives the same result as using the original policies, as long as the request agrees with the known
+	// parts of the partial request.
+	Residuals PolicyMap
+
+	// Diagnostic holds the errors of the policies which definitely fail to evaluate, and the reasons for the decision
+	// if it was made.
+	Diagnostic Diagnostic
+}
+
+// AuthorizePartial authorizes a request of which some parts are unknown.  If the decision does not depend on the
+// unknown parts, it is returned as for Authorize.  Otherwise, the response holds the residual policies, which can be
+// marshaled to Cedar or JSON, or analyzed to find the requests that would be allowed, e.g. the resources which a
+// principal may view when the resource is Unknown.
+func AuthorizePartial(policies PolicyIterator, entities types.EntityGetter, req PartialRequest) PartialResponse {
+	if entities == nil {
+		var zero types.EntityMap
+		entities = zero
+	}
+	if req.Context == nil {
+		req.Context = Record{}
+	}
+	env := eval.Env{
+		Entities:        entities,
+		Principal:       req.Principal,
+		Action:          req.Action,
+		Resource:        req.Resource,
+		Context:         req.Context,
+		UnknownEntities: types.NewEntityUIDSet(req.UnknownEntities...),
+	}
+	res := PartialResponse{Residuals: PolicyMap{}}
+	var forbids, permits []DiagnosticReason
+	var residualForbids, residualPermits int
+	for id, po := range policies.All() {
+		residual, keep := eval.PartialPolicy(env, po.ast)
+		if !keep {
+			continue
+		}
+		if msg, ok := partialError(residual); ok {
+			res.Diagnostic.Errors = append(res.Diagnostic.Errors, DiagnosticError{PolicyID: id, Position: po.Position(), Message: msg})
+			continue
+		}
+		res.Residuals[id] = newPolicy(residual)
+		satisfied := isSatisfied(residual)
+		switch {
+		case satisfied && po.Effect() == Forbid:
+			forbids = append(forbids, DiagnosticReason{PolicyID: id, Position: po.Position()})
+		case satisfied:
+			permits = append(permits, DiagnosticReason{PolicyID: id, Position: po.Position()})
+		case po.Effect() == Forbid:
+			residualForbids++
+		default:
+			residualPermits++
+		}
+	}
+	switch {
+	case len(forbids) > 0:
+		res.Decided, res.Decision, res.Diagnostic.Reasons = true, Deny, forbids
+	case len(permits) > 0 && residualForbids == 0:
+		res.Decided, res.Decision, res.Diagnostic.Reasons = true, Allow, permits
+	case len(permits) == 0 && residualPermits == 0:
+		res.Decided, res.Decision = true, Deny
+	}
+	return res
+}
+
+// isSatisfied reports whether a residual policy has no scope constraints or conditions left, and so applies to the
+// request.
+func isSatisfied(p *internalast.Policy) bool {
+	_, principalAll := p.Principal.(internalast.ScopeTypeAll)
+	_, actionAll := p.Action.(internalast.ScopeTypeAll)
+	_, resourceAll := p.Resource.(internalast.ScopeTypeAll)
+	return principalAll && actionAll && resourceAll && len(p.Conditions) == 0
+}
+
+// partialError returns the error message of a residual policy which definitely fails to evaluate: one whose only
+// remaining condition is an error.
+func partialError(p *internalast.Policy) (string, bool) {
+	if len(p.Conditions) != 1 {
+		return "", false
+	}
+	msg, ok := eval.ToPartialError(p.Conditions[0].Body)
+	if !ok {
+		return "", false
+	}
+	q := *p
+	q.Conditions = nil
+	return msg, isSatisfied(&q)
+}
@@ -0,0 +1,233 @@
+package cedar_test
+
+import (
+	"testing"
+
+	"github.com/cedar-policy/cedar-go"
+	"github.com/cedar-policy/cedar-go/internal/testutil"
+)
+
+func TestAuthorizePartial(t *testing.T) {
+	t.Parallel()
+	alice := cedar.NewEntityUID("User", "alice")
+	bob := cedar.NewEntityUID("User", "bob")
+	admins := cedar.NewEntityUID("Group", "admins")
+	view := cedar.NewEntityUID("Action", "view")
+	doc := cedar.NewEntityUID("Doc", "readme")
+	entities := cedar.EntityMap{
+		alice: cedar.Entity{UID: alice, Parents: cedar.NewEntityUIDSet(admins)},
+		doc:   cedar.Entity{UID: doc, Attributes: cedar.NewRecord(cedar.RecordMap{"owner": bob})},
+	}
+	tests := []struct {
+		name      string
+		policies  string
+		req       cedar.PartialRequest
+		decided   bool
+		decision  cedar.Decision
+		reasons   []cedar.PolicyID
+		errors    []string
+		residuals []cedar.PolicyID
+	}{
+		{"allow",
+			`permit(principal == User::"alice", action, resource);`,
+			cedar.PartialRequest{Principal: alice, Action: view, Resource: cedar.Unknown()},
+			true, cedar.Allow, []cedar.PolicyID{"policy0"}, nil, []cedar.PolicyID{"policy0"},
+		},
+		{"allowDespiteUnknownPermit",
+			`permit(principal == User::"alice", action, resource);
+			permit(principal, action, resource) when { resource.public };`,
+			cedar.PartialRequest{Principal: alice, Action: view, Resource: cedar.Unknown()},
+			true, cedar.Allow, []cedar.PolicyID{"policy0"}, nil, []cedar.PolicyID{"policy0", "policy1"},
+		},
+		{"forbid",
+			`permit(principal, action, resource) when { resource.public };
+			forbid(principal in Group::"admins", action, resource);`,
+			cedar.PartialRequest{Principal: alice, Action: view, Resource: cedar.Unknown()},
+			true, cedar.Deny, []cedar.PolicyID{"policy1"}, nil, []cedar.PolicyID{"policy0", "policy1"},
+		},
+		{"noPermits",
+			`permit(principal == User::"bob", action, resource) when { resource.public };
+			forbid(principal, action, resource) when { resource.private };`,
+			cedar.PartialRequest{Principal: alice, Action: view, Resource: cedar.Unknown()},
+			true, cedar.Deny, nil, nil, []cedar.PolicyID{"policy1"},
+		},
+		{"residualForbid",
+			`permit(principal == User::"alice", action, resource);
+			forbid(principal, action, resource) when { resource.private };`,
+			cedar.PartialRequest{Principal: alice, Action: view, Resource: cedar.Unknown()},
+			false, cedar.Deny, nil, nil, []cedar.PolicyID{"policy0", "policy1"},
+		},
+		{"contextAttribute",
+			`permit(principal, action, resource) when { context.authenticated && context.mfa };`,
+			cedar.PartialRequest{
+				Principal: alice,
+				Action:    view,
+				Resource:  doc,
+				Context:   cedar.NewRecord(cedar.RecordMap{"authenticated": cedar.True, "mfa": cedar.Unknown()}),
+			},
+			false, cedar.Deny, nil, nil, []cedar.PolicyID{"policy0"},
+		},
+		{"contextRecordWithUnknown",
+			`permit(principal, action, resource) when { context == {"mfa": true, "n": 1} };`,
+			cedar.PartialRequest{
+				Principal: alice,
+				Action:    view,
+				Resource:  doc,
+				Context:   cedar.NewRecord(cedar.RecordMap{"mfa": cedar.Unknown(), "n": cedar.Long(1)}),
+			},
+			false, cedar.Deny, nil, nil, []cedar.PolicyID{"policy0"},
+		},
+		{"contextSetWithUnknown",
+			`permit(principal, action, resource) when { context.roles.contains("admin") };`,
+			cedar.PartialRequest{
+				Principal: alice,
+				Action:    view,
+				Resource:  doc,
+				Context:   cedar.NewRecord(cedar.RecordMap{"roles": cedar.NewSet(cedar.Unknown(), cedar.String("user"))}),
+			},
+			false, cedar.Deny, nil, nil, []cedar.PolicyID{"policy0"},
+		},
+		{"setLiteralWithUnknown",
+			`permit(principal, action, resource) when { [context.flags].contains({"mfa": true}) };`,
+			cedar.PartialRequest{
+				Principal: alice,
+				Action:    view,
+				Resource:  doc,
+				Context:   cedar.NewRecord(cedar.RecordMap{"flags": cedar.NewRecord(cedar.RecordMap{"mfa": cedar.Unknown()})}),
+			},
+			false, cedar.Deny, nil, nil, []cedar.PolicyID{"policy0"},
+		},
+		{"unknownContext",
+			`permit(principal, action, resource) when { context.authenticated };`,
+			cedar.PartialRequest{Principal: alice, Action: view, Resource: doc, Context: cedar.Unknown()},
+			false, cedar.Deny, nil, nil, []cedar.PolicyID{"policy0"},
+		},
+		{"unknownEntity",
+			`permit(principal, action, resource) when { resource.owner == principal };`,
+			cedar.PartialRequest{Principal: alice, Action: view, Resource: doc, UnknownEntities: []cedar.EntityUID{doc}},
+			false, cedar.Deny, nil, nil, []cedar.PolicyID{"policy0"},
+		},
+		{"knownEntity",
+			`permit(principal, action, resource) when { resource.owner == principal };`,
+			cedar.PartialRequest{Principal: alice, Action: view, Resource: doc},
+			true, cedar.Deny, nil, nil, nil,
+		},
+		{"error",
+			`permit(principal, action, resource) when { resource.missing };
+			permit(principal, action, resource) when { context.unknown } when { resource.missing };`,
+			cedar.PartialRequest{Principal: alice, Action: view, Resource: doc, Context: cedar.Unknown()},
+			false, cedar.Deny, nil,
+			[]string{"while evaluating policy `policy0`: `Doc::\"readme\"` does not have the attribute `missing`"},
+			[]cedar.PolicyID{"policy1"},
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			ps, err := cedar.NewPolicySetFromBytes("policy.cedar", []byte(tt.policies))
+			testutil.OK(t, err)
+			res := cedar.AuthorizePartial(ps, entities, tt.req)
+			testutil.Equals(t, res.Decided, tt.decided)
+			testutil.Equals(t, res.Decision, tt.decision)
+			var reasons []cedar.PolicyID
+			for _, r := range res.Diagnostic.Reasons {
+				reasons = append(reasons, r.PolicyID)
+			}
+			testutil.Equals(t, reasons, tt.reasons)
+			var errors []string
+			for _, e := range res.Diagnostic.Errors {
+				errors = append(errors, e.String())
+			}
+			testutil.Equals(t, errors, tt.errors)
+			var residuals []cedar.PolicyID
+			for _, id := range []cedar.PolicyID{"policy0", "policy1"} {
+				if res.Residuals[id] != nil {
+					residuals = append(residuals, id)
+				}
+			}
+			testutil.Equals(t, residuals, tt.residuals)
+		})
+	}
+}
+
+func TestAuthorizePartialResiduals(t *testing.T) {
+	t.Parallel()
+	alice := cedar.NewEntityUID("User", "alice")
+	ps, err := cedar.NewPolicySetFromBytes("policy.cedar", []byte(`@id("owner")
+permit(principal, action == Action::"view", resource is Doc)
+when { principal == resource.owner && principal has department };
+forbid(principal, action, resource in Folder::"private");`))
+	testutil.OK(t, err)
+	doc := cedar.NewEntityUID("Doc", "readme")
+	entities := cedar.EntityMap{
+		alice: cedar.Entity{UID: alice, Attributes: cedar.NewRecord(cedar.RecordMap{"department": cedar.String("x")})},
+		doc:   cedar.Entity{UID: doc, Attributes: cedar.NewRecord(cedar.RecordMap{"owner": alice})},
+	}
+
+	res := cedar.AuthorizePartial(ps, entities, cedar.PartialRequest{
+		Principal: alice,
+		Action:    cedar.NewEntityUID("Action", "view"),
+		Resource:  cedar.Unknown(),
+	})
+	testutil.Equals(t, res.Decided, false)
+	testutil.Equals(t, string(res.Residuals["policy0"].MarshalCedar()), `@id("owner")
+permit (
+    principal,
+    action,
+    resource is Doc
+)
+when { User::"alice" == resource.owner && true };`)
+	testutil.Equals(t, string(res.Residuals["policy1"].MarshalCedar()), `forbid (
+    principal,
+    action,
+    resource in Folder::"private"
+);`)
+	_, err = res.Residuals["policy1"].MarshalJSON()
+	testutil.OK(t, err)
+
+	// Once the resource is known, the residuals give the same decision as the original policies.
+	req := cedar.Request{Principal: alice, Action: cedar.NewEntityUID("Action", "view"), Resource: doc}
+	want, _ := cedar.Authorize(ps, entities, req)
+	got, _ := cedar.Authorize(res.Residuals, entities, req)
+	testutil.Equals(t, got, want)
+}
+
+func TestAuthorizePartialRecordWithUnknown(t *testing.T) {
+	t.Parallel()
+	ps, err := cedar.NewPolicySetFromBytes("policy.cedar", []byte(
+		`permit(principal, action, resource) when { context.n == 1 && context has mfa && context == {"mfa": true, "n": 1} };`))
+	testutil.OK(t, err)
+	req := cedar.Request{
+		Principal: cedar.NewEntityUID("User", "alice"),
+		Action:    cedar.NewEntityUID("Action", "view"),
+		Resource:  cedar.NewEntityUID("Doc", "readme"),
+	}
+
+	res := cedar.AuthorizePartial(ps, nil, cedar.PartialRequest{
+		Principal: req.Principal,
+		Action:    req.Action,
+		Resource:  req.Resource,
+		Context:   cedar.NewRecord(cedar.RecordMap{"mfa": cedar.Unknown(), "n": cedar.Long(1)}),
+	})
+	testutil.Equals(t, res.Decided, false)
+	testutil.Equals(t, string(res.Residuals["policy0"].MarshalCedar()), `permit ( principal, action, resource )
+when { true && context == {"mfa":true, "n":1} };`)
+
+	// The residual gives the same decision as the original policy whatever the unknown attribute turns out to be.
+	for _, mfa := range []cedar.Boolean{cedar.True, cedar.False} {
+		req.Context = cedar.NewRecord(cedar.RecordMap{"mfa": mfa, "n": cedar.Long(1)})
+		want, _ := cedar.Authorize(ps, nil, req)
+		got, _ := cedar.Authorize(res.Residuals, nil, req)
+		testutil.Equals(t, got, want)
+		testutil.Equals(t, got, cedar.Decision(mfa))
+	}
+}
+
+func TestAuthorizePartialEmpty(t *testing.T) {
+	t.Parallel()
+	res := cedar.AuthorizePartial(cedar.PolicyMap{}, nil, cedar.PartialRequest{})
+	testutil.Equals(t, res.Decided, true)
+	testutil.Equals(t, res.Decision, cedar.Deny)
+	testutil.Equals(t, len(res.Residuals), 0)
+}
@@ -2,6 +2,7 @@ package cedar
 
 import (
 	"bytes"
+	"sync"
 
 	"github.com/cedar-policy/cedar-go/ast"
 	"github.com/cedar-policy/cedar-go/internal/eval"
@@ -12,12 +13,28 @@ import (
 
 // A Policy is the parsed form of a single Cedar language policy statement.
 type Policy struct {
-	eval eval.BoolEvaler // determines if a policy matches a request.
-	ast  *internalast.Policy
+	eval     eval.BoolEvaler // determines if a policy matches a request.
+	ast      *internalast.Policy
+	budgeted *budgetedEval
+}
+
+// A budgetedEval holds the policy compiled for evaluation with a Budget, which is slower than its usual evaluation and
+// so is only compiled when first needed.
+type budgetedEval struct {
+	once sync.Once
+	eval eval.BoolEvaler
 }
 
 func newPolicy(astIn *internalast.Policy) *Policy {
-	return &Policy{eval: eval.Compile(astIn), ast: astIn}
+	return &Policy{eval: eval.Compile(astIn), ast: astIn, budgeted: &budgetedEval{}}
+}
+
+// evalWithBudget returns the policy compiled for evaluation with a Budget.
+func (p *Policy) evalWithBudget() *eval.BoolEvaler {
+	p.budgeted.once.Do(func() {
+		p.budgeted.eval = eval.CompileBudgeted(p.ast)
+	})
+	return &p.budgeted.eval
 }
 
 // MarshalJSON encodes a single Policy statement in the JSON format specified by the [Cedar documentation].
@@ -36,6 +53,9 @@ func (p *Policy) UnmarshalJSON(b []byte) error {
 	if err := jsonPolicy.UnmarshalJSON(b); err != nil {
 		return err
 	}
+	if err := checkNoSlots((*internalast.Policy)(&jsonPolicy)); err != nil {
+		return err
+	}
 
 	*p = *newPolicy((*internalast.Policy)(&jsonPolicy))
 	return nil
@@ -61,12 +81,17 @@ func (p *Policy) UnmarshalCedar(b []byte) error {
 	if err := cedarPolicy.UnmarshalCedar(b); err != nil {
 		return err
 	}
+	if err := checkNoSlots((*internalast.Policy)(&cedarPolicy)); err != nil {
+		return err
+	}
 	*p = *newPolicy((*internalast.Policy)(&cedarPolicy))
 	return nil
 }
 
 // NewPolicyFromAST lets you create a new policy statement from a programmatically created AST.
-// Do not modify the *ast.Policy after passing it into NewPolicyFromAST.
+// Do not modify the *ast.Policy after passing it into NewPolicyFromAST.  The AST should not contain slots; use
+// NewTemplateFromAST to create a template.  A scope constraint on a slot which has not been linked matches no entity,
+// so such a policy never applies.
 func NewPolicyFromAST(astIn *ast.Policy) *Policy {
 	p := newPolicy((*internalast.Policy)(astIn))
 	return p
@@ -0,0 +1,190 @@
+package cedar
+
+import (
+	"iter"
+	"maps"
+	"slices"
+
+	"github.com/cedar-policy/cedar-go/internal/mapset"
+	"github.com/cedar-policy/cedar-go/types"
+	internalast "github.com/cedar-policy/cedar-go/x/exp/ast"
+)
+
+// An IndexedPolicySet is an immutable set of policies which are indexed by their principal, action and resource scope
+// constraints.  When an IndexedPolicySet is passed to Authorize, only the policies whose scope could match the request
+// are evaluated, which makes authorization much faster for large sets of policies whose scopes refer to specific
+// entities, e.g. those linked from templates.  The Decision and Diagnostic are the same as for authorizing with all
+// of the policies, in the order of their IDs.
+type IndexedPolicySet struct {
+	ids        []PolicyID
+	policies   []*Policy
+	principals scopeIndex
+	actions    scopeIndex
+	resources  scopeIndex
+}
+
+// NewIndexedPolicySet indexes the policies.  Later changes to the policies passed to NewIndexedPolicySet, e.g. adding
+// a policy to a PolicySet, do not affect the IndexedPolicySet.
+func NewIndexedPolicySet(policies PolicyIterator) *IndexedPolicySet {
+	all := maps.Collect(policies.All())
+	s := &IndexedPolicySet{ids: slices.Sorted(maps.Keys(all))}
+	for i, id := range s.ids {
+		p := all[id]
+		s.policies = append(s.policies, p)
+		s.principals.add(i, p.ast.Principal)
+		s.actions.add(i, p.ast.Action)
+		s.resources.add(i, p.ast.Resource)
+	}
+	return s
+}
+
+// All returns an iterator over the (PolicyID, *Policy) tuples in the IndexedPolicySet, in the order of their IDs.
+func (s *IndexedPolicySet) All() iter.Seq2[PolicyID, *Policy] {
+	return s.at(func(yield func(int) bool) {
+		for i := range s.ids {
+			if !yield(i) {
+				return
+			}
+		}
+	})
+}
+
+// at returns an iterator over the policies at the positions.
+func (s *IndexedPolicySet) at(positions iter.Seq[int]) iter.Seq2[PolicyID, *Policy] {
+	return func(yield func(PolicyID, *Policy) bool) {
+		for i := range positions {
+			if !yield(s.ids[i], s.policies[i]) {
+				break
+			}
+		}
+	}
+}
+
+// candidates returns an iterator over the policies whose scope could match the request, in the order of their IDs.
+func (s *IndexedPolicySet) candidates(entities types.EntityGetter, req Request) iter.Seq2[PolicyID, *Policy] {
+	dims := []indexDimension{
+		{&s.principals, req.Principal, ancestorsOf(entities, req.Principal),
+			func(p *internalast.Policy) internalast.IsScopeNode { return p.Principal }},
+		{&s.actions, req.Action, ancestorsOf(entities, req.Action),
+			func(p *internalast.Policy) internalast.IsScopeNode { return p.Action }},
+		{&s.resources, req.Resource, ancestorsOf(entities, req.Resource),
+			func(p *internalast.Policy) internalast.IsScopeNode { return p.Resource }},
+	}
+	// Gather the candidates from the most selective of the indexes, then check them against the other scopes.
+	slices.SortFunc(dims, func(a, b indexDimension) int {
+		return a.index.count(a.entity, a.ancestors) - b.index.count(b.entity, b.ancestors)
+	})
+	var res []int
+	for _, i := range dims[0].index.lookup(dims[0].entity, dims[0].ancestors) {
+		p := s.policies[i].ast
+		if dims[0].mayMatch(p) && dims[1].mayMatch(p) && dims[2].mayMatch(p) {
+			res = append(res, i)
+		}
+	}
+	slices.Sort(res)
+	return s.at(slices.Values(slices.Compact(res)))
+}
+
+// An indexDimension holds the index of one of the principal, action and resource scopes, and the entity of the request
+// to look up in it.
+type indexDimension struct {
+	index     *scopeIndex
+	entity    EntityUID
+	ancestors mapset.MapSet[EntityUID]
+	scope     func(*internalast.Policy) internalast.IsScopeNode
+}
+
+// mayMatch reports whether the scope constraint of the policy may match the entity of the request.
+func (d indexDimension) mayMatch(p *internalast.Policy) bool {
+	return scopeMayMatch(d.scope(p), d.entity, d.ancestors)
+}
+
+// ancestorsOf returns the entity and all of its ancestors.
+func ancestorsOf(entities types.EntityGetter, entity EntityUID) mapset.MapSet[EntityUID] {
+	var res mapset.MapSet[EntityUID]
+	todo := []EntityUID{entity}
+	for len(todo) > 0 {
+		var e EntityUID
+		e, todo = todo[len(todo)-1], todo[:len(todo)-1]
+		if res.Contains(e) {
+			continue
+		}
+		res.Add(e)
+		if fe, ok := entities.Get(e); ok {
+			todo = slices.AppendSeq(todo, fe.Parents.All())
+		}
+	}
+	return res
+}
+
+// A scopeIndex holds the positions of policies by the scope constraint on one of the principal, action and resource.
+type scopeIndex struct {
+	all []int
+	eq  map[EntityUID][]int
+	is  map[EntityType][]int
+	in  map[EntityUID][]int
+}
+
+func (x *scopeIndex) add(i int, n internalast.IsScopeNode) {
+	switch n := n.(type) {
+	case internalast.ScopeTypeEq:
+		x.eq = addPosition(x.eq, n.Entity, i)
+	case internalast.ScopeTypeIs:
+		x.is = addPosition(x.is, n.Type, i)
+	case internalast.ScopeTypeIn:
+		x.in = addPosition(x.in, n.Entity, i)
+	case internalast.ScopeTypeIsIn:
+		x.in = addPosition(x.in, n.Entity, i)
+	case internalast.ScopeTypeInSet:
+		for _, e := range n.Entities {
+			x.in = addPosition(x.in, e, i)
+		}
+	default:
+		x.all = append(x.all, i)
+	}
+}
+
+func addPosition[K comparable](m map[K][]int, k K, i int) map[K][]int {
+	if m == nil {
+		m = map[K][]int{}
+	}
+	m[k] = append(m[k], i)
+	return m
+}
+
+// count returns the number of positions which lookup returns, without gathering them.
+func (x *scopeIndex) count(entity EntityUID, ancestors mapset.MapSet[EntityUID]) int {
+	res := len(x.all) + len(x.eq[entity]) + len(x.is[entity.Type])
+	for a := range ancestors.All() {
+		res += len(x.in[a])
+	}
+	return res
+}
+
+// lookup returns the positions of the policies whose scope constraint may match the entity, which may include
+// duplicates.
+func (x *scopeIndex) lookup(entity EntityUID, ancestors mapset.MapSet[EntityUID]) []int {
+	res := slices.Concat(x.all, x.eq[entity], x.is[entity.Type])
+	for a := range ancestors.All() {
+		res = append(res, x.in[a]...)
+	}
+	return res
+}
+
+// scopeMayMatch reports whether a scope constraint may match the entity, given the entity and its ancestors.
+func scopeMayMatch(n internalast.IsScopeNode, entity EntityUID, ancestors mapset.MapSet[EntityUID]) bool {
+	switch n := n.(type) {
+	case internalast.ScopeTypeEq:
+		return n.Entity == entity
+	case internalast.ScopeTypeIs:
+		return n.Type == entity.Type
+	case internalast.ScopeTypeIn:
+		return ancestors.Contains(n.Entity)
+	case internalast.ScopeTypeIsIn:
+		return n.Type == entity.Type && ancestors.Contains(n.Entity)
+	case internalast.ScopeTypeInSet:
+		return slices.ContainsFunc(n.Entities, ancestors.Contains)
+	default:
+		return true
+	}
+}
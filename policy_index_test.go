@@ -0,0 +1,87 @@
+package cedar_test
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/cedar-policy/cedar-go"
+	"github.com/cedar-policy/cedar-go/ast"
+	"github.com/cedar-policy/cedar-go/internal/testutil"
+)
+
+func TestIndexedPolicySet(t *testing.T) {
+	t.Parallel()
+	ps, err := cedar.NewPolicySetFromBytes("policy.cedar", []byte(`
+permit(principal, action, resource);
+permit(principal == User::"alice", action == Action::"view", resource == Doc::"readme");
+permit(principal in Group::"staff", action in [Action::"view", Action::"edit"], resource in Folder::"shared");
+permit(principal is User, action, resource is Doc);
+permit(principal is User in Group::"admins", action in Action::"read", resource is Folder in Folder::"shared");
+forbid(principal == User::"bob", action, resource) when { resource.locked };
+forbid(principal, action == Action::"edit", resource) when { principal.missing };
+permit(principal is Group, action, resource);
+`))
+	testutil.OK(t, err)
+	indexed := cedar.NewIndexedPolicySet(ps)
+
+	var ids []cedar.PolicyID
+	for id := range indexed.All() {
+		ids = append(ids, id)
+	}
+	testutil.Equals(t, ids, []cedar.PolicyID{"policy0", "policy1", "policy2", "policy3", "policy4", "policy5", "policy6", "policy7"})
+	for range indexed.All() {
+		break
+	}
+
+	alice := cedar.NewEntityUID("User", "alice")
+	bob := cedar.NewEntityUID("User", "bob")
+	readme := cedar.NewEntityUID("Doc", "readme")
+	shared := cedar.NewEntityUID("Folder", "shared")
+	staff := cedar.NewEntityUID("Group", "staff")
+	admins := cedar.NewEntityUID("Group", "admins")
+	view := cedar.NewEntityUID("Action", "view")
+	entities := cedar.EntityMap{
+		alice:  {UID: alice, Parents: cedar.NewEntityUIDSet(staff)},
+		bob:    {UID: bob, Parents: cedar.NewEntityUIDSet(admins, alice)},
+		admins: {UID: admins, Parents: cedar.NewEntityUIDSet(staff)},
+		readme: {UID: readme, Parents: cedar.NewEntityUIDSet(shared), Attributes: cedar.NewRecord(cedar.RecordMap{"locked": cedar.True})},
+		view:   {UID: view, Parents: cedar.NewEntityUIDSet(cedar.NewEntityUID("Action", "read"))},
+	}
+	for _, principal := range []cedar.EntityUID{alice, bob, cedar.NewEntityUID("User", "carol"), staff} {
+		for _, action := range []string{"view", "edit", "read", "delete"} {
+			for _, resource := range []cedar.EntityUID{readme, shared, cedar.NewEntityUID("Doc", "other")} {
+				req := cedar.Request{Principal: principal, Action: cedar.NewEntityUID("Action", cedar.String(action)), Resource: resource}
+				t.Run(fmt.Sprintf("%v/%v/%v", principal, action, resource), func(t *testing.T) {
+					t.Parallel()
+					want, wantDiag := cedar.Authorize(ps, entities, req)
+					got, gotDiag := cedar.Authorize(indexed, entities, req)
+					testutil.Equals(t, got, want)
+					sortDiagnostic(&wantDiag)
+					testutil.Equals(t, gotDiag, wantDiag)
+				})
+			}
+		}
+	}
+}
+
+func sortDiagnostic(d *cedar.Diagnostic) {
+	slices.SortFunc(d.Reasons, func(a, b cedar.DiagnosticReason) int { return cmpPolicyID(a.PolicyID, b.PolicyID) })
+	slices.SortFunc(d.Errors, func(a, b cedar.DiagnosticError) int { return cmpPolicyID(a.PolicyID, b.PolicyID) })
+}
+
+func cmpPolicyID(a, b cedar.PolicyID) int {
+	return strings.Compare(string(a), string(b))
+}
+
+func TestIndexedPolicySetIsImmutable(t *testing.T) {
+	t.Parallel()
+	ps := cedar.NewPolicySet()
+	ps.Add("permit", cedar.NewPolicyFromAST(ast.Permit()))
+	indexed := cedar.NewIndexedPolicySet(ps)
+	ps.Remove("permit")
+	decision, diag := cedar.Authorize(indexed, nil, cedar.Request{})
+	testutil.Equals(t, decision, cedar.Allow)
+	testutil.Equals(t, diag.Reasons[0].PolicyID, "permit")
+}
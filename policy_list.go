@@ -26,7 +26,8 @@ func NewPolicyListFromBytes(fileName string, document []byte) (PolicyList, error
 }
 
 // UnmarshalCedar parses a concatenation of un-named Cedar policy statements. Names can be assigned to these policies
-// when adding them to a PolicySet.
+// when adding them to a PolicySet.  The statements must not include templates; use NewPolicySetFromBytes to parse a
+// document containing them.
 func (p *PolicyList) UnmarshalCedar(b []byte) error {
 	var res parser.PolicySlice
 	if err := res.UnmarshalCedar(b); err != nil {
@@ -34,6 +35,9 @@ func (p *PolicyList) UnmarshalCedar(b []byte) error {
 	}
 	policySlice := make([]*Policy, 0, len(res))
 	for _, p := range res {
+		if err := checkNoSlots((*internalast.Policy)(p)); err != nil {
+			return err
+		}
 		newPolicy := newPolicy((*internalast.Policy)(p))
 		policySlice = append(policySlice, newPolicy)
 	}
@@ -27,3 +27,11 @@ forbid (
 	testutil.OK(t, err)
 	testutil.Equals(t, string(policies.MarshalCedar()), policiesStr)
 }
+
+func TestPolicyListErrors(t *testing.T) {
+	t.Parallel()
+	_, err := cedar.NewPolicyListFromBytes("", []byte(`permit (`))
+	testutil.Error(t, err)
+	_, err = cedar.NewPolicyListFromBytes("", []byte(`permit (principal == ?principal, action, resource);`))
+	testutil.Error(t, err)
+}
@@ -3,6 +3,7 @@ package cedar
 
 import (
 	"bytes"
+	"cmp"
 	"encoding/json"
 	"fmt"
 	"iter"
@@ -10,6 +11,7 @@ import (
 	"slices"
 
 	internaljson "github.com/cedar-policy/cedar-go/internal/json"
+	"github.com/cedar-policy/cedar-go/internal/parser"
 	"github.com/cedar-policy/cedar-go/types"
 	internalast "github.com/cedar-policy/cedar-go/x/exp/ast"
 )
@@ -25,33 +27,42 @@ func (p PolicyMap) All() iter.Seq2[PolicyID, *Policy] {
 	return maps.All(p)
 }
 
-// PolicySet is a set of named policies against which a request can be authorized.
+// PolicySet is a set of named policies against which a request can be authorized, along with a set of named templates
+// from which policies can be instantiated.  The policies include both static policies and those linked from templates.
 type PolicySet struct {
 	// policies are stored internally so we can handle performance, concurrency bookkeeping however we want
-	policies PolicyMap
+	policies  PolicyMap
+	templates map[PolicyID]*Template
+	links     map[PolicyID]TemplateLink
 }
 
 // NewPolicySet creates a new, empty PolicySet
 func NewPolicySet() *PolicySet {
-	return &PolicySet{policies: PolicyMap{}}
+	return &PolicySet{policies: PolicyMap{}, templates: map[PolicyID]*Template{}, links: map[PolicyID]TemplateLink{}}
 }
 
 // NewPolicySetFromBytes will create a PolicySet from the given text document with the given file name used in Position
 // data.  If there is an error parsing the document, it will be returned.
 //
 // NewPolicySetFromBytes assigns default PolicyIDs to the policies contained in fileName in the format "policy<n>" where
-// <n> is incremented for each new policy found in the file.
+// <n> is incremented for each new policy found in the file.  Statements containing slots are added to the PolicySet as
+// templates, with IDs assigned in the same sequence.
 func NewPolicySetFromBytes(fileName string, document []byte) (*PolicySet, error) {
-	policySlice, err := NewPolicyListFromBytes(fileName, document)
-	if err != nil {
-		return &PolicySet{}, err
+	var res parser.PolicySlice
+	if err := res.UnmarshalCedar(document); err != nil {
+		return &PolicySet{}, fmt.Errorf("parser error: %w", err)
 	}
-	policyMap := make(PolicyMap, len(policySlice))
-	for i, p := range policySlice {
+	ps := NewPolicySet()
+	for i, p := range res {
+		p.Position.Filename = fileName
 		policyID := PolicyID(fmt.Sprintf("policy%d", i))
-		policyMap[policyID] = p
+		if len(slots((*internalast.Policy)(p))) > 0 {
+			ps.templates[policyID] = newTemplate((*internalast.Policy)(p))
+			continue
+		}
+		ps.policies[policyID] = newPolicy((*internalast.Policy)(p))
 	}
-	return &PolicySet{policies: policyMap}, nil
+	return ps, nil
 }
 
 // Get returns the Policy with the given ID. If a policy with the given ID
@@ -61,10 +72,12 @@ func (p *PolicySet) Get(policyID PolicyID) *Policy {
 }
 
 // Add inserts or updates a policy with the given ID. Returns true if a policy
-// with the given ID did not already exist in the set.
+// with the given ID did not already exist in the set.  Updating a linked policy
+// replaces it with a static policy.
 func (p *PolicySet) Add(policyID PolicyID, policy *Policy) bool {
 	_, exists := p.policies[policyID]
 	p.policies[policyID] = policy
+	delete(p.links, policyID)
 	return !exists
 }
 
@@ -73,9 +86,99 @@ func (p *PolicySet) Add(policyID PolicyID, policy *Policy) bool {
 func (p *PolicySet) Remove(policyID PolicyID) bool {
 	_, exists := p.policies[policyID]
 	delete(p.policies, policyID)
+	delete(p.links, policyID)
 	return exists
 }
 
+// GetTemplate returns the Template with the given ID. If a template with the given ID does not exist, nil is returned.
+func (p *PolicySet) GetTemplate(templateID PolicyID) *Template {
+	return p.templates[templateID]
+}
+
+// AddTemplate inserts or updates a template with the given ID. Returns true if a template with the given ID did not
+// already exist in the set.  Templates and policies share one namespace of IDs, as in the Cedar JSON format, so a
+// template should not be given the ID of a policy: LinkTemplate rejects a link ID which is that of a template, and
+// UnmarshalJSON rejects a template with the ID of a static policy.
+//
+// The policies linked from a template which is updated are linked again from the new template, so that they reflect
+// its changes.  Those whose values do not match the slots of the new template are unlinked.
+func (p *PolicySet) AddTemplate(templateID PolicyID, template *Template) bool {
+	_, exists := p.templates[templateID]
+	p.templates[templateID] = template
+	for linkID, l := range p.links {
+		if l.TemplateID != templateID {
+			continue
+		}
+		policy, err := template.link(l.Values)
+		if err != nil {
+			p.Unlink(linkID)
+			continue
+		}
+		p.policies[linkID] = policy
+	}
+	return !exists
+}
+
+// RemoveTemplate removes a template, and all the policies linked from it, from the PolicySet. Returns true if a
+// template with the given ID already existed in the set.
+func (p *PolicySet) RemoveTemplate(templateID PolicyID) bool {
+	_, exists := p.templates[templateID]
+	delete(p.templates, templateID)
+	for linkID := range p.TemplateLinks(templateID) {
+		p.Unlink(linkID)
+	}
+	return exists
+}
+
+// LinkTemplate instantiates the template with the given ID as a policy, replacing each of its slots with the entity
+// given for it in values, and adds the policy to the PolicySet with the given link ID.  The linked policy is evaluated
+// like any other, and authorization diagnostics refer to it by its link ID.
+//
+// An error is returned if the template does not exist, if values does not contain exactly one entity for each slot of
+// the template, or if a policy or template with the link ID already exists.
+func (p *PolicySet) LinkTemplate(templateID PolicyID, linkID PolicyID, values map[SlotID]EntityUID) error {
+	template, ok := p.templates[templateID]
+	if !ok {
+		return fmt.Errorf("template %v not found", templateID)
+	}
+	if _, exists := p.policies[linkID]; exists {
+		return fmt.Errorf("policy %v already exists", linkID)
+	}
+	if _, exists := p.templates[linkID]; exists {
+		return fmt.Errorf("template %v already exists", linkID)
+	}
+	policy, err := template.link(values)
+	if err != nil {
+		return fmt.Errorf("linking template %v: %w", templateID, err)
+	}
+	p.policies[linkID] = policy
+	p.links[linkID] = TemplateLink{TemplateID: templateID, Values: maps.Clone(values)}
+	return nil
+}
+
+// Unlink removes a policy linked from a template from the PolicySet. Returns true if a linked policy with the given ID
+// already existed in the set.  Static policies are not removed.
+func (p *PolicySet) Unlink(linkID PolicyID) bool {
+	if _, exists := p.links[linkID]; !exists {
+		return false
+	}
+	delete(p.policies, linkID)
+	delete(p.links, linkID)
+	return true
+}
+
+// TemplateLinks returns an iterator over the IDs of the policies linked from the template with the given ID, and the
+// links from which they were created.  Do not modify the values of the links.
+func (p *PolicySet) TemplateLinks(templateID PolicyID) iter.Seq2[PolicyID, TemplateLink] {
+	return func(yield func(PolicyID, TemplateLink) bool) {
+		for k, v := range p.links {
+			if v.TemplateID == templateID && !yield(k, v) {
+				break
+			}
+		}
+	}
+}
+
 // Map returns a new PolicyMap instance of the policies in the PolicySet.
 //
 // Deprecated: use the iterator returned by All() like so: maps.Collect(ps.All())
@@ -83,25 +186,32 @@ func (p *PolicySet) Map() PolicyMap {
 	return maps.Clone(p.policies)
 }
 
-// MarshalCedar emits a concatenated Cedar representation of a PolicySet. The policy names are stripped, but policies
-// are emitted in lexicographical order by ID.
+// MarshalCedar emits a concatenated Cedar representation of a PolicySet. The policy and template names are stripped,
+// but policies and templates are emitted in lexicographical order by ID.  The Cedar language cannot represent template
+// links, so policies linked from templates are omitted; use MarshalJSON to preserve them.
 func (p *PolicySet) MarshalCedar() []byte {
-	ids := make([]PolicyID, 0, len(p.policies))
-	for k := range p.policies {
-		ids = append(ids, k)
+	type statement struct {
+		id      PolicyID
+		marshal func() []byte
+	}
+	statements := make([]statement, 0, len(p.policies)+len(p.templates))
+	for k, v := range p.policies {
+		if _, linked := p.links[k]; !linked {
+			statements = append(statements, statement{k, v.MarshalCedar})
+		}
+	}
+	for k, v := range p.templates {
+		statements = append(statements, statement{k, v.MarshalCedar})
 	}
-	slices.Sort(ids)
+	slices.SortStableFunc(statements, func(a, b statement) int { return cmp.Compare(a.id, b.id) })
 
 	var buf bytes.Buffer
-	i := 0
-	for _, id := range ids {
-		policy := p.policies[id]
-		buf.Write(policy.MarshalCedar())
+	for i, s := range statements {
+		buf.Write(s.marshal())
 
-		if i < len(p.policies)-1 {
+		if i < len(statements)-1 {
 			buf.WriteString("\n\n")
 		}
-		i++
 	}
 	return buf.Bytes()
 }
@@ -114,7 +224,27 @@ func (p *PolicySet) MarshalJSON() ([]byte, error) {
 		StaticPolicies: make(internaljson.PolicySet, len(p.policies)),
 	}
 	for k, v := range p.policies {
-		jsonPolicySet.StaticPolicies[string(k)] = (*internaljson.Policy)(v.ast)
+		if _, linked := p.links[k]; !linked {
+			jsonPolicySet.StaticPolicies[string(k)] = (*internaljson.Policy)(v.ast)
+		}
+	}
+	if len(p.templates) > 0 {
+		jsonPolicySet.Templates = make(internaljson.PolicySet, len(p.templates))
+		for k, v := range p.templates {
+			jsonPolicySet.Templates[string(k)] = (*internaljson.Policy)(v.ast)
+		}
+	}
+	for _, linkID := range slices.Sorted(maps.Keys(p.links)) {
+		l := p.links[linkID]
+		values := make(map[string]types.ImplicitlyMarshaledEntityUID, len(l.Values))
+		for slot, e := range l.Values {
+			values[string(slot)] = types.ImplicitlyMarshaledEntityUID(e)
+		}
+		jsonPolicySet.TemplateLinks = append(jsonPolicySet.TemplateLinks, internaljson.TemplateLinkJSON{
+			TemplateID: string(l.TemplateID),
+			NewID:      string(linkID),
+			Values:     values,
+		})
 	}
 	return json.Marshal(jsonPolicySet)
 }
@@ -127,12 +257,33 @@ func (p *PolicySet) UnmarshalJSON(b []byte) error {
 	if err := json.Unmarshal(b, &jsonPolicySet); err != nil {
 		return err
 	}
-	*p = PolicySet{
-		policies: make(PolicyMap, len(jsonPolicySet.StaticPolicies)),
+	res := PolicySet{
+		policies:  make(PolicyMap, len(jsonPolicySet.StaticPolicies)),
+		templates: make(map[PolicyID]*Template, len(jsonPolicySet.Templates)),
+		links:     make(map[PolicyID]TemplateLink, len(jsonPolicySet.TemplateLinks)),
 	}
 	for k, v := range jsonPolicySet.StaticPolicies {
-		p.policies[PolicyID(k)] = newPolicy((*internalast.Policy)(v))
+		if err := checkNoSlots((*internalast.Policy)(v)); err != nil {
+			return fmt.Errorf("static policy %v: %w", k, err)
+		}
+		res.policies[PolicyID(k)] = newPolicy((*internalast.Policy)(v))
+	}
+	for k, v := range jsonPolicySet.Templates {
+		if _, exists := res.policies[PolicyID(k)]; exists {
+			return fmt.Errorf("template %v: a static policy has the same ID", k)
+		}
+		res.templates[PolicyID(k)] = newTemplate((*internalast.Policy)(v))
+	}
+	for _, l := range jsonPolicySet.TemplateLinks {
+		values := make(map[SlotID]EntityUID, len(l.Values))
+		for slot, e := range l.Values {
+			values[SlotID(slot)] = EntityUID(e)
+		}
+		if err := res.LinkTemplate(PolicyID(l.TemplateID), PolicyID(l.NewID), values); err != nil {
+			return fmt.Errorf("template link %v: %w", l.NewID, err)
+		}
 	}
+	*p = res
 	return nil
 }
 
@@ -154,3 +305,8 @@ func (p *PolicySet) All() iter.Seq2[PolicyID, *Policy] {
 		}
 	}
 }
+
+// AllTemplates returns an iterator over the (PolicyID, *Template) tuples in the PolicySet
+func (p *PolicySet) AllTemplates() iter.Seq2[PolicyID, *Template] {
+	return maps.All(p.templates)
+}
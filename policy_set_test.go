@@ -42,6 +42,38 @@ func TestNewPolicySetFromFile(t *testing.T) {
 		testutil.OK(t, err)
 		testutil.Equals(t, ps.Get("policy0").Annotations(), cedar.Annotations{"key": "value"})
 	})
+	t.Run("templates", func(t *testing.T) {
+		t.Parallel()
+		ps, err := cedar.NewPolicySetFromBytes("policy.cedar", []byte(`
+permit (principal, action, resource);
+permit (principal == ?principal, action, resource in ?resource);
+forbid (principal, action, resource);`))
+		testutil.OK(t, err)
+		testutil.Equals(t, ps.Get("policy1"), nil)
+		testutil.Equals(t, ps.GetTemplate("policy0"), nil)
+		testutil.Equals(t, ps.GetTemplate("policy1").Slots(), []cedar.SlotID{cedar.PrincipalSlot, cedar.ResourceSlot})
+		testutil.Equals(t, ps.GetTemplate("policy1").Position().Filename, "policy.cedar")
+		testutil.Equals(t, ps.Get("policy2").Effect(), cedar.Forbid)
+	})
+}
+
+func TestPolicySetTemplates(t *testing.T) {
+	t.Parallel()
+	template := cedar.NewTemplateFromAST(ast.Permit().PrincipalEqSlot())
+	ps := cedar.NewPolicySet()
+	testutil.Equals(t, ps.AddTemplate("template0", template), true)
+	testutil.Equals(t, ps.AddTemplate("template0", template), false)
+	testutil.Equals(t, ps.Add("template0", cedar.NewPolicyFromAST(ast.Permit())), true)
+	testutil.Equals(t, ps.GetTemplate("template0"), template)
+	testutil.Equals(t, maps.Collect(ps.AllTemplates()), map[cedar.PolicyID]*cedar.Template{"template0": template})
+
+	decision, _ := ps.IsAuthorized(cedar.EntityMap{}, cedar.Request{})
+	testutil.Equals(t, decision, cedar.Allow)
+
+	testutil.Equals(t, ps.RemoveTemplate("template0"), true)
+	testutil.Equals(t, ps.RemoveTemplate("template0"), false)
+	testutil.Equals(t, ps.GetTemplate("template0"), nil)
+	testutil.Equals(t, ps.Get("template0") != nil, true)
 }
 
 func TestUpsertPolicy(t *testing.T) {
@@ -170,6 +202,38 @@ func TestPolicySetJSON(t *testing.T) {
 		testutil.OK(t, err)
 		testutil.Equals(t, string(out), `{"staticPolicies":{"policy0":{"effect":"permit","principal":{"op":"All"},"action":{"op":"All"},"resource":{"op":"All"}}}}`)
 	})
+
+	t.Run("Templates", func(t *testing.T) {
+		t.Parallel()
+		in := `{"staticPolicies":{},"templates":{"share":{"effect":"permit","principal":{"op":"==","slot":"?principal"},"action":{"op":"All"},"resource":{"op":"All"}}}}`
+		var ps cedar.PolicySet
+		testutil.OK(t, ps.UnmarshalJSON([]byte(in)))
+		testutil.Equals(t, ps.GetTemplate("share").Slots(), []cedar.SlotID{cedar.PrincipalSlot})
+		out, err := ps.MarshalJSON()
+		testutil.OK(t, err)
+		testutil.Equals(t, string(out), in)
+	})
+
+	t.Run("StaticPolicyWithSlots", func(t *testing.T) {
+		t.Parallel()
+		var ps cedar.PolicySet
+		err := ps.UnmarshalJSON([]byte(`{"staticPolicies":{"policy0":{"effect":"permit","principal":{"op":"==","slot":"?principal"},"action":{"op":"All"},"resource":{"op":"All"}}}}`))
+		testutil.Error(t, err)
+	})
+}
+
+func TestPolicySetCedarWithTemplates(t *testing.T) {
+	t.Parallel()
+	in := `permit (
+    principal == ?principal,
+    action,
+    resource
+);
+
+forbid ( principal, action, resource );`
+	ps, err := cedar.NewPolicySetFromBytes("", []byte(in))
+	testutil.OK(t, err)
+	testutil.Equals(t, string(ps.MarshalCedar()), in)
 }
 
 func TestAll(t *testing.T) {
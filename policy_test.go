@@ -94,6 +94,46 @@ func TestPolicyAST(t *testing.T) {
 	_ = cedar.NewPolicyFromAST(astExample)
 }
 
+func TestPolicyASTWithSlots(t *testing.T) {
+	t.Parallel()
+	alice := cedar.NewEntityUID("User", "alice")
+	doc := cedar.NewEntityUID("Doc", "readme")
+	tests := []struct {
+		name string
+		ast  *ast.Policy
+	}{
+		{"principalEq", ast.Permit().PrincipalEqSlot()},
+		{"principalIn", ast.Permit().PrincipalInSlot()},
+		{"principalIsIn", ast.Permit().PrincipalIsInSlot("User")},
+		{"resourceEq", ast.Permit().ResourceEqSlot()},
+		{"resourceIn", ast.Permit().ResourceInSlot()},
+		{"resourceIsIn", ast.Permit().ResourceIsInSlot("Doc")},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			ps := cedar.NewPolicySet()
+			ps.Add("policy0", cedar.NewPolicyFromAST(tt.ast))
+			req := cedar.Request{Principal: alice, Action: cedar.NewEntityUID("Action", "view"), Resource: doc}
+
+			decision, diag := cedar.Authorize(ps, cedar.EntityMap{}, req)
+			testutil.Equals(t, decision, cedar.Deny)
+			testutil.Equals(t, len(diag.Reasons)+len(diag.Errors), 0)
+
+			decision, _, _ = cedar.AuthorizeWithTrace(ps, cedar.EntityMap{}, req)
+			testutil.Equals(t, decision, cedar.Deny)
+
+			res := cedar.AuthorizePartial(ps, cedar.EntityMap{}, cedar.PartialRequest{
+				Principal: cedar.Unknown(), Action: req.Action, Resource: cedar.Unknown(),
+			})
+			testutil.Equals(t, res.Decided, true)
+			testutil.Equals(t, res.Decision, cedar.Deny)
+			testutil.Equals(t, len(res.Residuals), 0)
+		})
+	}
+}
+
 func TestUnmarshalJSONPolicyErr(t *testing.T) {
 	t.Parallel()
 	var p cedar.Policy
@@ -0,0 +1,177 @@
+package cedar
+
+import (
+	"bytes"
+	"fmt"
+	"maps"
+	"slices"
+
+	"github.com/cedar-policy/cedar-go/ast"
+	"github.com/cedar-policy/cedar-go/internal/json"
+	"github.com/cedar-policy/cedar-go/internal/parser"
+	"github.com/cedar-policy/cedar-go/types"
+	internalast "github.com/cedar-policy/cedar-go/x/exp/ast"
+)
+
+// A Template is the parsed form of a single Cedar language policy template: a policy statement whose principal and/or
+// resource scope constraints refer to the ?principal and ?resource slots instead of to specific entities, e.g.
+//
+//	permit(principal == ?principal, action == Action::"view", resource in ?resource);
+//
+// A Template is not evaluated during authorization.  Instead, it is instantiated as a policy by linking each of its
+// slots to an entity.
+type Template struct {
+	ast *internalast.Policy
+}
+
+// A TemplateLink describes a policy created by linking a template: the ID of the template, and the entity linked to
+// each of its slots.
+type TemplateLink struct {
+	TemplateID PolicyID
+	Values     map[SlotID]EntityUID
+}
+
+func newTemplate(astIn *internalast.Policy) *Template {
+	return &Template{ast: astIn}
+}
+
+// NewTemplateFromAST lets you create a new template from a programmatically created AST.
+// Do not modify the *ast.Policy after passing it into NewTemplateFromAST.
+func NewTemplateFromAST(astIn *ast.Policy) *Template {
+	return newTemplate((*internalast.Policy)(astIn))
+}
+
+// MarshalJSON encodes a single Template in the JSON format specified by the [Cedar documentation].
+//
+// [Cedar documentation]: https://docs.cedarpolicy.com/policies/json-format.html
+func (t *Template) MarshalJSON() ([]byte, error) {
+	jsonPolicy := (*json.Policy)(t.ast)
+	return jsonPolicy.MarshalJSON()
+}
+
+// UnmarshalJSON parses a single Template in the JSON format specified by the [Cedar documentation].
+//
+// [Cedar documentation]: https://docs.cedarpolicy.com/policies/json-format.html
+func (t *Template) UnmarshalJSON(b []byte) error {
+	var jsonPolicy json.Policy
+	if err := jsonPolicy.UnmarshalJSON(b); err != nil {
+		return err
+	}
+	*t = *newTemplate((*internalast.Policy)(&jsonPolicy))
+	return nil
+}
+
+// MarshalCedar encodes a single Template in the human-readable format specified by the [Cedar documentation].
+//
+// [Cedar documentation]: https://docs.cedarpolicy.com/policies/syntax-grammar.html
+func (t *Template) MarshalCedar() []byte {
+	cedarPolicy := (*parser.Policy)(t.ast)
+
+	var buf bytes.Buffer
+	cedarPolicy.MarshalCedar(&buf)
+
+	return buf.Bytes()
+}
+
+// UnmarshalCedar parses a single Template in the human-readable format specified by the [Cedar documentation].
+//
+// [Cedar documentation]: https://docs.cedarpolicy.com/policies/syntax-grammar.html
+func (t *Template) UnmarshalCedar(b []byte) error {
+	var cedarPolicy parser.Policy
+	if err := cedarPolicy.UnmarshalCedar(b); err != nil {
+		return err
+	}
+	*t = *newTemplate((*internalast.Policy)(&cedarPolicy))
+	return nil
+}
+
+// Annotations retrieves the annotations associated with this template.
+func (t *Template) Annotations() Annotations {
+	res := make(Annotations, len(t.ast.Annotations))
+	for _, e := range t.ast.Annotations {
+		res[e.Key] = e.Value
+	}
+	return res
+}
+
+// Effect retrieves the effect of this template.
+func (t *Template) Effect() Effect {
+	return Effect(t.ast.Effect)
+}
+
+// Position retrieves the position of this template.
+func (t *Template) Position() Position {
+	return Position(t.ast.Position)
+}
+
+// SetFilename sets the filename of this template.
+func (t *Template) SetFilename(fileName string) {
+	t.ast.Position.Filename = fileName
+}
+
+// Slots returns the slots which this template contains, in the order ?principal, ?resource.
+func (t *Template) Slots() []SlotID {
+	return slots(t.ast)
+}
+
+// AST retrieves the AST of this template.  Do not modify the AST.
+func (t *Template) AST() *ast.Policy {
+	return (*ast.Policy)(t.ast)
+}
+
+// link instantiates the template as a policy, replacing each of its slots with the entity given for it in values.
+func (t *Template) link(values map[SlotID]EntityUID) (*Policy, error) {
+	want := t.Slots()
+	for _, s := range want {
+		if _, ok := values[s]; !ok {
+			return nil, fmt.Errorf("missing value for slot %v", s)
+		}
+	}
+	for _, s := range slices.Sorted(maps.Keys(values)) {
+		if !slices.Contains(want, s) {
+			return nil, fmt.Errorf("template has no slot %v", s)
+		}
+	}
+	p := *t.ast
+	p.Principal = linkScope(p.Principal, values)
+	p.Resource = linkScope(p.Resource, values)
+	return newPolicy(&p), nil
+}
+
+func linkScope[T internalast.IsScopeNode](n T, values map[SlotID]EntityUID) T {
+	var res internalast.IsScopeNode
+	switch s := any(n).(type) {
+	case internalast.ScopeTypeSlotEq:
+		res = internalast.Scope{}.Eq(values[s.Slot])
+	case internalast.ScopeTypeSlotIn:
+		res = internalast.Scope{}.In(values[s.Slot])
+	case internalast.ScopeTypeSlotIsIn:
+		res = internalast.Scope{}.IsIn(s.Type, values[s.Slot])
+	default:
+		return n
+	}
+	return res.(T)
+}
+
+func slots(p *internalast.Policy) []types.SlotID {
+	var res []types.SlotID
+	for _, n := range []internalast.IsScopeNode{p.Principal, p.Resource} {
+		switch n := n.(type) {
+		case internalast.ScopeTypeSlotEq:
+			res = append(res, n.Slot)
+		case internalast.ScopeTypeSlotIn:
+			res = append(res, n.Slot)
+		case internalast.ScopeTypeSlotIsIn:
+			res = append(res, n.Slot)
+		}
+	}
+	return res
+}
+
+// checkNoSlots returns an error if p is a template, and so cannot be used as a policy.
+func checkNoSlots(p *internalast.Policy) error {
+	if s := slots(p); len(s) > 0 {
+		return fmt.Errorf("policy contains slot %v: templates must be parsed as a Template or into a PolicySet", s[0])
+	}
+	return nil
+}
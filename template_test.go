@@ -0,0 +1,260 @@
+package cedar_test
+
+import (
+	"maps"
+	"testing"
+
+	"github.com/cedar-policy/cedar-go"
+	"github.com/cedar-policy/cedar-go/ast"
+	"github.com/cedar-policy/cedar-go/internal/testutil"
+)
+
+func TestTemplateCedar(t *testing.T) {
+	t.Parallel()
+
+	templateStr := `@id("share")
+permit (
+    principal == ?principal,
+    action == Action::"view",
+    resource in ?resource
+)
+when { resource.shared };`
+
+	var template cedar.Template
+	testutil.OK(t, template.UnmarshalCedar([]byte(templateStr)))
+	testutil.Equals(t, string(template.MarshalCedar()), templateStr)
+	testutil.Equals(t, template.Slots(), []cedar.SlotID{cedar.PrincipalSlot, cedar.ResourceSlot})
+	testutil.Equals(t, template.Annotations(), cedar.Annotations{"id": "share"})
+	testutil.Equals(t, template.Effect(), cedar.Permit)
+
+	template.SetFilename("templates.cedar")
+	testutil.Equals(t, template.Position(), cedar.Position{Filename: "templates.cedar", Offset: 0, Line: 1, Column: 1})
+}
+
+func TestTemplateJSON(t *testing.T) {
+	t.Parallel()
+
+	jsonEncodedTemplate := prettifyJSON([]byte(`
+		{
+			"effect": "forbid",
+			"principal": {
+				"op": "is",
+				"entity_type": "User",
+				"in": { "slot": "?principal" }
+			},
+			"action": {
+				"op": "All"
+			},
+			"resource": {
+				"op": "All"
+			}
+		}`,
+	))
+
+	var template cedar.Template
+	testutil.OK(t, template.UnmarshalJSON(jsonEncodedTemplate))
+	testutil.Equals(t, template.Slots(), []cedar.SlotID{cedar.PrincipalSlot})
+
+	output, err := template.MarshalJSON()
+	testutil.OK(t, err)
+	testutil.Equals(t, string(prettifyJSON(output)), string(jsonEncodedTemplate))
+}
+
+func TestTemplateAST(t *testing.T) {
+	t.Parallel()
+
+	astExample := ast.Permit().ResourceEqSlot()
+	template := cedar.NewTemplateFromAST(astExample)
+	testutil.Equals(t, template.Slots(), []cedar.SlotID{cedar.ResourceSlot})
+	testutil.Equals(t, template.AST(), astExample)
+	testutil.Equals(t, len(cedar.NewTemplateFromAST(ast.Permit()).Slots()), 0)
+}
+
+func TestTemplateErrors(t *testing.T) {
+	t.Parallel()
+	var template cedar.Template
+	testutil.Error(t, template.UnmarshalCedar([]byte(`permit (principal == ?resource, action, resource);`)))
+	testutil.Error(t, template.UnmarshalJSON([]byte(`!@#$`)))
+}
+
+func TestPolicyWithSlots(t *testing.T) {
+	t.Parallel()
+	var policy cedar.Policy
+	err := policy.UnmarshalCedar([]byte(`permit (principal in ?principal, action, resource);`))
+	testutil.Error(t, err)
+	testutil.Equals(t, err.Error(), "policy contains slot ?principal: templates must be parsed as a Template or into a PolicySet")
+
+	err = policy.UnmarshalJSON([]byte(`{"effect":"permit","principal":{"op":"All"},"action":{"op":"All"},"resource":{"op":"==","slot":"?resource"}}`))
+	testutil.Error(t, err)
+}
+
+func TestLinkTemplate(t *testing.T) {
+	t.Parallel()
+	alice := cedar.NewEntityUID("User", "alice")
+	bob := cedar.NewEntityUID("User", "bob")
+	doc := cedar.NewEntityUID("Doc", "readme")
+	folder := cedar.NewEntityUID("Folder", "shared")
+
+	ps, err := cedar.NewPolicySetFromBytes("policy.cedar", []byte(`
+permit (principal == ?principal, action == Action::"view", resource in ?resource);
+permit (principal is User in ?principal, action, resource);`))
+	testutil.OK(t, err)
+	testutil.OK(t, ps.LinkTemplate("policy0", "alice-readme", map[cedar.SlotID]cedar.EntityUID{
+		cedar.PrincipalSlot: alice,
+		cedar.ResourceSlot:  doc,
+	}))
+	testutil.OK(t, ps.LinkTemplate("policy0", "bob-shared", map[cedar.SlotID]cedar.EntityUID{
+		cedar.PrincipalSlot: bob,
+		cedar.ResourceSlot:  folder,
+	}))
+	testutil.OK(t, ps.LinkTemplate("policy1", "users", map[cedar.SlotID]cedar.EntityUID{
+		cedar.PrincipalSlot: cedar.NewEntityUID("Group", "users"),
+	}))
+	testutil.Equals(t, string(ps.Get("alice-readme").MarshalCedar()), `permit (
+    principal == User::"alice",
+    action == Action::"view",
+    resource in Doc::"readme"
+);`)
+	testutil.Equals(t, string(ps.Get("users").MarshalCedar()), `permit (
+    principal is User in Group::"users",
+    action,
+    resource
+);`)
+	testutil.Equals(t, ps.Get("alice-readme").Position(), ps.GetTemplate("policy0").Position())
+	testutil.Equals(t, maps.Collect(ps.TemplateLinks("policy0")), map[cedar.PolicyID]cedar.TemplateLink{
+		"alice-readme": {TemplateID: "policy0", Values: map[cedar.SlotID]cedar.EntityUID{cedar.PrincipalSlot: alice, cedar.ResourceSlot: doc}},
+		"bob-shared":   {TemplateID: "policy0", Values: map[cedar.SlotID]cedar.EntityUID{cedar.PrincipalSlot: bob, cedar.ResourceSlot: folder}},
+	})
+	for range ps.TemplateLinks("policy0") {
+		break
+	}
+
+	decision, diag := cedar.Authorize(ps, cedar.EntityMap{}, cedar.Request{
+		Principal: alice,
+		Action:    cedar.NewEntityUID("Action", "view"),
+		Resource:  doc,
+	})
+	testutil.Equals(t, decision, cedar.Allow)
+	testutil.Equals(t, len(diag.Reasons), 1)
+	testutil.Equals(t, diag.Reasons[0].PolicyID, "alice-readme")
+
+	testutil.Equals(t, ps.Unlink("alice-readme"), true)
+	testutil.Equals(t, ps.Unlink("alice-readme"), false)
+	testutil.Equals(t, ps.Get("alice-readme"), nil)
+	decision, _ = cedar.Authorize(ps, cedar.EntityMap{}, cedar.Request{
+		Principal: alice,
+		Action:    cedar.NewEntityUID("Action", "view"),
+		Resource:  doc,
+	})
+	testutil.Equals(t, decision, cedar.Deny)
+
+	ps.Add("static", cedar.NewPolicyFromAST(ast.Permit()))
+	testutil.Equals(t, ps.Unlink("static"), false)
+	testutil.Equals(t, ps.Get("static") != nil, true)
+}
+
+func TestLinkTemplateErrors(t *testing.T) {
+	t.Parallel()
+	alice := cedar.NewEntityUID("User", "alice")
+	ps := cedar.NewPolicySet()
+	ps.AddTemplate("template", cedar.NewTemplateFromAST(ast.Permit().PrincipalEqSlot()))
+	ps.Add("static", cedar.NewPolicyFromAST(ast.Permit()))
+	tests := []struct {
+		name       string
+		templateID cedar.PolicyID
+		linkID     cedar.PolicyID
+		values     map[cedar.SlotID]cedar.EntityUID
+		err        string
+	}{
+		{"missingTemplate", "missing", "link", map[cedar.SlotID]cedar.EntityUID{cedar.PrincipalSlot: alice}, "template missing not found"},
+		{"existingPolicy", "template", "static", map[cedar.SlotID]cedar.EntityUID{cedar.PrincipalSlot: alice}, "policy static already exists"},
+		{"existingTemplate", "template", "template", map[cedar.SlotID]cedar.EntityUID{cedar.PrincipalSlot: alice}, "template template already exists"},
+		{"missingValue", "template", "link", nil, "linking template template: missing value for slot ?principal"},
+		{
+			"extraValue",
+			"template",
+			"link",
+			map[cedar.SlotID]cedar.EntityUID{cedar.PrincipalSlot: alice, cedar.ResourceSlot: alice},
+			"linking template template: template has no slot ?resource",
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			err := ps.LinkTemplate(tt.templateID, tt.linkID, tt.values)
+			testutil.Error(t, err)
+			testutil.Equals(t, err.Error(), tt.err)
+		})
+	}
+}
+
+func TestTemplateLinksLifecycle(t *testing.T) {
+	t.Parallel()
+	alice := cedar.NewEntityUID("User", "alice")
+	doc := cedar.NewEntityUID("Doc", "readme")
+	ps := cedar.NewPolicySet()
+	ps.AddTemplate("principal", cedar.NewTemplateFromAST(ast.Permit().PrincipalEqSlot()))
+	ps.AddTemplate("other", cedar.NewTemplateFromAST(ast.Permit().ResourceEqSlot()))
+	testutil.OK(t, ps.LinkTemplate("principal", "link0", map[cedar.SlotID]cedar.EntityUID{cedar.PrincipalSlot: alice}))
+	testutil.OK(t, ps.LinkTemplate("principal", "link1", map[cedar.SlotID]cedar.EntityUID{cedar.PrincipalSlot: alice}))
+	testutil.OK(t, ps.LinkTemplate("other", "link2", map[cedar.SlotID]cedar.EntityUID{cedar.ResourceSlot: doc}))
+
+	// Replacing a template relinks its policies.
+	ps.AddTemplate("principal", cedar.NewTemplateFromAST(ast.Forbid().PrincipalInSlot()))
+	testutil.Equals(t, ps.Get("link0").Effect(), cedar.Forbid)
+	testutil.Equals(t, ps.Get("link2").Effect(), cedar.Permit)
+
+	// Replacing a static policy or removing a linked policy drops the link.
+	ps.Add("link0", cedar.NewPolicyFromAST(ast.Permit()))
+	testutil.Equals(t, ps.Remove("link1"), true)
+	testutil.Equals(t, len(maps.Collect(ps.TemplateLinks("principal"))), 0)
+	testutil.Equals(t, ps.Unlink("link0"), false)
+
+	// Replacing a template with one which has different slots unlinks the policies whose values no longer match.
+	ps.AddTemplate("other", cedar.NewTemplateFromAST(ast.Permit().PrincipalEqSlot()))
+	testutil.Equals(t, ps.Get("link2"), nil)
+
+	testutil.OK(t, ps.LinkTemplate("other", "link3", map[cedar.SlotID]cedar.EntityUID{cedar.PrincipalSlot: alice}))
+	testutil.Equals(t, ps.RemoveTemplate("other"), true)
+	testutil.Equals(t, ps.Get("link3"), nil)
+}
+
+func TestTemplateLinksJSON(t *testing.T) {
+	t.Parallel()
+	in := `{"staticPolicies":{"static":{"effect":"forbid","principal":{"op":"All"},"action":{"op":"All"},"resource":{"op":"All"}}},` +
+		`"templates":{"share":{"effect":"permit","principal":{"op":"==","slot":"?principal"},"action":{"op":"All"},"resource":{"op":"in","slot":"?resource"}}},` +
+		`"templateLinks":[` +
+		`{"templateId":"share","newId":"link0","values":{"?principal":{"type":"User","id":"alice"},"?resource":{"type":"Doc","id":"readme"}}},` +
+		`{"templateId":"share","newId":"link1","values":{"?principal":{"type":"User","id":"bob"},"?resource":{"type":"Doc","id":"readme"}}}]}`
+	var ps cedar.PolicySet
+	testutil.OK(t, ps.UnmarshalJSON([]byte(in)))
+	testutil.Equals(t, ps.Get("link1").AST(), ast.Permit().
+		PrincipalEq(cedar.NewEntityUID("User", "bob")).
+		ResourceIn(cedar.NewEntityUID("Doc", "readme")))
+	out, err := ps.MarshalJSON()
+	testutil.OK(t, err)
+	testutil.Equals(t, string(out), in)
+	testutil.Equals(t, string(ps.MarshalCedar()), `permit (
+    principal == ?principal,
+    action,
+    resource in ?resource
+);
+
+forbid ( principal, action, resource );`)
+
+	err = ps.UnmarshalJSON([]byte(`{"staticPolicies":{},"templateLinks":[{"templateId":"missing","newId":"link0","values":{}}]}`))
+	testutil.Error(t, err)
+	testutil.Equals(t, err.Error(), "template link link0: template missing not found")
+
+	err = ps.UnmarshalJSON([]byte(`{"staticPolicies":{"share":{"effect":"forbid","principal":{"op":"All"},"action":{"op":"All"},"resource":{"op":"All"}}},` +
+		`"templates":{"share":{"effect":"permit","principal":{"op":"==","slot":"?principal"},"action":{"op":"All"},"resource":{"op":"All"}}}}`))
+	testutil.Error(t, err)
+	testutil.Equals(t, err.Error(), "template share: a static policy has the same ID")
+
+	err = ps.UnmarshalJSON([]byte(`{"staticPolicies":{},` +
+		`"templates":{"share":{"effect":"permit","principal":{"op":"==","slot":"?principal"},"action":{"op":"All"},"resource":{"op":"All"}}},` +
+		`"templateLinks":[{"templateId":"share","newId":"share","values":{"?principal":{"type":"User","id":"alice"}}}]}`))
+	testutil.Error(t, err)
+	testutil.Equals(t, err.Error(), "template link share: template share already exists")
+}
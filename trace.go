@@ -0,0 +1,100 @@
+package cedar
+
+import (
+	"bytes"
+	"context"
+	"maps"
+	"slices"
+
+	"github.com/cedar-policy/cedar-go/internal/eval"
+	"github.com/cedar-policy/cedar-go/internal/parser"
+	"github.com/cedar-policy/cedar-go/types"
+	internalast "github.com/cedar-policy/cedar-go/x/exp/ast"
+)
+
+// AuthorizeWithTrace is like Authorize, but also returns a Trace which explains, for each of the policies in the order
+// of their IDs, whether its scope matched the request, which of its conditions held, and the values of the key
+// subexpressions of the conditions.  Tracing evaluates the policies again, and so is much slower than Authorize, but
+// it uses the same entities: if they implement EntityLoader, each entity is loaded at most once.
+func AuthorizeWithTrace(policies PolicyIterator, entities types.EntityGetter, req Request) (Decision, Diagnostic, Trace) {
+	ctx := context.Background()
+	env, loaded := newEnv(ctx, entities, req, nil)
+	decision, diag := authorizeEnv(ctx, policies, env, loaded, req)
+	all := maps.Collect(policies.All())
+	trace := Trace{Policies: []PolicyTrace{}}
+	for _, id := range slices.Sorted(maps.Keys(all)) {
+		trace.Policies = append(trace.Policies, tracePolicy(env, loaded, id, all[id]))
+	}
+	return decision, diag, trace
+}
+
+func tracePolicy(env eval.Env, loaded *loadedEntities, id PolicyID, p *Policy) PolicyTrace {
+	res := PolicyTrace{PolicyID: id, Position: p.Position(), Effect: p.Effect(), ScopeMatched: true}
+	satisfied, err := p.eval.Eval(env)
+	if loaded != nil {
+		if lerr := loaded.takeErr(); lerr != nil {
+			err = lerr
+		}
+	}
+	if err != nil {
+		res.Error = err.Error()
+	}
+	res.Satisfied = bool(satisfied)
+
+	filename := res.Position.Filename
+	for _, r := range eval.TraceScope(env, p.ast) {
+		res.Scope = append(res.Scope, expressionTrace(r, filename))
+		res.ScopeMatched = res.ScopeMatched && r.Value == types.True
+	}
+	evaluate := res.ScopeMatched
+	for _, c := range p.ast.Conditions {
+		ct := ConditionTrace{Kind: "when", Expression: ExpressionTrace{
+			Expression: marshalNode(c.Body),
+			Position:   tracePosition(eval.ConditionPosition(c), filename),
+		}}
+		if c.Condition == internalast.ConditionUnless {
+			ct.Kind = "unless"
+		}
+		if evaluate {
+			r := eval.EvalNode(env, c.Body)
+			r.Position = eval.ConditionPosition(c)
+			ct.Evaluated = true
+			ct.Expression = expressionTrace(r, filename)
+			ct.Holds = r.Err == nil && r.Value == types.Boolean(c.Condition == internalast.ConditionWhen)
+			for _, k := range eval.KeySubexpressions(env, c) {
+				ct.Subexpressions = append(ct.Subexpressions, expressionTrace(k, filename))
+			}
+			evaluate = ct.Holds
+		}
+		res.Conditions = append(res.Conditions, ct)
+	}
+	if loaded != nil {
+		// Errors loading entities while tracing are those already reported for the evaluation of the policy.
+		loaded.takeErr()
+	}
+	return res
+}
+
+func expressionTrace(r eval.NodeResult, filename string) ExpressionTrace {
+	res := ExpressionTrace{Expression: marshalNode(r.Node), Position: tracePosition(r.Position, filename), Value: r.Value}
+	if r.Err != nil {
+		res.Error = r.Err.Error()
+	}
+	return res
+}
+
+// tracePosition returns the position of an expression in the file of its policy, or nil if it is not known.
+func tracePosition(pos internalast.Position, filename string) *Position {
+	if pos.Line == 0 {
+		return nil
+	}
+	res := Position(pos)
+	res.Filename = filename
+	return &res
+}
+
+func marshalNode(n internalast.IsNode) string {
+	var buf bytes.Buffer
+	parser.MarshalNode(n, &buf)
+	return buf.String()
+}
@@ -0,0 +1,271 @@
+package cedar_test
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/cedar-policy/cedar-go"
+	"github.com/cedar-policy/cedar-go/ast"
+	"github.com/cedar-policy/cedar-go/internal/testutil"
+)
+
+func TestAuthorizeWithTrace(t *testing.T) {
+	t.Parallel()
+	ps, err := cedar.NewPolicySetFromBytes("policy.cedar", []byte(`permit(principal in Group::"staff", action == Action::"view", resource)
+when { resource.public || resource.owner == principal }
+unless { principal.suspended };
+forbid(principal, action, resource is Secret);
+permit(principal, action, resource) when { context.level > 3 } when { principal.missing };
+`))
+	testutil.OK(t, err)
+	alice := cedar.NewEntityUID("User", "alice")
+	doc := cedar.NewEntityUID("Doc", "plan")
+	entities := cedar.EntityMap{
+		alice: {UID: alice, Parents: cedar.NewEntityUIDSet(cedar.NewEntityUID("Group", "staff")),
+			Attributes: cedar.NewRecord(cedar.RecordMap{"suspended": cedar.True})},
+		doc: {UID: doc, Attributes: cedar.NewRecord(cedar.RecordMap{"public": cedar.False, "owner": alice})},
+	}
+	req := cedar.Request{
+		Principal: alice,
+		Action:    cedar.NewEntityUID("Action", "view"),
+		Resource:  doc,
+		Context:   cedar.NewRecord(cedar.RecordMap{"level": cedar.Long(5)}),
+	}
+	decision, diag, trace := cedar.AuthorizeWithTrace(ps, entities, req)
+	wantDecision, wantDiag := cedar.Authorize(ps, entities, req)
+	testutil.Equals(t, decision, wantDecision)
+	testutil.Equals(t, diag, wantDiag)
+
+	b, err := json.MarshalIndent(trace, "", "  ")
+	testutil.OK(t, err)
+	// Backquotes are written as single quotes, as the expected trace is a raw string literal.
+	testutil.Equals(t, string(b), strings.ReplaceAll(`{
+  "policies": [
+    {
+      "policy": "policy0",
+      "position": {
+        "filename": "policy.cedar",
+        "offset": 0,
+        "line": 1,
+        "column": 1
+      },
+      "effect": "permit",
+      "scope": [
+        {
+          "expression": "principal in Group::\"staff\"",
+          "value": true
+        },
+        {
+          "expression": "action == Action::\"view\"",
+          "value": true
+        }
+      ],
+      "scopeMatched": true,
+      "conditions": [
+        {
+          "kind": "when",
+          "evaluated": true,
+          "holds": true,
+          "expression": {
+            "expression": "resource.public || resource.owner == principal",
+            "position": {
+              "filename": "policy.cedar",
+              "offset": 79,
+              "line": 2,
+              "column": 8
+            },
+            "value": true
+          },
+          "subexpressions": [
+            {
+              "expression": "resource.public",
+              "position": {
+                "filename": "policy.cedar",
+                "offset": 79,
+                "line": 2,
+                "column": 8
+              },
+              "value": false
+            },
+            {
+              "expression": "resource.owner == principal",
+              "position": {
+                "filename": "policy.cedar",
+                "offset": 98,
+                "line": 2,
+                "column": 27
+              },
+              "value": true
+            },
+            {
+              "expression": "resource.owner",
+              "position": {
+                "filename": "policy.cedar",
+                "offset": 98,
+                "line": 2,
+                "column": 27
+              },
+              "value": {
+                "__entity": {
+                  "type": "User",
+                  "id": "alice"
+                }
+              }
+            },
+            {
+              "expression": "principal",
+              "position": {
+                "filename": "policy.cedar",
+                "offset": 116,
+                "line": 2,
+                "column": 45
+              },
+              "value": {
+                "__entity": {
+                  "type": "User",
+                  "id": "alice"
+                }
+              }
+            }
+          ]
+        },
+        {
+          "kind": "unless",
+          "evaluated": true,
+          "holds": false,
+          "expression": {
+            "expression": "principal.suspended",
+            "position": {
+              "filename": "policy.cedar",
+              "offset": 137,
+              "line": 3,
+              "column": 10
+            },
+            "value": true
+          }
+        }
+      ],
+      "satisfied": false
+    },
+    {
+      "policy": "policy1",
+      "position": {
+        "filename": "policy.cedar",
+        "offset": 160,
+        "line": 4,
+        "column": 1
+      },
+      "effect": "forbid",
+      "scope": [
+        {
+          "expression": "resource is Secret",
+          "value": false
+        }
+      ],
+      "scopeMatched": false,
+      "satisfied": false
+    },
+    {
+      "policy": "policy2",
+      "position": {
+        "filename": "policy.cedar",
+        "offset": 207,
+        "line": 5,
+        "column": 1
+      },
+      "effect": "permit",
+      "scopeMatched": true,
+      "conditions": [
+        {
+          "kind": "when",
+          "evaluated": true,
+          "holds": true,
+          "expression": {
+            "expression": "context.level \u003e 3",
+            "position": {
+              "filename": "policy.cedar",
+              "offset": 250,
+              "line": 5,
+              "column": 44
+            },
+            "value": true
+          },
+          "subexpressions": [
+            {
+              "expression": "context.level",
+              "position": {
+                "filename": "policy.cedar",
+                "offset": 250,
+                "line": 5,
+                "column": 44
+              },
+              "value": 5
+            }
+          ]
+        },
+        {
+          "kind": "when",
+          "evaluated": true,
+          "holds": false,
+          "expression": {
+            "expression": "principal.missing",
+            "position": {
+              "filename": "policy.cedar",
+              "offset": 277,
+              "line": 5,
+              "column": 71
+            },
+            "error": "'User::\"alice\"' does not have the attribute 'missing'"
+          }
+        }
+      ],
+      "satisfied": false,
+      "error": "'User::\"alice\"' does not have the attribute 'missing'"
+    }
+  ]
+}`, "'", "`"))
+}
+
+func TestAuthorizeWithTraceSkipsConditions(t *testing.T) {
+	t.Parallel()
+	ps, err := cedar.NewPolicySetFromBytes("policy.cedar", []byte(`
+permit(principal == User::"bob", action, resource) when { true };
+permit(principal, action, resource) when { false } unless { true };
+`))
+	testutil.OK(t, err)
+	decision, _, trace := cedar.AuthorizeWithTrace(ps, nil, cedar.Request{Principal: cedar.NewEntityUID("User", "alice")})
+	testutil.Equals(t, decision, cedar.Deny)
+	testutil.Equals(t, trace.Policies[0].ScopeMatched, false)
+	pos := func(offset, line, column int) *cedar.Position {
+		return &cedar.Position{Filename: "policy.cedar", Offset: offset, Line: line, Column: column}
+	}
+	testutil.Equals(t, trace.Policies[0].Conditions, []cedar.ConditionTrace{
+		{Kind: "when", Expression: cedar.ExpressionTrace{Expression: "true", Position: pos(59, 2, 59)}},
+	})
+	testutil.Equals(t, trace.Policies[1].Conditions, []cedar.ConditionTrace{
+		{Kind: "when", Evaluated: true, Expression: cedar.ExpressionTrace{Expression: "false", Position: pos(110, 3, 44), Value: cedar.False}},
+		{Kind: "unless", Expression: cedar.ExpressionTrace{Expression: "true", Position: pos(127, 3, 61)}},
+	})
+
+	_, _, trace = cedar.AuthorizeWithTrace(cedar.NewPolicySet(), nil, cedar.Request{})
+	b, err := json.Marshal(trace)
+	testutil.OK(t, err)
+	testutil.Equals(t, string(b), `{"policies":[]}`)
+}
+
+func TestAuthorizeWithTraceFromAST(t *testing.T) {
+	t.Parallel()
+	ps := cedar.NewPolicySet()
+	ps.Add("policy0", cedar.NewPolicyFromAST(ast.Permit().When(ast.Context().Access("x").Equal(ast.Long(1)))))
+	_, _, trace := cedar.AuthorizeWithTrace(ps, nil, cedar.Request{Context: cedar.NewRecord(cedar.RecordMap{"x": cedar.Long(1)})})
+	testutil.Equals(t, trace.Policies[0].Conditions, []cedar.ConditionTrace{{
+		Kind:       "when",
+		Evaluated:  true,
+		Holds:      true,
+		Expression: cedar.ExpressionTrace{Expression: "context.x == 1", Value: cedar.True},
+		Subexpressions: []cedar.ExpressionTrace{
+			{Expression: "context.x", Value: cedar.Long(1)},
+		},
+	}})
+}
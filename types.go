@@ -43,6 +43,10 @@ type Wildcard = types.Wildcard
 // cedar-go types
 
 type EntityGetter = types.EntityGetter
+type AncestorChecker = types.AncestorChecker
+type EntityLoader = types.EntityLoader
+type EntityBatchLoader = types.EntityBatchLoader
+type EntityStore = types.EntityStore
 type Value = types.Value
 
 type Request = types.Request
@@ -50,6 +54,15 @@ type Decision = types.Decision
 type Diagnostic = types.Diagnostic
 type DiagnosticReason = types.DiagnosticReason
 type DiagnosticError = types.DiagnosticError
+type Trace = types.Trace
+type PolicyTrace = types.PolicyTrace
+type ConditionTrace = types.ConditionTrace
+type ExpressionTrace = types.ExpressionTrace
+type ErrorKind = types.ErrorKind
+
+const (
+	EntityLoadError = types.EntityLoadError
+)
 
 const (
 	Allow = types.Allow
@@ -67,6 +80,13 @@ type Annotations = types.Annotations
 
 type Position = types.Position
 
+type SlotID = types.SlotID
+
+const (
+	PrincipalSlot = types.PrincipalSlot
+	ResourceSlot  = types.ResourceSlot
+)
+
 //   ____                _              _
 //  / ___|___  _ __  ___| |_ __ _ _ __ | |_ ___
 // | |   / _ \| '_ \/ __| __/ _` | '_ \| __/ __|
@@ -116,6 +136,11 @@ func NewEntityUIDSet(args ...EntityUID) EntityUIDSet {
 	return mapset.Immutable[EntityUID](args...)
 }
 
+// NewEntityStore returns an EntityStore holding the entities, or an error if their hierarchy contains a cycle.
+func NewEntityStore(entities EntityMap) (*EntityStore, error) {
+	return types.NewEntityStore(entities)
+}
+
 // NewPattern permits for the programmatic construction of a Pattern out of a slice of pattern components.
 // The pattern components may be one of string, cedar.String, or cedar.Wildcard.  Any other types will
 // cause a panic.
@@ -38,6 +38,8 @@ func (a *Decision) UnmarshalJSON(b []byte) error {
 type Diagnostic struct {
 	Reasons []DiagnosticReason `json:"reasons,omitempty"`
 	Errors  []DiagnosticError  `json:"errors,omitempty"`
+	// Aborted is the error which stopped authorization before all of the policies were evaluated, if any.
+	Aborted error `json:"-"`
 }
 
 // A DiagnosticReason details the PolicyID within a PolicySet and the Position within the text document, if applicable.
@@ -49,11 +51,22 @@ type DiagnosticReason struct {
 // An DiagnosticError details the PolicyID within a PolicySet, the Position within the text document if applicable, and
 // the resulting error message.
 type DiagnosticError struct {
-	PolicyID PolicyID `json:"policy"`
-	Position Position `json:"position"`
-	Message  string   `json:"message"`
+	PolicyID PolicyID  `json:"policy"`
+	Position Position  `json:"position"`
+	Message  string    `json:"message"`
+	Kind     ErrorKind `json:"kind,omitempty"`
 }
 
+// An ErrorKind distinguishes errors which are not caused by the evaluation of the policy itself.  Errors in evaluation,
+// e.g. accessing an attribute which does not exist, have no ErrorKind.
+type ErrorKind string
+
+// The kinds of DiagnosticError which are not errors in evaluation.
+const (
+	// EntityLoadError is the kind of error returned by an EntityLoader.
+	EntityLoadError = ErrorKind("entityLoad")
+)
+
 func (e DiagnosticError) String() string {
 	return fmt.Sprintf("while evaluating policy `%v`: %v", e.PolicyID, e.Message)
 }
@@ -61,6 +74,16 @@ func (e DiagnosticError) String() string {
 // PolicyID is a string identifier for the policy within the PolicySet
 type PolicyID string
 
+// SlotID identifies a slot in the scope of a policy template, which is filled with an entity when the template is
+// linked.
+type SlotID string
+
+// The slots which a policy template may contain.
+const (
+	PrincipalSlot = SlotID("?principal")
+	ResourceSlot  = SlotID("?resource")
+)
+
 // A Position describes an arbitrary source position including the file, line, and column location.
 type Position struct {
 	// Filename is the optional name of the source file for the enclosing policy, "" if the source is unknown or not a named file
@@ -86,6 +109,27 @@ const (
 	Forbid = Effect(false)
 )
 
+func (e Effect) String() string {
+	if e {
+		return "permit"
+	}
+	return "forbid"
+}
+
+func (e Effect) MarshalJSON() ([]byte, error) { return []byte(`"` + e.String() + `"`), nil }
+
+func (e *Effect) UnmarshalJSON(b []byte) error {
+	switch string(b) {
+	case `"permit"`:
+		*e = Permit
+	case `"forbid"`:
+		*e = Forbid
+	default:
+		return fmt.Errorf("invalid effect %s: expected \"permit\" or \"forbid\"", b)
+	}
+	return nil
+}
+
 // An Annotations is a map of key, value pairs found in the policy. Annotations
 // have no impact on policy evaluation.
 type Annotations map[Ident]String
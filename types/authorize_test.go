@@ -39,6 +39,45 @@ func TestJSONDecision(t *testing.T) {
 	})
 }
 
+func TestJSONEffect(t *testing.T) {
+	t.Parallel()
+	t.Run("MarshalPermit", func(t *testing.T) {
+		t.Parallel()
+		b, err := json.Marshal(Permit)
+		testutil.OK(t, err)
+		testutil.Equals(t, string(b), `"permit"`)
+	})
+	t.Run("MarshalForbid", func(t *testing.T) {
+		t.Parallel()
+		b, err := json.Marshal(Forbid)
+		testutil.OK(t, err)
+		testutil.Equals(t, string(b), `"forbid"`)
+	})
+	t.Run("UnmarshalPermit", func(t *testing.T) {
+		t.Parallel()
+		var e Effect
+		err := json.Unmarshal([]byte(`"permit"`), &e)
+		testutil.OK(t, err)
+		testutil.Equals(t, e, Permit)
+	})
+	t.Run("UnmarshalForbid", func(t *testing.T) {
+		t.Parallel()
+		var e Effect
+		err := json.Unmarshal([]byte(`"forbid"`), &e)
+		testutil.OK(t, err)
+		testutil.Equals(t, e, Forbid)
+	})
+	t.Run("UnmarshalInvalid", func(t *testing.T) {
+		t.Parallel()
+		for _, in := range []string{`"bogus"`, `"Permit"`, `true`, `123`, `null`} {
+			e := Permit
+			err := json.Unmarshal([]byte(in), &e)
+			testutil.Error(t, err)
+			testutil.Equals(t, e, Permit)
+		}
+	})
+}
+
 func TestError(t *testing.T) {
 	t.Parallel()
 	e := DiagnosticError{PolicyID: "policy42", Message: "bad error"}
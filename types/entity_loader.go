@@ -0,0 +1,17 @@
+package types
+
+import "context"
+
+// An EntityLoader loads an Entity by EntityUID from a source which can fail, e.g. a database.  When the EntityGetter
+// used for authorization also implements EntityLoader, Load is used instead of Get, and its errors are reported in the
+// Diagnostic as errors of kind EntityLoadError rather than as missing entities.
+type EntityLoader interface {
+	Load(ctx context.Context, uid EntityUID) (Entity, bool, error)
+}
+
+// An EntityBatchLoader is an EntityLoader which can also load many entities at once.  Entities which are not found are
+// left out of the returned EntityMap.
+type EntityBatchLoader interface {
+	EntityLoader
+	LoadMany(ctx context.Context, uids []EntityUID) (EntityMap, error)
+}
@@ -0,0 +1,214 @@
+package types
+
+import (
+	"errors"
+	"fmt"
+	"iter"
+	"sync"
+
+	"github.com/cedar-policy/cedar-go/internal/mapset"
+)
+
+// An AncestorChecker reports whether one entity is an ancestor of another, i.e. whether it can be reached by following
+// the parents of the other entity.  When the EntityGetter used for authorization implements AncestorChecker, the
+// evaluator uses it to evaluate `in` rather than traversing the parents of each entity.
+type AncestorChecker interface {
+	IsAncestor(entity, ancestor EntityUID) bool
+}
+
+// ErrEntityCycle is returned, wrapped, when an entity would be its own ancestor.
+var ErrEntityCycle = errors.New("entity hierarchy contains a cycle")
+
+var _ EntityGetter = &EntityStore{}
+var _ AncestorChecker = &EntityStore{}
+
+// An EntityStore is a collection of entities which maintains the transitive closure of their ancestors, so that it
+// can answer whether one entity is an ancestor of another in constant time.  The closure is updated incrementally as
+// entities are put and removed, and an EntityStore rejects any change which would make an entity its own ancestor.
+//
+// An EntityStore is safe for concurrent use.
+type EntityStore struct {
+	mu        sync.RWMutex
+	entities  EntityMap
+	ancestors map[EntityUID]EntityUIDSet
+	children  map[EntityUID]*mapset.MapSet[EntityUID]
+}
+
+// NewEntityStore returns an EntityStore holding the entities, or an error wrapping ErrEntityCycle if their hierarchy
+// contains a cycle.
+func NewEntityStore(entities EntityMap) (*EntityStore, error) {
+	s := &EntityStore{
+		entities:  make(EntityMap, len(entities)),
+		ancestors: make(map[EntityUID]EntityUIDSet, len(entities)),
+		children:  map[EntityUID]*mapset.MapSet[EntityUID]{},
+	}
+	affected := mapset.Make[EntityUID](len(entities))
+	for uid, e := range entities {
+		s.set(uid, e)
+		affected.Add(uid)
+	}
+	if err := s.recompute(affected); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// Get returns the entity with the given UID, if the EntityStore holds it.
+func (s *EntityStore) Get(uid EntityUID) (Entity, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	e, ok := s.entities[uid]
+	return e, ok
+}
+
+// All returns an iterator over the entities in the EntityStore.  The EntityStore must not be changed during iteration.
+func (s *EntityStore) All() iter.Seq2[EntityUID, Entity] {
+	return func(yield func(EntityUID, Entity) bool) {
+		s.mu.RLock()
+		defer s.mu.RUnlock()
+		for uid, e := range s.entities {
+			if !yield(uid, e) {
+				return
+			}
+		}
+	}
+}
+
+// IsAncestor reports whether ancestor is an ancestor of entity.  An entity is not its own ancestor.
+func (s *EntityStore) IsAncestor(entity, ancestor EntityUID) bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.ancestors[entity].Contains(ancestor)
+}
+
+// Ancestors returns all the ancestors of the entity.
+func (s *EntityStore) Ancestors(uid EntityUID) EntityUIDSet {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.ancestors[uid]
+}
+
+// Put adds the entity to the EntityStore, replacing any entity with the same UID, and updates the ancestors of its
+// descendants.  If the entity would be its own ancestor, Put returns an error wrapping ErrEntityCycle and leaves the
+// EntityStore unchanged.
+func (s *EntityStore) Put(e Entity) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	old, existed := s.entities[e.UID]
+	s.set(e.UID, e)
+	affected := s.descendants(e.UID)
+	err := s.recompute(affected)
+	if err == nil {
+		return nil
+	}
+	if existed {
+		s.set(e.UID, old)
+	} else {
+		s.delete(e.UID)
+	}
+	_ = s.recompute(affected)
+	return err
+}
+
+// Remove removes the entity with the given UID from the EntityStore, and updates the ancestors of its descendants.
+// It reports whether the EntityStore held the entity.
+func (s *EntityStore) Remove(uid EntityUID) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.entities[uid]; !ok {
+		return false
+	}
+	s.delete(uid)
+	_ = s.recompute(s.descendants(uid))
+	return true
+}
+
+func (s *EntityStore) set(uid EntityUID, e Entity) {
+	s.delete(uid)
+	s.entities[uid] = e
+	for p := range e.Parents.All() {
+		c, ok := s.children[p]
+		if !ok {
+			c = &mapset.MapSet[EntityUID]{}
+			s.children[p] = c
+		}
+		c.Add(uid)
+	}
+}
+
+func (s *EntityStore) delete(uid EntityUID) {
+	for p := range s.entities[uid].Parents.All() {
+		c := s.children[p]
+		c.Remove(uid)
+		if c.Len() == 0 {
+			delete(s.children, p)
+		}
+	}
+	delete(s.entities, uid)
+	delete(s.ancestors, uid)
+}
+
+// descendants returns the entity and all of its descendants.
+func (s *EntityStore) descendants(uid EntityUID) *mapset.MapSet[EntityUID] {
+	res := mapset.FromItems(uid)
+	todo := []EntityUID{uid}
+	for len(todo) > 0 {
+		var u EntityUID
+		u, todo = todo[len(todo)-1], todo[:len(todo)-1]
+		if c, ok := s.children[u]; ok {
+			for child := range c.All() {
+				if res.Add(child) {
+					todo = append(todo, child)
+				}
+			}
+		}
+	}
+	return res
+}
+
+// recompute computes the ancestors of the affected entities from their parents, whose ancestors must be up to date
+// unless they are affected too.  It returns an error wrapping ErrEntityCycle if an affected entity is its own
+// ancestor.
+func (s *EntityStore) recompute(affected *mapset.MapSet[EntityUID]) error {
+	const (
+		visiting = iota + 1
+		done
+	)
+	state := map[EntityUID]int{}
+	var visit func(uid EntityUID) error
+	visit = func(uid EntityUID) error {
+		switch state[uid] {
+		case visiting:
+			return fmt.Errorf("%w: %v is its own ancestor", ErrEntityCycle, uid)
+		case done:
+			return nil
+		}
+		state[uid] = visiting
+		e, ok := s.entities[uid]
+		if !ok {
+			state[uid] = done
+			return nil
+		}
+		var res mapset.MapSet[EntityUID]
+		for p := range e.Parents.All() {
+			res.Add(p)
+			if affected.Contains(p) {
+				if err := visit(p); err != nil {
+					return err
+				}
+			}
+			for a := range s.ancestors[p].All() {
+				res.Add(a)
+			}
+		}
+		s.ancestors[uid] = EntityUIDSet(res)
+		state[uid] = done
+		return nil
+	}
+	for uid := range affected.All() {
+		if err := visit(uid); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,147 @@
+package types_test
+
+import (
+	"maps"
+	"slices"
+	"testing"
+
+	"github.com/cedar-policy/cedar-go/internal/testutil"
+	"github.com/cedar-policy/cedar-go/types"
+)
+
+func storeEntity(id string, parents ...string) types.Entity {
+	var ps []types.EntityUID
+	for _, p := range parents {
+		ps = append(ps, types.NewEntityUID("T", types.String(p)))
+	}
+	return types.Entity{UID: types.NewEntityUID("T", types.String(id)), Parents: types.NewEntityUIDSet(ps...)}
+}
+
+func storeUID(id string) types.EntityUID {
+	return types.NewEntityUID("T", types.String(id))
+}
+
+func storeAncestors(s *types.EntityStore, id string) []string {
+	var res []string
+	for a := range s.Ancestors(storeUID(id)).All() {
+		res = append(res, string(a.ID))
+	}
+	slices.Sort(res)
+	return res
+}
+
+func newStore(t *testing.T, entities ...types.Entity) *types.EntityStore {
+	m := types.EntityMap{}
+	for _, e := range entities {
+		m[e.UID] = e
+	}
+	s, err := types.NewEntityStore(m)
+	testutil.OK(t, err)
+	return s
+}
+
+func TestEntityStore(t *testing.T) {
+	t.Parallel()
+	t.Run("Closure", func(t *testing.T) {
+		t.Parallel()
+		s := newStore(t,
+			storeEntity("a", "b", "c"),
+			storeEntity("b", "d"),
+			storeEntity("c", "d", "dangling"),
+			storeEntity("d"),
+		)
+		testutil.Equals(t, storeAncestors(s, "a"), []string{"b", "c", "d", "dangling"})
+		testutil.Equals(t, storeAncestors(s, "b"), []string{"d"})
+		testutil.Equals(t, storeAncestors(s, "d"), nil)
+		testutil.Equals(t, storeAncestors(s, "missing"), nil)
+		testutil.Equals(t, s.IsAncestor(storeUID("a"), storeUID("d")), true)
+		testutil.Equals(t, s.IsAncestor(storeUID("a"), storeUID("dangling")), true)
+		testutil.Equals(t, s.IsAncestor(storeUID("a"), storeUID("a")), false)
+		testutil.Equals(t, s.IsAncestor(storeUID("d"), storeUID("a")), false)
+	})
+
+	t.Run("GetAndAll", func(t *testing.T) {
+		t.Parallel()
+		a, b := storeEntity("a", "b"), storeEntity("b")
+		s := newStore(t, a, b)
+		got, ok := s.Get(a.UID)
+		testutil.Equals(t, ok, true)
+		testutil.Equals(t, got, a)
+		_, ok = s.Get(storeUID("missing"))
+		testutil.Equals(t, ok, false)
+		testutil.Equals(t, maps.Collect(s.All()), types.EntityMap{a.UID: a, b.UID: b})
+		for range s.All() {
+			break
+		}
+	})
+
+	t.Run("Put", func(t *testing.T) {
+		t.Parallel()
+		s := newStore(t, storeEntity("a", "b"), storeEntity("c", "a"))
+		testutil.Equals(t, storeAncestors(s, "c"), []string{"a", "b"})
+
+		testutil.OK(t, s.Put(storeEntity("b", "d")))
+		testutil.Equals(t, storeAncestors(s, "c"), []string{"a", "b", "d"})
+
+		testutil.OK(t, s.Put(storeEntity("a", "e")))
+		testutil.Equals(t, storeAncestors(s, "a"), []string{"e"})
+		testutil.Equals(t, storeAncestors(s, "c"), []string{"a", "e"})
+		testutil.Equals(t, s.IsAncestor(storeUID("c"), storeUID("b")), false)
+
+		testutil.OK(t, s.Put(storeEntity("e", "b")))
+		testutil.Equals(t, storeAncestors(s, "c"), []string{"a", "b", "d", "e"})
+	})
+
+	t.Run("Remove", func(t *testing.T) {
+		t.Parallel()
+		s := newStore(t, storeEntity("a", "b"), storeEntity("b", "c"), storeEntity("c"))
+		testutil.Equals(t, s.Remove(storeUID("b")), true)
+		testutil.Equals(t, s.Remove(storeUID("b")), false)
+		_, ok := s.Get(storeUID("b"))
+		testutil.Equals(t, ok, false)
+		testutil.Equals(t, storeAncestors(s, "a"), []string{"b"})
+		testutil.Equals(t, storeAncestors(s, "b"), nil)
+
+		testutil.OK(t, s.Put(storeEntity("b", "c")))
+		testutil.Equals(t, storeAncestors(s, "a"), []string{"b", "c"})
+	})
+
+	t.Run("NewCycle", func(t *testing.T) {
+		t.Parallel()
+		for _, entities := range [][]types.Entity{
+			{storeEntity("a", "a")},
+			{storeEntity("a", "b"), storeEntity("b", "a")},
+			{storeEntity("a", "b"), storeEntity("b", "c"), storeEntity("c", "a"), storeEntity("d", "a")},
+		} {
+			m := types.EntityMap{}
+			for _, e := range entities {
+				m[e.UID] = e
+			}
+			s, err := types.NewEntityStore(m)
+			testutil.ErrorIs(t, err, types.ErrEntityCycle)
+			testutil.Equals(t, s, nil)
+		}
+	})
+
+	t.Run("PutCycle", func(t *testing.T) {
+		t.Parallel()
+		s := newStore(t, storeEntity("a", "b"), storeEntity("b", "c"), storeEntity("c"), storeEntity("d", "a"))
+
+		err := s.Put(storeEntity("c", "x", "a"))
+		testutil.ErrorIs(t, err, types.ErrEntityCycle)
+		got, _ := s.Get(storeUID("c"))
+		testutil.Equals(t, got, storeEntity("c"))
+		testutil.Equals(t, storeAncestors(s, "a"), []string{"b", "c"})
+		testutil.Equals(t, storeAncestors(s, "c"), nil)
+		testutil.Equals(t, storeAncestors(s, "d"), []string{"a", "b", "c"})
+
+		err = s.Put(storeEntity("e", "e"))
+		testutil.ErrorIs(t, err, types.ErrEntityCycle)
+		_, ok := s.Get(storeUID("e"))
+		testutil.Equals(t, ok, false)
+		testutil.Equals(t, storeAncestors(s, "e"), nil)
+
+		testutil.OK(t, s.Put(storeEntity("c", "x")))
+		testutil.Equals(t, storeAncestors(s, "d"), []string{"a", "b", "c", "x"})
+	})
+}
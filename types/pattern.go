@@ -52,6 +52,21 @@ func NewPattern(components ...any) Pattern {
 	return Pattern{comps: comps}
 }
 
+// Components returns the components of the pattern in the form accepted by NewPattern: each is either a String, which
+// matches itself, or a Wildcard.
+func (p Pattern) Components() []any {
+	var res []any
+	for _, comp := range p.comps {
+		if comp.Wildcard {
+			res = append(res, Wildcard{})
+		}
+		if comp.Literal != "" {
+			res = append(res, String(comp.Literal))
+		}
+	}
+	return res
+}
+
 func (p Pattern) MarshalCedar() []byte {
 	var buf bytes.Buffer
 	buf.WriteRune('"')
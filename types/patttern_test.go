@@ -30,6 +30,13 @@ func TestPattern(t *testing.T) {
 		t.Parallel()
 		testutil.Equals(t, string(NewPattern(String("*foo"), Wildcard{}).MarshalCedar()), `"\*foo*"`)
 	})
+	t.Run("Components", func(t *testing.T) {
+		t.Parallel()
+		components := []any{String("foo"), Wildcard{}, String("bar"), Wildcard{}}
+		testutil.Equals(t, NewPattern(components...).Components(), components)
+		testutil.Equals(t, NewPattern(Wildcard{}, String("foo")).Components(), []any{Wildcard{}, String("foo")})
+		testutil.Equals(t, len(NewPattern().Components()), 0)
+	})
 }
 
 func TestPatternMatch(t *testing.T) {
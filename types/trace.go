@@ -0,0 +1,50 @@
+package types
+
+// A Trace explains an authorization decision, policy by policy.
+type Trace struct {
+	Policies []PolicyTrace `json:"policies"`
+}
+
+// A PolicyTrace explains why a policy was or was not satisfied by a request.  A policy is satisfied when its scope
+// matches the request and each of its conditions holds.
+type PolicyTrace struct {
+	PolicyID PolicyID `json:"policy"`
+	Position Position `json:"position"`
+	Effect   Effect   `json:"effect"`
+	// Scope holds the results of the constraints of the scope on the principal, action and resource, leaving out those
+	// which match any entity.
+	Scope        []ExpressionTrace `json:"scope,omitempty"`
+	ScopeMatched bool              `json:"scopeMatched"`
+	// Conditions holds the results of the `when` and `unless` conditions of the policy, in order.
+	Conditions []ConditionTrace `json:"conditions,omitempty"`
+	Satisfied  bool             `json:"satisfied"`
+	// Error is the error which evaluating the policy resulted in, if any.
+	Error string `json:"error,omitempty"`
+}
+
+// A ConditionTrace explains whether a `when` or `unless` condition of a policy held.
+type ConditionTrace struct {
+	// Kind is either "when" or "unless".
+	Kind string `json:"kind"`
+	// Evaluated reports whether the condition was evaluated.  The conditions of a policy are evaluated in order until
+	// one does not hold, and none is evaluated if the scope does not match.
+	Evaluated bool `json:"evaluated"`
+	// Holds reports whether the condition held, i.e. whether its expression was true for `when` or false for
+	// `unless`.
+	Holds      bool            `json:"holds"`
+	Expression ExpressionTrace `json:"expression"`
+	// Subexpressions holds the results of the key subexpressions of the condition: the operands of `&&`, `||` and
+	// `!`, and the operands of the comparisons and tests between them which are not literal values.
+	Subexpressions []ExpressionTrace `json:"subexpressions,omitempty"`
+}
+
+// An ExpressionTrace holds the result of evaluating an expression.
+type ExpressionTrace struct {
+	// Expression is the expression in the human-readable Cedar format.
+	Expression string `json:"expression"`
+	// Position is the source position of the expression if the policy was parsed from Cedar text, and otherwise nil.
+	// The parser does not record the positions of the constraints of the scope, so those have none.
+	Position *Position `json:"position,omitempty"`
+	Value    Value     `json:"value,omitempty"`
+	Error    string    `json:"error,omitempty"`
+}
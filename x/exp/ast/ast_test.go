@@ -178,6 +178,36 @@ func TestASTByTable(t *testing.T) {
 			ast.Permit().ResourceIsIn("T", types.NewEntityUID("T", "42")),
 			ast.Policy{Effect: ast.EffectPermit, Principal: ast.ScopeTypeAll{}, Action: ast.ScopeTypeAll{}, Resource: ast.ScopeTypeIsIn{Type: types.EntityType("T"), Entity: types.NewEntityUID("T", "42")}},
 		},
+		{
+			"scopePrincipalEqSlot",
+			ast.Permit().PrincipalEqSlot(),
+			ast.Policy{Effect: ast.EffectPermit, Principal: ast.ScopeTypeSlotEq{Slot: types.PrincipalSlot}, Action: ast.ScopeTypeAll{}, Resource: ast.ScopeTypeAll{}},
+		},
+		{
+			"scopePrincipalInSlot",
+			ast.Permit().PrincipalInSlot(),
+			ast.Policy{Effect: ast.EffectPermit, Principal: ast.ScopeTypeSlotIn{Slot: types.PrincipalSlot}, Action: ast.ScopeTypeAll{}, Resource: ast.ScopeTypeAll{}},
+		},
+		{
+			"scopePrincipalIsInSlot",
+			ast.Permit().PrincipalIsInSlot("T"),
+			ast.Policy{Effect: ast.EffectPermit, Principal: ast.ScopeTypeSlotIsIn{Type: types.EntityType("T"), Slot: types.PrincipalSlot}, Action: ast.ScopeTypeAll{}, Resource: ast.ScopeTypeAll{}},
+		},
+		{
+			"scopeResourceEqSlot",
+			ast.Permit().ResourceEqSlot(),
+			ast.Policy{Effect: ast.EffectPermit, Principal: ast.ScopeTypeAll{}, Action: ast.ScopeTypeAll{}, Resource: ast.ScopeTypeSlotEq{Slot: types.ResourceSlot}},
+		},
+		{
+			"scopeResourceInSlot",
+			ast.Permit().ResourceInSlot(),
+			ast.Policy{Effect: ast.EffectPermit, Principal: ast.ScopeTypeAll{}, Action: ast.ScopeTypeAll{}, Resource: ast.ScopeTypeSlotIn{Slot: types.ResourceSlot}},
+		},
+		{
+			"scopeResourceIsInSlot",
+			ast.Permit().ResourceIsInSlot("T"),
+			ast.Policy{Effect: ast.EffectPermit, Principal: ast.ScopeTypeAll{}, Action: ast.ScopeTypeAll{}, Resource: ast.ScopeTypeSlotIsIn{Type: types.EntityType("T"), Slot: types.ResourceSlot}},
+		},
 		{
 			"variablePrincipal",
 			ast.Permit().When(ast.Principal()),
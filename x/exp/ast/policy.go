@@ -18,6 +18,9 @@ const (
 type ConditionType struct {
 	Condition Condition
 	Body      IsNode
+	// Positions holds the source positions of the nodes of Body if it was parsed from Cedar text, in post-order: each
+	// node follows its operands, which are in the order in which they appear in the source.
+	Positions []Position
 }
 
 type Effect bool
@@ -30,6 +30,18 @@ func (s Scope) IsIn(entityType types.EntityType, entity types.EntityUID) ScopeTy
 	return ScopeTypeIsIn{Type: entityType, Entity: entity}
 }
 
+func (s Scope) EqSlot(slot types.SlotID) ScopeTypeSlotEq {
+	return ScopeTypeSlotEq{Slot: slot}
+}
+
+func (s Scope) InSlot(slot types.SlotID) ScopeTypeSlotIn {
+	return ScopeTypeSlotIn{Slot: slot}
+}
+
+func (s Scope) IsInSlot(entityType types.EntityType, slot types.SlotID) ScopeTypeSlotIsIn {
+	return ScopeTypeSlotIsIn{Type: entityType, Slot: slot}
+}
+
 func (p *Policy) PrincipalEq(entity types.EntityUID) *Policy {
 	p.Principal = Scope{}.Eq(entity)
 	return p
@@ -50,6 +62,21 @@ func (p *Policy) PrincipalIsIn(entityType types.EntityType, entity types.EntityU
 	return p
 }
 
+func (p *Policy) PrincipalEqSlot() *Policy {
+	p.Principal = Scope{}.EqSlot(types.PrincipalSlot)
+	return p
+}
+
+func (p *Policy) PrincipalInSlot() *Policy {
+	p.Principal = Scope{}.InSlot(types.PrincipalSlot)
+	return p
+}
+
+func (p *Policy) PrincipalIsInSlot(entityType types.EntityType) *Policy {
+	p.Principal = Scope{}.IsInSlot(entityType, types.PrincipalSlot)
+	return p
+}
+
 func (p *Policy) ActionEq(entity types.EntityUID) *Policy {
 	p.Action = Scope{}.Eq(entity)
 	return p
@@ -85,6 +112,21 @@ func (p *Policy) ResourceIsIn(entityType types.EntityType, entity types.EntityUI
 	return p
 }
 
+func (p *Policy) ResourceEqSlot() *Policy {
+	p.Resource = Scope{}.EqSlot(types.ResourceSlot)
+	return p
+}
+
+func (p *Policy) ResourceInSlot() *Policy {
+	p.Resource = Scope{}.InSlot(types.ResourceSlot)
+	return p
+}
+
+func (p *Policy) ResourceIsInSlot(entityType types.EntityType) *Policy {
+	p.Resource = Scope{}.IsInSlot(entityType, types.ResourceSlot)
+	return p
+}
+
 type IsScopeNode interface {
 	isScope()
 }
@@ -163,3 +205,25 @@ type ScopeTypeIsIn struct {
 	Type   types.EntityType
 	Entity types.EntityUID
 }
+
+type ScopeTypeSlotEq struct {
+	ScopeNode
+	PrincipalScopeNode
+	ResourceScopeNode
+	Slot types.SlotID
+}
+
+type ScopeTypeSlotIn struct {
+	ScopeNode
+	PrincipalScopeNode
+	ResourceScopeNode
+	Slot types.SlotID
+}
+
+type ScopeTypeSlotIsIn struct {
+	ScopeNode
+	PrincipalScopeNode
+	ResourceScopeNode
+	Type types.EntityType
+	Slot types.SlotID
+}
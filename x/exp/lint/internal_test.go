@@ -0,0 +1,17 @@
+package lint
+
+import (
+	"testing"
+
+	"github.com/cedar-policy/cedar-go/internal/testutil"
+	"github.com/cedar-policy/cedar-go/types"
+	"github.com/cedar-policy/cedar-go/x/exp/ast"
+)
+
+func TestScopeIncludesSlot(t *testing.T) {
+	t.Parallel()
+	slot := ast.ScopeTypeSlotEq{Slot: types.PrincipalSlot}
+	testutil.Equals(t, scopeIncludes(slot, slot), false)
+	testutil.Equals(t, scopeIncludes(ast.ScopeTypeIn{Entity: types.NewEntityUID("User", "alice")}, slot), false)
+	testutil.Equals(t, scopeIncludes(ast.ScopeTypeIs{Type: "User"}, slot), false)
+}
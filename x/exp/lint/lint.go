@@ -0,0 +1,373 @@
+// Package lint finds common mistakes in Cedar policies: conditions which always or never hold, policies which apply
+// to every request, comparisons which can never succeed, and constraints on the principal or action which can never
+// match.  Unlike validation, linting needs no schema, and each finding is a likely mistake rather than a certain one.
+//
+// Linting a set of policies also finds the permit policies which have no effect: those which apply only when another
+// permit policy does, and those which apply only when a forbid policy does.  This analysis is conservative: it compares
+// the scopes of the policies without entity data, and the conditions of the policies as conjunctions of expressions
+// which must be identical, so it does not find every such policy.
+package lint
+
+import (
+	"bytes"
+	"cmp"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+
+	"github.com/cedar-policy/cedar-go"
+	"github.com/cedar-policy/cedar-go/internal/eval"
+	"github.com/cedar-policy/cedar-go/internal/parser"
+	"github.com/cedar-policy/cedar-go/types"
+	"github.com/cedar-policy/cedar-go/x/exp/ast"
+)
+
+// A Rule identifies the kind of mistake which a Finding reports.
+type Rule string
+
+const (
+	// ConstantCondition reports a condition which constant-folds to a value, and so always or never holds.
+	ConstantCondition Rule = "constant-condition"
+	// UnlessFalse reports an `unless { false }` condition, which always holds.
+	UnlessFalse Rule = "unless-false"
+	// Unconstrained reports a policy with no scope constraints and no conditions, which applies to every request.
+	Unconstrained Rule = "unconstrained-policy"
+	// MixedTypeEquality reports `==` or `!=` between literals of different types, which are never equal.
+	MixedTypeEquality Rule = "mixed-type-equality"
+	// LikeWithoutWildcard reports a `like` pattern with no wildcards, which is better written with `==`.
+	LikeWithoutWildcard Rule = "like-without-wildcard"
+	// ActionInNonAction reports `action in` an entity which is not an action.
+	ActionInNonAction Rule = "action-in-non-action"
+	// DuplicateCondition reports a condition which repeats an earlier condition of the same policy.
+	DuplicateCondition Rule = "duplicate-condition"
+	// ActionAsPrincipal reports a constraint which requires the principal to be an action.
+	ActionAsPrincipal Rule = "action-as-principal"
+	// RedundantPermit reports a permit policy which applies only when another permit policy does.
+	RedundantPermit Rule = "redundant-permit"
+	// ShadowedPermit reports a permit policy which applies only when a forbid policy does, and so never allows a
+	// request.
+	ShadowedPermit Rule = "shadowed-permit"
+)
+
+// A Severity is how likely a Finding is to be a mistake.
+type Severity string
+
+const (
+	// Error marks a finding which makes a policy, or one of its conditions, never apply or always fail.
+	Error Severity = "error"
+	// Warning marks a finding which is redundant or suspicious, but does not prevent a policy from applying.
+	Warning Severity = "warning"
+)
+
+// A Finding is a likely mistake in a policy.  Position is the position of the offending condition or expression if the
+// parser recorded it, and otherwise that of the policy.
+type Finding struct {
+	Rule     Rule           `json:"rule"`
+	Severity Severity       `json:"severity"`
+	PolicyID types.PolicyID `json:"policy"`
+	Position types.Position `json:"position"`
+	Message  string         `json:"message"`
+}
+
+func (f Finding) String() string {
+	return fmt.Sprintf("%v:%v:%v: %v: policy `%v`: %v (%v)",
+		f.Position.Filename, f.Position.Line, f.Position.Column, f.Severity, f.PolicyID, f.Message, f.Rule)
+}
+
+// Policies returns the findings for each of the policies, in order of their positions and then of their IDs, including
+// those of the RedundantPermit and ShadowedPermit rules, which compare each policy with the others.
+func Policies(policies cedar.PolicyIterator) []Finding {
+	all := maps.Collect(policies.All())
+	ids := slices.SortedFunc(maps.Keys(all), func(a, b cedar.PolicyID) int {
+		pa, pb := all[a].Position(), all[b].Position()
+		return cmp.Or(strings.Compare(pa.Filename, pb.Filename), cmp.Compare(pa.Offset, pb.Offset), cmp.Compare(a, b))
+	})
+	summaries := make([]summary, len(ids))
+	for i, id := range ids {
+		summaries[i] = summarize(id, (*ast.Policy)(all[id].AST()))
+	}
+	var res []Finding
+	for i, id := range ids {
+		l := linter{id: id, pos: all[id].Position()}
+		l.policy(summaries[i].policy)
+		l.overlaps(summaries, i)
+		res = append(res, l.findings...)
+	}
+	return res
+}
+
+// Policy returns the findings for a policy, apart from those which compare it with other policies.
+func Policy(id types.PolicyID, p *cedar.Policy) []Finding {
+	l := linter{id: id, pos: p.Position()}
+	l.policy((*ast.Policy)(p.AST()))
+	return l.findings
+}
+
+type linter struct {
+	id       types.PolicyID
+	pos      types.Position
+	findings []Finding
+}
+
+// report adds a finding at the position, or at that of the policy if the position is not valid.
+func (l *linter) report(pos ast.Position, rule Rule, severity Severity, format string, args ...any) {
+	at := l.pos
+	if pos.Line > 0 {
+		at = types.Position(pos)
+		at.Filename = l.pos.Filename
+	}
+	l.findings = append(l.findings, Finding{
+		Rule:     rule,
+		Severity: severity,
+		PolicyID: l.id,
+		Position: at,
+		Message:  fmt.Sprintf(format, args...),
+	})
+}
+
+func (l *linter) policy(p *ast.Policy) {
+	_, principalAll := p.Principal.(ast.ScopeTypeAll)
+	_, actionAll := p.Action.(ast.ScopeTypeAll)
+	_, resourceAll := p.Resource.(ast.ScopeTypeAll)
+	if principalAll && actionAll && resourceAll && len(p.Conditions) == 0 {
+		l.report(ast.Position{}, Unconstrained, Warning,
+			"%v policy has no scope constraints or conditions, and so applies to every request", types.Effect(p.Effect))
+	}
+	l.principalScope(p.Principal)
+	l.actionScope(p.Action)
+
+	seen := map[string]bool{}
+	for _, c := range p.Conditions {
+		kind := conditionKind(c.Condition)
+		text := kind + " " + marshalNode(c.Body)
+		if seen[text] {
+			l.report(eval.ConditionPosition(c), DuplicateCondition, Warning, "`%v` condition repeats an earlier one", kind)
+		}
+		seen[text] = true
+		l.condition(c)
+		eval.WalkCondition(c, l.node)
+	}
+}
+
+func (l *linter) principalScope(s ast.IsPrincipalScopeNode) {
+	switch s := s.(type) {
+	case ast.ScopeTypeEq:
+		l.principalEntities(ast.Position{}, "principal ==", []types.EntityUID{s.Entity})
+	case ast.ScopeTypeIn:
+		l.principalEntities(ast.Position{}, "principal in", []types.EntityUID{s.Entity})
+	case ast.ScopeTypeIs:
+		l.principalType(ast.Position{}, "principal is", s.Type)
+	case ast.ScopeTypeIsIn:
+		l.principalType(ast.Position{}, "principal is", s.Type)
+		l.principalEntities(ast.Position{}, "principal in", []types.EntityUID{s.Entity})
+	}
+}
+
+func (l *linter) actionScope(s ast.IsActionScopeNode) {
+	switch s := s.(type) {
+	case ast.ScopeTypeIn:
+		l.actionIn(ast.Position{}, []types.EntityUID{s.Entity})
+	case ast.ScopeTypeInSet:
+		l.actionIn(ast.Position{}, s.Entities)
+	}
+}
+
+func (l *linter) principalEntities(pos ast.Position, op string, entities []types.EntityUID) {
+	for _, e := range entities {
+		if isActionType(e.Type) {
+			l.report(pos, ActionAsPrincipal, Error, "`%v %v` compares the principal with an action", op, e)
+		}
+	}
+}
+
+func (l *linter) principalType(pos ast.Position, op string, t types.EntityType) {
+	if isActionType(t) {
+		l.report(pos, ActionAsPrincipal, Error, "`%v %v` requires the principal to be an action", op, t)
+	}
+}
+
+func (l *linter) actionIn(pos ast.Position, entities []types.EntityUID) {
+	for _, e := range entities {
+		if !isActionType(e.Type) {
+			l.report(pos, ActionInNonAction, Error, "`action in %v` never holds, as actions are only in other actions", e)
+		}
+	}
+}
+
+func (l *linter) condition(c ast.ConditionType) {
+	if isMixedTypeEquality(c.Body) {
+		// node reports the condition as a MixedTypeEquality, which says why it never or always holds.
+		return
+	}
+	kind := conditionKind(c.Condition)
+	v, ok := eval.Fold(c.Body).(ast.NodeValue)
+	if !ok {
+		return
+	}
+	pos := eval.ConditionPosition(c)
+	b, ok := v.Value.(types.Boolean)
+	switch {
+	case !ok:
+		l.report(pos, ConstantCondition, Error,
+			"`%v` condition is always %s, which is not a boolean, so the policy never applies", kind, v.Value.MarshalCedar())
+	case c.Condition == ast.ConditionUnless && isFalse(c.Body):
+		l.report(pos, UnlessFalse, Warning, "`unless { false }` always holds and can be removed")
+	case bool(b) == bool(c.Condition):
+		l.report(pos, ConstantCondition, Warning, "`%v` condition always holds and can be removed", kind)
+	default:
+		l.report(pos, ConstantCondition, Error, "`%v` condition never holds, so the policy never applies", kind)
+	}
+}
+
+// node reports the mistakes within an expression of a condition, at its position.
+func (l *linter) node(n ast.IsNode, pos ast.Position) {
+	switch n := n.(type) {
+	case ast.NodeTypeEquals:
+		l.equality(pos, "==", n.BinaryNode, "false")
+		l.principalEquals(pos, "==", n.BinaryNode)
+	case ast.NodeTypeNotEquals:
+		l.equality(pos, "!=", n.BinaryNode, "true")
+		l.principalEquals(pos, "!=", n.BinaryNode)
+	case ast.NodeTypeLike:
+		if !slices.ContainsFunc(n.Value.Components(), func(c any) bool { return c == types.Wildcard{} }) {
+			l.report(pos, LikeWithoutWildcard, Warning, "`like %s` has no wildcards, and is better written with `==`",
+				n.Value.MarshalCedar())
+		}
+	case ast.NodeTypeIn:
+		switch {
+		case isVariable(n.Left, "action"):
+			l.actionIn(pos, literalEntities(n.Right))
+		case isVariable(n.Left, "principal"):
+			l.principalEntities(pos, "principal in", literalEntities(n.Right))
+		}
+	case ast.NodeTypeIs:
+		if isVariable(n.Left, "principal") {
+			l.principalType(pos, "principal is", n.EntityType)
+		}
+	case ast.NodeTypeIsIn:
+		if isVariable(n.Left, "principal") {
+			l.principalType(pos, "principal is", n.EntityType)
+			l.principalEntities(pos, "principal in", literalEntities(n.Entity))
+		}
+	}
+}
+
+// equality reports an `==` or `!=` between literals of different types.
+func (l *linter) equality(pos ast.Position, op string, n ast.BinaryNode, result string) {
+	if lt, rt, ok := mixedTypes(n); ok {
+		l.report(pos, MixedTypeEquality, Error, "`%v` compares values of types %v and %v, and so is always %v",
+			op, lt, rt, result)
+	}
+}
+
+// principalEquals reports an `==` or `!=` between the principal and an action.
+func (l *linter) principalEquals(pos ast.Position, op string, n ast.BinaryNode) {
+	switch {
+	case isVariable(n.Left, "principal"):
+		l.principalEntities(pos, "principal "+op, literalEntities(n.Right))
+	case isVariable(n.Right, "principal"):
+		l.principalEntities(pos, "principal "+op, literalEntities(n.Left))
+	}
+}
+
+// mixedTypes returns the names of the types of the operands of an `==` or `!=`, if they are literals of different
+// types.
+func mixedTypes(n ast.BinaryNode) (string, string, bool) {
+	left, lok := eval.Fold(n.Left).(ast.NodeValue)
+	right, rok := eval.Fold(n.Right).(ast.NodeValue)
+	if !lok || !rok {
+		return "", "", false
+	}
+	lt, rt := typeName(left.Value), typeName(right.Value)
+	return lt, rt, lt != rt
+}
+
+// isMixedTypeEquality reports whether an expression is an `==` or `!=` between literals of different types.
+func isMixedTypeEquality(n ast.IsNode) bool {
+	var b ast.BinaryNode
+	switch n := n.(type) {
+	case ast.NodeTypeEquals:
+		b = n.BinaryNode
+	case ast.NodeTypeNotEquals:
+		b = n.BinaryNode
+	default:
+		return false
+	}
+	_, _, ok := mixedTypes(b)
+	return ok
+}
+
+func conditionKind(c ast.Condition) string {
+	if c == ast.ConditionWhen {
+		return "when"
+	}
+	return "unless"
+}
+
+func isFalse(n ast.IsNode) bool {
+	v, ok := n.(ast.NodeValue)
+	return ok && v.Value == types.False
+}
+
+func isVariable(n ast.IsNode, name types.String) bool {
+	v, ok := n.(ast.NodeTypeVariable)
+	return ok && v.Name == name
+}
+
+// isActionType reports whether entities of the type are actions: whether its name, without any namespace, is Action.
+func isActionType(t types.EntityType) bool {
+	return t == "Action" || strings.HasSuffix(string(t), "::Action")
+}
+
+// literalEntities returns the entities of an expression which is an entity literal or a set literal, or nil.
+func literalEntities(n ast.IsNode) []types.EntityUID {
+	v, ok := eval.Fold(n).(ast.NodeValue)
+	if !ok {
+		return nil
+	}
+	var res []types.EntityUID
+	switch v := v.Value.(type) {
+	case types.EntityUID:
+		res = append(res, v)
+	case types.Set:
+		for e := range v.All() {
+			if e, ok := e.(types.EntityUID); ok {
+				res = append(res, e)
+			}
+		}
+	}
+	return res
+}
+
+// typeName returns the name of the type of a value, in which all entity types are alike.
+func typeName(v types.Value) string {
+	switch v.(type) {
+	case types.Boolean:
+		return "bool"
+	case types.Long:
+		return "long"
+	case types.String:
+		return "string"
+	case types.EntityUID:
+		return "entity"
+	case types.Set:
+		return "set"
+	case types.Record:
+		return "record"
+	case types.Decimal:
+		return "decimal"
+	case types.IPAddr:
+		return "ipaddr"
+	case types.Datetime:
+		return "datetime"
+	default:
+		return "duration"
+	}
+}
+
+func marshalNode(n ast.IsNode) string {
+	var buf bytes.Buffer
+	parser.MarshalNode(n, &buf)
+	return buf.String()
+}
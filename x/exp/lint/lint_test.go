@@ -0,0 +1,191 @@
+package lint_test
+
+import (
+	"testing"
+
+	"github.com/cedar-policy/cedar-go"
+	"github.com/cedar-policy/cedar-go/internal/testutil"
+	"github.com/cedar-policy/cedar-go/x/exp/lint"
+)
+
+func TestPolicy(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name   string
+		policy string
+		out    []string
+	}{
+		{"clean",
+			`permit(principal == User::"alice", action in Action::"read", resource) when { resource.name like "doc*" };`,
+			nil,
+		},
+		{"unconstrainedPermit",
+			`permit(principal, action, resource);`,
+			[]string{"unconstrained-policy warning: permit policy has no scope constraints or conditions, and so applies to every request"},
+		},
+		{"unconstrainedForbid",
+			`forbid(principal, action, resource);`,
+			[]string{"unconstrained-policy warning: forbid policy has no scope constraints or conditions, and so applies to every request"},
+		},
+		{"whenTrue",
+			`permit(principal, action, resource) when { 1 + 1 == 2 };`,
+			[]string{"constant-condition warning: `when` condition always holds and can be removed"},
+		},
+		{"whenFalse",
+			`permit(principal, action, resource) when { [1, 2].contains(3) };`,
+			[]string{"constant-condition error: `when` condition never holds, so the policy never applies"},
+		},
+		{"unlessTrue",
+			`permit(principal, action, resource) unless { true };`,
+			[]string{"constant-condition error: `unless` condition never holds, so the policy never applies"},
+		},
+		{"unlessFolded",
+			`permit(principal, action, resource) unless { 1 > 2 };`,
+			[]string{"constant-condition warning: `unless` condition always holds and can be removed"},
+		},
+		{"unlessFalse",
+			`permit(principal, action, resource) unless { false };`,
+			[]string{"unless-false warning: `unless { false }` always holds and can be removed"},
+		},
+		{"notBoolean",
+			`permit(principal, action, resource) when { 42 };`,
+			[]string{"constant-condition error: `when` condition is always 42, which is not a boolean, so the policy never applies"},
+		},
+		{"mixedTypeEquality",
+			`permit(principal, action, resource) when { context.a == 1 || "1" == 1 };`,
+			[]string{"mixed-type-equality error: `==` compares values of types string and long, and so is always false"},
+		},
+		{"mixedTypeInequality",
+			`permit(principal, action, resource) when { context.a && [1] != {a: 1} };`,
+			[]string{"mixed-type-equality error: `!=` compares values of types set and record, and so is always true"},
+		},
+		{"mixedTypeWholeCondition",
+			`permit(principal, action, resource) when { 1 == "a" };`,
+			[]string{"mixed-type-equality error: `==` compares values of types long and string, and so is always false"},
+		},
+		{"mixedTypeInequalityWholeCondition",
+			`permit(principal, action, resource) unless { [1] != {a: 1} };`,
+			[]string{"mixed-type-equality error: `!=` compares values of types set and record, and so is always true"},
+		},
+		{"mixedTypeEqualityAllTypes",
+			`permit(principal, action, resource) when { context.a && (true == User::"a" || decimal("1.0") == ip("::1") || datetime("2024-01-01") == duration("1h")) };`,
+			[]string{
+				"mixed-type-equality error: `==` compares values of types bool and entity, and so is always false",
+				"mixed-type-equality error: `==` compares values of types decimal and ipaddr, and so is always false",
+				"mixed-type-equality error: `==` compares values of types datetime and duration, and so is always false",
+			},
+		},
+		{"sameTypeEquality",
+			`permit(principal, action, resource) when { context.a && User::"a" != Group::"a" };`,
+			nil,
+		},
+		{"likeWithoutWildcard",
+			`permit(principal, action, resource) when { resource.name like "doc" };`,
+			[]string{"like-without-wildcard warning: `like \"doc\"` has no wildcards, and is better written with `==`"},
+		},
+		{"actionScopeIn",
+			`permit(principal, action in Group::"admins", resource);`,
+			[]string{"action-in-non-action error: `action in Group::\"admins\"` never holds, as actions are only in other actions"},
+		},
+		{"actionScopeInSet",
+			`permit(principal, action in [Action::"read", Photos::Action::"view", Group::"admins"], resource);`,
+			[]string{"action-in-non-action error: `action in Group::\"admins\"` never holds, as actions are only in other actions"},
+		},
+		{"actionConditionIn",
+			`permit(principal, action, resource) when { action in [Group::"admins"] };`,
+			[]string{"action-in-non-action error: `action in Group::\"admins\"` never holds, as actions are only in other actions"},
+		},
+		{"duplicateCondition",
+			`permit(principal, action, resource) when { context.a } unless { context.a } when { context.a };`,
+			[]string{"duplicate-condition warning: `when` condition repeats an earlier one"},
+		},
+		{"principalScopeEq",
+			`permit(principal == Action::"read", action, resource);`,
+			[]string{"action-as-principal error: `principal == Action::\"read\"` compares the principal with an action"},
+		},
+		{"principalScopeIn",
+			`permit(principal in Action::"read", action, resource);`,
+			[]string{"action-as-principal error: `principal in Action::\"read\"` compares the principal with an action"},
+		},
+		{"principalScopeIs",
+			`permit(principal is Action, action, resource);`,
+			[]string{"action-as-principal error: `principal is Action` requires the principal to be an action"},
+		},
+		{"principalScopeIsIn",
+			`permit(principal is Action in Action::"read", action, resource);`,
+			[]string{
+				"action-as-principal error: `principal is Action` requires the principal to be an action",
+				"action-as-principal error: `principal in Action::\"read\"` compares the principal with an action",
+			},
+		},
+		{"principalConditions",
+			`permit(principal, action, resource) when {
+	principal == Action::"a" || Action::"b" != principal || principal in [Action::"c", 1] ||
+	principal is Action || principal is User in Action::"d" || context.a == principal
+};`,
+			[]string{
+				"action-as-principal error: `principal == Action::\"a\"` compares the principal with an action",
+				"action-as-principal error: `principal != Action::\"b\"` compares the principal with an action",
+				"action-as-principal error: `principal in Action::\"c\"` compares the principal with an action",
+				"action-as-principal error: `principal is Action` requires the principal to be an action",
+				"action-as-principal error: `principal in Action::\"d\"` compares the principal with an action",
+			},
+		},
+		{"resourceConditions",
+			`permit(principal, action, resource) when { resource in Action::"a" && resource is Action && resource is Action in Action::"a" && resource in context.a };`,
+			nil,
+		},
+		{"nested",
+			`permit(principal, action, resource) when {
+	if context.a then {a: [context.b like "x"]}.a.contains(-context.c) else
+	context has d && context.e.isEmpty() && context.f.hasTag("x" == 1) && context.g.getTag("y") < 1 + 2 - 3 * 4 &&
+	context.h <= 1 && context.i > 1 && context.j >= 1 && context.k.containsAll([]) && context.l.containsAny([]) &&
+	!(principal in [Action::"x"])
+};`,
+			[]string{
+				"like-without-wildcard warning: `like \"x\"` has no wildcards, and is better written with `==`",
+				"mixed-type-equality error: `==` compares values of types string and long, and so is always false",
+				"action-as-principal error: `principal in Action::\"x\"` compares the principal with an action",
+			},
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			var p cedar.Policy
+			testutil.OK(t, p.UnmarshalCedar([]byte(tt.policy)))
+			var out []string
+			for _, f := range lint.Policy("policy0", &p) {
+				out = append(out, string(f.Rule)+" "+string(f.Severity)+": "+f.Message)
+			}
+			testutil.Equals(t, out, tt.out)
+		})
+	}
+}
+
+func TestPolicies(t *testing.T) {
+	t.Parallel()
+	ps, err := cedar.NewPolicySetFromBytes("policy.cedar", []byte(`permit(principal == User::"alice", action, resource);
+permit(principal, action, resource);
+forbid(principal, action, resource) unless { false };
+`))
+	testutil.OK(t, err)
+	other, err := cedar.NewPolicySetFromBytes("other.cedar", []byte(`forbid(principal, action, resource);`))
+	testutil.OK(t, err)
+	ps.Add("zzz", other.Get("policy0"))
+
+	var out []string
+	for _, f := range lint.Policies(ps) {
+		out = append(out, f.String())
+	}
+	testutil.Equals(t, out, []string{
+		"other.cedar:1:1: warning: policy `zzz`: forbid policy has no scope constraints or conditions, and so applies to every request (unconstrained-policy)",
+		"policy.cedar:1:1: warning: policy `policy0`: permit policy applies only when permit policy `policy1` does, and so is redundant (redundant-permit)",
+		"policy.cedar:1:1: error: policy `policy0`: permit policy applies only when forbid policy `zzz` does, and so never allows a request (shadowed-permit)",
+		"policy.cedar:2:1: warning: policy `policy1`: permit policy has no scope constraints or conditions, and so applies to every request (unconstrained-policy)",
+		"policy.cedar:2:1: error: policy `policy1`: permit policy applies only when forbid policy `zzz` does, and so never allows a request (shadowed-permit)",
+		"policy.cedar:3:46: warning: policy `policy2`: `unless { false }` always holds and can be removed (unless-false)",
+	})
+	testutil.Equals(t, lint.Policies(cedar.NewPolicySet()), nil)
+}
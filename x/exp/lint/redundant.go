@@ -0,0 +1,162 @@
+package lint
+
+import (
+	"slices"
+
+	"github.com/cedar-policy/cedar-go/internal/eval"
+	"github.com/cedar-policy/cedar-go/types"
+	"github.com/cedar-policy/cedar-go/x/exp/ast"
+)
+
+// A summary holds what is needed to decide whether a policy applies whenever another does: its scope, and its
+// conditions as a set of conjuncts, each of which must evaluate to true for the policy to apply.
+type summary struct {
+	id        types.PolicyID
+	policy    *ast.Policy
+	conjuncts map[string]bool
+}
+
+func summarize(id types.PolicyID, p *ast.Policy) summary {
+	s := summary{id: id, policy: p, conjuncts: map[string]bool{}}
+	for _, c := range p.Conditions {
+		if c.Condition == ast.ConditionWhen {
+			s.addConjuncts(c.Body)
+		} else {
+			s.addConjuncts(negate(c.Body))
+		}
+	}
+	return s
+}
+
+// addConjuncts splits an expression into the expressions which must all evaluate to true for it to, and adds their
+// Cedar text, after constant folding, leaving out those which are always true.
+func (s summary) addConjuncts(n ast.IsNode) {
+	switch n := n.(type) {
+	case ast.NodeTypeAnd:
+		s.addConjuncts(n.Left)
+		s.addConjuncts(n.Right)
+		return
+	case ast.NodeTypeNot:
+		if or, ok := n.Arg.(ast.NodeTypeOr); ok {
+			s.addConjuncts(negate(or.Left))
+			s.addConjuncts(negate(or.Right))
+			return
+		}
+	}
+	n = eval.Fold(n)
+	if v, ok := n.(ast.NodeValue); ok && v.Value == types.True {
+		return
+	}
+	s.conjuncts[marshalNode(n)] = true
+}
+
+// covers reports whether the policy of s applies whenever that of o does: whether its scope includes that of o, and
+// each of its conjuncts is one of those of o.
+func (s summary) covers(o summary) bool {
+	if !scopeIncludes(s.policy.Principal, o.policy.Principal) ||
+		!scopeIncludes(s.policy.Action, o.policy.Action) ||
+		!scopeIncludes(s.policy.Resource, o.policy.Resource) {
+		return false
+	}
+	for c := range s.conjuncts {
+		if !o.conjuncts[c] {
+			return false
+		}
+	}
+	return true
+}
+
+// overlaps reports whether the permit policy summarized by summaries[i] is redundant with another permit policy, or
+// shadowed by a forbid policy.  Of two permit policies which cover each other, only the later is reported.
+func (l *linter) overlaps(summaries []summary, i int) {
+	s := summaries[i]
+	if s.policy.Effect != ast.EffectPermit {
+		return
+	}
+	for j, o := range summaries {
+		if j != i && o.policy.Effect == ast.EffectPermit && o.covers(s) && (j < i || !s.covers(o)) {
+			l.report(ast.Position{}, RedundantPermit, Warning,
+				"permit policy applies only when permit policy `%v` does, and so is redundant", o.id)
+			break
+		}
+	}
+	for _, o := range summaries {
+		if o.policy.Effect == ast.EffectForbid && o.covers(s) {
+			l.report(ast.Position{}, ShadowedPermit, Error,
+				"permit policy applies only when forbid policy `%v` does, and so never allows a request", o.id)
+			break
+		}
+	}
+}
+
+// scopeIncludes reports whether each entity which matches the scope constraint b also matches a, which is decided
+// without knowing the ancestors of any entity.
+func scopeIncludes(a, b ast.IsScopeNode) bool {
+	switch a := a.(type) {
+	case ast.ScopeTypeAll:
+		return true
+	case ast.ScopeTypeEq:
+		b, ok := b.(ast.ScopeTypeEq)
+		return ok && b.Entity == a.Entity
+	case ast.ScopeTypeIn:
+		entities, ok := scopeEntities(b)
+		return ok && allIn(entities, []types.EntityUID{a.Entity})
+	case ast.ScopeTypeInSet:
+		entities, ok := scopeEntities(b)
+		return ok && allIn(entities, a.Entities)
+	case ast.ScopeTypeIs:
+		t, ok := scopeEntityType(b)
+		return ok && t == a.Type
+	case ast.ScopeTypeIsIn:
+		t, ok := scopeEntityType(b)
+		entities, eok := scopeEntities(b)
+		return ok && eok && t == a.Type && allIn(entities, []types.EntityUID{a.Entity})
+	}
+	return false
+}
+
+// scopeEntities returns the entities of a scope constraint which requires the entity to be in one of them, including
+// by being equal to one of them.
+func scopeEntities(s ast.IsScopeNode) ([]types.EntityUID, bool) {
+	switch s := s.(type) {
+	case ast.ScopeTypeEq:
+		return []types.EntityUID{s.Entity}, true
+	case ast.ScopeTypeIn:
+		return []types.EntityUID{s.Entity}, true
+	case ast.ScopeTypeInSet:
+		return s.Entities, true
+	case ast.ScopeTypeIsIn:
+		return []types.EntityUID{s.Entity}, true
+	}
+	return nil, false
+}
+
+// scopeEntityType returns the entity type which a scope constraint requires the entity to have.
+func scopeEntityType(s ast.IsScopeNode) (types.EntityType, bool) {
+	switch s := s.(type) {
+	case ast.ScopeTypeEq:
+		return s.Entity.Type, true
+	case ast.ScopeTypeIs:
+		return s.Type, true
+	case ast.ScopeTypeIsIn:
+		return s.Type, true
+	}
+	return "", false
+}
+
+func allIn(entities, set []types.EntityUID) bool {
+	for _, e := range entities {
+		if !slices.Contains(set, e) {
+			return false
+		}
+	}
+	return true
+}
+
+// negate returns the negation of an expression, removing a double negation.
+func negate(n ast.IsNode) ast.IsNode {
+	if n, ok := n.(ast.NodeTypeNot); ok {
+		return n.Arg
+	}
+	return ast.NodeTypeNot{UnaryNode: ast.UnaryNode{Arg: n}}
+}
@@ -0,0 +1,110 @@
+package lint_test
+
+import (
+	"testing"
+
+	"github.com/cedar-policy/cedar-go"
+	"github.com/cedar-policy/cedar-go/internal/testutil"
+	"github.com/cedar-policy/cedar-go/x/exp/lint"
+)
+
+func TestRedundantAndShadowed(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name     string
+		policies string
+		out      []string
+	}{
+		{"distinct",
+			`permit(principal == User::"alice", action, resource);
+permit(principal == User::"bob", action, resource);`,
+			nil,
+		},
+		{"broaderScope",
+			`permit(principal == User::"alice", action == Action::"view", resource is Doc in Folder::"a");
+permit(principal in Group::"staff", action, resource in Folder::"a");
+permit(principal == User::"alice", action in [Action::"view", Action::"edit"], resource in Folder::"a");`,
+			[]string{
+				"policy0 redundant-permit: permit policy applies only when permit policy `policy2` does, and so is redundant",
+			},
+		},
+		{"weakerConditions",
+			`permit(principal, action == Action::"view", resource) when { resource.public && context.mfa } unless { principal.suspended };
+permit(principal, action == Action::"view", resource) when { context.mfa && 1 + 1 == 2 } unless { principal.suspended || false };
+permit(principal, action == Action::"view", resource) when { resource.public && context.sso };
+permit(principal, action == Action::"view", resource) when { context.sso } unless { !resource.public };`,
+			[]string{
+				"policy0 redundant-permit: permit policy applies only when permit policy `policy1` does, and so is redundant",
+				"policy3 redundant-permit: permit policy applies only when permit policy `policy2` does, and so is redundant",
+			},
+		},
+		{"equivalentReportsLater",
+			`permit(principal == User::"alice", action, resource) when { context.a };
+permit(principal == User::"alice", action, resource) when { context.a };`,
+			[]string{
+				"policy1 redundant-permit: permit policy applies only when permit policy `policy0` does, and so is redundant",
+			},
+		},
+		{"shadowed",
+			`permit(principal is User, action == Action::"delete", resource) when { !context.mfa };
+forbid(principal, action == Action::"delete", resource) unless { context.mfa };
+forbid(principal, action == Action::"delete", resource) unless { context.admin };`,
+			[]string{
+				"policy0 shadowed-permit: permit policy applies only when forbid policy `policy1` does, and so never allows a request",
+			},
+		},
+		{"narrowerForbid",
+			`permit(principal, action in Action::"write", resource);
+forbid(principal, action == Action::"delete", resource);
+forbid(principal, action in [Action::"delete"], resource);
+forbid(principal, action in Action::"write", resource) when { context.readOnly };`,
+			nil,
+		},
+		{"actionSets",
+			`permit(principal, action in [Action::"write"], resource);
+permit(principal, action in [Action::"write", Action::"read"], resource);
+forbid(principal, action in Action::"write", resource);`,
+			[]string{
+				"policy0 redundant-permit: permit policy applies only when permit policy `policy1` does, and so is redundant",
+				"policy0 shadowed-permit: permit policy applies only when forbid policy `policy2` does, and so never allows a request",
+			},
+		},
+		{"scopeLattice",
+			`permit(principal == User::"alice", action, resource == Doc::"a");
+permit(principal is User, action, resource is Doc in Folder::"a");
+permit(principal in User::"alice", action, resource in Doc::"a");
+forbid(principal is Admin in Group::"x", action, resource is Doc);
+forbid(principal is User in Group::"x", action, resource == Doc::"b");
+forbid(principal is User in User::"alice", action, resource in Doc::"a");`,
+			[]string{
+				"policy0 redundant-permit: permit policy applies only when permit policy `policy2` does, and so is redundant",
+				"policy0 shadowed-permit: permit policy applies only when forbid policy `policy5` does, and so never allows a request",
+			},
+		},
+		{"isIn",
+			`permit(principal is User in Group::"x", action, resource);
+permit(principal == User::"alice", action, resource);
+permit(principal is User in User::"alice", action, resource);
+permit(principal is User in Group::"x", action, resource) when { context.a };`,
+			[]string{
+				"policy1 redundant-permit: permit policy applies only when permit policy `policy2` does, and so is redundant",
+				"policy3 redundant-permit: permit policy applies only when permit policy `policy0` does, and so is redundant",
+			},
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			ps, err := cedar.NewPolicySetFromBytes("policy.cedar", []byte(tt.policies))
+			testutil.OK(t, err)
+			var out []string
+			for _, f := range lint.Policies(ps) {
+				if f.Rule == lint.RedundantPermit || f.Rule == lint.ShadowedPermit {
+					out = append(out, string(f.PolicyID)+" "+string(f.Rule)+": "+f.Message)
+				}
+			}
+			testutil.Equals(t, out, tt.out)
+		})
+	}
+}
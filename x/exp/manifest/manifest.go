@@ -0,0 +1,453 @@
+// Package manifest analyzes a set of policies to find the entity data which they may read when authorizing a request,
+// and fetches just that data for a request.  This lets an application with a large entity graph load, for each
+// request, a small slice of it rather than all of it.
+//
+// The analysis is conservative: the slice it fetches holds every entity which the policies read when authorizing the
+// request, and so the decision and diagnostic are the same as when authorizing with all of the entities.
+package manifest
+
+import (
+	"context"
+	"maps"
+	"slices"
+
+	"github.com/cedar-policy/cedar-go"
+	"github.com/cedar-policy/cedar-go/internal/consts"
+	"github.com/cedar-policy/cedar-go/types"
+	"github.com/cedar-policy/cedar-go/x/exp/ast"
+)
+
+// An Access describes the data which may be read from a value, e.g. `principal` or `resource.owner`.  When the value is
+// an entity, the entity must be loaded if its ancestors, attributes or tags may be read.  When the value is a record,
+// only its attributes may be read, and the record itself need not be loaded.
+type Access struct {
+	// Ancestors reports whether the ancestors of the entity may be read, e.g. by `in`.
+	Ancestors bool
+	// Attributes holds, by name, the attributes which may be read and what may be read from their values.
+	Attributes map[types.String]*Access
+	// Tags, if not nil, describes what may be read from any of the tags of the entity.
+	Tags *Access
+}
+
+// Reads reports whether any data may be read from the value, i.e. whether an entity must be loaded.
+func (a *Access) Reads() bool {
+	return a.Ancestors || len(a.Attributes) > 0 || a.Tags != nil
+}
+
+func (a *Access) attribute(name types.String) *Access {
+	if a.Attributes == nil {
+		a.Attributes = map[types.String]*Access{}
+	}
+	res, ok := a.Attributes[name]
+	if !ok {
+		res = &Access{}
+		a.Attributes[name] = res
+	}
+	return res
+}
+
+func (a *Access) tags() *Access {
+	if a.Tags == nil {
+		a.Tags = &Access{}
+	}
+	return a.Tags
+}
+
+// merge adds what may be read according to o to a.
+func (a *Access) merge(o *Access) {
+	a.Ancestors = a.Ancestors || o.Ancestors
+	for name, oa := range o.Attributes {
+		a.attribute(name).merge(oa)
+	}
+	if o.Tags != nil {
+		a.tags().merge(o.Tags)
+	}
+}
+
+// A Slice describes the data which policies may read from a request and from the entities which they name.
+type Slice struct {
+	Principal Access
+	Action    Access
+	Resource  Access
+	Context   Access
+	// Entities holds what may be read from the entities which the policies name, e.g. `Group::"admins".members`.
+	Entities map[types.EntityUID]*Access
+}
+
+func (s *Slice) entity(uid types.EntityUID) *Access {
+	if s.Entities == nil {
+		s.Entities = map[types.EntityUID]*Access{}
+	}
+	res, ok := s.Entities[uid]
+	if !ok {
+		res = &Access{}
+		s.Entities[uid] = res
+	}
+	return res
+}
+
+func (s *Slice) merge(o *Slice) {
+	s.Principal.merge(&o.Principal)
+	s.Action.merge(&o.Action)
+	s.Resource.merge(&o.Resource)
+	s.Context.merge(&o.Context)
+	for uid, oa := range o.Entities {
+		s.entity(uid).merge(oa)
+	}
+}
+
+// A Manifest describes, for each action, the data which a set of policies may read when authorizing a request for the
+// action.
+type Manifest struct {
+	// Actions holds, by action, the data read by the policies whose action scope names the action, either as the
+	// action of the request or as one of its ancestors.
+	Actions map[types.EntityUID]*Slice
+	// All holds the data read by the policies whose action scope does not name an action.
+	All Slice
+}
+
+// New analyzes the policies.
+func New(policies cedar.PolicyIterator) *Manifest {
+	m := &Manifest{Actions: map[types.EntityUID]*Slice{}}
+	for _, p := range policies.All() {
+		var s Slice
+		pa := (*ast.Policy)(p.AST())
+		analyzePolicy(&s, pa)
+		actions := scopeEntities(pa.Action)
+		if actions == nil {
+			m.All.merge(&s)
+			continue
+		}
+		for _, a := range actions {
+			as, ok := m.Actions[a]
+			if !ok {
+				as = &Slice{}
+				m.Actions[a] = as
+			}
+			as.merge(&s)
+		}
+	}
+	return m
+}
+
+// ForAction returns the data which the policies may read when authorizing a request for the action, given the
+// ancestors of the action.
+func (m *Manifest) ForAction(action types.EntityUID, ancestors types.EntityUIDSet) *Slice {
+	res := &Slice{}
+	res.merge(&m.All)
+	if s, ok := m.Actions[action]; ok {
+		res.merge(s)
+	}
+	for a := range ancestors.All() {
+		if s, ok := m.Actions[a]; ok {
+			res.merge(s)
+		}
+	}
+	return res
+}
+
+// Fetch loads, with the loader, the entities which the policies may read when authorizing the request.  Entities are
+// loaded in rounds, following the attributes which the policies read from the entities loaded in earlier rounds; if
+// the loader is an EntityBatchLoader, each round is loaded at once.  Entities which are not found are left out of the
+// returned EntityMap, as are the ancestors of entities whose ancestors are not read.
+func (m *Manifest) Fetch(ctx context.Context, req cedar.Request, loader types.EntityLoader) (types.EntityMap, error) {
+	f := fetcher{
+		loader:    loader,
+		entities:  types.EntityMap{},
+		loaded:    map[types.EntityUID]bool{},
+		done:      map[wanted]bool{},
+		ancestors: &Access{Ancestors: true},
+	}
+
+	// The ancestors of the action determine which policies may apply.
+	f.want(req.Action, f.ancestors)
+	if err := f.run(ctx); err != nil {
+		return nil, err
+	}
+	var ancestors []types.EntityUID
+	for _, e := range f.entities {
+		ancestors = slices.AppendSeq(ancestors, e.Parents.All())
+	}
+	s := m.ForAction(req.Action, types.NewEntityUIDSet(ancestors...))
+
+	f.want(req.Principal, &s.Principal)
+	f.want(req.Action, &s.Action)
+	f.want(req.Resource, &s.Resource)
+	f.wantValue(req.Context, &s.Context)
+	for uid, a := range s.Entities {
+		f.want(uid, a)
+	}
+	if err := f.run(ctx); err != nil {
+		return nil, err
+	}
+	return f.entities, nil
+}
+
+// A fetcher loads entities in rounds, and reads the data described by an Access from each entity once it is loaded.
+type fetcher struct {
+	loader   types.EntityLoader
+	entities types.EntityMap
+	loaded   map[types.EntityUID]bool
+	pending  []wanted
+	done     map[wanted]bool
+	// ancestors is the Access of the ancestors of entities whose ancestors may be read.
+	ancestors *Access
+}
+
+type wanted struct {
+	uid    types.EntityUID
+	access *Access
+}
+
+func (f *fetcher) want(uid types.EntityUID, a *Access) {
+	if a.Reads() {
+		f.pending = append(f.pending, wanted{uid: uid, access: a})
+	}
+}
+
+func (f *fetcher) wantValue(v types.Value, a *Access) {
+	switch v := v.(type) {
+	case types.EntityUID:
+		f.want(v, a)
+	case types.Record:
+		for name, aa := range a.Attributes {
+			if av, ok := v.Get(name); ok {
+				f.wantValue(av, aa)
+			}
+		}
+	}
+}
+
+func (f *fetcher) run(ctx context.Context) error {
+	for len(f.pending) > 0 {
+		round := f.pending
+		f.pending = nil
+		if err := f.load(ctx, round); err != nil {
+			return err
+		}
+		for _, w := range round {
+			if f.done[w] {
+				continue
+			}
+			f.done[w] = true
+			e, ok := f.entities[w.uid]
+			if !ok {
+				continue
+			}
+			if w.access.Ancestors {
+				for p := range e.Parents.All() {
+					f.want(p, f.ancestors)
+				}
+			}
+			for name, a := range w.access.Attributes {
+				if v, ok := e.Attributes.Get(name); ok {
+					f.wantValue(v, a)
+				}
+			}
+			if w.access.Tags != nil {
+				for _, v := range e.Tags.All() {
+					f.wantValue(v, w.access.Tags)
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// load loads the entities of the round which have not been loaded already.
+func (f *fetcher) load(ctx context.Context, round []wanted) error {
+	var uids []types.EntityUID
+	for _, w := range round {
+		if !f.loaded[w.uid] {
+			f.loaded[w.uid] = true
+			uids = append(uids, w.uid)
+		}
+	}
+	if bl, ok := f.loader.(types.EntityBatchLoader); ok && len(uids) > 0 {
+		entities, err := bl.LoadMany(ctx, uids)
+		if err != nil {
+			return err
+		}
+		maps.Copy(f.entities, entities)
+		return nil
+	}
+	for _, uid := range uids {
+		e, ok, err := f.loader.Load(ctx, uid)
+		if err != nil {
+			return err
+		}
+		if ok {
+			f.entities[uid] = e
+		}
+	}
+	return nil
+}
+
+// scopeEntities returns the entities which a scope constraint names, or nil if it names none.
+func scopeEntities(n ast.IsScopeNode) []types.EntityUID {
+	switch n := n.(type) {
+	case ast.ScopeTypeEq:
+		return []types.EntityUID{n.Entity}
+	case ast.ScopeTypeIn:
+		return []types.EntityUID{n.Entity}
+	case ast.ScopeTypeInSet:
+		return n.Entities
+	default:
+		return nil
+	}
+}
+
+func analyzePolicy(s *Slice, p *ast.Policy) {
+	analyzeScope(&s.Principal, p.Principal)
+	analyzeScope(&s.Action, p.Action)
+	analyzeScope(&s.Resource, p.Resource)
+	for _, c := range p.Conditions {
+		analyze(s, c.Body)
+	}
+}
+
+func analyzeScope(a *Access, n ast.IsScopeNode) {
+	switch n.(type) {
+	case ast.ScopeTypeIn, ast.ScopeTypeInSet, ast.ScopeTypeIsIn:
+		a.Ancestors = true
+	}
+}
+
+// analyze adds the data which evaluating the expression may read to the slice, and returns the Accesses of the values
+// which the expression may evaluate to, if they are values from which data may be read.
+//
+//nolint:revive // due to the number of node types
+func analyze(s *Slice, n ast.IsNode) []*Access {
+	switch n := n.(type) {
+	case ast.NodeTypeVariable:
+		switch n.Name {
+		case consts.Principal:
+			return []*Access{&s.Principal}
+		case consts.Action:
+			return []*Access{&s.Action}
+		case consts.Resource:
+			return []*Access{&s.Resource}
+		default:
+			return []*Access{&s.Context}
+		}
+	case ast.NodeValue:
+		return valueAccesses(s, n.Value)
+	case ast.NodeTypeAccess:
+		if r, ok := n.Arg.(ast.NodeTypeRecord); ok {
+			for _, e := range r.Elements {
+				if e.Key != n.Value {
+					analyze(s, e.Value)
+				}
+			}
+			for _, e := range r.Elements {
+				if e.Key == n.Value {
+					return analyze(s, e.Value)
+				}
+			}
+			return nil
+		}
+		if v, ok := n.Arg.(ast.NodeValue); ok {
+			if r, ok := v.Value.(types.Record); ok {
+				av, _ := r.Get(n.Value)
+				return valueAccesses(s, av)
+			}
+		}
+		var res []*Access
+		for _, a := range analyze(s, n.Arg) {
+			res = append(res, a.attribute(n.Value))
+		}
+		return res
+	case ast.NodeTypeHas:
+		for _, a := range analyze(s, n.Arg) {
+			a.attribute(n.Value)
+		}
+	case ast.NodeTypeGetTag:
+		analyze(s, n.Right)
+		var res []*Access
+		for _, a := range analyze(s, n.Left) {
+			res = append(res, a.tags())
+		}
+		return res
+	case ast.NodeTypeHasTag:
+		analyze(s, n.Right)
+		for _, a := range analyze(s, n.Left) {
+			a.tags()
+		}
+	case ast.NodeTypeIn:
+		analyze(s, n.Right)
+		readAncestors(analyze(s, n.Left))
+	case ast.NodeTypeIsIn:
+		analyze(s, n.Entity)
+		readAncestors(analyze(s, n.Left))
+	case ast.NodeTypeIfThenElse:
+		analyze(s, n.If)
+		return append(analyze(s, n.Then), analyze(s, n.Else)...)
+	case ast.NodeTypeIs:
+		analyze(s, n.Left)
+	case ast.NodeTypeLike:
+		analyze(s, n.Arg)
+	case ast.NodeTypeRecord:
+		for _, e := range n.Elements {
+			analyze(s, e.Value)
+		}
+	case ast.NodeTypeSet:
+		analyzeAll(s, n.Elements...)
+	case ast.NodeTypeExtensionCall:
+		analyzeAll(s, n.Args...)
+	case ast.NodeTypeNegate:
+		analyze(s, n.Arg)
+	case ast.NodeTypeNot:
+		analyze(s, n.Arg)
+	case ast.NodeTypeIsEmpty:
+		analyze(s, n.Arg)
+	case ast.NodeTypeAnd:
+		analyzeAll(s, n.Left, n.Right)
+	case ast.NodeTypeOr:
+		analyzeAll(s, n.Left, n.Right)
+	case ast.NodeTypeEquals:
+		analyzeAll(s, n.Left, n.Right)
+	case ast.NodeTypeNotEquals:
+		analyzeAll(s, n.Left, n.Right)
+	case ast.NodeTypeLessThan:
+		analyzeAll(s, n.Left, n.Right)
+	case ast.NodeTypeLessThanOrEqual:
+		analyzeAll(s, n.Left, n.Right)
+	case ast.NodeTypeGreaterThan:
+		analyzeAll(s, n.Left, n.Right)
+	case ast.NodeTypeGreaterThanOrEqual:
+		analyzeAll(s, n.Left, n.Right)
+	case ast.NodeTypeAdd:
+		analyzeAll(s, n.Left, n.Right)
+	case ast.NodeTypeSub:
+		analyzeAll(s, n.Left, n.Right)
+	case ast.NodeTypeMult:
+		analyzeAll(s, n.Left, n.Right)
+	case ast.NodeTypeContains:
+		analyzeAll(s, n.Left, n.Right)
+	case ast.NodeTypeContainsAll:
+		analyzeAll(s, n.Left, n.Right)
+	case ast.NodeTypeContainsAny:
+		analyzeAll(s, n.Left, n.Right)
+	}
+	return nil
+}
+
+func analyzeAll(s *Slice, nodes ...ast.IsNode) {
+	for _, n := range nodes {
+		analyze(s, n)
+	}
+}
+
+// valueAccesses returns the Access of a literal value, if it is an entity.
+func valueAccesses(s *Slice, v types.Value) []*Access {
+	if uid, ok := v.(types.EntityUID); ok {
+		return []*Access{s.entity(uid)}
+	}
+	return nil
+}
+
+func readAncestors(accesses []*Access) {
+	for _, a := range accesses {
+		a.Ancestors = true
+	}
+}
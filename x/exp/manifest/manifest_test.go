@@ -0,0 +1,300 @@
+package manifest_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/cedar-policy/cedar-go"
+	"github.com/cedar-policy/cedar-go/ast"
+	"github.com/cedar-policy/cedar-go/internal/testutil"
+	"github.com/cedar-policy/cedar-go/types"
+	"github.com/cedar-policy/cedar-go/x/exp/manifest"
+)
+
+const policies = `
+permit(principal in Group::"staff", action == Action::"view", resource) when { resource.owner.team == principal.team };
+permit(principal, action in Action::"write", resource) when { resource.getTag("editor") == principal && context.doc.public };
+forbid(principal, action, resource) when { principal has locked && principal.locked };
+permit(principal, action == Action::"admin", resource) when { principal in Group::"admins".delegate };
+permit(principal, action == Action::"view", resource is Doc in Folder::"public");
+`
+
+func TestNew(t *testing.T) {
+	t.Parallel()
+	ps, err := cedar.NewPolicySetFromBytes("policy.cedar", []byte(policies))
+	testutil.OK(t, err)
+	m := manifest.New(ps)
+
+	locked := &manifest.Access{Attributes: map[types.String]*manifest.Access{"locked": {}}}
+	testutil.Equals(t, m.All, manifest.Slice{Principal: *locked})
+	testutil.Equals(t, len(m.Actions), 3)
+	testutil.Equals(t, m.Actions[cedar.NewEntityUID("Action", "view")], &manifest.Slice{
+		Principal: manifest.Access{Ancestors: true, Attributes: map[types.String]*manifest.Access{"team": {}}},
+		Resource: manifest.Access{Ancestors: true, Attributes: map[types.String]*manifest.Access{
+			"owner": {Attributes: map[types.String]*manifest.Access{"team": {}}},
+		}},
+	})
+	testutil.Equals(t, m.Actions[cedar.NewEntityUID("Action", "write")], &manifest.Slice{
+		Action:   manifest.Access{Ancestors: true},
+		Resource: manifest.Access{Tags: &manifest.Access{}},
+		Context: manifest.Access{Attributes: map[types.String]*manifest.Access{
+			"doc": {Attributes: map[types.String]*manifest.Access{"public": {}}},
+		}},
+	})
+	testutil.Equals(t, m.Actions[cedar.NewEntityUID("Action", "admin")], &manifest.Slice{
+		Principal: manifest.Access{Ancestors: true},
+		Entities: map[types.EntityUID]*manifest.Access{
+			cedar.NewEntityUID("Group", "admins"): {Attributes: map[types.String]*manifest.Access{"delegate": {}}},
+		},
+	})
+
+	view := m.ForAction(cedar.NewEntityUID("Action", "view"), types.NewEntityUIDSet(cedar.NewEntityUID("Action", "write")))
+	testutil.Equals(t, view.Action.Ancestors, true)
+	testutil.Equals(t, view.Resource.Tags != nil, true)
+	testutil.Equals(t, slices.Sorted(maps.Keys(view.Principal.Attributes)), []types.String{"locked", "team"})
+	testutil.Equals(t, view.Principal.Reads(), true)
+	testutil.Equals(t, view.Action.Attributes == nil, true)
+}
+
+func TestAnalysis(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name   string
+		policy string
+		want   manifest.Slice
+	}{
+		{"entity", `permit(principal, action, resource) when { User::"alice".x };`,
+			manifest.Slice{Entities: map[types.EntityUID]*manifest.Access{
+				cedar.NewEntityUID("User", "alice"): {Attributes: map[types.String]*manifest.Access{"x": {}}},
+			}}},
+		{"none", `permit(principal == User::"alice", action, resource is Doc) when { context.n + 1 > 2 };`,
+			manifest.Slice{Context: manifest.Access{Attributes: map[types.String]*manifest.Access{"n": {}}}}},
+		{"scope", `permit(principal is User in Group::"g", action in [Action::"a"], resource in Folder::"f");`,
+			manifest.Slice{Principal: manifest.Access{Ancestors: true}, Action: manifest.Access{Ancestors: true}, Resource: manifest.Access{Ancestors: true}}},
+		{"ifThenElse", `permit(principal, action, resource) when { (if context.b then principal else resource).x };`,
+			manifest.Slice{
+				Principal: manifest.Access{Attributes: map[types.String]*manifest.Access{"x": {}}},
+				Resource:  manifest.Access{Attributes: map[types.String]*manifest.Access{"x": {}}},
+				Context:   manifest.Access{Attributes: map[types.String]*manifest.Access{"b": {}}},
+			}},
+		{"recordLiteral", `permit(principal, action, resource) when { {a: principal, b: resource.y}.a.x };`,
+			manifest.Slice{
+				Principal: manifest.Access{Attributes: map[types.String]*manifest.Access{"x": {}}},
+				Resource:  manifest.Access{Attributes: map[types.String]*manifest.Access{"y": {}}},
+			}},
+		{"record", `permit(principal, action, resource) when { {a: principal.x} == context };`,
+			manifest.Slice{Principal: manifest.Access{Attributes: map[types.String]*manifest.Access{"x": {}}}}},
+		{"action", `permit(principal, action, resource) when { action.x };`,
+			manifest.Slice{Action: manifest.Access{Attributes: map[types.String]*manifest.Access{"x": {}}}}},
+		{"recordLiteralMissing", `permit(principal, action, resource) when { {a: principal}.b };`,
+			manifest.Slice{}},
+		{"hasTag", `permit(principal, action, resource) when { principal.hasTag(resource.k) };`,
+			manifest.Slice{
+				Principal: manifest.Access{Tags: &manifest.Access{}},
+				Resource:  manifest.Access{Attributes: map[types.String]*manifest.Access{"k": {}}},
+			}},
+		{"getTag", `permit(principal, action, resource) when { principal.getTag("t").x in resource };`,
+			manifest.Slice{Principal: manifest.Access{Tags: &manifest.Access{Attributes: map[types.String]*manifest.Access{"x": {Ancestors: true}}}}}},
+		{"isIn", `permit(principal, action, resource) when { principal.x is User in resource.y };`,
+			manifest.Slice{
+				Principal: manifest.Access{Attributes: map[types.String]*manifest.Access{"x": {Ancestors: true}}},
+				Resource:  manifest.Access{Attributes: map[types.String]*manifest.Access{"y": {}}},
+			}},
+		{"operators", `permit(principal, action, resource) when {
+			!(-principal.a < 1 || principal.b <= 1 || principal.c > 1 || principal.d >= 1 || principal.e * 2 == 1 ||
+			  principal.f - 1 != 1 || principal.g is User || principal.h like "*" || principal.i.isEmpty() ||
+			  principal.j.contains(1) || principal.k.containsAll([principal.l]) || principal.m.containsAny([]) ||
+			  ip(principal.n).isIpv4())
+		};`,
+			manifest.Slice{Principal: manifest.Access{Attributes: map[types.String]*manifest.Access{
+				"a": {}, "b": {}, "c": {}, "d": {}, "e": {}, "f": {}, "g": {}, "h": {}, "i": {}, "j": {}, "k": {}, "l": {},
+				"m": {}, "n": {},
+			}}}},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			ps, err := cedar.NewPolicySetFromBytes("policy.cedar", []byte(tt.policy))
+			testutil.OK(t, err)
+			m := manifest.New(ps)
+			testutil.Equals(t, m.ForAction(cedar.NewEntityUID("Action", "a"), types.EntityUIDSet{}), &tt.want)
+		})
+	}
+
+	t.Run("recordValue", func(t *testing.T) {
+		t.Parallel()
+		alice := cedar.NewEntityUID("User", "alice")
+		record := cedar.NewRecord(cedar.RecordMap{"a": alice, "b": cedar.Long(1)})
+		ps := cedar.NewPolicySet()
+		ps.Add("entity", cedar.NewPolicyFromAST(ast.Permit().When(ast.Value(record).Access("a").Access("x"))))
+		ps.Add("long", cedar.NewPolicyFromAST(ast.Permit().When(ast.Value(record).Access("b").Access("x"))))
+		m := manifest.New(ps)
+		testutil.Equals(t, m.All, manifest.Slice{Entities: map[types.EntityUID]*manifest.Access{
+			alice: {Attributes: map[types.String]*manifest.Access{"x": {}}},
+		}})
+	})
+}
+
+var errOutage = errors.New("database outage")
+
+// testLoader is an EntityLoader which fails to load the entities of one type.
+type testLoader struct {
+	entities cedar.EntityMap
+	failing  types.EntityType
+}
+
+func (l *testLoader) Load(_ context.Context, uid cedar.EntityUID) (cedar.Entity, bool, error) {
+	if uid.Type == l.failing {
+		return cedar.Entity{}, false, errOutage
+	}
+	e, ok := l.entities[uid]
+	return e, ok, nil
+}
+
+// testBatchLoader is a testLoader which loads each round of entities at once.
+type testBatchLoader struct {
+	testLoader
+	rounds int
+}
+
+func (l *testBatchLoader) LoadMany(ctx context.Context, uids []cedar.EntityUID) (cedar.EntityMap, error) {
+	l.rounds++
+	res := cedar.EntityMap{}
+	for _, uid := range uids {
+		e, ok, err := l.Load(ctx, uid)
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			res[uid] = e
+		}
+	}
+	return res, nil
+}
+
+func testEntities() cedar.EntityMap {
+	res := cedar.EntityMap{}
+	add := func(typ, id string, parents []cedar.EntityUID, attrs cedar.RecordMap, tags cedar.RecordMap) {
+		uid := cedar.NewEntityUID(cedar.EntityType(typ), cedar.String(id))
+		res[uid] = cedar.Entity{UID: uid, Parents: cedar.NewEntityUIDSet(parents...), Attributes: cedar.NewRecord(attrs), Tags: cedar.NewRecord(tags)}
+	}
+	uid := cedar.NewEntityUID
+	add("Action", "view", nil, nil, nil)
+	add("Action", "edit", []cedar.EntityUID{uid("Action", "write")}, nil, nil)
+	add("Action", "write", nil, nil, nil)
+	add("Group", "staff", []cedar.EntityUID{uid("Group", "all")}, nil, nil)
+	add("Group", "all", nil, nil, nil)
+	add("Group", "admins", nil, cedar.RecordMap{"delegate": uid("Group", "staff")}, nil)
+	add("Team", "red", nil, cedar.RecordMap{"size": cedar.Long(3)}, nil)
+	add("Team", "blue", nil, nil, nil)
+	add("User", "alice", []cedar.EntityUID{uid("Group", "staff")}, cedar.RecordMap{"team": uid("Team", "red"), "locked": cedar.False}, nil)
+	add("User", "bob", nil, cedar.RecordMap{"team": uid("Team", "blue"), "manager": uid("User", "alice")}, nil)
+	add("User", "carol", []cedar.EntityUID{uid("Group", "admins")}, cedar.RecordMap{"locked": cedar.True}, nil)
+	add("Folder", "public", nil, nil, nil)
+	add("Doc", "plan", []cedar.EntityUID{uid("Folder", "public")}, cedar.RecordMap{"owner": uid("User", "bob")},
+		cedar.RecordMap{"editor": uid("User", "alice")})
+	add("Doc", "secret", nil, cedar.RecordMap{"owner": uid("User", "alice")}, nil)
+	for i := range 100 {
+		add("User", fmt.Sprint("other", i), []cedar.EntityUID{uid("Group", "all")}, cedar.RecordMap{"team": uid("Team", "red")}, nil)
+	}
+	return res
+}
+
+func sortedUIDs(m cedar.EntityMap) []string {
+	var res []string
+	for uid := range m {
+		res = append(res, uid.String())
+	}
+	slices.Sort(res)
+	return res
+}
+
+func TestFetch(t *testing.T) {
+	t.Parallel()
+	ps, err := cedar.NewPolicySetFromBytes("policy.cedar", []byte(policies))
+	testutil.OK(t, err)
+	m := manifest.New(ps)
+	entities := testEntities()
+	uid := cedar.NewEntityUID
+
+	t.Run("Slice", func(t *testing.T) {
+		t.Parallel()
+		l := &testLoader{entities: entities}
+		got, err := m.Fetch(context.Background(), cedar.Request{
+			Principal: uid("User", "alice"),
+			Action:    uid("Action", "view"),
+			Resource:  uid("Doc", "plan"),
+		}, l)
+		testutil.OK(t, err)
+		testutil.Equals(t, sortedUIDs(got), []string{
+			`Action::"view"`,
+			`Doc::"plan"`,
+			`Folder::"public"`,
+			`Group::"all"`,
+			`Group::"staff"`,
+			`User::"alice"`,
+			`User::"bob"`,
+		})
+	})
+
+	var reqs []cedar.Request
+	for _, principal := range []string{"alice", "bob", "carol", "other1", "nobody"} {
+		for _, action := range []string{"view", "edit", "admin"} {
+			for _, resource := range []string{"plan", "secret", "missing"} {
+				reqs = append(reqs, cedar.Request{
+					Principal: uid("User", cedar.String(principal)),
+					Action:    uid("Action", cedar.String(action)),
+					Resource:  uid("Doc", cedar.String(resource)),
+					Context: cedar.NewRecord(cedar.RecordMap{
+						"doc": cedar.NewRecord(cedar.RecordMap{"public": cedar.True}),
+					}),
+				})
+			}
+		}
+	}
+	for _, req := range reqs {
+		t.Run(fmt.Sprintf("%v/%v/%v", req.Principal, req.Action, req.Resource), func(t *testing.T) {
+			t.Parallel()
+			l := &testBatchLoader{testLoader: testLoader{entities: entities}}
+			got, err := m.Fetch(context.Background(), req, l)
+			testutil.OK(t, err)
+			testutil.Equals(t, len(got) < 20, true)
+			testutil.Equals(t, l.rounds <= 5, true)
+
+			want, wantDiag := cedar.Authorize(ps, entities, req)
+			decision, diag := cedar.Authorize(ps, got, req)
+			testutil.Equals(t, decision, want)
+			testutil.Equals(t, sortedDiagnostic(diag), sortedDiagnostic(wantDiag))
+		})
+	}
+
+	t.Run("Error", func(t *testing.T) {
+		t.Parallel()
+		for _, l := range []types.EntityLoader{
+			&testLoader{entities: entities, failing: "Action"},
+			&testLoader{entities: entities, failing: "User"},
+			&testBatchLoader{testLoader: testLoader{entities: entities, failing: "Action"}},
+		} {
+			_, err := m.Fetch(context.Background(), reqs[0], l)
+			testutil.ErrorIs(t, err, errOutage)
+		}
+	})
+}
+
+func sortedDiagnostic(d cedar.Diagnostic) string {
+	var res []string
+	for _, r := range d.Reasons {
+		res = append(res, "reason "+string(r.PolicyID))
+	}
+	for _, e := range d.Errors {
+		res = append(res, e.String())
+	}
+	slices.Sort(res)
+	return strings.Join(res, "\n")
+}
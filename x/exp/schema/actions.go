@@ -0,0 +1,136 @@
+package schema
+
+import (
+	"context"
+
+	"github.com/cedar-policy/cedar-go/types"
+)
+
+// ActionEntities returns an entity for each action the schema declares, whose parents are the action groups the
+// schema declares it a member of.  Policies which test for membership in an action group, e.g.
+// `action in Action::"readOnly"`, need these entities to be present in the entities passed to cedar.Authorize.
+func ActionEntities(s *Schema) (types.EntityMap, error) {
+	rs, err := s.resolve()
+	if err != nil {
+		return nil, err
+	}
+	return rs.actionEntities(), nil
+}
+
+func (s *resolvedSchema) actionEntities() types.EntityMap {
+	res := make(types.EntityMap, len(s.actions))
+	for uid, a := range s.actions {
+		res[uid] = types.Entity{UID: uid, Parents: types.NewEntityUIDSet(a.memberOf...)}
+	}
+	return res
+}
+
+// WithActionEntities returns an EntityGetter which returns the action entities of the schema, as ActionEntities does,
+// and otherwise the entities of the given EntityGetter.  The schema's definition of an action takes precedence over an
+// entity with the same UID in entities.  The EntityGetter is a types.EntityLoader, types.EntityBatchLoader or
+// types.AncestorChecker if entities is, so that cedar.Authorize uses them as it would entities.
+func WithActionEntities(s *Schema, entities types.EntityGetter) (types.EntityGetter, error) {
+	rs, err := s.resolve()
+	if err != nil {
+		return nil, err
+	}
+	return withActionEntities(rs.actionEntities(), entities), nil
+}
+
+// withActionEntities is WithActionEntities with the action entities already computed.
+func withActionEntities(actions types.EntityMap, entities types.EntityGetter) types.EntityGetter {
+	g := actionEntityGetter{actions: actions, entities: entities}
+	switch e := entities.(type) {
+	case types.EntityBatchLoader:
+		return actionEntityBatchLoader{actionEntityLoader{g, e}, e}
+	case types.EntityLoader:
+		return actionEntityLoader{g, e}
+	case types.AncestorChecker:
+		return actionAncestorChecker{g, e}
+	}
+	return g
+}
+
+type actionEntityGetter struct {
+	actions  types.EntityMap
+	entities types.EntityGetter
+}
+
+func (g actionEntityGetter) Get(uid types.EntityUID) (types.Entity, bool) {
+	if e, ok := g.actions[uid]; ok {
+		return e, true
+	}
+	if g.entities == nil {
+		return types.Entity{}, false
+	}
+	return g.entities.Get(uid)
+}
+
+type actionEntityLoader struct {
+	actionEntityGetter
+	loader types.EntityLoader
+}
+
+func (l actionEntityLoader) Load(ctx context.Context, uid types.EntityUID) (types.Entity, bool, error) {
+	if e, ok := l.actions[uid]; ok {
+		return e, true, nil
+	}
+	return l.loader.Load(ctx, uid)
+}
+
+type actionEntityBatchLoader struct {
+	actionEntityLoader
+	batchLoader types.EntityBatchLoader
+}
+
+func (l actionEntityBatchLoader) LoadMany(ctx context.Context, uids []types.EntityUID) (types.EntityMap, error) {
+	res := types.EntityMap{}
+	var rest []types.EntityUID
+	for _, uid := range uids {
+		if e, ok := l.actions[uid]; ok {
+			res[uid] = e
+		} else {
+			rest = append(rest, uid)
+		}
+	}
+	if len(rest) == 0 {
+		return res, nil
+	}
+	loaded, err := l.batchLoader.LoadMany(ctx, rest)
+	if err != nil {
+		return nil, err
+	}
+	for uid, e := range loaded {
+		res[uid] = e
+	}
+	return res, nil
+}
+
+type actionAncestorChecker struct {
+	actionEntityGetter
+	checker types.AncestorChecker
+}
+
+// IsAncestor follows the action groups of an action declared by the schema, whose ancestors are only actions, and
+// otherwise asks the AncestorChecker.
+func (c actionAncestorChecker) IsAncestor(entity, ancestor types.EntityUID) bool {
+	if _, ok := c.actions[entity]; !ok {
+		return c.checker.IsAncestor(entity, ancestor)
+	}
+	seen := map[types.EntityUID]bool{entity: true}
+	todo := []types.EntityUID{entity}
+	for len(todo) > 0 {
+		uid := todo[len(todo)-1]
+		todo = todo[:len(todo)-1]
+		for p := range c.actions[uid].Parents.All() {
+			if p == ancestor {
+				return true
+			}
+			if !seen[p] {
+				seen[p] = true
+				todo = append(todo, p)
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,203 @@
+package schema_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/cedar-policy/cedar-go"
+	"github.com/cedar-policy/cedar-go/internal/testutil"
+	"github.com/cedar-policy/cedar-go/types"
+	"github.com/cedar-policy/cedar-go/x/exp/schema"
+)
+
+const actionsSchema = `entity User;
+entity Doc;
+action readOnly;
+action read in [readOnly] appliesTo { principal: User, resource: Doc };
+action list in ["read", Other::Action::"browse"] appliesTo { principal: User, resource: Doc };
+namespace Other {
+  action browse;
+}
+`
+
+func TestActionEntities(t *testing.T) {
+	t.Parallel()
+	var s schema.Schema
+	testutil.OK(t, s.UnmarshalCedar([]byte(actionsSchema)))
+	entities, err := schema.ActionEntities(&s)
+	testutil.OK(t, err)
+	readOnly := types.NewEntityUID("Action", "readOnly")
+	read := types.NewEntityUID("Action", "read")
+	list := types.NewEntityUID("Action", "list")
+	browse := types.NewEntityUID("Other::Action", "browse")
+	testutil.Equals(t, entities, types.EntityMap{
+		readOnly: {UID: readOnly, Parents: types.NewEntityUIDSet()},
+		read:     {UID: read, Parents: types.NewEntityUIDSet(readOnly)},
+		list:     {UID: list, Parents: types.NewEntityUIDSet(read, browse)},
+		browse:   {UID: browse, Parents: types.NewEntityUIDSet()},
+	})
+
+	var bad schema.Schema
+	testutil.OK(t, bad.UnmarshalCedar([]byte(`action a in [b];`)))
+	_, err = schema.ActionEntities(&bad)
+	testutil.Error(t, err)
+	_, err = schema.WithActionEntities(&bad, nil)
+	testutil.Error(t, err)
+}
+
+func TestWithActionEntities(t *testing.T) {
+	t.Parallel()
+	var s schema.Schema
+	testutil.OK(t, s.UnmarshalCedar([]byte(actionsSchema)))
+	ps, err := cedar.NewPolicySetFromBytes("policy.cedar", []byte(`permit(principal, action in Action::"readOnly", resource);`))
+	testutil.OK(t, err)
+	alice := types.NewEntityUID("User", "alice")
+	list := types.NewEntityUID("Action", "list")
+	req := types.Request{Principal: alice, Action: list, Resource: types.NewEntityUID("Doc", "d")}
+
+	decision, _ := cedar.Authorize(ps, types.EntityMap{}, req)
+	testutil.Equals(t, decision, types.Deny)
+
+	entities, err := schema.WithActionEntities(&s, types.EntityMap{
+		alice: {UID: alice},
+		list:  {UID: list},
+	})
+	testutil.OK(t, err)
+	decision, _ = cedar.Authorize(ps, entities, req)
+	testutil.Equals(t, decision, types.Allow)
+	e, ok := entities.Get(alice)
+	testutil.Equals(t, ok, true)
+	testutil.Equals(t, e.UID, alice)
+	_, ok = entities.Get(types.NewEntityUID("User", "bob"))
+	testutil.Equals(t, ok, false)
+
+	entities, err = schema.WithActionEntities(&s, nil)
+	testutil.OK(t, err)
+	_, ok = entities.Get(alice)
+	testutil.Equals(t, ok, false)
+
+	a, err := schema.NewAuthorizer(&s, ps)
+	testutil.OK(t, err)
+	decision, _, err = a.Authorize(nil, req)
+	testutil.OK(t, err)
+	testutil.Equals(t, decision, types.Allow)
+}
+
+var errOutage = errors.New("database outage")
+
+// testLoader loads entities from a map, failing for those which are not in it.
+type testLoader struct {
+	types.EntityMap
+}
+
+func (l testLoader) Load(_ context.Context, uid types.EntityUID) (types.Entity, bool, error) {
+	e, ok := l.EntityMap[uid]
+	if !ok {
+		return types.Entity{}, false, errOutage
+	}
+	return e, true, nil
+}
+
+type testBatchLoader struct {
+	testLoader
+}
+
+func (l testBatchLoader) LoadMany(_ context.Context, uids []types.EntityUID) (types.EntityMap, error) {
+	res := types.EntityMap{}
+	for _, uid := range uids {
+		e, ok := l.EntityMap[uid]
+		if !ok {
+			return nil, errOutage
+		}
+		res[uid] = e
+	}
+	return res, nil
+}
+
+func TestWithActionEntitiesInterfaces(t *testing.T) {
+	t.Parallel()
+	var s schema.Schema
+	testutil.OK(t, s.UnmarshalCedar([]byte(actionsSchema)))
+	ctx := context.Background()
+	alice := types.NewEntityUID("User", "alice")
+	group := types.NewEntityUID("Group", "g")
+	bob := types.NewEntityUID("User", "bob")
+	readOnly := types.NewEntityUID("Action", "readOnly")
+	read := types.NewEntityUID("Action", "read")
+	list := types.NewEntityUID("Action", "list")
+	browse := types.NewEntityUID("Other::Action", "browse")
+	entities := types.EntityMap{
+		alice: {UID: alice, Parents: types.NewEntityUIDSet(group)},
+		group: {UID: group},
+	}
+
+	t.Run("loader", func(t *testing.T) {
+		t.Parallel()
+		g, err := schema.WithActionEntities(&s, testLoader{entities})
+		testutil.OK(t, err)
+		l, ok := g.(types.EntityLoader)
+		testutil.Equals(t, ok, true)
+		_, isBatch := g.(types.EntityBatchLoader)
+		testutil.Equals(t, isBatch, false)
+		e, found, err := l.Load(ctx, read)
+		testutil.OK(t, err)
+		testutil.Equals(t, found, true)
+		testutil.Equals(t, e.Parents, types.NewEntityUIDSet(readOnly))
+		e, found, err = l.Load(ctx, alice)
+		testutil.OK(t, err)
+		testutil.Equals(t, found, true)
+		testutil.Equals(t, e.UID, alice)
+		_, _, err = l.Load(ctx, bob)
+		testutil.ErrorIs(t, err, errOutage)
+	})
+
+	t.Run("batchLoader", func(t *testing.T) {
+		t.Parallel()
+		g, err := schema.WithActionEntities(&s, testBatchLoader{testLoader{entities}})
+		testutil.OK(t, err)
+		l, ok := g.(types.EntityBatchLoader)
+		testutil.Equals(t, ok, true)
+		loaded, err := l.LoadMany(ctx, []types.EntityUID{read, alice})
+		testutil.OK(t, err)
+		testutil.Equals(t, loaded, types.EntityMap{
+			read:  {UID: read, Parents: types.NewEntityUIDSet(readOnly)},
+			alice: entities[alice],
+		})
+		loaded, err = l.LoadMany(ctx, []types.EntityUID{read})
+		testutil.OK(t, err)
+		testutil.Equals(t, len(loaded), 1)
+		_, err = l.LoadMany(ctx, []types.EntityUID{read, bob})
+		testutil.ErrorIs(t, err, errOutage)
+		_, _, err = l.Load(ctx, bob)
+		testutil.ErrorIs(t, err, errOutage)
+	})
+
+	t.Run("ancestorChecker", func(t *testing.T) {
+		t.Parallel()
+		store, err := types.NewEntityStore(entities)
+		testutil.OK(t, err)
+		g, err := schema.WithActionEntities(&s, store)
+		testutil.OK(t, err)
+		_, isLoader := g.(types.EntityLoader)
+		testutil.Equals(t, isLoader, false)
+		c, ok := g.(types.AncestorChecker)
+		testutil.Equals(t, ok, true)
+		testutil.Equals(t, c.IsAncestor(alice, group), true)
+		testutil.Equals(t, c.IsAncestor(group, alice), false)
+		testutil.Equals(t, c.IsAncestor(list, readOnly), true)
+		testutil.Equals(t, c.IsAncestor(list, browse), true)
+		testutil.Equals(t, c.IsAncestor(readOnly, list), false)
+		testutil.Equals(t, c.IsAncestor(list, group), false)
+	})
+
+	t.Run("getter", func(t *testing.T) {
+		t.Parallel()
+		g, err := schema.WithActionEntities(&s, entities)
+		testutil.OK(t, err)
+		_, isLoader := g.(types.EntityLoader)
+		testutil.Equals(t, isLoader, false)
+		_, isChecker := g.(types.AncestorChecker)
+		testutil.Equals(t, isChecker, false)
+	})
+}
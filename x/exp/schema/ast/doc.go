@@ -0,0 +1,24 @@
+/*
+Package ast exposes the declaration AST of a Cedar schema.  This AST is subject
+to change.  It is produced by both the human-readable and the JSON schema
+parsers and is the common model that the two formats are converted through.
+
+The AST records declarations as they were written: type names are not
+resolved, so a reference such as `User` or `Long` is kept as a [TypeRef]
+until the schema is resolved against its namespaces.
+
+Example:
+
+	import (
+		"github.com/cedar-policy/cedar-go/x/exp/schema"
+	)
+
+	func main() {
+		var s schema.Schema
+		_ = s.UnmarshalCedar([]byte(`entity User;`))
+		for _, ns := range s.AST().Namespaces {
+			_ = ns
+		}
+	}
+*/
+package ast
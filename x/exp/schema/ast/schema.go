@@ -0,0 +1,112 @@
+package ast
+
+import (
+	"github.com/cedar-policy/cedar-go/types"
+)
+
+// Schema is the root of a Cedar schema.  Declarations which are not enclosed
+// in a namespace are held in a Namespace with an empty Name.
+type Schema struct {
+	Namespaces []*Namespace
+}
+
+// Namespace returns the namespace with the given name, or nil if the schema
+// does not declare it.
+func (s *Schema) Namespace(name types.Path) *Namespace {
+	for _, ns := range s.Namespaces {
+		if ns.Name == name {
+			return ns
+		}
+	}
+	return nil
+}
+
+type Annotation struct {
+	Key   types.Ident
+	Value types.String
+}
+
+type Namespace struct {
+	Name        types.Path
+	Annotations []Annotation
+	Entities    []*Entity
+	Actions     []*Action
+	CommonTypes []*CommonType
+	Position    types.Position
+}
+
+// Entity returns the entity type declaration with the given name, or nil.
+func (n *Namespace) Entity(name types.Ident) *Entity {
+	for _, e := range n.Entities {
+		if e.Name == name {
+			return e
+		}
+	}
+	return nil
+}
+
+// Action returns the action declaration with the given name, or nil.
+func (n *Namespace) Action(name types.String) *Action {
+	for _, a := range n.Actions {
+		if a.Name == name {
+			return a
+		}
+	}
+	return nil
+}
+
+// CommonType returns the common type declaration with the given name, or nil.
+func (n *Namespace) CommonType(name types.Ident) *CommonType {
+	for _, c := range n.CommonTypes {
+		if c.Name == name {
+			return c
+		}
+	}
+	return nil
+}
+
+// Entity declares an entity type.  Shape is nil when no attributes are
+// declared, otherwise it is a RecordType or a reference to a common type
+// which is a record.  Enum is non-nil only for enumerated entity types, which
+// cannot declare a shape, tags or parents.
+type Entity struct {
+	Name        types.Ident
+	Annotations []Annotation
+	MemberOf    []types.Path
+	Shape       IsType
+	Tags        IsType
+	Enum        []types.String
+	Position    types.Position
+}
+
+// Action declares an action.  An action with a nil AppliesTo cannot be used
+// in any request.
+type Action struct {
+	Name        types.String
+	Annotations []Annotation
+	MemberOf    []ActionRef
+	AppliesTo   *AppliesTo
+	Position    types.Position
+}
+
+// ActionRef refers to an action group.  An empty Type refers to an action in
+// the enclosing namespace.
+type ActionRef struct {
+	Type types.Path
+	ID   types.String
+}
+
+// AppliesTo lists the principal and resource entity types an action may be
+// used with.  A nil Context is the same as an empty record.
+type AppliesTo struct {
+	Principals []types.Path
+	Resources  []types.Path
+	Context    IsType
+}
+
+type CommonType struct {
+	Name        types.Ident
+	Annotations []Annotation
+	Type        IsType
+	Position    types.Position
+}
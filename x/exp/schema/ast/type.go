@@ -0,0 +1,94 @@
+package ast
+
+import (
+	"github.com/cedar-policy/cedar-go/types"
+)
+
+type IsType interface {
+	isType()
+}
+
+type BooleanType struct{}
+
+func (BooleanType) isType() { _ = 0 } // No-op statement injected for code coverage instrumentation
+
+type LongType struct{}
+
+func (LongType) isType() { _ = 0 } // No-op statement injected for code coverage instrumentation
+
+type StringType struct{}
+
+func (StringType) isType() { _ = 0 } // No-op statement injected for code coverage instrumentation
+
+// ExtensionType is one of the extension types, e.g. `ipaddr` or `decimal`.
+type ExtensionType struct {
+	Name types.Ident
+}
+
+func (ExtensionType) isType() { _ = 0 } // No-op statement injected for code coverage instrumentation
+
+type SetType struct {
+	Element IsType
+}
+
+func (SetType) isType() { _ = 0 } // No-op statement injected for code coverage instrumentation
+
+type RecordType struct {
+	Attributes []Attribute
+}
+
+func (RecordType) isType() { _ = 0 } // No-op statement injected for code coverage instrumentation
+
+// Attribute returns the attribute with the given name and true, or false if
+// the record does not declare it.
+func (r RecordType) Attribute(name types.String) (Attribute, bool) {
+	for _, a := range r.Attributes {
+		if a.Name == name {
+			return a, true
+		}
+	}
+	return Attribute{}, false
+}
+
+type Attribute struct {
+	Name        types.String
+	Type        IsType
+	Optional    bool
+	Annotations []Annotation
+	Position    types.Position
+}
+
+// EntityTypeRef is a reference which is known to name an entity type.
+type EntityTypeRef struct {
+	Name types.Path
+}
+
+func (EntityTypeRef) isType() { _ = 0 } // No-op statement injected for code coverage instrumentation
+
+// TypeRef is a reference to a common type, an entity type or a built-in type
+// which is resolved relative to the namespace in which it appears.
+type TypeRef struct {
+	Name types.Path
+}
+
+func (TypeRef) isType() { _ = 0 } // No-op statement injected for code coverage instrumentation
+
+// CedarNamespace is the reserved namespace which holds the built-in types, e.g. `__cedar::Long`.
+const CedarNamespace = "__cedar"
+
+var builtinTypes = map[types.Ident]IsType{
+	"Bool":     BooleanType{},
+	"Boolean":  BooleanType{},
+	"Long":     LongType{},
+	"String":   StringType{},
+	"ipaddr":   ExtensionType{Name: "ipaddr"},
+	"decimal":  ExtensionType{Name: "decimal"},
+	"datetime": ExtensionType{Name: "datetime"},
+	"duration": ExtensionType{Name: "duration"},
+}
+
+// BuiltinType returns the built-in primitive or extension type with the given unqualified name.
+func BuiltinType(name types.Ident) (IsType, bool) {
+	t, ok := builtinTypes[name]
+	return t, ok
+}
@@ -0,0 +1,298 @@
+package schema
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+	"strconv"
+
+	"github.com/cedar-policy/cedar-go/types"
+	"github.com/cedar-policy/cedar-go/x/exp/schema/ast"
+)
+
+// A DecodeError details a problem found while decoding entities or a context according to a schema.  UID identifies
+// the entity being decoded and is zero when decoding a context.  Path locates the offending value, as in EntityError
+// and RequestError.
+type DecodeError struct {
+	UID     types.EntityUID
+	Path    string
+	Message string
+}
+
+func (e DecodeError) Error() string {
+	var sb bytes.Buffer
+	sb.WriteString("error decoding ")
+	if e.UID.IsZero() {
+		sb.WriteString("context")
+	} else {
+		fmt.Fprintf(&sb, "entity `%v`", e.UID)
+	}
+	if e.Path != "" {
+		fmt.Fprintf(&sb, " at `%v`", e.Path)
+	}
+	sb.WriteString(": " + e.Message)
+	return sb.String()
+}
+
+type entityJSON struct {
+	UID     types.EntityUID   `json:"uid"`
+	Parents []types.EntityUID `json:"parents"`
+	Attrs   json.RawMessage   `json:"attrs"`
+	Tags    json.RawMessage   `json:"tags"`
+}
+
+// UnmarshalEntities decodes a JSON array of entities, in the format accepted by types.EntityMap.UnmarshalJSON, using the
+// schema to determine the type of each attribute and tag.  Extension values may be written as bare strings, e.g.
+// "10.0.0.1" for an ipaddr attribute, and entity references may omit the `__entity` escape.  The decoded entities
+// must conform to the schema as ValidateEntities checks; if they do not, the returned error joins a DecodeError for
+// each problem.
+func UnmarshalEntities(s *Schema, b []byte) (types.EntityMap, error) {
+	rs, err := s.resolve()
+	if err != nil {
+		return nil, err
+	}
+	var raw []json.RawMessage
+	if err := json.Unmarshal(b, &raw); err != nil {
+		return nil, err
+	}
+	res := make(types.EntityMap, len(raw))
+	for _, r := range raw {
+		e, err := rs.decodeEntity(r)
+		if err != nil {
+			return nil, err
+		}
+		if _, ok := res[e.UID]; ok {
+			return nil, DecodeError{UID: e.UID, Message: "duplicate entity"}
+		}
+		res[e.UID] = e
+	}
+	return res, nil
+}
+
+// UnmarshalEntity decodes a single JSON entity as UnmarshalEntities does.
+func UnmarshalEntity(s *Schema, b []byte) (types.Entity, error) {
+	rs, err := s.resolve()
+	if err != nil {
+		return types.Entity{}, err
+	}
+	return rs.decodeEntity(b)
+}
+
+// UnmarshalContext decodes a JSON context record for a request for the given action, using the action's context type
+// to determine the type of each attribute.  The decoded context must conform to the context type as ValidateRequest
+// checks; if it does not, the returned error joins a DecodeError for each problem.
+func UnmarshalContext(s *Schema, action types.EntityUID, b []byte) (types.Record, error) {
+	rs, err := s.resolve()
+	if err != nil {
+		return types.Record{}, err
+	}
+	a, ok := rs.actions[action]
+	if !ok {
+		return types.Record{}, DecodeError{Message: fmt.Sprintf("unknown action %v", action)}
+	}
+	var context ast.RecordType
+	if a.appliesTo != nil {
+		context = a.appliesTo.context
+	}
+	d := decoder{}
+	v, err := d.value(b, context, "context")
+	if err != nil {
+		return types.Record{}, err
+	}
+	c := valueChecker{}
+	c.record(v.(types.Record), context, "context")
+	return v.(types.Record), joinDecodeErrors(c.errors)
+}
+
+func (s *resolvedSchema) decodeEntity(b []byte) (types.Entity, error) {
+	var ej entityJSON
+	dec := json.NewDecoder(bytes.NewReader(b))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&ej); err != nil {
+		return types.Entity{}, err
+	}
+	if ej.UID.IsZero() {
+		return types.Entity{}, errors.New("error decoding entity: missing uid")
+	}
+	res := types.Entity{UID: ej.UID, Parents: types.NewEntityUIDSet(ej.Parents...)}
+	d := decoder{uid: ej.UID}
+	var shape ast.RecordType
+	var tags ast.IsType
+	if e, ok := s.entities[ej.UID.Type]; ok {
+		shape, tags = e.shape, e.tags
+	}
+	if len(ej.Attrs) > 0 {
+		attrs, err := d.value(ej.Attrs, shape, "")
+		if err != nil {
+			return types.Entity{}, err
+		}
+		res.Attributes = attrs.(types.Record)
+	}
+	if len(ej.Tags) > 0 {
+		var raw map[types.String]json.RawMessage
+		if err := json.Unmarshal(ej.Tags, &raw); err != nil {
+			return types.Entity{}, DecodeError{UID: ej.UID, Message: "tags: expected an object, got " + jsonKind(ej.Tags)}
+		}
+		m := make(types.RecordMap, len(raw))
+		for _, k := range slices.Sorted(maps.Keys(raw)) {
+			var err error
+			if m[k], err = d.value(raw[k], tags, "getTag("+strconv.Quote(string(k))+")"); err != nil {
+				return types.Entity{}, err
+			}
+		}
+		res.Tags = types.NewRecord(m)
+	}
+	return res, joinDecodeErrors(s.validateEntity(res))
+}
+
+func joinDecodeErrors(errs []EntityError) error {
+	var res []error
+	for _, e := range errs {
+		res = append(res, DecodeError(e))
+	}
+	return errors.Join(res...)
+}
+
+type decoder struct {
+	uid types.EntityUID
+}
+
+func (d decoder) errorf(path string, format string, args ...any) error {
+	return DecodeError{UID: d.uid, Path: path, Message: fmt.Sprintf(format, args...)}
+}
+
+// value decodes b as a value of the resolved schema type t.  A nil type, as for an attribute which the schema does not
+// declare, decodes b without reference to the schema.
+func (d decoder) value(b []byte, t ast.IsType, path string) (types.Value, error) {
+	mismatch := func() error {
+		return d.errorf(path, "expected %v, got %v", valueTypeFromSchema(t), jsonKind(b))
+	}
+	if t != nil && jsonKind(b) == "null" {
+		return nil, mismatch()
+	}
+	switch t := t.(type) {
+	case nil:
+		var v types.Value
+		if err := types.UnmarshalJSON(b, &v); err != nil {
+			return nil, d.errorf(path, "%v", err)
+		}
+		return v, nil
+	case ast.BooleanType:
+		var v bool
+		if err := json.Unmarshal(b, &v); err != nil {
+			return nil, mismatch()
+		}
+		return types.Boolean(v), nil
+	case ast.LongType:
+		var v json.Number
+		if jsonKind(b) != "a number" || json.Unmarshal(b, &v) != nil {
+			return nil, mismatch()
+		}
+		l, err := v.Int64()
+		if err != nil {
+			return nil, d.errorf(path, "long out of range: %v", v)
+		}
+		return types.Long(l), nil
+	case ast.StringType:
+		var v string
+		if err := json.Unmarshal(b, &v); err != nil {
+			return nil, mismatch()
+		}
+		return types.String(v), nil
+	case ast.ExtensionType:
+		return d.extension(b, t.Name, path)
+	case ast.EntityTypeRef:
+		var v types.EntityUID
+		if err := json.Unmarshal(b, &v); err != nil {
+			return nil, mismatch()
+		}
+		if v.Type != types.EntityType(t.Name) {
+			return nil, d.errorf(path, "expected %v, got %v", t.Name, v)
+		}
+		return v, nil
+	case ast.SetType:
+		var raw []json.RawMessage
+		if err := json.Unmarshal(b, &raw); err != nil {
+			return nil, mismatch()
+		}
+		elems := make([]types.Value, len(raw))
+		for i, r := range raw {
+			var err error
+			if elems[i], err = d.value(r, t.Element, path); err != nil {
+				return nil, err
+			}
+		}
+		return types.NewSet(elems...), nil
+	case ast.RecordType:
+		var raw map[types.String]json.RawMessage
+		if err := json.Unmarshal(b, &raw); err != nil {
+			return nil, mismatch()
+		}
+		m := make(types.RecordMap, len(raw))
+		for _, k := range slices.Sorted(maps.Keys(raw)) {
+			var at ast.IsType
+			if a, ok := t.Attribute(k); ok {
+				at = a.Type
+			}
+			var err error
+			if m[k], err = d.value(raw[k], at, attributePath(path, k)); err != nil {
+				return nil, err
+			}
+		}
+		return types.NewRecord(m), nil
+	}
+	return nil, d.errorf(path, "unsupported type %v", valueTypeFromSchema(t))
+}
+
+func (d decoder) extension(b []byte, name types.Ident, path string) (types.Value, error) {
+	var v types.Value
+	var err error
+	switch name {
+	case "ipaddr":
+		v, err = unmarshalExtension[types.IPAddr](b)
+	case "decimal":
+		v, err = unmarshalExtension[types.Decimal](b)
+	case "datetime":
+		v, err = unmarshalExtension[types.Datetime](b)
+	case "duration":
+		v, err = unmarshalExtension[types.Duration](b)
+	default:
+		return nil, d.errorf(path, "unknown extension type %v", name)
+	}
+	if err != nil {
+		return nil, d.errorf(path, "expected %v: %v", name, err)
+	}
+	return v, nil
+}
+
+// unmarshalExtension decodes an extension value, which may be written as a bare string or in either of the forms
+// accepted by the value's UnmarshalJSON method.
+func unmarshalExtension[T types.Value](b []byte) (types.Value, error) {
+	var v T
+	err := json.Unmarshal(b, &v)
+	return v, err
+}
+
+// jsonKind describes the kind of JSON value b holds, for use in error messages.
+func jsonKind(b []byte) string {
+	b = bytes.TrimSpace(b)
+	if len(b) == 0 {
+		return "nothing"
+	}
+	switch b[0] {
+	case '"':
+		return "a string"
+	case '{':
+		return "an object"
+	case '[':
+		return "an array"
+	case 't', 'f':
+		return "a boolean"
+	case 'n':
+		return "null"
+	}
+	return "a number"
+}
@@ -0,0 +1,188 @@
+package schema_test
+
+import (
+	"errors"
+	"net/netip"
+	"testing"
+	"time"
+
+	"github.com/cedar-policy/cedar-go/internal/testutil"
+	"github.com/cedar-policy/cedar-go/types"
+	"github.com/cedar-policy/cedar-go/x/exp/schema"
+)
+
+const decodeSchema = `entity User in [Group] = {
+  name: String,
+  age: Long,
+  active: Bool,
+  manager?: User,
+  ip: ipaddr,
+  limit: decimal,
+  since: datetime,
+  timeout: duration,
+  roles: Set<String>,
+  address: { city: String, zip?: String }
+} tags Set<Long>;
+entity Group;
+action view appliesTo {
+  principal: [User],
+  resource: [Group],
+  context: { authenticated: Bool, source: ipaddr }
+};
+`
+
+const decodeUser = `{
+	"uid": {"type": "User", "id": "alice"},
+	"parents": [{"type": "Group", "id": "g"}],
+	"attrs": {
+		"name": "Alice",
+		"age": 42,
+		"active": true,
+		"manager": {"type": "User", "id": "bob"},
+		"ip": "10.0.0.1",
+		"limit": {"__extn": {"fn": "decimal", "arg": "1.5"}},
+		"since": "2024-01-01",
+		"timeout": {"fn": "duration", "arg": "1h"},
+		"roles": ["admin", "user"],
+		"address": {"city": "London"}
+	},
+	"tags": {"level": [1, 2]}
+}`
+
+func decodedUser(t *testing.T) types.Entity {
+	limit, err := types.NewDecimal(15, -1)
+	testutil.OK(t, err)
+	return types.Entity{
+		UID:     types.NewEntityUID("User", "alice"),
+		Parents: types.NewEntityUIDSet(types.NewEntityUID("Group", "g")),
+		Attributes: types.NewRecord(types.RecordMap{
+			"name":    types.String("Alice"),
+			"age":     types.Long(42),
+			"active":  types.True,
+			"manager": types.NewEntityUID("User", "bob"),
+			"ip":      types.IPAddr(netip.MustParsePrefix("10.0.0.1/32")),
+			"limit":   limit,
+			"since":   types.NewDatetime(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)),
+			"timeout": types.NewDuration(time.Hour),
+			"roles":   types.NewSet(types.String("admin"), types.String("user")),
+			"address": types.NewRecord(types.RecordMap{"city": types.String("London")}),
+		}),
+		Tags: types.NewRecord(types.RecordMap{"level": types.NewSet(types.Long(1), types.Long(2))}),
+	}
+}
+
+func TestUnmarshalEntity(t *testing.T) {
+	t.Parallel()
+	var s schema.Schema
+	testutil.OK(t, s.UnmarshalCedar([]byte(decodeSchema)))
+	e, err := schema.UnmarshalEntity(&s, []byte(decodeUser))
+	testutil.OK(t, err)
+	testutil.Equals(t, e, decodedUser(t))
+}
+
+func TestUnmarshalEntities(t *testing.T) {
+	t.Parallel()
+	var s schema.Schema
+	testutil.OK(t, s.UnmarshalCedar([]byte(decodeSchema)))
+	entities, err := schema.UnmarshalEntities(&s, []byte(`[`+decodeUser+`, {"uid": {"__entity": {"type": "Group", "id": "g"}}, "parents": []}]`))
+	testutil.OK(t, err)
+	testutil.Equals(t, entities, types.EntityMap{
+		types.NewEntityUID("User", "alice"): decodedUser(t),
+		types.NewEntityUID("Group", "g"):    {UID: types.NewEntityUID("Group", "g"), Parents: types.NewEntityUIDSet()},
+	})
+
+	_, err = schema.UnmarshalEntities(&s, []byte(`[{"uid": {"type": "Group", "id": "g"}}, {"uid": {"type": "Group", "id": "g"}}]`))
+	testutil.Equals(t, err, error(schema.DecodeError{UID: types.NewEntityUID("Group", "g"), Message: "duplicate entity"}))
+	_, err = schema.UnmarshalEntities(&s, []byte(`{}`))
+	testutil.Error(t, err)
+}
+
+func TestUnmarshalEntityErrors(t *testing.T) {
+	t.Parallel()
+	alice := types.NewEntityUID("User", "alice")
+	tests := []struct {
+		name  string
+		attrs string
+		want  string
+	}{
+		{"long", `"age": "42"`, "error decoding entity `User::\"alice\"` at `age`: expected Long, got a string"},
+		{"longRange", `"age": 1e30`, "error decoding entity `User::\"alice\"` at `age`: long out of range: 1e30"},
+		{"bool", `"active": 1`, "error decoding entity `User::\"alice\"` at `active`: expected Bool, got a number"},
+		{"string", `"name": null`, "error decoding entity `User::\"alice\"` at `name`: expected String, got null"},
+		{"extension", `"ip": "not an ip"`,
+			"error decoding entity `User::\"alice\"` at `ip`: expected ipaddr: error parsing ip value: error parsing IP address not an ip"},
+		{"entity", `"manager": "bob"`, "error decoding entity `User::\"alice\"` at `manager`: expected User, got a string"},
+		{"entityType", `"manager": {"type": "Group", "id": "g"}`,
+			"error decoding entity `User::\"alice\"` at `manager`: expected User, got Group::\"g\""},
+		{"set", `"roles": "admin"`, "error decoding entity `User::\"alice\"` at `roles`: expected Set<String>, got a string"},
+		{"setElement", `"roles": ["admin", 1]`, "error decoding entity `User::\"alice\"` at `roles`: expected String, got a number"},
+		{"record", `"address": []`,
+			"error decoding entity `User::\"alice\"` at `address`: expected { city: String, zip?: String }, got an array"},
+		{"nested", `"address": {"city": 1}`, "error decoding entity `User::\"alice\"` at `address.city`: expected String, got a number"},
+	}
+	var s schema.Schema
+	testutil.OK(t, s.UnmarshalCedar([]byte(decodeSchema)))
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			_, err := schema.UnmarshalEntity(&s, []byte(`{"uid": {"type": "User", "id": "alice"}, "attrs": {`+tt.attrs+`}}`))
+			var decodeErr schema.DecodeError
+			testutil.Equals(t, errors.As(err, &decodeErr), true)
+			testutil.Equals(t, decodeErr.UID, alice)
+			testutil.Equals(t, err.Error(), tt.want)
+		})
+	}
+}
+
+func TestUnmarshalEntityValidation(t *testing.T) {
+	t.Parallel()
+	var s schema.Schema
+	testutil.OK(t, s.UnmarshalCedar([]byte(decodeSchema)))
+	_, err := schema.UnmarshalEntity(&s, []byte(`{"uid": {"type": "Group", "id": "g"}, "parents": [{"type": "User", "id": "alice"}],
+		"attrs": {"extra": 1}, "tags": {"a": "b"}}`))
+	testutil.Equals(t, err.Error(), "error decoding entity `Group::\"g\"`: parent User::\"alice\" is not permitted: "+
+		"entity type Group is not a member of entity type User\n"+
+		"error decoding entity `Group::\"g\"` at `extra`: attribute is not declared in the schema\n"+
+		"error decoding entity `Group::\"g\"`: entity type Group does not declare tags")
+
+	_, err = schema.UnmarshalEntity(&s, []byte(`{"uid": {"type": "User", "id": "alice"}, "tags": {"a": ["b"]}}`))
+	testutil.Equals(t, errors.Is(err, schema.DecodeError{
+		UID: types.NewEntityUID("User", "alice"), Path: `getTag("a")`, Message: "expected Long, got a string",
+	}), true)
+	_, err = schema.UnmarshalEntity(&s, []byte(`{"uid": {"type": "User", "id": "alice"}, "tags": []}`))
+	testutil.Equals(t, err.Error(), "error decoding entity `User::\"alice\"`: tags: expected an object, got an array")
+	_, err = schema.UnmarshalEntity(&s, []byte(`{"attrs": {}}`))
+	testutil.Equals(t, err.Error(), "error decoding entity: missing uid")
+	_, err = schema.UnmarshalEntity(&s, []byte(`{"uid": {"type": "User", "id": "alice"}, "unknown": 1}`))
+	testutil.Error(t, err)
+}
+
+func TestUnmarshalContext(t *testing.T) {
+	t.Parallel()
+	var s schema.Schema
+	testutil.OK(t, s.UnmarshalCedar([]byte(decodeSchema)))
+	view := types.NewEntityUID("Action", "view")
+	ctx, err := schema.UnmarshalContext(&s, view, []byte(`{"authenticated": true, "source": "192.168.0.1"}`))
+	testutil.OK(t, err)
+	testutil.Equals(t, ctx, types.NewRecord(types.RecordMap{
+		"authenticated": types.True,
+		"source":        types.IPAddr(netip.MustParsePrefix("192.168.0.1/32")),
+	}))
+
+	_, err = schema.UnmarshalContext(&s, view, []byte(`{"authenticated": "yes"}`))
+	testutil.Equals(t, err.Error(), "error decoding context at `context.authenticated`: expected Bool, got a string")
+	_, err = schema.UnmarshalContext(&s, view, []byte(`{"authenticated": true}`))
+	testutil.Equals(t, err.Error(), "error decoding context at `context.source`: required attribute is missing")
+	_, err = schema.UnmarshalContext(&s, types.NewEntityUID("Action", "edit"), []byte(`{}`))
+	testutil.Equals(t, err.Error(), "error decoding context: unknown action Action::\"edit\"")
+
+	var bad schema.Schema
+	testutil.OK(t, bad.UnmarshalCedar([]byte(`entity User in [Group];`)))
+	_, err = schema.UnmarshalContext(&bad, view, []byte(`{}`))
+	testutil.Error(t, err)
+	_, err = schema.UnmarshalEntity(&bad, []byte(`{}`))
+	testutil.Error(t, err)
+	_, err = schema.UnmarshalEntities(&bad, []byte(`[]`))
+	testutil.Error(t, err)
+}
@@ -0,0 +1,260 @@
+package schema
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+
+	"github.com/cedar-policy/cedar-go"
+	"github.com/cedar-policy/cedar-go/types"
+	"github.com/cedar-policy/cedar-go/x/exp/schema/ast"
+)
+
+// A SchemaChange is a difference between two schemas found by DiffSchemas.  Location identifies the declaration which
+// changed: an entity type such as `User`, an action such as `Action::"view"`, or an attribute or the tags of one, e.g.
+// `User.address.city`, `User tags` or `Action::"view" context.ip`.
+//
+// A change is breaking if entities, requests or policies which conform to the old schema may not conform to the new
+// one.
+type SchemaChange struct {
+	Location string `json:"location"`
+	Message  string `json:"message"`
+	Breaking bool   `json:"breaking"`
+}
+
+func (c SchemaChange) String() string {
+	if c.Breaking {
+		return fmt.Sprintf("breaking change at `%v`: %v", c.Location, c.Message)
+	}
+	return fmt.Sprintf("change at `%v`: %v", c.Location, c.Message)
+}
+
+// A SchemaDiff holds the differences between two schemas.  BrokenPolicies holds the errors reported by Validate
+// against the new schema for the policies which validate against the old schema but not against the new one.
+type SchemaDiff struct {
+	Changes        []SchemaChange    `json:"changes,omitempty"`
+	BrokenPolicies []ValidationError `json:"brokenPolicies,omitempty"`
+}
+
+// Breaking reports whether any of the changes is breaking or any policy stops validating.
+func (d SchemaDiff) Breaking() bool {
+	return len(d.BrokenPolicies) > 0 || slices.ContainsFunc(d.Changes, func(c SchemaChange) bool { return c.Breaking })
+}
+
+// DiffSchemas compares an old version of a schema, from, with a new version, to.  Common types are compared by their
+// definitions, so moving a type into or out of a common type is not a change.  The following changes are breaking:
+//   - removing an entity type, an action, an enumerated value, a parent type, a parent action, or a principal or
+//     resource type of an action
+//   - making an entity type enumerated
+//   - removing an attribute or tags, or changing the type of one
+//   - adding a required attribute, or making an attribute required or optional
+//
+// Adding declarations, optional attributes or tags, and removing the enumeration of an entity type's values are not
+// breaking.  Changes are returned in order of entity type and then of action.
+//
+// If policies is not nil, each policy is validated against both schemas in strict mode, and the errors of those
+// which validate against from but not against to are returned.  The returned error is non-nil only if either schema
+// is invalid.
+func DiffSchemas(from, to *Schema, policies *cedar.PolicySet) (SchemaDiff, error) {
+	oldRS, err := from.resolve()
+	if err != nil {
+		return SchemaDiff{}, err
+	}
+	newRS, err := to.resolve()
+	if err != nil {
+		return SchemaDiff{}, err
+	}
+	d := differ{}
+	d.entities(oldRS, newRS)
+	d.actions(oldRS, newRS)
+	res := SchemaDiff{Changes: d.changes}
+	if policies == nil {
+		return res, nil
+	}
+	oldResult, err := Validate(from, policies)
+	if err != nil {
+		return SchemaDiff{}, err
+	}
+	newResult, err := Validate(to, policies)
+	if err != nil {
+		return SchemaDiff{}, err
+	}
+	invalid := map[types.PolicyID]bool{}
+	for _, e := range oldResult.Errors {
+		invalid[e.PolicyID] = true
+	}
+	for _, e := range newResult.Errors {
+		if !invalid[e.PolicyID] {
+			res.BrokenPolicies = append(res.BrokenPolicies, e)
+		}
+	}
+	return res, nil
+}
+
+type differ struct {
+	changes []SchemaChange
+}
+
+func (d *differ) breaking(loc string, format string, args ...any) {
+	d.changes = append(d.changes, SchemaChange{Location: loc, Message: fmt.Sprintf(format, args...), Breaking: true})
+}
+
+func (d *differ) compatible(loc string, format string, args ...any) {
+	d.changes = append(d.changes, SchemaChange{Location: loc, Message: fmt.Sprintf(format, args...)})
+}
+
+// sortedUnion returns the keys of both maps in sorted order.
+func sortedUnion[K ~string, V any](a, b map[K]V) []K {
+	keys := slices.Collect(maps.Keys(a))
+	for k := range b {
+		if _, ok := a[k]; !ok {
+			keys = append(keys, k)
+		}
+	}
+	slices.Sort(keys)
+	return keys
+}
+
+// diffLists reports the elements removed from and added to a list.
+func diffLists[T comparable](from, to []T, removed, added func(T)) {
+	for _, v := range from {
+		if !slices.Contains(to, v) {
+			removed(v)
+		}
+	}
+	for _, v := range to {
+		if !slices.Contains(from, v) {
+			added(v)
+		}
+	}
+}
+
+func (d *differ) entities(from, to *resolvedSchema) {
+	for _, name := range sortedUnion(from.entities, to.entities) {
+		loc := string(name)
+		o, inOld := from.entities[name]
+		n, inNew := to.entities[name]
+		switch {
+		case !inNew:
+			d.breaking(loc, "entity type removed")
+			continue
+		case !inOld:
+			d.compatible(loc, "entity type added")
+			continue
+		}
+		switch {
+		case o.enum == nil && n.enum != nil:
+			d.breaking(loc, "entity type made enumerated")
+		case o.enum != nil && n.enum == nil:
+			d.compatible(loc, "entity type no longer enumerated")
+		default:
+			diffLists(o.enum, n.enum,
+				func(v types.String) { d.breaking(loc, "enumerated value %q removed", v) },
+				func(v types.String) { d.compatible(loc, "enumerated value %q added", v) })
+		}
+		diffLists(o.memberOf, n.memberOf,
+			func(t types.EntityType) { d.breaking(loc, "parent type %v removed", t) },
+			func(t types.EntityType) { d.compatible(loc, "parent type %v added", t) })
+		d.record(loc, o.shape, n.shape)
+		switch {
+		case o.tags == nil && n.tags != nil:
+			d.compatible(loc+" tags", "tags added")
+		case o.tags != nil && n.tags == nil:
+			d.breaking(loc+" tags", "tags removed")
+		case o.tags != nil:
+			d.typ(loc+" tags", o.tags, n.tags)
+		}
+	}
+}
+
+func (d *differ) actions(from, to *resolvedSchema) {
+	uids := slices.Collect(maps.Keys(from.actions))
+	for uid := range to.actions {
+		if _, ok := from.actions[uid]; !ok {
+			uids = append(uids, uid)
+		}
+	}
+	sortUIDs(uids)
+	for _, uid := range uids {
+		loc := uid.String()
+		o, inOld := from.actions[uid]
+		n, inNew := to.actions[uid]
+		switch {
+		case !inNew:
+			d.breaking(loc, "action removed")
+			continue
+		case !inOld:
+			d.compatible(loc, "action added")
+			continue
+		}
+		diffLists(o.memberOf, n.memberOf,
+			func(p types.EntityUID) { d.breaking(loc, "parent action %v removed", p) },
+			func(p types.EntityUID) { d.compatible(loc, "parent action %v added", p) })
+		switch {
+		case o.appliesTo == nil && n.appliesTo != nil:
+			d.compatible(loc, "appliesTo added")
+		case o.appliesTo != nil && n.appliesTo == nil:
+			d.breaking(loc, "appliesTo removed")
+		case o.appliesTo != nil:
+			diffLists(o.appliesTo.principals, n.appliesTo.principals,
+				func(t types.EntityType) { d.breaking(loc, "principal type %v removed", t) },
+				func(t types.EntityType) { d.compatible(loc, "principal type %v added", t) })
+			diffLists(o.appliesTo.resources, n.appliesTo.resources,
+				func(t types.EntityType) { d.breaking(loc, "resource type %v removed", t) },
+				func(t types.EntityType) { d.compatible(loc, "resource type %v added", t) })
+			d.record(loc+" context", o.appliesTo.context, n.appliesTo.context)
+		}
+	}
+}
+
+func (d *differ) record(loc string, from, to ast.RecordType) {
+	oldAttrs := map[types.String]ast.Attribute{}
+	for _, a := range from.Attributes {
+		oldAttrs[a.Name] = a
+	}
+	newAttrs := map[types.String]ast.Attribute{}
+	for _, a := range to.Attributes {
+		newAttrs[a.Name] = a
+	}
+	for _, name := range sortedUnion(oldAttrs, newAttrs) {
+		aloc := attributePath(loc, name)
+		o, inOld := oldAttrs[name]
+		n, inNew := newAttrs[name]
+		switch {
+		case !inNew:
+			d.breaking(aloc, "attribute removed")
+			continue
+		case !inOld && n.Optional:
+			d.compatible(aloc, "optional attribute added")
+			continue
+		case !inOld:
+			d.breaking(aloc, "required attribute added")
+			continue
+		case o.Optional && !n.Optional:
+			d.breaking(aloc, "attribute made required")
+		case !o.Optional && n.Optional:
+			d.breaking(aloc, "attribute made optional")
+		}
+		d.typ(aloc, o.Type, n.Type)
+	}
+}
+
+// typ compares two resolved types, descending into records and the elements of sets.
+func (d *differ) typ(loc string, from, to ast.IsType) {
+	switch o := from.(type) {
+	case ast.RecordType:
+		if n, ok := to.(ast.RecordType); ok {
+			d.record(loc, o, n)
+			return
+		}
+	case ast.SetType:
+		if n, ok := to.(ast.SetType); ok {
+			d.typ(loc, o.Element, n.Element)
+			return
+		}
+	}
+	oldType, newType := valueTypeFromSchema(from).String(), valueTypeFromSchema(to).String()
+	if oldType != newType {
+		d.breaking(loc, "type changed from %v to %v", oldType, newType)
+	}
+}
@@ -0,0 +1,164 @@
+package schema_test
+
+import (
+	"testing"
+
+	"github.com/cedar-policy/cedar-go"
+	"github.com/cedar-policy/cedar-go/internal/testutil"
+	"github.com/cedar-policy/cedar-go/x/exp/schema"
+)
+
+const diffOldSchema = `type Address = { city: String, zip?: String };
+entity Group;
+entity Team;
+entity User in [Group, Team] = {
+  address: Address,
+  age: Long,
+  nickname?: String,
+  email?: String,
+  roles: Set<String>,
+  active: Bool,
+} tags String;
+entity Photo tags Long;
+entity Doc;
+entity Color enum ["red", "green"];
+entity Size enum ["s"];
+entity Shape;
+entity Legacy;
+action read;
+action view in [read] appliesTo {
+  principal: [User, Group],
+  resource: [Photo],
+  context: { ip: ipaddr, trace?: String }
+};
+action edit appliesTo { principal: User, resource: Photo };
+action old;
+action later;
+`
+
+const diffNewSchema = `entity Group;
+entity Team;
+entity User in [Group] = {
+  address: { city: String, zip?: Long },
+  age: String,
+  nickname: String,
+  email?: String,
+  roles: Set<Long>,
+  active: Bool,
+  manager: User,
+  title?: String,
+};
+entity Photo tags Long;
+entity Doc tags String;
+entity Color enum ["red", "blue"];
+entity Size;
+entity Shape enum ["circle"];
+entity Folder;
+action read;
+action list;
+action view in [list] appliesTo {
+  principal: [User, Folder],
+  resource: [Photo, Doc],
+  context: { ip: String, trace?: String, session?: Long }
+};
+action edit;
+action later appliesTo { principal: User, resource: Photo };
+`
+
+func TestDiffSchemas(t *testing.T) {
+	t.Parallel()
+	var from, to schema.Schema
+	testutil.OK(t, from.UnmarshalCedar([]byte(diffOldSchema)))
+	testutil.OK(t, to.UnmarshalCedar([]byte(diffNewSchema)))
+	diff, err := schema.DiffSchemas(&from, &to, nil)
+	testutil.OK(t, err)
+	breaking := func(loc, msg string) schema.SchemaChange {
+		return schema.SchemaChange{Location: loc, Message: msg, Breaking: true}
+	}
+	compatible := func(loc, msg string) schema.SchemaChange {
+		return schema.SchemaChange{Location: loc, Message: msg}
+	}
+	testutil.Equals(t, diff.Changes, []schema.SchemaChange{
+		breaking("Color", `enumerated value "green" removed`),
+		compatible("Color", `enumerated value "blue" added`),
+		compatible("Doc tags", "tags added"),
+		compatible("Folder", "entity type added"),
+		breaking("Legacy", "entity type removed"),
+		breaking("Shape", "entity type made enumerated"),
+		compatible("Size", "entity type no longer enumerated"),
+		breaking("User", "parent type Team removed"),
+		breaking("User.address.zip", "type changed from String to Long"),
+		breaking("User.age", "type changed from Long to String"),
+		breaking("User.manager", "required attribute added"),
+		breaking("User.nickname", "attribute made required"),
+		breaking("User.roles", "type changed from String to Long"),
+		compatible("User.title", "optional attribute added"),
+		breaking("User tags", "tags removed"),
+		breaking(`Action::"edit"`, "appliesTo removed"),
+		compatible(`Action::"later"`, "appliesTo added"),
+		compatible(`Action::"list"`, "action added"),
+		breaking(`Action::"old"`, "action removed"),
+		breaking(`Action::"view"`, `parent action Action::"read" removed`),
+		compatible(`Action::"view"`, `parent action Action::"list" added`),
+		breaking(`Action::"view"`, "principal type Group removed"),
+		compatible(`Action::"view"`, "principal type Folder added"),
+		compatible(`Action::"view"`, "resource type Doc added"),
+		breaking(`Action::"view" context.ip`, "type changed from ipaddr to String"),
+		compatible(`Action::"view" context.session`, "optional attribute added"),
+	})
+	testutil.Equals(t, diff.Breaking(), true)
+	testutil.Equals(t, diff.Changes[0].String(), "breaking change at `Color`: enumerated value \"green\" removed")
+	testutil.Equals(t, diff.Changes[1].String(), "change at `Color`: enumerated value \"blue\" added")
+
+	same, err := schema.DiffSchemas(&from, &from, nil)
+	testutil.OK(t, err)
+	testutil.Equals(t, same, schema.SchemaDiff{})
+	testutil.Equals(t, same.Breaking(), false)
+}
+
+func TestDiffSchemasOptional(t *testing.T) {
+	t.Parallel()
+	var from, to schema.Schema
+	testutil.OK(t, from.UnmarshalCedar([]byte(`entity User = { name: String, tags: Set<{ a: Long }> };`)))
+	testutil.OK(t, to.UnmarshalCedar([]byte(`entity User = { name?: String, tags: Set<{ a: Long, b?: Long }> };`)))
+	diff, err := schema.DiffSchemas(&from, &to, nil)
+	testutil.OK(t, err)
+	testutil.Equals(t, diff.Changes, []schema.SchemaChange{
+		{Location: "User.name", Message: "attribute made optional", Breaking: true},
+		{Location: "User.tags.b", Message: "optional attribute added"},
+	})
+}
+
+func TestDiffSchemasPolicies(t *testing.T) {
+	t.Parallel()
+	var from, to schema.Schema
+	testutil.OK(t, from.UnmarshalCedar([]byte(diffOldSchema)))
+	testutil.OK(t, to.UnmarshalCedar([]byte(diffNewSchema)))
+	policies, err := cedar.NewPolicySetFromBytes("policy.cedar", []byte(`
+permit(principal is User, action == Action::"view", resource) when { principal.age > 3 };
+permit(principal is User, action == Action::"view", resource) when { context.trace == "x" };
+permit(principal, action == Action::"view", resource) when { principal.nickname == "x" };
+permit(principal is User, action == Action::"view", resource) when { principal.active };
+`))
+	testutil.OK(t, err)
+	diff, err := schema.DiffSchemas(&from, &to, policies)
+	testutil.OK(t, err)
+	testutil.Equals(t, len(diff.BrokenPolicies), 1)
+	testutil.Equals(t, diff.BrokenPolicies[0].PolicyID, "policy0")
+
+	onlyCompatible, err := schema.DiffSchemas(&from, &from, policies)
+	testutil.OK(t, err)
+	testutil.Equals(t, onlyCompatible.BrokenPolicies, nil)
+	testutil.Equals(t, onlyCompatible.Breaking(), false)
+}
+
+func TestDiffSchemasErrors(t *testing.T) {
+	t.Parallel()
+	var good, bad schema.Schema
+	testutil.OK(t, good.UnmarshalCedar([]byte(`entity User;`)))
+	testutil.OK(t, bad.UnmarshalCedar([]byte(`entity User in [Missing];`)))
+	_, err := schema.DiffSchemas(&bad, &good, nil)
+	testutil.Error(t, err)
+	_, err = schema.DiffSchemas(&good, &bad, nil)
+	testutil.Error(t, err)
+}
@@ -0,0 +1,248 @@
+package schema
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/cedar-policy/cedar-go/types"
+	"github.com/cedar-policy/cedar-go/x/exp/schema/ast"
+)
+
+// An EntityError details a problem found by ValidateEntities in an entity.  Path locates the offending value within
+// the entity using Cedar expression syntax, e.g. `address.city` or `getTag("team")`; it is empty for problems with the
+// entity as a whole or with its parents.
+type EntityError struct {
+	UID     types.EntityUID `json:"uid"`
+	Path    string          `json:"path,omitempty"`
+	Message string          `json:"message"`
+}
+
+func (e EntityError) String() string {
+	if e.Path == "" {
+		return fmt.Sprintf("while validating entity `%v`: %v", e.UID, e.Message)
+	}
+	return fmt.Sprintf("while validating entity `%v`, at `%v`: %v", e.UID, e.Path, e.Message)
+}
+
+// ValidateEntities checks every entity in the EntityMap against the schema.  It reports:
+//   - entities whose type the schema does not declare, actions the schema does not declare and values which are not
+//     members of an enumerated entity type
+//   - required attributes which are missing, attributes which are not declared and attribute values of the wrong
+//     type, including the attributes of nested records and the elements of sets
+//   - parents whose type is not among the entity type's memberOfTypes
+//   - tags on entity types which do not declare tags, and tag values of the wrong type
+//
+// Errors are returned in order of entity UID and then path.  The returned error is non-nil only if the schema itself is
+// invalid.
+func ValidateEntities(s *Schema, entities types.EntityMap) ([]EntityError, error) {
+	rs, err := s.resolve()
+	if err != nil {
+		return nil, err
+	}
+	uids := make([]types.EntityUID, 0, len(entities))
+	for uid := range entities {
+		uids = append(uids, uid)
+	}
+	sortUIDs(uids)
+	var res []EntityError
+	for _, uid := range uids {
+		res = append(res, rs.validateEntity(entities[uid])...)
+	}
+	return res, nil
+}
+
+// valueChecker checks values against resolved schema types.  It is used for both entity data, in which case uid
+// identifies the entity being checked, and request contexts.
+type valueChecker struct {
+	uid    types.EntityUID
+	errors []EntityError
+}
+
+func (c *valueChecker) errorf(path string, format string, args ...any) {
+	c.errors = append(c.errors, EntityError{UID: c.uid, Path: path, Message: fmt.Sprintf(format, args...)})
+}
+
+func (s *resolvedSchema) validateEntity(e types.Entity) []EntityError {
+	c := valueChecker{uid: e.UID}
+	if s.isActionType(e.UID.Type) {
+		s.validateActionEntity(&c, e)
+		return c.errors
+	}
+	et, ok := s.entities[e.UID.Type]
+	if !ok {
+		c.errorf("", "unknown entity type %v", e.UID.Type)
+		return c.errors
+	}
+	if et.enum != nil && !slices.Contains(et.enum, e.UID.ID) {
+		c.errorf("", "%v is not a declared value of enumerated entity type %v", e.UID, e.UID.Type)
+	}
+	for _, p := range sortedParents(e) {
+		if !slices.Contains(et.memberOf, p.Type) {
+			c.errorf("", "parent %v is not permitted: entity type %v is not a member of entity type %v", p,
+				e.UID.Type, p.Type)
+		}
+	}
+	c.record(e.Attributes, et.shape, "")
+	switch {
+	case e.Tags.Len() == 0:
+	case et.tags == nil:
+		c.errorf("", "entity type %v does not declare tags", e.UID.Type)
+	default:
+		for _, k := range sortedKeys(e.Tags) {
+			v, _ := e.Tags.Get(k)
+			c.value(v, et.tags, "getTag("+strconv.Quote(string(k))+")")
+		}
+	}
+	return c.errors
+}
+
+// validateActionEntity checks an action which appears in the entity data.  Actions may not have attributes or tags,
+// and their parents must be the action groups declared by the schema.
+func (s *resolvedSchema) validateActionEntity(c *valueChecker, e types.Entity) {
+	a, ok := s.actions[e.UID]
+	if !ok {
+		c.errorf("", "unknown action %v", e.UID)
+		return
+	}
+	if e.Attributes.Len() > 0 {
+		c.errorf("", "actions may not have attributes")
+	}
+	if e.Tags.Len() > 0 {
+		c.errorf("", "actions may not have tags")
+	}
+	for _, p := range sortedParents(e) {
+		if !slices.Contains(a.memberOf, p) {
+			c.errorf("", "parent %v is not permitted: the schema does not declare action %v a member of it", p, e.UID)
+		}
+	}
+}
+
+func sortUIDs(uids []types.EntityUID) {
+	slices.SortFunc(uids, func(a, b types.EntityUID) int { return strings.Compare(a.String(), b.String()) })
+}
+
+func sortedParents(e types.Entity) []types.EntityUID {
+	res := e.Parents.Slice()
+	sortUIDs(res)
+	return res
+}
+
+func sortedKeys(r types.Record) []types.String {
+	keys := slices.Collect(r.Keys())
+	slices.Sort(keys)
+	return keys
+}
+
+func (c *valueChecker) record(r types.Record, t ast.RecordType, path string) {
+	for _, a := range t.Attributes {
+		if _, ok := r.Get(a.Name); !ok && !a.Optional {
+			c.errorf(attributePath(path, a.Name), "required attribute is missing")
+		}
+	}
+	for _, k := range sortedKeys(r) {
+		a, ok := t.Attribute(k)
+		if !ok {
+			c.errorf(attributePath(path, k), "attribute is not declared in the schema")
+			continue
+		}
+		v, _ := r.Get(k)
+		c.value(v, a.Type, attributePath(path, k))
+	}
+}
+
+// value checks that v conforms to the resolved schema type t.
+func (c *valueChecker) value(v types.Value, t ast.IsType, path string) {
+	mismatch := func() {
+		c.errorf(path, "expected %v, got %v", valueTypeFromSchema(t), describeValue(v))
+	}
+	switch t := t.(type) {
+	case ast.BooleanType:
+		if _, ok := v.(types.Boolean); !ok {
+			mismatch()
+		}
+	case ast.LongType:
+		if _, ok := v.(types.Long); !ok {
+			mismatch()
+		}
+	case ast.StringType:
+		if _, ok := v.(types.String); !ok {
+			mismatch()
+		}
+	case ast.ExtensionType:
+		if describeValue(v) != string(t.Name) {
+			mismatch()
+		}
+	case ast.EntityTypeRef:
+		if uid, ok := v.(types.EntityUID); !ok || uid.Type != types.EntityType(t.Name) {
+			mismatch()
+		}
+	case ast.SetType:
+		set, ok := v.(types.Set)
+		if !ok {
+			mismatch()
+			return
+		}
+		for e := range set.All() {
+			c.value(e, t.Element, path)
+		}
+	case ast.RecordType:
+		r, ok := v.(types.Record)
+		if !ok {
+			mismatch()
+			return
+		}
+		c.record(r, t, path)
+	}
+}
+
+// describeValue returns the name of the type of v, in the form used by valueType.String.
+func describeValue(v types.Value) string {
+	switch v := v.(type) {
+	case types.Boolean:
+		return "Bool"
+	case types.Long:
+		return "Long"
+	case types.String:
+		return "String"
+	case types.EntityUID:
+		return string(v.Type)
+	case types.Set:
+		return "Set"
+	case types.Record:
+		return "record"
+	case types.Decimal:
+		return "decimal"
+	case types.IPAddr:
+		return "ipaddr"
+	case types.Datetime:
+		return "datetime"
+	case types.Duration:
+		return "duration"
+	}
+	return fmt.Sprintf("%T", v)
+}
+
+// attributePath appends an attribute access to path, quoting the attribute name if it is not an identifier.
+func attributePath(path string, name types.String) string {
+	if !isIdent(string(name)) {
+		return path + "[" + strconv.Quote(string(name)) + "]"
+	}
+	if path == "" {
+		return string(name)
+	}
+	return path + "." + string(name)
+}
+
+func isIdent(s string) bool {
+	for i, r := range s {
+		switch {
+		case r == '_', r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z':
+		case i > 0 && r >= '0' && r <= '9':
+		default:
+			return false
+		}
+	}
+	return s != ""
+}
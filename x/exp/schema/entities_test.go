@@ -0,0 +1,154 @@
+package schema_test
+
+import (
+	"net/netip"
+	"testing"
+
+	"github.com/cedar-policy/cedar-go/internal/testutil"
+	"github.com/cedar-policy/cedar-go/types"
+	"github.com/cedar-policy/cedar-go/x/exp/schema"
+)
+
+func TestValidateEntities(t *testing.T) {
+	t.Parallel()
+	alice := types.NewEntityUID("User", "alice")
+	group := types.NewEntityUID("Group", "g")
+	photo := types.NewEntityUID("Photo", "p")
+	validUser := types.RecordMap{
+		"name":    types.String("Alice"),
+		"age":     types.Long(42),
+		"address": types.NewRecord(types.RecordMap{"city": types.String("London")}),
+	}
+	user := func(attrs types.RecordMap, tags types.RecordMap, parents ...types.EntityUID) types.Entity {
+		return types.Entity{
+			UID:        alice,
+			Parents:    types.NewEntityUIDSet(parents...),
+			Attributes: types.NewRecord(attrs),
+			Tags:       types.NewRecord(tags),
+		}
+	}
+	with := func(k types.String, v types.Value) types.RecordMap {
+		res := types.RecordMap{}
+		for kk, vv := range validUser {
+			res[kk] = vv
+		}
+		if v == nil {
+			delete(res, k)
+		} else {
+			res[k] = v
+		}
+		return res
+	}
+	tests := []struct {
+		name   string
+		entity types.Entity
+		want   []schema.EntityError
+	}{
+		{"valid", user(validUser, types.RecordMap{"team": types.String("cedar")}, group), nil},
+		{"validOptional", user(with("manager", alice), nil), nil},
+		{"validSetAndExtension", types.Entity{UID: photo, Parents: types.NewEntityUIDSet(types.NewEntityUID("Album", "a")),
+			Attributes: types.NewRecord(types.RecordMap{
+				"owner":  alice,
+				"labels": types.NewSet(types.String("a"), types.String("b")),
+				"ip":     types.IPAddr(netip.MustParsePrefix("10.0.0.1/32")),
+			})}, nil},
+		{"validEnum", types.Entity{UID: types.NewEntityUID("Color", "red")}, nil},
+		{"validAction", types.Entity{UID: types.NewEntityUID("Action", "edit"),
+			Parents: types.NewEntityUIDSet(types.NewEntityUID("Action", "view"))}, nil},
+		{"unknownType", types.Entity{UID: types.NewEntityUID("Admin", "a")},
+			[]schema.EntityError{{UID: types.NewEntityUID("Admin", "a"), Message: "unknown entity type Admin"}}},
+		{"unknownAction", types.Entity{UID: types.NewEntityUID("Action", "delete")},
+			[]schema.EntityError{{UID: types.NewEntityUID("Action", "delete"), Message: `unknown action Action::"delete"`}}},
+		{"actionParent", types.Entity{UID: types.NewEntityUID("Action", "view"),
+			Parents: types.NewEntityUIDSet(types.NewEntityUID("Action", "edit"))},
+			[]schema.EntityError{{UID: types.NewEntityUID("Action", "view"),
+				Message: `parent Action::"edit" is not permitted: the schema does not declare action Action::"view" a member of it`}}},
+		{"actionAttributes", types.Entity{UID: types.NewEntityUID("Action", "view"),
+			Attributes: types.NewRecord(types.RecordMap{"a": types.Long(1)}), Tags: types.NewRecord(types.RecordMap{"a": types.Long(1)})},
+			[]schema.EntityError{
+				{UID: types.NewEntityUID("Action", "view"), Message: "actions may not have attributes"},
+				{UID: types.NewEntityUID("Action", "view"), Message: "actions may not have tags"},
+			}},
+		{"enumValue", types.Entity{UID: types.NewEntityUID("Color", "blue")},
+			[]schema.EntityError{{UID: types.NewEntityUID("Color", "blue"),
+				Message: `Color::"blue" is not a declared value of enumerated entity type Color`}}},
+		{"missingAttribute", user(with("age", nil), nil),
+			[]schema.EntityError{{UID: alice, Path: "age", Message: "required attribute is missing"}}},
+		{"undeclaredAttribute", user(with("first name", types.String("A")), nil),
+			[]schema.EntityError{{UID: alice, Path: `["first name"]`, Message: "attribute is not declared in the schema"}}},
+		{"wrongType", user(with("age", types.String("42")), nil),
+			[]schema.EntityError{{UID: alice, Path: "age", Message: "expected Long, got String"}}},
+		{"wrongEntityType", user(with("manager", group), nil),
+			[]schema.EntityError{{UID: alice, Path: "manager", Message: "expected User, got Group"}}},
+		{"nestedRecord", user(with("address", types.NewRecord(types.RecordMap{"zip": types.Long(1)})), nil),
+			[]schema.EntityError{
+				{UID: alice, Path: "address.city", Message: "required attribute is missing"},
+				{UID: alice, Path: "address.zip", Message: "expected String, got Long"},
+			}},
+		{"notARecord", user(with("address", types.String("London")), nil),
+			[]schema.EntityError{{UID: alice, Path: "address", Message: "expected { city: String, zip?: String }, got String"}}},
+		{"setElements", types.Entity{UID: photo, Attributes: types.NewRecord(types.RecordMap{
+			"owner":  alice,
+			"labels": types.NewSet(types.Long(1)),
+			"ip":     types.String("10.0.0.1"),
+		})},
+			[]schema.EntityError{
+				{UID: photo, Path: "ip", Message: "expected ipaddr, got String"},
+				{UID: photo, Path: "labels", Message: "expected String, got Long"},
+			}},
+		{"notASet", types.Entity{UID: photo, Attributes: types.NewRecord(types.RecordMap{
+			"owner":  alice,
+			"labels": types.String("a"),
+			"ip":     types.IPAddr(netip.MustParsePrefix("10.0.0.1/32")),
+		})},
+			[]schema.EntityError{{UID: photo, Path: "labels", Message: "expected Set<String>, got String"}}},
+		{"parentType", user(validUser, nil, group, photo),
+			[]schema.EntityError{{UID: alice,
+				Message: `parent Photo::"p" is not permitted: entity type User is not a member of entity type Photo`}}},
+		{"undeclaredTags", types.Entity{UID: types.NewEntityUID("Group", "g"), Tags: types.NewRecord(types.RecordMap{"a": types.Long(1)})},
+			[]schema.EntityError{{UID: group, Message: "entity type Group does not declare tags"}}},
+		{"tagType", user(validUser, types.RecordMap{"team": types.Long(1)}),
+			[]schema.EntityError{{UID: alice, Path: `getTag("team")`, Message: "expected String, got Long"}}},
+	}
+	var s schema.Schema
+	testutil.OK(t, s.UnmarshalCedar([]byte(validateSchema)))
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			got, err := schema.ValidateEntities(&s, types.EntityMap{tt.entity.UID: tt.entity})
+			testutil.OK(t, err)
+			testutil.Equals(t, got, tt.want)
+		})
+	}
+}
+
+func TestValidateEntitiesOrder(t *testing.T) {
+	t.Parallel()
+	var s schema.Schema
+	testutil.OK(t, s.UnmarshalJSON([]byte(`{"": {
+		"entityTypes": {"User": {"shape": {"type": "Record", "attributes": {"age": {"type": "Long"}}}}},
+		"actions": {}
+	}}`)))
+	a := types.NewEntityUID("User", "a")
+	b := types.NewEntityUID("User", "b")
+	got, err := schema.ValidateEntities(&s, types.EntityMap{
+		b:                                {UID: b},
+		a:                                {UID: a, Attributes: types.NewRecord(types.RecordMap{"age": types.Long(1)})},
+		types.NewEntityUID("Admin", "x"): {UID: types.NewEntityUID("Admin", "x")},
+	})
+	testutil.OK(t, err)
+	testutil.Equals(t, got, []schema.EntityError{
+		{UID: types.NewEntityUID("Admin", "x"), Message: "unknown entity type Admin"},
+		{UID: b, Path: "age", Message: "required attribute is missing"},
+	})
+	testutil.Equals(t, got[0].String(), "while validating entity `Admin::\"x\"`: unknown entity type Admin")
+	testutil.Equals(t, got[1].String(), "while validating entity `User::\"b\"`, at `age`: required attribute is missing")
+	testutil.JSONMarshalsTo(t, got[1],
+		`{"uid":{"__entity":{"type":"User","id":"b"}},"path":"age","message":"required attribute is missing"}`)
+
+	var bad schema.Schema
+	testutil.OK(t, bad.UnmarshalCedar([]byte(`entity User in [Group];`)))
+	_, err = schema.ValidateEntities(&bad, nil)
+	testutil.Error(t, err)
+}
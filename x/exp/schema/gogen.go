@@ -0,0 +1,326 @@
+package schema
+
+import (
+	"bytes"
+	"fmt"
+	"go/format"
+	"maps"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/cedar-policy/cedar-go/types"
+	"github.com/cedar-policy/cedar-go/x/exp/schema/ast"
+)
+
+// GenerateGo returns the source of a Go file in the named package which declares types for the entities and action
+// contexts that the schema describes.  For each entity type, e.g. `App::User`, the file declares:
+//   - a constant UserType holding the entity type
+//   - a function NewUserUID which returns the UID of the entity with a given ID
+//   - for an enumerated entity type, a variable holding the UID of each of its entities, e.g. ColorRed
+//   - otherwise, a struct User with the entity's ID, its parents if the entity type has any memberOfTypes, a field for
+//     each attribute and a map of its tags if the entity type declares tags, and methods UID, Record and Entity which
+//     convert it to a types.EntityUID, a types.Record of its attributes and a types.Entity
+//
+// For each action the file declares a variable holding its UID, e.g. ActionView, and for each action with context
+// attributes a struct, e.g. ViewContext, whose Record method returns the context.  Records nested within attributes
+// are declared as structs with a Record method, named after the attribute, e.g. UserAddress.
+//
+// Names are converted to Go identifiers by capitalizing each word.  Entity types declared in different namespaces with
+// the same name are distinguished by their namespace, e.g. AppUser, and any other clashing name is given a numeric
+// suffix.  Optional attributes are represented by pointers, sets by slices, Booleans, numbers, strings and extension
+// values by the corresponding types in the types package, and entity references by types.EntityUID.
+func GenerateGo(s *Schema, packageName string) ([]byte, error) {
+	rs, err := s.resolve()
+	if err != nil {
+		return nil, err
+	}
+	g := goGenerator{
+		rs:        rs,
+		names:     map[string]bool{},
+		typeNames: map[types.EntityType]string{},
+		declared:  map[string]string{},
+	}
+	g.generate(packageName)
+	return format.Source(g.buf.Bytes())
+}
+
+// goRecord is a struct type which is to be declared for a record type.
+type goRecord struct {
+	name string
+	doc  string
+	typ  ast.RecordType
+}
+
+type goField struct {
+	name string
+	typ  string
+	attr ast.Attribute
+}
+
+type goGenerator struct {
+	rs        *resolvedSchema
+	buf       bytes.Buffer
+	names     map[string]bool
+	typeNames map[types.EntityType]string
+	records   []goRecord
+	declared  map[string]string
+	setHelper bool
+}
+
+func (g *goGenerator) printf(format string, args ...any) {
+	fmt.Fprintf(&g.buf, format, args...)
+}
+
+var goInitialisms = map[string]string{
+	"api": "API", "http": "HTTP", "id": "ID", "ip": "IP", "json": "JSON", "uid": "UID", "uri": "URI", "url": "URL",
+}
+
+// goName converts a Cedar name to an exported Go identifier by capitalizing each of its words.
+func goName(s string) string {
+	var sb strings.Builder
+	words := strings.FieldsFunc(s, func(r rune) bool { return !unicode.IsLetter(r) && !unicode.IsDigit(r) })
+	for _, w := range words {
+		if v, ok := goInitialisms[strings.ToLower(w)]; ok {
+			sb.WriteString(v)
+			continue
+		}
+		r, size := utf8.DecodeRuneInString(w)
+		sb.WriteRune(unicode.ToUpper(r))
+		sb.WriteString(w[size:])
+	}
+	res := sb.String()
+	if r, _ := utf8.DecodeRuneInString(res); !unicode.IsUpper(r) {
+		res = "X" + res
+	}
+	return res
+}
+
+// unique returns name, or name with the smallest numeric suffix which makes it unique, and records it as used.
+func unique(used map[string]bool, name string) string {
+	res := name
+	for i := 2; used[res]; i++ {
+		res = name + strconv.Itoa(i)
+	}
+	used[res] = true
+	return res
+}
+
+func (g *goGenerator) generate(packageName string) {
+	g.printf("// Code generated from a Cedar schema. DO NOT EDIT.\n\npackage %v\n\n", packageName)
+	g.printf("import \"github.com/cedar-policy/cedar-go/types\"\n")
+
+	basenames := map[string]int{}
+	for _, name := range g.rs.entityOrder {
+		_, basename := splitEntityType(name)
+		basenames[basename]++
+	}
+	for _, name := range g.rs.entityOrder {
+		ns, basename := splitEntityType(name)
+		if basenames[basename] > 1 {
+			basename = string(ns) + " " + basename
+		}
+		g.typeNames[name] = unique(g.names, goName(basename))
+	}
+	for _, name := range g.rs.entityOrder {
+		g.entity(g.rs.entities[name])
+	}
+	for _, uid := range g.rs.actionOrder {
+		g.action(g.rs.actions[uid])
+	}
+	for len(g.records) > 0 {
+		r := g.records[0]
+		g.records = g.records[1:]
+		g.record(r)
+	}
+	if g.setHelper {
+		g.printf("\nfunc newSet[T any](vs []T, f func(T) types.Value) types.Set {\n")
+		g.printf("vals := make([]types.Value, len(vs))\nfor i, v := range vs {\nvals[i] = f(v)\n}\n")
+		g.printf("return types.NewSet(vals...)\n}\n")
+	}
+}
+
+func (g *goGenerator) entity(e *resolvedEntity) {
+	name := g.typeNames[e.name]
+	typeConst := unique(g.names, name+"Type")
+	newUID := unique(g.names, "New"+name+"UID")
+	g.printf("\n// %v is the Cedar entity type %v.\nconst %v types.EntityType = %q\n", typeConst, e.name, typeConst, e.name)
+	g.printf("\n// %v returns the UID of the %v entity with the given ID.\n", newUID, e.name)
+	g.printf("func %v(id types.String) types.EntityUID {\nreturn types.NewEntityUID(%v, id)\n}\n", newUID, typeConst)
+
+	if e.enum != nil {
+		g.printf("\n// The entities of the enumerated entity type %v.\nvar (\n", e.name)
+		for _, v := range e.enum {
+			g.printf("%v = %v(%q)\n", unique(g.names, name+goName(string(v))), newUID, v)
+		}
+		g.printf(")\n")
+		return
+	}
+
+	reserved := map[string]bool{"ID": true, "Parents": true, "Tags": true, "UID": true, "Record": true, "Entity": true}
+	fields := g.fields(name, name, e.shape, reserved)
+	g.printf("\n// %v is an entity of type %v.\ntype %v struct {\nID types.String\n", name, e.name, name)
+	if len(e.memberOf) > 0 {
+		g.printf("Parents []types.EntityUID\n")
+	}
+	for _, f := range fields {
+		g.printf("%v %v\n", f.name, g.fieldType(f))
+	}
+	var tagType string
+	if e.tags != nil {
+		tagType = g.goType(e.tags, name, name+"Tag")
+		g.printf("Tags map[types.String]%v\n", tagType)
+	}
+	g.printf("}\n")
+
+	g.printf("\n// UID returns the UID of the entity.\nfunc (e %v) UID() types.EntityUID {\nreturn %v(e.ID)\n}\n", name, newUID)
+	g.printf("\n// Record returns the attributes of the entity.\nfunc (e %v) Record() types.Record {\n", name)
+	g.recordBody("e", fields)
+	g.printf("}\n")
+
+	g.printf("\n// Entity returns the entity.\nfunc (e %v) Entity() types.Entity {\n", name)
+	if e.tags != nil {
+		g.printf("tags := make(types.RecordMap, len(e.Tags))\nfor k, v := range e.Tags {\ntags[k] = %v\n}\n",
+			g.valueExpr(e.tags, tagType, "v"))
+	}
+	g.printf("return types.Entity{\nUID: e.UID(),\n")
+	if len(e.memberOf) > 0 {
+		g.printf("Parents: types.NewEntityUIDSet(e.Parents...),\n")
+	} else {
+		g.printf("Parents: types.NewEntityUIDSet(),\n")
+	}
+	g.printf("Attributes: e.Record(),\n")
+	if e.tags != nil {
+		g.printf("Tags: types.NewRecord(tags),\n")
+	}
+	g.printf("}\n}\n")
+}
+
+func (g *goGenerator) action(a *resolvedAction) {
+	name := goName(string(a.uid.ID))
+	varName := unique(g.names, "Action"+name)
+	g.printf("\n// %v is the UID of the action %v.\n", varName, a.uid)
+	g.printf("var %v = types.NewEntityUID(%q, %q)\n", varName, a.uid.Type, a.uid.ID)
+	if a.appliesTo != nil && len(a.appliesTo.context.Attributes) > 0 {
+		g.records = append(g.records, goRecord{
+			name: unique(g.names, name+"Context"),
+			doc:  fmt.Sprintf("is the context of a request for the action %v.", a.uid),
+			typ:  a.appliesTo.context,
+		})
+	}
+}
+
+// fields returns the fields of the struct owner for the attributes of a record type, avoiding the reserved names.
+func (g *goGenerator) fields(owner string, prefix string, t ast.RecordType, reserved map[string]bool) []goField {
+	used := maps.Clone(reserved)
+	res := make([]goField, len(t.Attributes))
+	for i, a := range t.Attributes {
+		name := goName(string(a.Name))
+		if reserved[name] {
+			name += "Attr"
+		}
+		res[i] = goField{name: unique(used, name), attr: a}
+	}
+	for i, f := range res {
+		res[i].typ = g.goType(f.attr.Type, owner, prefix+f.name)
+	}
+	return res
+}
+
+func (g *goGenerator) fieldType(f goField) string {
+	if f.attr.Optional {
+		return "*" + f.typ
+	}
+	return f.typ
+}
+
+// goType returns the Go type which represents a resolved schema type within the struct owner.  A record is represented
+// by a struct type named after prefix, which is declared later, unless a struct has already been declared for an
+// identical record type, as happens when a common type is used more than once.
+func (g *goGenerator) goType(t ast.IsType, owner string, prefix string) string {
+	switch t := t.(type) {
+	case ast.BooleanType:
+		return "types.Boolean"
+	case ast.LongType:
+		return "types.Long"
+	case ast.StringType:
+		return "types.String"
+	case ast.EntityTypeRef:
+		return "types.EntityUID"
+	case ast.ExtensionType:
+		switch t.Name {
+		case "ipaddr":
+			return "types.IPAddr"
+		case "decimal":
+			return "types.Decimal"
+		case "datetime":
+			return "types.Datetime"
+		case "duration":
+			return "types.Duration"
+		}
+	case ast.SetType:
+		return "[]" + g.goType(t.Element, owner, prefix)
+	case ast.RecordType:
+		key := valueTypeFromSchema(t).String()
+		if name, ok := g.declared[key]; ok {
+			return name
+		}
+		name := unique(g.names, prefix)
+		g.declared[key] = name
+		g.records = append(g.records, goRecord{name: name, doc: "is a record type used by " + owner + ".", typ: t})
+		return name
+	}
+	return "types.Value"
+}
+
+// valueExpr returns an expression which converts the Go expression expr, of the Go type goType which represents t, to
+// a types.Value.
+func (g *goGenerator) valueExpr(t ast.IsType, goType string, expr string) string {
+	switch t := t.(type) {
+	case ast.SetType:
+		g.setHelper = true
+		elem := strings.TrimPrefix(goType, "[]")
+		return fmt.Sprintf("newSet(%v, func(v %v) types.Value { return %v })", expr, elem, g.valueExpr(t.Element, elem, "v"))
+	case ast.RecordType:
+		return expr + ".Record()"
+	}
+	return expr
+}
+
+// recordBody generates the body of a Record method which returns the fields of the receiver recv as a types.Record.
+func (g *goGenerator) recordBody(recv string, fields []goField) {
+	if len(fields) == 0 {
+		g.printf("return types.Record{}\n")
+		return
+	}
+	g.printf("m := types.RecordMap{\n")
+	for _, f := range fields {
+		if !f.attr.Optional {
+			g.printf("%q: %v,\n", f.attr.Name, g.valueExpr(f.attr.Type, f.typ, recv+"."+f.name))
+		}
+	}
+	g.printf("}\n")
+	for _, f := range fields {
+		if f.attr.Optional {
+			expr := "*" + recv + "." + f.name
+			if _, ok := f.attr.Type.(ast.RecordType); ok {
+				expr = recv + "." + f.name
+			}
+			g.printf("if %v.%v != nil {\nm[%q] = %v\n}\n", recv, f.name, f.attr.Name, g.valueExpr(f.attr.Type, f.typ, expr))
+		}
+	}
+	g.printf("return types.NewRecord(m)\n")
+}
+
+func (g *goGenerator) record(r goRecord) {
+	fields := g.fields(r.name, r.name, r.typ, map[string]bool{"Record": true})
+	g.printf("\n// %v %v\ntype %v struct {\n", r.name, r.doc, r.name)
+	for _, f := range fields {
+		g.printf("%v %v\n", f.name, g.fieldType(f))
+	}
+	g.printf("}\n")
+	g.printf("\n// Record returns the record.\nfunc (r %v) Record() types.Record {\n", r.name)
+	g.recordBody("r", fields)
+	g.printf("}\n")
+}
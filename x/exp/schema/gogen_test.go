@@ -0,0 +1,104 @@
+package schema_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/cedar-policy/cedar-go/internal/testutil"
+	"github.com/cedar-policy/cedar-go/x/exp/schema"
+)
+
+func TestGenerateGo(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name   string
+		schema string
+		want   []string
+	}{
+		{
+			"reservedFields",
+			`entity User = { id: String, uid: String, tags: Long, "record": Bool, entity: String, parents: Long, "": Long };`,
+			[]string{
+				"\tIDAttr      types.String\n",
+				"\tUIDAttr     types.String\n",
+				"\tTagsAttr    types.Long\n",
+				"\tRecordAttr  types.Boolean\n",
+				"\tEntityAttr  types.String\n",
+				"\tParentsAttr types.Long\n",
+				"\tX           types.Long\n",
+				`"id":      e.IDAttr,`,
+			},
+		},
+		{
+			"clashingNames",
+			`entity User = { "a b": Long, a_b: String, "ünïcode": Long, "日本": Long, "9lives": Bool };
+entity UserType;
+action "a b"; action a_b;`,
+			[]string{
+				"\tAB      types.Long\n",
+				"\tAB2     types.String\n",
+				"\tÜnïcode types.Long\n",
+				"\tX日本     types.Long\n",
+				"\tX9lives types.Boolean\n",
+				"const UserType2 types.EntityType = \"User\"",
+				"const UserTypeType types.EntityType = \"UserType\"",
+				"var ActionAB = types.NewEntityUID(\"Action\", \"a b\")",
+				"var ActionAB2 = types.NewEntityUID(\"Action\", \"a_b\")",
+			},
+		},
+		{
+			"namespaces",
+			`namespace A { entity User; } namespace B { entity User; entity Doc; }`,
+			[]string{"type AUser struct", "type BUser struct", "type Doc struct", `const BUserType types.EntityType = "B::User"`},
+		},
+		{
+			"nestedRecords",
+			`type R = { x: Long };
+entity User = { a: R, b?: R, c: Set<Set<R>>, d: { x: String } } tags R;
+action view appliesTo { principal: User, resource: User, context: R };`,
+			[]string{
+				"\tA UserA\n",
+				"\tB *UserA\n",
+				"\tC [][]UserA\n",
+				"\tD UserD\n",
+				"\tTags map[types.String]UserA\n",
+				"// UserA is a record type used by User.",
+				"// ViewContext is the context of a request for the action Action::\"view\".",
+				`"c": newSet(e.C, func(v []UserA) types.Value {`,
+				`return newSet(v, func(v UserA) types.Value { return v.Record() })`,
+				"tags[k] = v.Record()",
+				"m[\"b\"] = e.B.Record()",
+			},
+		},
+		{
+			"extensions",
+			`entity E = { a: ipaddr, b: decimal, c: datetime, d: duration, e?: Bool };`,
+			[]string{"\tA types.IPAddr\n", "\tB types.Decimal\n", "\tC types.Datetime\n", "\tD types.Duration\n", "\tE *types.Boolean\n"},
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			var s schema.Schema
+			testutil.OK(t, s.UnmarshalCedar([]byte(tt.schema)))
+			b, err := schema.GenerateGo(&s, "p")
+			testutil.OK(t, err)
+			// Compare with runs of whitespace collapsed, so that the expectations are independent of alignment.
+			normalize := func(s string) string { return strings.Join(strings.Fields(s), " ") }
+			for _, want := range tt.want {
+				if !strings.Contains(normalize(string(b)), normalize(want)) {
+					t.Errorf("generated code does not contain %q:\n%s", want, b)
+				}
+			}
+		})
+	}
+}
+
+func TestGenerateGoSchemaError(t *testing.T) {
+	t.Parallel()
+	var s schema.Schema
+	testutil.OK(t, s.UnmarshalCedar([]byte(`entity User in [Missing];`)))
+	_, err := schema.GenerateGo(&s, "p")
+	testutil.Error(t, err)
+}
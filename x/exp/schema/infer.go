@@ -0,0 +1,655 @@
+package schema
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+
+	"github.com/cedar-policy/cedar-go"
+	"github.com/cedar-policy/cedar-go/internal/consts"
+	"github.com/cedar-policy/cedar-go/types"
+	"github.com/cedar-policy/cedar-go/x/exp/ast"
+	schemaast "github.com/cedar-policy/cedar-go/x/exp/schema/ast"
+)
+
+// An InferenceWarning details a part of a schema produced by InferSchema which could not be determined with
+// certainty.  Location identifies the declaration using Cedar expression syntax rooted at an entity type or at the
+// context, e.g. `User.address.city`, `User tags` or `context.ip`.
+type InferenceWarning struct {
+	Location string `json:"location"`
+	Message  string `json:"message"`
+}
+
+func (w InferenceWarning) String() string {
+	return fmt.Sprintf("at `%v`: %v", w.Location, w.Message)
+}
+
+// InferSchema returns a draft schema describing the given entities and policies, which may be nil.
+//
+// Entity types are inferred from the entities, their parents and the entity references among their attributes, and
+// from the entity types which the policies mention.  The shape of each entity type is the union of the attributes of
+// its entities: an attribute is required if every entity of the type has it.  The parent types of an entity type
+// become its memberOfTypes, and the types of its tags determine its tags type.
+//
+// Actions are inferred from the action scopes of the policies, and from any action entities among the entities, whose
+// parents become the action groups the action is a member of.  The principal and resource types an action applies to
+// are the types named by the scopes of the policies which apply to it; an action whose policies do not constrain its
+// principal or resource type applies to every inferred entity type.  The context type of an action includes the
+// context attributes which its policies access, typed according to how they are used.
+//
+// InferSchema returns a warning for each value whose type conflicts with the type of other values in the same place,
+// in which case the type which was observed first is used, and for each type which it cannot determine, in which case
+// String is used.
+func InferSchema(entities types.EntityMap, policies *cedar.PolicySet) (*Schema, []InferenceWarning) {
+	in := inferrer{
+		entities: map[types.EntityType]*inferredEntity{},
+		actions:  map[types.EntityUID]*inferredAction{},
+	}
+	for _, uid := range sortedEntityUIDs(entities) {
+		in.entity(entities[uid])
+	}
+	var ps []*ast.Policy
+	if policies != nil {
+		for _, id := range sortedPolicyIDs(policies) {
+			ps = append(ps, (*ast.Policy)(policies.Get(id).AST()))
+		}
+	}
+	for _, p := range ps {
+		in.policyReferences(p)
+	}
+	for _, p := range ps {
+		in.policy(p)
+	}
+	s := in.schema()
+	slices.SortStableFunc(in.warnings, func(a, b InferenceWarning) int { return strings.Compare(a.Location, b.Location) })
+	return NewSchemaFromAST(s), in.warnings
+}
+
+func sortedEntityUIDs(entities types.EntityMap) []types.EntityUID {
+	uids := slices.Collect(maps.Keys(entities))
+	sortUIDs(uids)
+	return uids
+}
+
+// inferredType accumulates the types of the values observed in one place.  Kind is the name of the type, as returned
+// by valueType.String for primitive and extension types, or one of "Set", "Record" and "Entity".
+type inferredType struct {
+	kind    string
+	entity  types.EntityType
+	element *inferredType
+	attrs   map[types.String]*inferredAttribute
+	records int
+}
+
+type inferredAttribute struct {
+	typ      *inferredType
+	count    int
+	optional bool
+}
+
+func (t *inferredType) String() string {
+	switch t.kind {
+	case "Entity":
+		return string(t.entity)
+	case "Set":
+		if t.element == nil {
+			return "Set"
+		}
+		return "Set<" + t.element.String() + ">"
+	case "Record":
+		return "record"
+	}
+	return t.kind
+}
+
+func (t *inferredType) attribute(name types.String) *inferredAttribute {
+	if t.attrs == nil {
+		t.attrs = map[types.String]*inferredAttribute{}
+	}
+	a, ok := t.attrs[name]
+	if !ok {
+		a = &inferredAttribute{}
+		t.attrs[name] = a
+	}
+	return a
+}
+
+type inferredEntity struct {
+	attrs    inferredType
+	memberOf map[types.EntityType]bool
+	tags     *inferredType
+}
+
+type inferredAction struct {
+	memberOf   map[types.EntityUID]bool
+	principals map[types.EntityType]bool
+	resources  map[types.EntityType]bool
+	anyPrin    bool
+	anyRes     bool
+	context    inferredType
+}
+
+type inferrer struct {
+	entities map[types.EntityType]*inferredEntity
+	actions  map[types.EntityUID]*inferredAction
+	warnings []InferenceWarning
+}
+
+func (in *inferrer) warnf(loc string, format string, args ...any) {
+	w := InferenceWarning{Location: loc, Message: fmt.Sprintf(format, args...)}
+	if !slices.Contains(in.warnings, w) {
+		in.warnings = append(in.warnings, w)
+	}
+}
+
+func isInferredActionType(t types.EntityType) bool {
+	return t == "Action" || strings.HasSuffix(string(t), "::Action")
+}
+
+func (in *inferrer) entityType(t types.EntityType) *inferredEntity {
+	e, ok := in.entities[t]
+	if !ok {
+		e = &inferredEntity{attrs: inferredType{kind: "Record"}, memberOf: map[types.EntityType]bool{}}
+		in.entities[t] = e
+	}
+	return e
+}
+
+func (in *inferrer) action(uid types.EntityUID) *inferredAction {
+	a, ok := in.actions[uid]
+	if !ok {
+		a = &inferredAction{
+			memberOf:   map[types.EntityUID]bool{},
+			principals: map[types.EntityType]bool{},
+			resources:  map[types.EntityType]bool{},
+			context:    inferredType{kind: "Record"},
+		}
+		in.actions[uid] = a
+	}
+	return a
+}
+
+// reference records an entity type or action mentioned by an entity or policy.
+func (in *inferrer) reference(uid types.EntityUID) {
+	if isInferredActionType(uid.Type) {
+		in.action(uid)
+		return
+	}
+	in.entityType(uid.Type)
+}
+
+func (in *inferrer) entity(e types.Entity) {
+	if isInferredActionType(e.UID.Type) {
+		a := in.action(e.UID)
+		for _, p := range sortedParents(e) {
+			in.action(p)
+			a.memberOf[p] = true
+		}
+		return
+	}
+	et := in.entityType(e.UID.Type)
+	for _, p := range sortedParents(e) {
+		in.reference(p)
+		et.memberOf[p.Type] = true
+	}
+	in.observe(string(e.UID.Type), &et.attrs, e.Attributes)
+	for _, k := range sortedKeys(e.Tags) {
+		v, _ := e.Tags.Get(k)
+		in.observeInto(string(e.UID.Type)+" tags", &et.tags, v)
+	}
+}
+
+// observeInto records the type of v in *dst, allocating it if necessary.
+func (in *inferrer) observeInto(loc string, dst **inferredType, v types.Value) {
+	if *dst == nil {
+		*dst = &inferredType{}
+	}
+	in.observe(loc, *dst, v)
+}
+
+// observe records the type of v in t.  A value whose type conflicts with t is reported and otherwise ignored.
+func (in *inferrer) observe(loc string, t *inferredType, v types.Value) {
+	obs := inferredType{kind: describeValue(v)}
+	switch v := v.(type) {
+	case types.EntityUID:
+		obs = inferredType{kind: "Entity", entity: v.Type}
+		in.reference(v)
+	case types.Set:
+		obs.kind = "Set"
+	case types.Record:
+		obs.kind = "Record"
+	}
+	if t.kind == "" {
+		t.kind, t.entity = obs.kind, obs.entity
+	}
+	if t.kind != obs.kind || t.entity != obs.entity {
+		in.warnf(loc, "observed values of type %v and %v; using %v", t, &obs, t)
+		return
+	}
+	switch v := v.(type) {
+	case types.Set:
+		for e := range v.All() {
+			in.observeInto(loc, &t.element, e)
+		}
+	case types.Record:
+		t.records++
+		for _, k := range sortedKeys(v) {
+			a := t.attribute(k)
+			a.count++
+			e, _ := v.Get(k)
+			in.observeInto(attributePath(loc, k), &a.typ, e)
+		}
+	}
+}
+
+// merge records the type src, which was inferred from the use of a value, in *dst.
+func (in *inferrer) merge(loc string, dst **inferredType, src *inferredType) {
+	if src == nil {
+		return
+	}
+	if *dst == nil {
+		*dst = &inferredType{kind: src.kind, entity: src.entity}
+	}
+	t := *dst
+	if t.kind != src.kind || t.entity != src.entity {
+		in.warnf(loc, "used as both %v and %v; using %v", t, src, t)
+		return
+	}
+	in.merge(loc, &t.element, src.element)
+	t.records += src.records
+	for _, k := range slices.Sorted(maps.Keys(src.attrs)) {
+		sa := src.attrs[k]
+		a := t.attribute(k)
+		a.count += sa.count
+		a.optional = a.optional || sa.optional
+		in.merge(attributePath(loc, k), &a.typ, sa.typ)
+	}
+}
+
+// policyReferences records the entity types and actions which the policy mentions.
+func (in *inferrer) policyReferences(p *ast.Policy) {
+	scopeReference := func(n ast.IsScopeNode) {
+		switch n := n.(type) {
+		case ast.ScopeTypeEq:
+			in.reference(n.Entity)
+		case ast.ScopeTypeIn:
+			in.reference(n.Entity)
+		case ast.ScopeTypeInSet:
+			for _, e := range n.Entities {
+				in.reference(e)
+			}
+		case ast.ScopeTypeIs:
+			in.entityType(n.Type)
+		case ast.ScopeTypeIsIn:
+			in.entityType(n.Type)
+			in.reference(n.Entity)
+		}
+	}
+	scopeReference(p.Principal)
+	scopeReference(p.Action)
+	scopeReference(p.Resource)
+	for _, cond := range p.Conditions {
+		walk(cond.Body, func(n ast.IsNode) {
+			switch n := n.(type) {
+			case ast.NodeValue:
+				walkValue(n.Value, in.reference)
+			case ast.NodeTypeIs:
+				in.entityType(n.EntityType)
+			case ast.NodeTypeIsIn:
+				in.entityType(n.EntityType)
+			}
+		})
+	}
+}
+
+// scopeActions returns the actions which the action scope of a policy matches.
+func (in *inferrer) scopeActions(n ast.IsScopeNode) []types.EntityUID {
+	var groups []types.EntityUID
+	switch n := n.(type) {
+	case ast.ScopeTypeEq:
+		return []types.EntityUID{n.Entity}
+	case ast.ScopeTypeIn:
+		groups = []types.EntityUID{n.Entity}
+	case ast.ScopeTypeInSet:
+		groups = n.Entities
+	}
+	var res []types.EntityUID
+	for _, uid := range sortedActionUIDs(in.actions) {
+		if groups == nil || slices.ContainsFunc(groups, func(g types.EntityUID) bool { return in.actionIn(uid, g) }) {
+			res = append(res, uid)
+		}
+	}
+	return res
+}
+
+func sortedActionUIDs(actions map[types.EntityUID]*inferredAction) []types.EntityUID {
+	uids := slices.Collect(maps.Keys(actions))
+	sortUIDs(uids)
+	return uids
+}
+
+func (in *inferrer) actionIn(child, parent types.EntityUID) bool {
+	seen := map[types.EntityUID]bool{}
+	queue := []types.EntityUID{child}
+	for len(queue) > 0 {
+		uid := queue[0]
+		queue = queue[1:]
+		if uid == parent {
+			return true
+		}
+		if seen[uid] {
+			continue
+		}
+		seen[uid] = true
+		if a, ok := in.actions[uid]; ok {
+			queue = append(queue, slices.Collect(maps.Keys(a.memberOf))...)
+		}
+	}
+	return false
+}
+
+// scopeType returns the entity type which the principal or resource scope of a policy requires, if any.
+func scopeType(n ast.IsScopeNode) (types.EntityType, bool) {
+	switch n := n.(type) {
+	case ast.ScopeTypeEq:
+		return n.Entity.Type, true
+	case ast.ScopeTypeIs:
+		return n.Type, true
+	case ast.ScopeTypeIsIn:
+		return n.Type, true
+	}
+	return "", false
+}
+
+func (in *inferrer) policy(p *ast.Policy) {
+	context := &inferredType{kind: "Record"}
+	for _, cond := range p.Conditions {
+		in.contextUsage(cond.Body, context)
+	}
+	principal, principalOK := scopeType(p.Principal)
+	resource, resourceOK := scopeType(p.Resource)
+	for _, uid := range in.scopeActions(p.Action) {
+		a := in.action(uid)
+		if principalOK {
+			a.principals[principal] = true
+		} else {
+			a.anyPrin = true
+		}
+		if resourceOK {
+			a.resources[resource] = true
+		} else {
+			a.anyRes = true
+		}
+		ctx := &a.context
+		in.merge("context", &ctx, context)
+	}
+}
+
+// contextPath returns the attributes accessed by an expression such as `context.a.b`.  The path of `context` itself
+// is empty.
+func contextPath(n ast.IsNode) ([]types.String, bool) {
+	switch n := n.(type) {
+	case ast.NodeTypeVariable:
+		return nil, n.Name == consts.Context
+	case ast.NodeTypeAccess:
+		path, ok := contextPath(n.Arg)
+		return append(path, n.Value), ok
+	}
+	return nil, false
+}
+
+// literalType returns the type of an expression which is a literal or a call to an extension type constructor.
+func (in *inferrer) literalType(n ast.IsNode) *inferredType {
+	switch n := n.(type) {
+	case ast.NodeValue:
+		t := &inferredType{}
+		in.observe("", t, n.Value)
+		return t
+	case ast.NodeTypeExtensionCall:
+		if _, ok := extensionConstructors[n.Name]; ok {
+			return &inferredType{kind: extensionSignatures[n.Name].result.String()}
+		}
+	case ast.NodeTypeSet:
+		t := &inferredType{kind: "Set"}
+		for _, e := range n.Elements {
+			if et := in.literalType(e); et != nil && t.element == nil {
+				t.element = et
+			}
+		}
+		return t
+	}
+	return nil
+}
+
+var (
+	inferredBool = &inferredType{kind: "Bool"}
+	inferredLong = &inferredType{kind: "Long"}
+	inferredStr  = &inferredType{kind: "String"}
+)
+
+// contextUsage records in context the types of the context attributes used in the expression, according to how they
+// are used.
+//
+//nolint:revive // this is a large but simple switch over the node types
+func (in *inferrer) contextUsage(body ast.IsNode, context *inferredType) {
+	use := func(n ast.IsNode, t *inferredType) {
+		path, ok := contextPath(n)
+		if !ok || len(path) == 0 {
+			return
+		}
+		in.contextAttribute(context, path, false, t)
+	}
+	either := func(b ast.BinaryNode, t func(other ast.IsNode) *inferredType) {
+		use(b.Left, t(b.Right))
+		use(b.Right, t(b.Left))
+	}
+	literalOr := func(def *inferredType) func(ast.IsNode) *inferredType {
+		return func(other ast.IsNode) *inferredType {
+			if t := in.literalType(other); t != nil {
+				return t
+			}
+			return def
+		}
+	}
+	use(body, inferredBool)
+	walk(body, func(n ast.IsNode) {
+		switch n := n.(type) {
+		case ast.NodeTypeAccess:
+			use(n, nil)
+		case ast.NodeTypeHas:
+			if path, ok := contextPath(n.Arg); ok {
+				in.contextAttribute(context, append(path, n.Value), true, nil)
+			}
+		case ast.NodeTypeEquals:
+			either(n.BinaryNode, literalOr(nil))
+		case ast.NodeTypeNotEquals:
+			either(n.BinaryNode, literalOr(nil))
+		case ast.NodeTypeLessThan:
+			either(n.BinaryNode, literalOr(inferredLong))
+		case ast.NodeTypeLessThanOrEqual:
+			either(n.BinaryNode, literalOr(inferredLong))
+		case ast.NodeTypeGreaterThan:
+			either(n.BinaryNode, literalOr(inferredLong))
+		case ast.NodeTypeGreaterThanOrEqual:
+			either(n.BinaryNode, literalOr(inferredLong))
+		case ast.NodeTypeAdd:
+			either(n.BinaryNode, literalOr(inferredLong))
+		case ast.NodeTypeSub:
+			either(n.BinaryNode, literalOr(inferredLong))
+		case ast.NodeTypeMult:
+			either(n.BinaryNode, literalOr(inferredLong))
+		case ast.NodeTypeNegate:
+			use(n.Arg, inferredLong)
+		case ast.NodeTypeAnd:
+			either(n.BinaryNode, literalOr(inferredBool))
+		case ast.NodeTypeOr:
+			either(n.BinaryNode, literalOr(inferredBool))
+		case ast.NodeTypeNot:
+			use(n.Arg, inferredBool)
+		case ast.NodeTypeIfThenElse:
+			use(n.If, inferredBool)
+		case ast.NodeTypeLike:
+			use(n.Arg, inferredStr)
+		case ast.NodeTypeIs:
+			use(n.Left, &inferredType{kind: "Entity", entity: n.EntityType})
+		case ast.NodeTypeIsIn:
+			use(n.Left, &inferredType{kind: "Entity", entity: n.EntityType})
+		case ast.NodeTypeContains:
+			use(n.Left, &inferredType{kind: "Set", element: in.literalType(n.Right)})
+			if s := in.literalType(n.Left); s != nil {
+				use(n.Right, s.element)
+			}
+		case ast.NodeTypeContainsAll:
+			either(n.BinaryNode, literalOr(&inferredType{kind: "Set"}))
+		case ast.NodeTypeContainsAny:
+			either(n.BinaryNode, literalOr(&inferredType{kind: "Set"}))
+		case ast.NodeTypeIsEmpty:
+			use(n.Arg, &inferredType{kind: "Set"})
+		case ast.NodeTypeExtensionCall:
+			if sig, ok := extensionSignatures[n.Name]; ok && len(sig.args) == len(n.Args) {
+				for i, a := range n.Args {
+					use(a, &inferredType{kind: sig.args[i].String()})
+				}
+			}
+		}
+	})
+}
+
+// contextAttribute records the use of the context attribute at the given path, which must be non-empty.
+func (in *inferrer) contextAttribute(context *inferredType, path []types.String, optional bool, t *inferredType) {
+	loc := "context"
+	rec := context
+	for i, name := range path {
+		loc = attributePath(loc, name)
+		a := rec.attribute(name)
+		if i == len(path)-1 {
+			a.optional = a.optional || optional
+			in.merge(loc, &a.typ, t)
+			return
+		}
+		in.merge(loc, &a.typ, &inferredType{kind: "Record"})
+		if a.typ.kind != "Record" {
+			return
+		}
+		rec = a.typ
+	}
+}
+
+func splitEntityType(t types.EntityType) (types.Path, string) {
+	i := strings.LastIndex(string(t), "::")
+	if i < 0 {
+		return "", string(t)
+	}
+	return types.Path(t[:i]), string(t[i+2:])
+}
+
+func (in *inferrer) schemaType(loc string, t *inferredType) schemaast.IsType {
+	if t == nil {
+		in.warnf(loc, "the type could not be determined; using String")
+		return schemaast.StringType{}
+	}
+	switch t.kind {
+	case "Bool":
+		return schemaast.BooleanType{}
+	case "Long":
+		return schemaast.LongType{}
+	case "String":
+		return schemaast.StringType{}
+	case "Entity":
+		return schemaast.EntityTypeRef{Name: types.Path(t.entity)}
+	case "Set":
+		return schemaast.SetType{Element: in.schemaType(loc, t.element)}
+	case "Record":
+		return in.recordType(loc, t)
+	}
+	return schemaast.ExtensionType{Name: types.Ident(t.kind)}
+}
+
+func (in *inferrer) recordType(loc string, t *inferredType) schemaast.RecordType {
+	res := schemaast.RecordType{}
+	for _, k := range slices.Sorted(maps.Keys(t.attrs)) {
+		a := t.attrs[k]
+		res.Attributes = append(res.Attributes, schemaast.Attribute{
+			Name:     k,
+			Type:     in.schemaType(attributePath(loc, k), a.typ),
+			Optional: a.optional || a.count < t.records,
+		})
+	}
+	return res
+}
+
+func (in *inferrer) schema() *schemaast.Schema {
+	namespaces := map[types.Path]*schemaast.Namespace{}
+	namespace := func(name types.Path) *schemaast.Namespace {
+		ns, ok := namespaces[name]
+		if !ok {
+			ns = &schemaast.Namespace{Name: name}
+			namespaces[name] = ns
+		}
+		return ns
+	}
+
+	entityTypes := slices.Sorted(maps.Keys(in.entities))
+	for _, t := range entityTypes {
+		e := in.entities[t]
+		nsName, name := splitEntityType(t)
+		decl := &schemaast.Entity{Name: types.Ident(name)}
+		for _, p := range slices.Sorted(maps.Keys(e.memberOf)) {
+			decl.MemberOf = append(decl.MemberOf, types.Path(p))
+		}
+		if len(e.attrs.attrs) > 0 {
+			decl.Shape = in.recordType(string(t), &e.attrs)
+		}
+		if e.tags != nil {
+			decl.Tags = in.schemaType(string(t)+" tags", e.tags)
+		}
+		ns := namespace(nsName)
+		ns.Entities = append(ns.Entities, decl)
+	}
+
+	entityTypeList := func(set map[types.EntityType]bool, anyType bool) []types.Path {
+		var res []types.Path
+		if anyType {
+			for _, t := range entityTypes {
+				res = append(res, types.Path(t))
+			}
+			return res
+		}
+		for _, t := range slices.Sorted(maps.Keys(set)) {
+			res = append(res, types.Path(t))
+		}
+		return res
+	}
+	for _, uid := range sortedActionUIDs(in.actions) {
+		a := in.actions[uid]
+		nsName, _ := splitEntityType(uid.Type)
+		decl := &schemaast.Action{Name: uid.ID}
+		memberOf := slices.Collect(maps.Keys(a.memberOf))
+		sortUIDs(memberOf)
+		for _, p := range memberOf {
+			ref := schemaast.ActionRef{ID: p.ID}
+			if p.Type != uid.Type {
+				ref.Type = types.Path(p.Type)
+			}
+			decl.MemberOf = append(decl.MemberOf, ref)
+		}
+		principals := entityTypeList(a.principals, a.anyPrin)
+		resources := entityTypeList(a.resources, a.anyRes)
+		if len(principals) > 0 && len(resources) > 0 {
+			decl.AppliesTo = &schemaast.AppliesTo{
+				Principals: principals,
+				Resources:  resources,
+				Context:    in.recordType("context", &a.context),
+			}
+		}
+		ns := namespace(nsName)
+		ns.Actions = append(ns.Actions, decl)
+	}
+
+	res := &schemaast.Schema{}
+	for _, name := range slices.Sorted(maps.Keys(namespaces)) {
+		res.Namespaces = append(res.Namespaces, namespaces[name])
+	}
+	return res
+}
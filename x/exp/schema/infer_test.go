@@ -0,0 +1,238 @@
+package schema_test
+
+import (
+	"testing"
+
+	"github.com/cedar-policy/cedar-go"
+	"github.com/cedar-policy/cedar-go/internal/testutil"
+	"github.com/cedar-policy/cedar-go/types"
+	"github.com/cedar-policy/cedar-go/x/exp/schema"
+)
+
+const inferEntities = `[
+  {
+    "uid": {"type": "App::User", "id": "alice"},
+    "parents": [{"type": "App::Group", "id": "admins"}],
+    "attrs": {
+      "age": 42,
+      "name": "Alice",
+      "address": {"city": "Seattle"},
+      "ip": {"__extn": {"fn": "ip", "arg": "10.0.0.1"}}
+    },
+    "tags": {"level": 1}
+  },
+  {
+    "uid": {"type": "App::User", "id": "bob"},
+    "attrs": {
+      "age": "unknown",
+      "friends": [{"__entity": {"type": "App::User", "id": "alice"}}]
+    }
+  },
+  {"uid": {"type": "App::Action", "id": "view"}, "parents": [{"type": "App::Action", "id": "read"}]}
+]`
+
+const inferPolicies = `
+permit(principal is App::User, action in App::Action::"read", resource == App::Photo::"p")
+when {
+  context.count > 3 &&
+  context.ip.isInRange(ip("10.0.0.0/8")) &&
+  (context has location && context.location.country == "US") &&
+  context.trusted
+};
+permit(principal, action == App::Action::"edit", resource)
+when { context.labels.contains("draft") && context.reason like "*fix*" };
+`
+
+func TestInferSchema(t *testing.T) {
+	t.Parallel()
+	var entities types.EntityMap
+	testutil.OK(t, entities.UnmarshalJSON([]byte(inferEntities)))
+	policies, err := cedar.NewPolicySetFromBytes("policy.cedar", []byte(inferPolicies))
+	testutil.OK(t, err)
+
+	s, warnings := schema.InferSchema(entities, policies)
+	testutil.Equals(t, warnings, []schema.InferenceWarning{
+		{Location: "App::User.age", Message: "observed values of type Long and String; using Long"},
+	})
+	cedarSchema, err := s.MarshalCedar()
+	testutil.OK(t, err)
+	testutil.Equals(t, string(cedarSchema), `namespace App {
+  entity Group;
+  entity Photo;
+  entity User in [App::Group] = {
+    address?: {
+      city: String
+    },
+    age: Long,
+    friends?: Set<App::User>,
+    ip?: ipaddr,
+    name?: String
+  } tags Long;
+  action "edit" appliesTo {
+    principal: [App::Group, App::Photo, App::User],
+    resource: [App::Group, App::Photo, App::User],
+    context: {
+      labels: Set<String>,
+      reason: String
+    }
+  };
+  action "read" appliesTo {
+    principal: [App::User],
+    resource: [App::Photo],
+    context: {
+      count: Long,
+      ip: ipaddr,
+      location?: {
+        country: String
+      },
+      trusted: Bool
+    }
+  };
+  action "view" in ["read"] appliesTo {
+    principal: [App::User],
+    resource: [App::Photo],
+    context: {
+      count: Long,
+      ip: ipaddr,
+      location?: {
+        country: String
+      },
+      trusted: Bool
+    }
+  };
+}
+`)
+
+	// The JSON form of the draft describes the same schema.
+	jsonSchema, err := s.MarshalJSON()
+	testutil.OK(t, err)
+	var fromJSON schema.Schema
+	testutil.OK(t, fromJSON.UnmarshalJSON(jsonSchema))
+	roundTrip, err := fromJSON.MarshalCedar()
+	testutil.OK(t, err)
+	testutil.Equals(t, string(roundTrip), string(cedarSchema))
+
+	// The draft accepts the policies, and every entity apart from the one with the conflicting attribute.
+	result, err := schema.Validate(s, policies)
+	testutil.OK(t, err)
+	testutil.Equals(t, result.Valid(), true)
+	entityErrors, err := schema.ValidateEntities(s, entities)
+	testutil.OK(t, err)
+	testutil.Equals(t, entityErrors, []schema.EntityError{
+		{UID: types.NewEntityUID("App::User", "bob"), Path: "age", Message: "expected Long, got String"},
+	})
+}
+
+func TestInferSchemaWarnings(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name     string
+		entities string
+		policies string
+		schema   string
+		warnings []schema.InferenceWarning
+	}{
+		{
+			"emptySet",
+			`[{"uid": {"type": "User", "id": "a"}, "attrs": {"roles": []}}]`,
+			``,
+			"entity User = {\n  roles: Set<String>\n};\n",
+			[]schema.InferenceWarning{{Location: "User.roles", Message: "the type could not be determined; using String"}},
+		},
+		{
+			"tagConflict",
+			`[{"uid": {"type": "User", "id": "a"}, "tags": {"x": 1, "y": true}}]`,
+			``,
+			"entity User tags Long;\n",
+			[]schema.InferenceWarning{{Location: "User tags", Message: "observed values of type Long and Bool; using Long"}},
+		},
+		{
+			"entityTypeConflict",
+			`[
+  {"uid": {"type": "User", "id": "a"}, "attrs": {"owner": {"__entity": {"type": "User", "id": "b"}}}},
+  {"uid": {"type": "User", "id": "b"}, "attrs": {"owner": {"__entity": {"type": "Team", "id": "t"}}}}
+]`,
+			``,
+			"entity Team;\nentity User = {\n  owner: User\n};\n",
+			[]schema.InferenceWarning{{Location: "User.owner", Message: "observed values of type User and Team; using User"}},
+		},
+		{
+			"contextUseConflict",
+			`[]`,
+			`permit(principal == User::"a", action == Action::"view", resource == Doc::"d") when { context.x > 1 && context.x like "a*" };`,
+			"entity Doc;\nentity User;\naction \"view\" appliesTo {\n  principal: [User],\n  resource: [Doc],\n  context: {\n    x: Long\n  }\n};\n",
+			[]schema.InferenceWarning{{Location: "context.x", Message: "used as both Long and String; using Long"}},
+		},
+		{
+			"contextUnknown",
+			`[]`,
+			`permit(principal is User, action == Action::"view", resource is Doc) when { context["a b"] == context.y };`,
+			"entity Doc;\nentity User;\naction \"view\" appliesTo {\n  principal: [User],\n  resource: [Doc],\n  context: {\n    \"a b\": String,\n    y: String\n  }\n};\n",
+			[]schema.InferenceWarning{
+				{Location: "context.y", Message: "the type could not be determined; using String"},
+				{Location: `context["a b"]`, Message: "the type could not be determined; using String"},
+			},
+		},
+		{
+			"contextEntity",
+			`[]`,
+			`permit(principal, action == Action::"view", resource) when { context.owner is User && context.tags.containsAny([1]) && !context.flag };`,
+			"entity User;\naction \"view\" appliesTo {\n  principal: [User],\n  resource: [User],\n  context: {\n    flag: Bool,\n    owner: User,\n    tags: Set<Long>\n  }\n};\n",
+			nil,
+		},
+		{
+			"actionGroups",
+			`[
+  {"uid": {"type": "Action", "id": "read"}, "parents": [{"type": "Ns::Action", "id": "all"}]}
+]`,
+			`permit(principal == User::"a", action in Ns::Action::"all", resource == Doc::"d");`,
+			`entity Doc;
+entity User;
+action "read" in [Ns::Action::"all"] appliesTo {
+  principal: [User],
+  resource: [Doc],
+  context: {}
+};
+
+namespace Ns {
+  action "all" appliesTo {
+    principal: [User],
+    resource: [Doc],
+    context: {}
+  };
+}
+`,
+			nil,
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			var entities types.EntityMap
+			testutil.OK(t, entities.UnmarshalJSON([]byte(tt.entities)))
+			policies, err := cedar.NewPolicySetFromBytes("policy.cedar", []byte(tt.policies))
+			testutil.OK(t, err)
+			s, warnings := schema.InferSchema(entities, policies)
+			testutil.Equals(t, warnings, tt.warnings)
+			b, err := s.MarshalCedar()
+			testutil.OK(t, err)
+			testutil.Equals(t, string(b), tt.schema)
+		})
+	}
+}
+
+func TestInferSchemaNilPolicies(t *testing.T) {
+	t.Parallel()
+	s, warnings := schema.InferSchema(types.EntityMap{}, nil)
+	testutil.Equals(t, warnings, nil)
+	b, err := s.MarshalCedar()
+	testutil.OK(t, err)
+	testutil.Equals(t, string(b), "")
+}
+
+func TestInferenceWarningString(t *testing.T) {
+	t.Parallel()
+	w := schema.InferenceWarning{Location: "User.age", Message: "observed values of type Long and String; using Long"}
+	testutil.Equals(t, w.String(), "at `User.age`: observed values of type Long and String; using Long")
+}
@@ -0,0 +1,161 @@
+package schema
+
+import (
+	"github.com/cedar-policy/cedar-go"
+	"github.com/cedar-policy/cedar-go/types"
+	"github.com/cedar-policy/cedar-go/x/exp/ast"
+)
+
+// PolicyLevels returns the entity dereference level of each policy in the PolicySet: the greatest number of entities
+// which evaluating the policy may have to traverse, starting from the entities of the request.  The principal,
+// resource and context, and entity literals, are at level 0.  Accessing an attribute or tag of an entity, testing for
+// one with `has` or `hasTag`, and testing the ancestors of an entity with `in`, dereference the entity, so that
+// `principal.name` and `principal in Group::"admins"` are at level 1 and `principal.manager.manager.dept` is at level
+// 3.  Accessing an attribute of a record does not dereference an entity, so `context.user.name` is at level 1.  The
+// attributes and ancestors of actions are declared by the schema, so testing them does not dereference an entity.
+//
+// The level of a policy is computed from the types of its expressions in each request environment which its scope
+// matches, and is the greatest level found.  An application which provides the entities up to the level of each
+// policy, e.g. by fetching entities to that many hops from the request, is guaranteed that evaluating the policies will
+// not require any other entities.  The returned error is non-nil only if the schema itself is invalid.
+func PolicyLevels(s *Schema, policies *cedar.PolicySet) (map[types.PolicyID]int, error) {
+	rs, err := s.resolve()
+	if err != nil {
+		return nil, err
+	}
+	res := map[types.PolicyID]int{}
+	for id, p := range policies.All() {
+		res[id] = rs.policyLevel((*ast.Policy)(p.AST()))
+	}
+	return res, nil
+}
+
+// ValidateWithLevel is like ValidateWithMode, but also reports each policy whose entity dereference level, as
+// computed by PolicyLevels, exceeds maxLevel.  The outcome of typechecking the policy in each request environment is
+// unaffected.
+func ValidateWithLevel(s *Schema, policies *cedar.PolicySet, mode ValidationMode, maxLevel int) (ValidationResult, error) {
+	return validate(s, policies, mode, maxLevel)
+}
+
+func (s *resolvedSchema) policyLevel(p *ast.Policy) int {
+	res := 0
+	for _, env := range s.environments() {
+		if !s.scopeMatches(p, env) {
+			continue
+		}
+		l := levelChecker{c: typechecker{schema: s, mode: Permissive, env: env}}
+		l.scope(p.Principal, env.principal)
+		l.scope(p.Resource, env.resource)
+		for _, cond := range p.Conditions {
+			l.level(cond.Body)
+		}
+		res = max(res, l.max)
+	}
+	return res
+}
+
+// levelChecker computes the greatest entity dereference level of the expressions in a request environment.  It uses
+// a typechecker to determine which expressions are entities, but ignores any errors it reports, which validation
+// reports separately.
+type levelChecker struct {
+	c   typechecker
+	max int
+}
+
+// deref records the dereference of an entity at the given level, and returns the level of the values obtained from it.
+func (l *levelChecker) deref(t valueType, level int) int {
+	e, ok := t.(typeEntity)
+	if !ok {
+		return level
+	}
+	for _, name := range e.names {
+		if !l.c.schema.isActionType(name) {
+			level++
+			l.max = max(l.max, level)
+			return level
+		}
+	}
+	return level
+}
+
+// scope records the dereference of the principal or resource required by an `in` scope constraint.
+func (l *levelChecker) scope(n ast.IsScopeNode, t types.EntityType) {
+	switch n.(type) {
+	case ast.ScopeTypeIn, ast.ScopeTypeIsIn:
+		l.deref(typeEntity{names: []types.EntityType{t}}, 0)
+	}
+}
+
+// level returns the type of n, or nil if it is not needed to determine levels, and the level of its value.
+func (l *levelChecker) level(n ast.IsNode) (valueType, int) {
+	switch n := n.(type) {
+	case ast.NodeValue:
+		return l.c.valueType(n.Value), 0
+	case ast.NodeTypeVariable:
+		return l.c.variable(n.Name), 0
+	case ast.NodeTypeAccess:
+		t, level := l.level(n.Arg)
+		level = l.deref(t, level)
+		attrs, _, _ := l.c.attributes(t)
+		return attrs[n.Value].typ, level
+	case ast.NodeTypeHas:
+		t, level := l.level(n.Arg)
+		l.deref(t, level)
+		return nil, 0
+	case ast.NodeTypeGetTag:
+		t, level := l.level(n.Left)
+		l.level(n.Right)
+		level = l.deref(t, level)
+		if e, ok := t.(typeEntity); ok {
+			return l.c.tagType(e), level
+		}
+		return nil, level
+	case ast.NodeTypeHasTag:
+		t, level := l.level(n.Left)
+		l.level(n.Right)
+		l.deref(t, level)
+		return nil, 0
+	case ast.NodeTypeIn:
+		t, level := l.level(n.Left)
+		l.level(n.Right)
+		l.deref(t, level)
+		return nil, 0
+	case ast.NodeTypeIsIn:
+		t, level := l.level(n.Left)
+		l.level(n.Entity)
+		l.deref(t, level)
+		return nil, 0
+	case ast.NodeTypeIfThenElse:
+		l.level(n.If)
+		t, thenLevel := l.level(n.Then)
+		elseType, elseLevel := l.level(n.Else)
+		if t == nil {
+			t = elseType
+		}
+		return t, max(thenLevel, elseLevel)
+	case ast.NodeTypeSet:
+		res := typeSet{}
+		level := 0
+		for _, e := range n.Elements {
+			t, elementLevel := l.level(e)
+			if res.element == nil {
+				res.element = t
+			}
+			level = max(level, elementLevel)
+		}
+		return res, level
+	case ast.NodeTypeRecord:
+		res := typeRecord{attributes: map[types.String]attributeType{}}
+		level := 0
+		for _, e := range n.Elements {
+			t, attrLevel := l.level(e.Value)
+			res.attributes[e.Key] = attributeType{typ: t, required: true}
+			level = max(level, attrLevel)
+		}
+		return res, level
+	}
+	for _, c := range children(n) {
+		l.level(c)
+	}
+	return nil, 0
+}
@@ -0,0 +1,116 @@
+package schema_test
+
+import (
+	"testing"
+
+	"github.com/cedar-policy/cedar-go"
+	"github.com/cedar-policy/cedar-go/internal/testutil"
+	"github.com/cedar-policy/cedar-go/types"
+	"github.com/cedar-policy/cedar-go/x/exp/schema"
+)
+
+const levelSchema = `entity Dept = { name: String };
+entity Group;
+entity User in [Group] = {
+  name: String,
+  manager: User,
+  dept: Dept,
+  address: { city: String, owner: User },
+  friends: Set<User>,
+} tags User;
+entity Doc = { owner: User };
+action read;
+action view in [read] appliesTo {
+  principal: [User],
+  resource: [Doc],
+  context: { user: User, info: { user: User }, ip: ipaddr },
+};
+`
+
+func TestPolicyLevels(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name   string
+		policy string
+		level  int
+	}{
+		{"noConditions", `permit(principal, action, resource);`, 0},
+		{"scopeEq", `permit(principal == User::"a", action == Action::"view", resource is Doc);`, 0},
+		{"scopeIn", `permit(principal in Group::"g", action, resource);`, 1},
+		{"scopeIsIn", `permit(principal, action, resource is Doc in Doc::"d");`, 1},
+		{"context", `permit(principal, action, resource) when { context.ip.isLoopback() };`, 0},
+		{"contextEntity", `permit(principal, action, resource) when { context.user == principal };`, 0},
+		{"attribute", `permit(principal, action, resource) when { principal.name == "a" };`, 1},
+		{"chain", `permit(principal, action, resource) when { principal.manager.manager.dept.name == "a" };`, 4},
+		{"example", `permit(principal, action, resource) when { principal.manager.manager.dept == Dept::"d" };`, 3},
+		{"record", `permit(principal, action, resource) when { principal.address.city == "a" };`, 1},
+		{"recordEntity", `permit(principal, action, resource) when { principal.address.owner.name == "a" };`, 2},
+		{"contextRecord", `permit(principal, action, resource) when { context.info.user.name == "a" };`, 1},
+		{"resource", `permit(principal, action, resource) when { resource.owner.manager has name };`, 3},
+		{"in", `permit(principal, action, resource) when { resource.owner in Group::"g" };`, 2},
+		{"isIn", `permit(principal, action, resource) when { context.user is User in Group::"g" };`, 1},
+		{"literal", `permit(principal, action, resource) when { User::"a".manager.name == "b" };`, 2},
+		{"tags", `permit(principal, action, resource) when { principal.hasTag("t") && principal.getTag("t").name == "a" };`, 2},
+		{"hasTag", `permit(principal, action, resource) when { principal.manager.hasTag("t") };`, 2},
+		{"set", `permit(principal, action, resource) when { principal.friends.contains(context.user) };`, 1},
+		{"setLiteral", `permit(principal, action, resource) when { [principal.manager, principal].contains(context.user) };`, 1},
+		{"recordLiteral", `permit(principal, action, resource) when { {a: principal.manager}.a.name == "x" };`, 2},
+		{"ifThenElse", `permit(principal, action, resource) when { (if true then context.user else principal.manager).name == "a" };`, 2},
+		{"ifThenElseValue", `permit(principal, action, resource) when { (if principal.name == "a" then 1 else 2) == 1 };`, 1},
+		{"action", `permit(principal, action, resource) when { action in Action::"read" && !(action has x) };`, 0},
+		{"unless", `permit(principal, action, resource) unless { principal.manager.name == "a" };`, 2},
+		{"outOfScope", `permit(principal, action, resource is User) when { principal.manager.name == "a" };`, 0},
+	}
+	var s schema.Schema
+	testutil.OK(t, s.UnmarshalCedar([]byte(levelSchema)))
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			policies, err := cedar.NewPolicySetFromBytes("policy.cedar", []byte(tt.policy))
+			testutil.OK(t, err)
+			levels, err := schema.PolicyLevels(&s, policies)
+			testutil.OK(t, err)
+			testutil.Equals(t, levels, map[types.PolicyID]int{"policy0": tt.level})
+		})
+	}
+}
+
+func TestValidateWithLevel(t *testing.T) {
+	t.Parallel()
+	var s schema.Schema
+	testutil.OK(t, s.UnmarshalCedar([]byte(levelSchema)))
+	policies, err := cedar.NewPolicySetFromBytes("policy.cedar", []byte(`
+permit(principal, action, resource) when { principal.name == "a" };
+permit(principal, action, resource) when { principal.manager.manager.dept == Dept::"d" };
+permit(principal, action, resource) when { principal.manager.nope && principal.manager.name == "a" };
+`))
+	testutil.OK(t, err)
+
+	result, err := schema.ValidateWithLevel(&s, policies, schema.Strict, 2)
+	testutil.OK(t, err)
+	var msgs []string
+	for _, e := range result.Errors {
+		msgs = append(msgs, e.String())
+	}
+	testutil.Equals(t, msgs, []string{
+		"while validating policy `policy1`: the policy dereferences entities to level 3, exceeding the maximum level of 2",
+		"while validating policy `policy2`: attribute `nope` not found on entity type User",
+	})
+	testutil.Equals(t, result.Policies[1].Environments[0].Status, schema.EnvironmentValid)
+
+	result, err = schema.ValidateWithLevel(&s, policies, schema.Strict, 0)
+	testutil.OK(t, err)
+	testutil.Equals(t, len(result.Errors), 4)
+
+	unlimited, err := schema.ValidateWithMode(&s, policies, schema.Strict)
+	testutil.OK(t, err)
+	testutil.Equals(t, len(unlimited.Errors), 1)
+
+	var bad schema.Schema
+	testutil.OK(t, bad.UnmarshalCedar([]byte(`entity User in [Missing];`)))
+	_, err = schema.PolicyLevels(&bad, policies)
+	testutil.Error(t, err)
+	_, err = schema.ValidateWithLevel(&bad, policies, schema.Strict, 1)
+	testutil.Error(t, err)
+}
@@ -0,0 +1,98 @@
+package schema
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+
+	"github.com/cedar-policy/cedar-go"
+	"github.com/cedar-policy/cedar-go/types"
+)
+
+// A RequestError details a problem found by ValidateRequest.  Path locates the offending value within the context,
+// e.g. `context.address.city`; it is empty for problems with the principal, action or resource.
+type RequestError struct {
+	Path    string
+	Message string
+}
+
+func (e RequestError) Error() string {
+	if e.Path == "" {
+		return "invalid request: " + e.Message
+	}
+	return fmt.Sprintf("invalid request at `%v`: %v", e.Path, e.Message)
+}
+
+// ValidateRequest checks that the request is permitted by the schema: the action must be declared, the principal and
+// resource types must be among those the action applies to, and the context must conform to the action's context
+// type.  If there are several problems, the returned error joins a RequestError for each of them.
+func ValidateRequest(s *Schema, req types.Request) error {
+	rs, err := s.resolve()
+	if err != nil {
+		return err
+	}
+	return rs.validateRequest(req)
+}
+
+func (s *resolvedSchema) validateRequest(req types.Request) error {
+	a, ok := s.actions[req.Action]
+	if !ok {
+		return RequestError{Message: fmt.Sprintf("unknown action %v", req.Action)}
+	}
+	if a.appliesTo == nil {
+		return RequestError{Message: fmt.Sprintf("action %v does not apply to any principal or resource", req.Action)}
+	}
+	var errs []error
+	check := func(role string, uid types.EntityUID, permitted []types.EntityType) {
+		if !slices.Contains(permitted, uid.Type) {
+			errs = append(errs, RequestError{
+				Message: fmt.Sprintf("%s type %v is not permitted for action %v", role, uid.Type, req.Action),
+			})
+			return
+		}
+		if e := s.entities[uid.Type]; e.enum != nil && !slices.Contains(e.enum, uid.ID) {
+			errs = append(errs, RequestError{
+				Message: fmt.Sprintf("%v is not a declared value of enumerated entity type %v", uid, uid.Type),
+			})
+		}
+	}
+	check("principal", req.Principal, a.appliesTo.principals)
+	check("resource", req.Resource, a.appliesTo.resources)
+	c := valueChecker{}
+	c.record(req.Context, a.appliesTo.context, "context")
+	for _, e := range c.errors {
+		errs = append(errs, RequestError{Path: e.Path, Message: e.Message})
+	}
+	return errors.Join(errs...)
+}
+
+// An Authorizer authorizes requests against a set of policies after checking that each request is permitted by a
+// schema.  The action entities of the schema are available to the policies as if they had been merged into the
+// entities passed to Authorize; see WithActionEntities.
+type Authorizer struct {
+	schema   *resolvedSchema
+	actions  types.EntityMap
+	policies cedar.PolicyIterator
+}
+
+// NewAuthorizer returns an Authorizer for the given schema and policies.  It returns an error if the schema is invalid.
+func NewAuthorizer(s *Schema, policies cedar.PolicyIterator) (*Authorizer, error) {
+	rs, err := s.resolve()
+	if err != nil {
+		return nil, err
+	}
+	return &Authorizer{schema: rs, actions: rs.actionEntities(), policies: policies}, nil
+}
+
+// Authorize validates the request as ValidateRequest does and, if it is valid, authorizes it as cedar.Authorize does.
+// An invalid request is denied without evaluating any policy, and the returned error describes why it is invalid, so
+// that it can be distinguished from errors in the evaluation of individual policies, which are reported in the
+// Diagnostic.  Entities which implement types.EntityLoader or types.AncestorChecker are used as cedar.Authorize uses
+// them.
+func (a *Authorizer) Authorize(entities types.EntityGetter, req types.Request) (types.Decision, types.Diagnostic, error) {
+	if err := a.schema.validateRequest(req); err != nil {
+		return types.Deny, types.Diagnostic{}, err
+	}
+	decision, diag := cedar.Authorize(a.policies, withActionEntities(a.actions, entities), req)
+	return decision, diag, nil
+}
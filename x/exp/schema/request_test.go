@@ -0,0 +1,157 @@
+package schema_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/cedar-policy/cedar-go"
+	"github.com/cedar-policy/cedar-go/internal/testutil"
+	"github.com/cedar-policy/cedar-go/types"
+	"github.com/cedar-policy/cedar-go/x/exp/schema"
+)
+
+func TestValidateRequest(t *testing.T) {
+	t.Parallel()
+	alice := types.NewEntityUID("User", "alice")
+	photo := types.NewEntityUID("Photo", "p")
+	view := types.NewEntityUID("Action", "view")
+	edit := types.NewEntityUID("Action", "edit")
+	authenticated := types.NewRecord(types.RecordMap{"authenticated": types.True})
+	tests := []struct {
+		name string
+		req  types.Request
+		want []schema.RequestError
+	}{
+		{"valid", types.Request{Principal: alice, Action: view, Resource: photo, Context: authenticated}, nil},
+		{"validNoContext", types.Request{Principal: alice, Action: edit, Resource: types.NewEntityUID("Album", "a")}, nil},
+		{"unknownAction", types.Request{Principal: alice, Action: types.NewEntityUID("Action", "delete"), Resource: photo},
+			[]schema.RequestError{{Message: `unknown action Action::"delete"`}}},
+		{"noAppliesTo", types.Request{Principal: alice, Action: types.NewEntityUID("Action", "admin"), Resource: photo},
+			[]schema.RequestError{{Message: `action Action::"admin" does not apply to any principal or resource`}}},
+		{"principalType", types.Request{Principal: photo, Action: view, Resource: alice, Context: authenticated},
+			[]schema.RequestError{
+				{Message: `principal type Photo is not permitted for action Action::"view"`},
+				{Message: `resource type User is not permitted for action Action::"view"`},
+			}},
+		{"missingContext", types.Request{Principal: alice, Action: view, Resource: photo},
+			[]schema.RequestError{{Path: "context.authenticated", Message: "required attribute is missing"}}},
+		{"contextType", types.Request{Principal: alice, Action: view, Resource: photo,
+			Context: types.NewRecord(types.RecordMap{"authenticated": types.String("yes"), "now": types.Long(0)})},
+			[]schema.RequestError{
+				{Path: "context.authenticated", Message: "expected Bool, got String"},
+				{Path: "context.now", Message: "expected datetime, got Long"},
+			}},
+		{"undeclaredContext", types.Request{Principal: alice, Action: edit, Resource: photo, Context: authenticated},
+			[]schema.RequestError{{Path: "context.authenticated", Message: "attribute is not declared in the schema"}}},
+	}
+	var s schema.Schema
+	testutil.OK(t, s.UnmarshalCedar([]byte(validateSchema)))
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			err := schema.ValidateRequest(&s, tt.req)
+			var got []schema.RequestError
+			if joined, ok := err.(interface{ Unwrap() []error }); ok {
+				for _, e := range joined.Unwrap() {
+					got = append(got, e.(schema.RequestError))
+				}
+			} else if err != nil {
+				got = append(got, err.(schema.RequestError))
+			}
+			testutil.Equals(t, got, tt.want)
+		})
+	}
+}
+
+func TestRequestError(t *testing.T) {
+	t.Parallel()
+	testutil.Equals(t, schema.RequestError{Message: "bad"}.Error(), "invalid request: bad")
+	testutil.Equals(t, schema.RequestError{Path: "context.a", Message: "bad"}.Error(), "invalid request at `context.a`: bad")
+
+	var bad schema.Schema
+	testutil.OK(t, bad.UnmarshalCedar([]byte(`entity User in [Group];`)))
+	err := schema.ValidateRequest(&bad, types.Request{})
+	var schemaErr schema.SchemaError
+	testutil.Equals(t, errors.As(err, &schemaErr), true)
+	_, err = schema.NewAuthorizer(&bad, cedar.NewPolicySet())
+	testutil.Equals(t, errors.As(err, &schemaErr), true)
+}
+
+func TestAuthorizer(t *testing.T) {
+	t.Parallel()
+	var s schema.Schema
+	testutil.OK(t, s.UnmarshalCedar([]byte(validateSchema)))
+	ps, err := cedar.NewPolicySetFromBytes("policy.cedar", []byte(`permit(principal, action, resource) when { context.authenticated };`))
+	testutil.OK(t, err)
+	a, err := schema.NewAuthorizer(&s, ps)
+	testutil.OK(t, err)
+
+	req := types.Request{
+		Principal: types.NewEntityUID("User", "alice"),
+		Action:    types.NewEntityUID("Action", "view"),
+		Resource:  types.NewEntityUID("Photo", "p"),
+		Context:   types.NewRecord(types.RecordMap{"authenticated": types.True}),
+	}
+	decision, diag, err := a.Authorize(types.EntityMap{}, req)
+	testutil.OK(t, err)
+	testutil.Equals(t, decision, types.Allow)
+	testutil.Equals(t, len(diag.Reasons), 1)
+
+	req.Context = types.Record{}
+	decision, diag, err = a.Authorize(types.EntityMap{}, req)
+	var reqErr schema.RequestError
+	testutil.Equals(t, errors.As(err, &reqErr), true)
+	testutil.Equals(t, reqErr.Path, "context.authenticated")
+	testutil.Equals(t, decision, types.Deny)
+	testutil.Equals(t, diag, types.Diagnostic{})
+}
+
+func TestAuthorizerEntities(t *testing.T) {
+	t.Parallel()
+	var s schema.Schema
+	testutil.OK(t, s.UnmarshalCedar([]byte(actionsSchema)))
+	ps, err := cedar.NewPolicySetFromBytes("policy.cedar", []byte(`permit(principal in Group::"g", action in Action::"readOnly", resource);`))
+	testutil.OK(t, err)
+	a, err := schema.NewAuthorizer(&s, ps)
+	testutil.OK(t, err)
+	alice := types.NewEntityUID("User", "alice")
+	group := types.NewEntityUID("Group", "g")
+	req := types.Request{
+		Principal: alice,
+		Action:    types.NewEntityUID("Action", "list"),
+		Resource:  types.NewEntityUID("Doc", "d"),
+	}
+	entities := types.EntityMap{alice: {UID: alice, Parents: types.NewEntityUIDSet(group)}}
+
+	t.Run("loader", func(t *testing.T) {
+		t.Parallel()
+		decision, diag, err := a.Authorize(testLoader{entities}, req)
+		testutil.OK(t, err)
+		testutil.Equals(t, decision, types.Allow)
+		testutil.Equals(t, len(diag.Reasons), 1)
+
+		decision, diag, err = a.Authorize(testLoader{}, req)
+		testutil.OK(t, err)
+		testutil.Equals(t, decision, types.Deny)
+		testutil.Equals(t, len(diag.Errors), 1)
+		testutil.Equals(t, diag.Errors[0].Kind, types.EntityLoadError)
+		testutil.Equals(t, diag.Errors[0].Message, `failed to load entity User::"alice": database outage`)
+	})
+
+	t.Run("store", func(t *testing.T) {
+		t.Parallel()
+		store, err := types.NewEntityStore(entities)
+		testutil.OK(t, err)
+		decision, diag, err := a.Authorize(store, req)
+		testutil.OK(t, err)
+		testutil.Equals(t, decision, types.Allow)
+		testutil.Equals(t, len(diag.Reasons), 1)
+
+		store, err = types.NewEntityStore(types.EntityMap{alice: {UID: alice}})
+		testutil.OK(t, err)
+		decision, _, err = a.Authorize(store, req)
+		testutil.OK(t, err)
+		testutil.Equals(t, decision, types.Deny)
+	})
+}
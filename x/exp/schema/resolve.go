@@ -0,0 +1,374 @@
+package schema
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/cedar-policy/cedar-go/types"
+	"github.com/cedar-policy/cedar-go/x/exp/schema/ast"
+)
+
+// resolvedSchema is a schema in which every name has been resolved to its fully qualified form and every common type
+// has been inlined.  The only types which appear in a resolved schema are the built-in types, ast.SetType,
+// ast.RecordType and ast.EntityTypeRef.
+type resolvedSchema struct {
+	entities    map[types.EntityType]*resolvedEntity
+	actions     map[types.EntityUID]*resolvedAction
+	entityOrder []types.EntityType
+	actionOrder []types.EntityUID
+}
+
+type resolvedEntity struct {
+	name     types.EntityType
+	memberOf []types.EntityType
+	shape    ast.RecordType
+	tags     ast.IsType
+	enum     []types.String
+	pos      types.Position
+}
+
+type resolvedAction struct {
+	uid       types.EntityUID
+	memberOf  []types.EntityUID
+	appliesTo *resolvedAppliesTo
+	pos       types.Position
+}
+
+type resolvedAppliesTo struct {
+	principals []types.EntityType
+	resources  []types.EntityType
+	context    ast.RecordType
+}
+
+// SchemaError is returned when a schema is well formed but refers to a declaration which does not exist or is
+// otherwise inconsistent.
+type SchemaError struct {
+	Position types.Position
+	Message  string
+}
+
+func (e SchemaError) Error() string {
+	if e.Position.Line > 0 {
+		return fmt.Sprintf("schema error at %v: %v", formatPosition(e.Position), e.Message)
+	}
+	return "schema error: " + e.Message
+}
+
+func formatPosition(p types.Position) string {
+	filename := p.Filename
+	if filename == "" {
+		filename = "<input>"
+	}
+	return fmt.Sprintf("%v:%v:%v", filename, p.Line, p.Column)
+}
+
+func schemaErrorf(pos types.Position, format string, args ...any) error {
+	return SchemaError{Position: pos, Message: fmt.Sprintf(format, args...)}
+}
+
+func qualify(ns types.Path, name string) string {
+	if ns == "" {
+		return name
+	}
+	return string(ns) + "::" + name
+}
+
+func actionType(ns types.Path) types.EntityType {
+	return types.EntityType(qualify(ns, "Action"))
+}
+
+type commonTypeDecl struct {
+	ns   types.Path
+	decl *ast.CommonType
+}
+
+type resolver struct {
+	entities    map[types.EntityType]*ast.Entity
+	commonTypes map[string]commonTypeDecl
+	actions     map[types.EntityUID]*ast.Action
+	inProgress  []string
+}
+
+// resolve resolves every name in the schema according to the Cedar name resolution rules.  An unqualified name is
+// first looked up in the enclosing namespace, then in the empty namespace and finally among the built-in types.
+func (s *Schema) resolve() (*resolvedSchema, error) {
+	r := resolver{
+		entities:    map[types.EntityType]*ast.Entity{},
+		commonTypes: map[string]commonTypeDecl{},
+		actions:     map[types.EntityUID]*ast.Action{},
+	}
+	namespaces := s.AST().Namespaces
+	for _, ns := range namespaces {
+		if ns.Name == ast.CedarNamespace || strings.HasPrefix(string(ns.Name), ast.CedarNamespace+"::") {
+			return nil, schemaErrorf(ns.Position, "namespace %v is reserved", ns.Name)
+		}
+		for _, c := range ns.CommonTypes {
+			r.commonTypes[qualify(ns.Name, string(c.Name))] = commonTypeDecl{ns: ns.Name, decl: c}
+		}
+		for _, e := range ns.Entities {
+			name := types.EntityType(qualify(ns.Name, string(e.Name)))
+			if _, ok := r.commonTypes[string(name)]; ok {
+				return nil, schemaErrorf(e.Position, "entity type %v has the same name as a common type", name)
+			}
+			r.entities[name] = e
+		}
+		for _, a := range ns.Actions {
+			r.actions[types.NewEntityUID(actionType(ns.Name), a.Name)] = a
+		}
+	}
+
+	res := &resolvedSchema{
+		entities: map[types.EntityType]*resolvedEntity{},
+		actions:  map[types.EntityUID]*resolvedAction{},
+	}
+	for _, ns := range namespaces {
+		for _, e := range ns.Entities {
+			entity, err := r.entity(ns.Name, e)
+			if err != nil {
+				return nil, err
+			}
+			res.entities[entity.name] = entity
+			res.entityOrder = append(res.entityOrder, entity.name)
+		}
+	}
+	for _, ns := range namespaces {
+		for _, a := range ns.Actions {
+			action, err := r.action(ns.Name, a)
+			if err != nil {
+				return nil, err
+			}
+			res.actions[action.uid] = action
+			res.actionOrder = append(res.actionOrder, action.uid)
+		}
+	}
+	for _, uid := range res.actionOrder {
+		if res.actionIn(uid, uid, false) {
+			return nil, schemaErrorf(res.actions[uid].pos, "action %v is a member of itself", uid)
+		}
+	}
+	return res, nil
+}
+
+func (r *resolver) entity(ns types.Path, e *ast.Entity) (*resolvedEntity, error) {
+	res := &resolvedEntity{
+		name: types.EntityType(qualify(ns, string(e.Name))),
+		enum: e.Enum,
+		pos:  e.Position,
+	}
+	for _, p := range e.MemberOf {
+		t, err := r.entityType(ns, p, e.Position)
+		if err != nil {
+			return nil, err
+		}
+		res.memberOf = append(res.memberOf, t)
+	}
+	if e.Shape != nil {
+		shape, err := r.typ(ns, e.Shape, e.Position)
+		if err != nil {
+			return nil, err
+		}
+		var ok bool
+		if res.shape, ok = shape.(ast.RecordType); !ok {
+			return nil, schemaErrorf(e.Position, "shape of entity type %v must be a record", res.name)
+		}
+	}
+	if e.Tags != nil {
+		var err error
+		if res.tags, err = r.typ(ns, e.Tags, e.Position); err != nil {
+			return nil, err
+		}
+	}
+	return res, nil
+}
+
+func (r *resolver) action(ns types.Path, a *ast.Action) (*resolvedAction, error) {
+	res := &resolvedAction{uid: types.NewEntityUID(actionType(ns), a.Name), pos: a.Position}
+	for _, ref := range a.MemberOf {
+		uid := types.NewEntityUID(actionType(ns), ref.ID)
+		if ref.Type != "" {
+			uid = types.NewEntityUID(types.EntityType(ref.Type), ref.ID)
+		}
+		if _, ok := r.actions[uid]; !ok {
+			return nil, schemaErrorf(a.Position, "action %v is a member of undeclared action %v", res.uid, uid)
+		}
+		res.memberOf = append(res.memberOf, uid)
+	}
+	if a.AppliesTo == nil {
+		return res, nil
+	}
+	res.appliesTo = &resolvedAppliesTo{}
+	for _, p := range a.AppliesTo.Principals {
+		t, err := r.entityType(ns, p, a.Position)
+		if err != nil {
+			return nil, err
+		}
+		res.appliesTo.principals = append(res.appliesTo.principals, t)
+	}
+	for _, p := range a.AppliesTo.Resources {
+		t, err := r.entityType(ns, p, a.Position)
+		if err != nil {
+			return nil, err
+		}
+		res.appliesTo.resources = append(res.appliesTo.resources, t)
+	}
+	if a.AppliesTo.Context != nil {
+		context, err := r.typ(ns, a.AppliesTo.Context, a.Position)
+		if err != nil {
+			return nil, err
+		}
+		var ok bool
+		if res.appliesTo.context, ok = context.(ast.RecordType); !ok {
+			return nil, schemaErrorf(a.Position, "context of action %v must be a record", res.uid)
+		}
+	}
+	return res, nil
+}
+
+// entityType resolves a name which must refer to an entity type.
+func (r *resolver) entityType(ns types.Path, name types.Path, pos types.Position) (types.EntityType, error) {
+	candidates := []string{string(name)}
+	if !strings.Contains(string(name), "::") {
+		candidates = []string{qualify(ns, string(name)), string(name)}
+	}
+	for _, c := range candidates {
+		if _, ok := r.entities[types.EntityType(c)]; ok {
+			return types.EntityType(c), nil
+		}
+	}
+	return "", schemaErrorf(pos, "undeclared entity type %v", name)
+}
+
+// typ resolves every reference within the given type.
+func (r *resolver) typ(ns types.Path, t ast.IsType, pos types.Position) (ast.IsType, error) {
+	switch t := t.(type) {
+	case ast.SetType:
+		element, err := r.typ(ns, t.Element, pos)
+		if err != nil {
+			return nil, err
+		}
+		return ast.SetType{Element: element}, nil
+	case ast.RecordType:
+		res := ast.RecordType{Attributes: make([]ast.Attribute, len(t.Attributes))}
+		for i, a := range t.Attributes {
+			attrPos := a.Position
+			if attrPos.Line == 0 {
+				attrPos = pos
+			}
+			at, err := r.typ(ns, a.Type, attrPos)
+			if err != nil {
+				return nil, err
+			}
+			a.Type = at
+			res.Attributes[i] = a
+		}
+		return res, nil
+	case ast.EntityTypeRef:
+		name, err := r.entityType(ns, t.Name, pos)
+		if err != nil {
+			return nil, err
+		}
+		return ast.EntityTypeRef{Name: types.Path(name)}, nil
+	case ast.TypeRef:
+		return r.typeRef(ns, t.Name, pos)
+	case nil:
+		return nil, schemaErrorf(pos, "missing type")
+	}
+	return t, nil
+}
+
+func (r *resolver) typeRef(ns types.Path, name types.Path, pos types.Position) (ast.IsType, error) {
+	if builtin, ok := strings.CutPrefix(string(name), ast.CedarNamespace+"::"); ok {
+		if t, ok := ast.BuiltinType(types.Ident(builtin)); ok {
+			return t, nil
+		}
+		return nil, schemaErrorf(pos, "undeclared type %v", name)
+	}
+	candidates := []string{string(name)}
+	if !strings.Contains(string(name), "::") {
+		candidates = []string{qualify(ns, string(name)), string(name)}
+	}
+	for _, c := range candidates {
+		if ct, ok := r.commonTypes[c]; ok {
+			if slices.Contains(r.inProgress, c) {
+				return nil, schemaErrorf(pos, "common type %v refers to itself", c)
+			}
+			r.inProgress = append(r.inProgress, c)
+			t, err := r.typ(ct.ns, ct.decl.Type, ct.decl.Position)
+			r.inProgress = r.inProgress[:len(r.inProgress)-1]
+			return t, err
+		}
+		if _, ok := r.entities[types.EntityType(c)]; ok {
+			return ast.EntityTypeRef{Name: types.Path(c)}, nil
+		}
+	}
+	if t, ok := ast.BuiltinType(types.Ident(name)); ok {
+		return t, nil
+	}
+	return nil, schemaErrorf(pos, "undeclared type %v", name)
+}
+
+// entityIn reports whether an entity of type child may be a descendant of an entity of type parent, or may be the
+// parent itself when reflexive is true.
+func (s *resolvedSchema) entityIn(child, parent types.EntityType, reflexive bool) bool {
+	if reflexive && child == parent {
+		return true
+	}
+	seen := map[types.EntityType]bool{}
+	queue := []types.EntityType{child}
+	for len(queue) > 0 {
+		t := queue[0]
+		queue = queue[1:]
+		e, ok := s.entities[t]
+		if !ok {
+			continue
+		}
+		for _, p := range e.memberOf {
+			if p == parent {
+				return true
+			}
+			if !seen[p] {
+				seen[p] = true
+				queue = append(queue, p)
+			}
+		}
+	}
+	return false
+}
+
+// actionIn reports whether the action child is a descendant of the action parent, or is the parent itself when
+// reflexive is true.
+func (s *resolvedSchema) actionIn(child, parent types.EntityUID, reflexive bool) bool {
+	if reflexive && child == parent {
+		return true
+	}
+	seen := map[types.EntityUID]bool{}
+	queue := []types.EntityUID{child}
+	for len(queue) > 0 {
+		a, ok := s.actions[queue[0]]
+		queue = queue[1:]
+		if !ok {
+			continue
+		}
+		for _, p := range a.memberOf {
+			if p == parent {
+				return true
+			}
+			if !seen[p] {
+				seen[p] = true
+				queue = append(queue, p)
+			}
+		}
+	}
+	return false
+}
+
+// isActionType reports whether any declared action has the given entity type.
+func (s *resolvedSchema) isActionType(t types.EntityType) bool {
+	for _, uid := range s.actionOrder {
+		if uid.Type == t {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,109 @@
+// Package schema provides parsing and serialization of Cedar schemas.  This package is experimental and its API is
+// subject to change.
+package schema
+
+import (
+	"bytes"
+
+	"github.com/cedar-policy/cedar-go/internal/json"
+	"github.com/cedar-policy/cedar-go/internal/parser"
+	"github.com/cedar-policy/cedar-go/x/exp/schema/ast"
+)
+
+// A Schema is the parsed form of a Cedar schema, which declares the entity types, actions and common types that
+// policies, entities and requests are expected to conform to.
+type Schema struct {
+	ast *ast.Schema
+}
+
+// NewSchemaFromAST lets you create a new schema from a programmatically created AST.
+// Do not modify the *ast.Schema after passing it into NewSchemaFromAST.
+func NewSchemaFromAST(in *ast.Schema) *Schema {
+	return &Schema{ast: in}
+}
+
+// AST returns the declaration AST of the schema.  The returned value must not be modified.
+func (s *Schema) AST() *ast.Schema {
+	if s.ast == nil {
+		return &ast.Schema{}
+	}
+	return s.ast
+}
+
+// MarshalCedar encodes the schema in the human-readable format specified by the [Cedar documentation].
+//
+// [Cedar documentation]: https://docs.cedarpolicy.com/schema/human-readable-schema-grammar.html
+func (s *Schema) MarshalCedar() ([]byte, error) {
+	var buf bytes.Buffer
+	if err := (*parser.Schema)(s.AST()).MarshalCedar(&buf); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// UnmarshalCedar parses a schema in the human-readable format specified by the [Cedar documentation].
+//
+// [Cedar documentation]: https://docs.cedarpolicy.com/schema/human-readable-schema-grammar.html
+func (s *Schema) UnmarshalCedar(b []byte) error {
+	var cedarSchema parser.Schema
+	if err := cedarSchema.UnmarshalCedar(b); err != nil {
+		return err
+	}
+	s.ast = (*ast.Schema)(&cedarSchema)
+	return nil
+}
+
+// MarshalJSON encodes the schema in the JSON format specified by the [Cedar documentation].  Namespaces, common types
+// and annotations are retained, so a schema converted between the JSON and human-readable formats loses no
+// declarations.
+//
+// [Cedar documentation]: https://docs.cedarpolicy.com/schema/json-schema.html
+func (s *Schema) MarshalJSON() ([]byte, error) {
+	return (*json.Schema)(s.AST()).MarshalJSON()
+}
+
+// UnmarshalJSON parses a schema in the JSON format specified by the [Cedar documentation].
+//
+// [Cedar documentation]: https://docs.cedarpolicy.com/schema/json-schema.html
+func (s *Schema) UnmarshalJSON(b []byte) error {
+	var jsonSchema json.Schema
+	if err := jsonSchema.UnmarshalJSON(b); err != nil {
+		return err
+	}
+	s.ast = (*ast.Schema)(&jsonSchema)
+	return nil
+}
+
+// SetFilename sets the filename recorded in the positions of all of the schema's declarations.
+func (s *Schema) SetFilename(fileName string) {
+	for _, ns := range s.AST().Namespaces {
+		ns.Position.Filename = fileName
+		for _, e := range ns.Entities {
+			e.Position.Filename = fileName
+			setAttributeFilenames(e.Shape, fileName)
+			setAttributeFilenames(e.Tags, fileName)
+		}
+		for _, a := range ns.Actions {
+			a.Position.Filename = fileName
+			if a.AppliesTo != nil {
+				setAttributeFilenames(a.AppliesTo.Context, fileName)
+			}
+		}
+		for _, c := range ns.CommonTypes {
+			c.Position.Filename = fileName
+			setAttributeFilenames(c.Type, fileName)
+		}
+	}
+}
+
+func setAttributeFilenames(t ast.IsType, fileName string) {
+	switch t := t.(type) {
+	case ast.RecordType:
+		for i := range t.Attributes {
+			t.Attributes[i].Position.Filename = fileName
+			setAttributeFilenames(t.Attributes[i].Type, fileName)
+		}
+	case ast.SetType:
+		setAttributeFilenames(t.Element, fileName)
+	}
+}
@@ -0,0 +1,123 @@
+package schema_test
+
+import (
+	"testing"
+
+	"github.com/cedar-policy/cedar-go/internal/testutil"
+	"github.com/cedar-policy/cedar-go/types"
+	"github.com/cedar-policy/cedar-go/x/exp/schema"
+	"github.com/cedar-policy/cedar-go/x/exp/schema/ast"
+)
+
+const photoFlash = `namespace PhotoFlash {
+  type Context = {
+    ip: ipaddr
+  };
+  entity User in [UserGroup] = {
+    department: String,
+    jobLevel: Long
+  };
+  entity UserGroup;
+  entity Album in [Album] = {
+    owner: User,
+    tags?: Set<String>
+  };
+  action "viewAlbum" appliesTo {
+    principal: [User, UserGroup],
+    resource: [Album],
+    context: Context
+  };
+}
+`
+
+func TestSchemaCedarRoundTrip(t *testing.T) {
+	t.Parallel()
+	var s schema.Schema
+	testutil.OK(t, s.UnmarshalCedar([]byte(photoFlash)))
+	out, err := s.MarshalCedar()
+	testutil.OK(t, err)
+	testutil.Equals(t, string(out), photoFlash)
+}
+
+func TestSchemaUnmarshalCedarError(t *testing.T) {
+	t.Parallel()
+	var s schema.Schema
+	testutil.Error(t, s.UnmarshalCedar([]byte(`entity User`)))
+}
+
+func TestSchemaSetFilename(t *testing.T) {
+	t.Parallel()
+	var s schema.Schema
+	testutil.OK(t, s.UnmarshalCedar([]byte(photoFlash)))
+	s.SetFilename("photoflash.cedarschema")
+	ns := s.AST().Namespace("PhotoFlash")
+	testutil.Equals(t, ns.Position.Filename, "photoflash.cedarschema")
+	testutil.Equals(t, ns.Entity("User").Position.Filename, "photoflash.cedarschema")
+	testutil.Equals(t, ns.Action("viewAlbum").Position.Filename, "photoflash.cedarschema")
+	ctx := ns.CommonType("Context")
+	testutil.Equals(t, ctx.Position.Filename, "photoflash.cedarschema")
+	attr, ok := ctx.Type.(ast.RecordType).Attribute("ip")
+	testutil.Equals(t, ok, true)
+	testutil.Equals(t, attr.Position, types.Position{Filename: "photoflash.cedarschema", Offset: 46, Line: 3, Column: 5})
+}
+
+func TestNewSchemaFromAST(t *testing.T) {
+	t.Parallel()
+	s := schema.NewSchemaFromAST(&ast.Schema{Namespaces: []*ast.Namespace{{
+		Entities: []*ast.Entity{{Name: "User", Shape: ast.RecordType{Attributes: []ast.Attribute{
+			{Name: "age", Type: ast.LongType{}},
+		}}}},
+	}}})
+	out, err := s.MarshalCedar()
+	testutil.OK(t, err)
+	testutil.Equals(t, string(out), "entity User = {\n  age: Long\n};\n")
+}
+
+func TestSchemaZeroValue(t *testing.T) {
+	t.Parallel()
+	var s schema.Schema
+	testutil.Equals(t, s.AST(), &ast.Schema{})
+	out, err := s.MarshalCedar()
+	testutil.OK(t, err)
+	testutil.Equals(t, len(out), 0)
+}
+
+func TestSchemaJSONRoundTrip(t *testing.T) {
+	t.Parallel()
+	const annotated = `@doc("photos")
+namespace PhotoFlash {
+  @doc("context")
+  type Context = {
+    @doc("source address")
+    ip: ipaddr
+  };
+  @doc("a user")
+  entity User;
+  entity Photo = {
+    owner: User
+  } tags String;
+  @doc("view")
+  action "viewPhoto" appliesTo {
+    principal: [User],
+    resource: [Photo],
+    context: Context
+  };
+}
+`
+	var s schema.Schema
+	testutil.OK(t, s.UnmarshalCedar([]byte(annotated)))
+	b, err := s.MarshalJSON()
+	testutil.OK(t, err)
+
+	var fromJSON schema.Schema
+	testutil.OK(t, fromJSON.UnmarshalJSON(b))
+	out, err := fromJSON.MarshalCedar()
+	testutil.OK(t, err)
+	testutil.Equals(t, string(out), annotated)
+}
+
+func TestSchemaUnmarshalJSONError(t *testing.T) {
+	t.Parallel()
+	var s schema.Schema
+	testutil.Error(t, s.UnmarshalJSON([]byte(`{"": {"entityTypes": {"A B": {}}, "actions": {}}}`)))
+}
@@ -0,0 +1,884 @@
+package schema
+
+import (
+	"fmt"
+	"reflect"
+	"slices"
+	"strconv"
+
+	"github.com/cedar-policy/cedar-go/internal/consts"
+	"github.com/cedar-policy/cedar-go/types"
+	"github.com/cedar-policy/cedar-go/x/exp/ast"
+)
+
+// requestEnv is one combination of principal type, action and resource type that the schema permits.
+type requestEnv struct {
+	principal types.EntityType
+	action    types.EntityUID
+	resource  types.EntityType
+	context   typeRecord
+}
+
+func (e requestEnv) public() RequestEnvironment {
+	return RequestEnvironment{Principal: e.principal, Action: e.action, Resource: e.resource}
+}
+
+// capabilities is the set of attributes and tags which are known to be present when an expression evaluates to
+// true.  Each capability is keyed by the text of the guarded expression and the attribute or tag name.
+type capabilities map[string]struct{}
+
+func (c capabilities) with(keys ...string) capabilities {
+	res := make(capabilities, len(c)+len(keys))
+	for k := range c {
+		res[k] = struct{}{}
+	}
+	for _, k := range keys {
+		res[k] = struct{}{}
+	}
+	return res
+}
+
+func (c capabilities) union(o capabilities) capabilities {
+	res := c.with()
+	for k := range o {
+		res[k] = struct{}{}
+	}
+	return res
+}
+
+func (c capabilities) intersect(o capabilities) capabilities {
+	res := capabilities{}
+	for k := range c {
+		if _, ok := o[k]; ok {
+			res[k] = struct{}{}
+		}
+	}
+	return res
+}
+
+func attributeCapability(key string, attr types.String) string {
+	return key + "." + strconv.Quote(string(attr))
+}
+
+func tagCapability(key string, tag types.String) string {
+	return key + ".getTag(" + strconv.Quote(string(tag)) + ")"
+}
+
+// expressionKey returns a key which identifies the value of the expression for the purpose of tracking capabilities.
+// Only variables, entity literals and attribute accesses on them are tracked.
+func expressionKey(n ast.IsNode) (string, bool) {
+	switch n := n.(type) {
+	case ast.NodeTypeVariable:
+		return string(n.Name), true
+	case ast.NodeValue:
+		if uid, ok := n.Value.(types.EntityUID); ok {
+			return uid.String(), true
+		}
+	case ast.NodeTypeAccess:
+		if key, ok := expressionKey(n.Arg); ok {
+			return attributeCapability(key, n.Value), true
+		}
+	}
+	return "", false
+}
+
+// typechecker infers the types of the expressions in a policy for a single request environment.  The errors it finds
+// are reported at the position of the node being typechecked, or, for an operand of the wrong type, of the operand.
+type typechecker struct {
+	schema *resolvedSchema
+	mode   ValidationMode
+	env    requestEnv
+	errors []ValidationError
+	node   *nodePositions // the positions of the node being typechecked, if known
+	last   types.Position // the position of the node most recently typechecked
+}
+
+func (c *typechecker) position() types.Position {
+	if c.node == nil {
+		return types.Position{}
+	}
+	return c.node.position
+}
+
+func (c *typechecker) errorf(format string, args ...any) valueType {
+	return c.errorAt(c.position(), format, args...)
+}
+
+func (c *typechecker) errorAt(pos types.Position, format string, args ...any) valueType {
+	c.errors = append(c.errors, ValidationError{Position: pos, Message: fmt.Sprintf(format, args...)})
+	return typeError{}
+}
+
+// A nodePositions holds the source position of a node of a condition, along with those of its operands in the order
+// returned by children.
+type nodePositions struct {
+	node     ast.IsNode
+	position types.Position
+	children []*nodePositions
+	checked  bool
+}
+
+// conditionPositions returns the positions of the nodes of a condition of the policy.  If the parser did not record
+// them, every node takes the position of the policy.
+func conditionPositions(p *ast.Policy, cond ast.ConditionType) *nodePositions {
+	count := 0
+	walk(cond.Body, func(ast.IsNode) { count++ })
+	positions := cond.Positions
+	if len(positions) != count {
+		positions = nil
+	}
+	return buildPositions(cond.Body, &positions, types.Position(p.Position))
+}
+
+// buildPositions consumes the positions of n and its descendants, which follow those of the nodes before n in
+// post-order.
+func buildPositions(n ast.IsNode, positions *[]ast.Position, policy types.Position) *nodePositions {
+	res := &nodePositions{node: n, position: policy}
+	for _, c := range children(n) {
+		res.children = append(res.children, buildPositions(c, positions, policy))
+	}
+	if len(*positions) > 0 {
+		res.position = types.Position((*positions)[0])
+		res.position.Filename = policy.Filename
+		*positions = (*positions)[1:]
+	}
+	return res
+}
+
+// child returns the positions of the operand n of the node: the first equal operand not yet typechecked, if any.  An
+// operand which the typechecker introduces itself takes the position of the node.
+func (p *nodePositions) child(n ast.IsNode) *nodePositions {
+	var found *nodePositions
+	for _, c := range p.children {
+		if !reflect.DeepEqual(c.node, n) {
+			continue
+		}
+		if !c.checked {
+			c.checked = true
+			return c
+		}
+		if found == nil {
+			found = c
+		}
+	}
+	if found != nil {
+		return found
+	}
+	return &nodePositions{node: n, position: p.position, children: p.children}
+}
+
+// walk calls f for p and the positions of each of the descendants of its node.
+func (p *nodePositions) walk(f func(*nodePositions)) {
+	f(p)
+	for _, c := range p.children {
+		c.walk(f)
+	}
+}
+
+// expectBool checks that n is a boolean expression, reporting an error if it is not.  It returns the type of n and the
+// capabilities it establishes.  The other expect methods are similar but discard the capabilities.
+func (c *typechecker) expectBool(n ast.IsNode, caps capabilities, context string) (valueType, capabilities) {
+	t, res := c.typeOf(n, caps)
+	switch t.(type) {
+	case typeBool, typeError:
+		return t, res
+	}
+	return c.errorAt(c.last, "%s: expected Bool, got %v", context, t), res
+}
+
+func (c *typechecker) expectLong(n ast.IsNode, caps capabilities, context string) valueType {
+	t, _ := c.typeOf(n, caps)
+	switch t.(type) {
+	case typeLong, typeError:
+		return t
+	}
+	return c.errorAt(c.last, "%s: expected Long, got %v", context, t)
+}
+
+func (c *typechecker) expectString(n ast.IsNode, caps capabilities, context string) valueType {
+	t, _ := c.typeOf(n, caps)
+	switch t.(type) {
+	case typeString, typeError:
+		return t
+	}
+	return c.errorAt(c.last, "%s: expected String, got %v", context, t)
+}
+
+func (c *typechecker) expectEntity(n ast.IsNode, caps capabilities, context string) valueType {
+	t, _ := c.typeOf(n, caps)
+	switch t.(type) {
+	case typeEntity, typeError:
+		return t
+	}
+	return c.errorAt(c.last, "%s: expected an entity, got %v", context, t)
+}
+
+func (c *typechecker) expectSet(n ast.IsNode, caps capabilities, context string) valueType {
+	t, _ := c.typeOf(n, caps)
+	switch t.(type) {
+	case typeSet, typeError:
+		return t
+	}
+	return c.errorAt(c.last, "%s: expected a set, got %v", context, t)
+}
+
+// typeOf returns the type of n and the capabilities that hold when n evaluates to true.
+func (c *typechecker) typeOf(n ast.IsNode, caps capabilities) (valueType, capabilities) {
+	parent := c.node
+	c.node = parent.child(n)
+	t, res := c.typeOfNode(n, caps)
+	c.last = c.position()
+	c.node = parent
+	return t, res
+}
+
+//nolint:revive // this is a large but simple switch over the node types
+func (c *typechecker) typeOfNode(n ast.IsNode, caps capabilities) (valueType, capabilities) {
+	switch n := n.(type) {
+	case ast.NodeValue:
+		return c.valueType(n.Value), caps
+	case ast.NodeTypeVariable:
+		return c.variable(n.Name), caps
+	case ast.NodeTypeRecord:
+		res := typeRecord{attributes: make(map[types.String]attributeType, len(n.Elements))}
+		for _, e := range n.Elements {
+			t, _ := c.typeOf(e.Value, caps)
+			res.attributes[e.Key] = attributeType{typ: t, required: true}
+		}
+		return res, caps
+	case ast.NodeTypeSet:
+		return c.set(n, caps), caps
+	case ast.NodeTypeAccess:
+		return c.access(n, caps), caps
+	case ast.NodeTypeHas:
+		return c.has(n, caps)
+	case ast.NodeTypeGetTag:
+		return c.getTag(n, caps), caps
+	case ast.NodeTypeHasTag:
+		return c.hasTag(n, caps)
+	case ast.NodeTypeLike:
+		c.expectString(n.Arg, caps, "like")
+		return typeAnyBool, caps
+	case ast.NodeTypeIs:
+		return c.is(n.Left, n.EntityType, caps), caps
+	case ast.NodeTypeIsIn:
+		t := c.is(n.Left, n.EntityType, caps)
+		if t == typeFalse {
+			return t, caps
+		}
+		in := c.in(ast.NodeTypeIn{BinaryNode: ast.BinaryNode{Left: n.Left, Right: n.Entity}}, caps)
+		return and(t, in), caps
+	case ast.NodeTypeIn:
+		return c.in(n, caps), caps
+	case ast.NodeTypeAnd:
+		return c.and(n, caps)
+	case ast.NodeTypeOr:
+		return c.or(n, caps)
+	case ast.NodeTypeNot:
+		t, _ := c.expectBool(n.Arg, caps, "!")
+		if b, ok := t.(typeBool); ok && b.known {
+			return typeBool{known: true, value: !b.value}, caps
+		}
+		return t, caps
+	case ast.NodeTypeIfThenElse:
+		return c.ifThenElse(n, caps)
+	case ast.NodeTypeEquals:
+		return c.equals(n.Left, n.Right, caps), caps
+	case ast.NodeTypeNotEquals:
+		t := c.equals(n.Left, n.Right, caps)
+		if b, ok := t.(typeBool); ok && b.known {
+			return typeBool{known: true, value: !b.value}, caps
+		}
+		return t, caps
+	case ast.NodeTypeLessThan:
+		return c.compare(n.BinaryNode, "<", caps), caps
+	case ast.NodeTypeLessThanOrEqual:
+		return c.compare(n.BinaryNode, "<=", caps), caps
+	case ast.NodeTypeGreaterThan:
+		return c.compare(n.BinaryNode, ">", caps), caps
+	case ast.NodeTypeGreaterThanOrEqual:
+		return c.compare(n.BinaryNode, ">=", caps), caps
+	case ast.NodeTypeAdd:
+		return c.arithmetic(n.BinaryNode, "+", caps), caps
+	case ast.NodeTypeSub:
+		return c.arithmetic(n.BinaryNode, "-", caps), caps
+	case ast.NodeTypeMult:
+		return c.arithmetic(n.BinaryNode, "*", caps), caps
+	case ast.NodeTypeNegate:
+		if _, ok := c.expectLong(n.Arg, caps, "unary -").(typeError); ok {
+			return typeError{}, caps
+		}
+		return typeLong{}, caps
+	case ast.NodeTypeContains:
+		return c.contains(n.BinaryNode, caps), caps
+	case ast.NodeTypeContainsAll:
+		return c.containsSet(n.BinaryNode, "containsAll", caps), caps
+	case ast.NodeTypeContainsAny:
+		return c.containsSet(n.BinaryNode, "containsAny", caps), caps
+	case ast.NodeTypeIsEmpty:
+		c.expectSet(n.Arg, caps, "isEmpty")
+		return typeAnyBool, caps
+	case ast.NodeTypeExtensionCall:
+		return c.extensionCall(n, caps), caps
+	}
+	return c.errorf("unknown expression %T", n), caps
+}
+
+func (c *typechecker) variable(name types.String) valueType {
+	switch name {
+	case consts.Principal:
+		return typeEntity{names: []types.EntityType{c.env.principal}}
+	case consts.Action:
+		action := c.env.action
+		return typeEntity{names: []types.EntityType{action.Type}, literal: &action}
+	case consts.Resource:
+		return typeEntity{names: []types.EntityType{c.env.resource}}
+	case consts.Context:
+		return c.env.context
+	}
+	return c.errorf("unknown variable %v", name)
+}
+
+// entityType checks that an entity type used in a policy is declared by the schema.
+func (c *typechecker) entityType(t types.EntityType) bool {
+	if _, ok := c.schema.entities[t]; ok || c.schema.isActionType(t) {
+		return true
+	}
+	c.errorf("unknown entity type %v", t)
+	return false
+}
+
+// entityUID checks that an entity literal refers to a declared entity type and, for actions and enumerated entity
+// types, to a declared entity.
+func (c *typechecker) entityUID(uid types.EntityUID) bool {
+	if !c.entityType(uid.Type) {
+		return false
+	}
+	if c.schema.isActionType(uid.Type) {
+		if _, ok := c.schema.actions[uid]; !ok {
+			c.errorf("unknown action %v", uid)
+			return false
+		}
+		return true
+	}
+	if e := c.schema.entities[uid.Type]; e.enum != nil && !slices.Contains(e.enum, uid.ID) {
+		c.errorf("%v is not a declared value of enumerated entity type %v", uid, uid.Type)
+		return false
+	}
+	return true
+}
+
+func (c *typechecker) valueType(v types.Value) valueType {
+	switch v := v.(type) {
+	case types.Boolean:
+		return typeBool{known: true, value: bool(v)}
+	case types.Long:
+		return typeLong{}
+	case types.String:
+		return typeString{}
+	case types.EntityUID:
+		if !c.entityUID(v) {
+			return typeError{}
+		}
+		res := typeEntity{names: []types.EntityType{v.Type}}
+		if c.schema.isActionType(v.Type) {
+			res.literal = &v
+		}
+		return res
+	case types.Set:
+		var element valueType
+		for e := range v.All() {
+			t := c.valueType(e)
+			if element == nil {
+				element = t
+				continue
+			}
+			var ok bool
+			if element, ok = leastUpperBound(element, t, c.mode); !ok {
+				return c.errorf("set elements must have compatible types")
+			}
+		}
+		return typeSet{element: element}
+	case types.Record:
+		res := typeRecord{attributes: map[types.String]attributeType{}}
+		for k, e := range v.All() {
+			res.attributes[k] = attributeType{typ: c.valueType(e), required: true}
+		}
+		return res
+	case types.Decimal:
+		return typeExtension{name: "decimal"}
+	case types.IPAddr:
+		return typeExtension{name: "ipaddr"}
+	case types.Datetime:
+		return typeExtension{name: "datetime"}
+	case types.Duration:
+		return typeExtension{name: "duration"}
+	}
+	return c.errorf("unknown value %T", v)
+}
+
+func (c *typechecker) set(n ast.NodeTypeSet, caps capabilities) valueType {
+	if len(n.Elements) == 0 {
+		if c.mode == Permissive {
+			return typeSet{}
+		}
+		return c.errorf("empty set literals are not allowed because their element type cannot be determined")
+	}
+	var element valueType
+	for _, e := range n.Elements {
+		t, _ := c.typeOf(e, caps)
+		if element == nil {
+			element = t
+			continue
+		}
+		lub, ok := leastUpperBound(element, t, c.mode)
+		if !ok {
+			return c.errorf("set elements must have compatible types, got %v and %v", element, t)
+		}
+		element = lub
+	}
+	return typeSet{element: element}
+}
+
+// attributes returns the attributes of a record or entity type, along with a description of the type for use in
+// error messages.  For an entity type which may be one of several types, only the attributes common to all of them
+// are returned.
+func (c *typechecker) attributes(t valueType) (map[types.String]attributeType, string, bool) {
+	switch t := t.(type) {
+	case typeRecord:
+		return t.attributes, "record", true
+	case typeEntity:
+		var res map[types.String]attributeType
+		for _, name := range t.names {
+			var attrs map[types.String]attributeType
+			if e, ok := c.schema.entities[name]; ok {
+				attrs = recordTypeFromSchema(e.shape).attributes
+			}
+			if res == nil {
+				res = attrs
+				continue
+			}
+			common := map[types.String]attributeType{}
+			for k, a := range res {
+				if b, ok := attrs[k]; ok {
+					if lub, ok := leastUpperBound(a.typ, b.typ, c.mode); ok {
+						common[k] = attributeType{typ: lub, required: a.required && b.required}
+					}
+				}
+			}
+			res = common
+		}
+		return res, "entity type " + t.String(), true
+	}
+	return nil, "", false
+}
+
+func (c *typechecker) access(n ast.NodeTypeAccess, caps capabilities) valueType {
+	t, _ := c.typeOf(n.Arg, caps)
+	if _, ok := t.(typeError); ok {
+		return t
+	}
+	attrs, desc, ok := c.attributes(t)
+	if !ok {
+		return c.errorf("cannot access attribute `%s`: expected a record or an entity, got %v", n.Value, t)
+	}
+	a, ok := attrs[n.Value]
+	if !ok {
+		return c.errorf("attribute `%s` not found on %s", n.Value, desc)
+	}
+	if !a.required {
+		key, ok := expressionKey(n.Arg)
+		if _, guarded := caps[attributeCapability(key, n.Value)]; !ok || !guarded {
+			return c.errorf("unsafe access to optional attribute `%s` of %s; guard it with a `has` check", n.Value, desc)
+		}
+	}
+	return a.typ
+}
+
+func (c *typechecker) has(n ast.NodeTypeHas, caps capabilities) (valueType, capabilities) {
+	t, _ := c.typeOf(n.Arg, caps)
+	if _, ok := t.(typeError); ok {
+		return t, caps
+	}
+	attrs, _, ok := c.attributes(t)
+	if !ok {
+		return c.errorf("cannot test for attribute `%s`: expected a record or an entity, got %v", n.Value, t), caps
+	}
+	// A required attribute of a record is always present, but an entity may not exist, in which case it has no
+	// attributes at all.
+	a, ok := attrs[n.Value]
+	r, isRecord := t.(typeRecord)
+	switch {
+	case !ok && r.open:
+		return typeAnyBool, caps
+	case !ok:
+		return typeFalse, caps
+	case a.required && isRecord:
+		return typeTrue, caps
+	}
+	if key, ok := expressionKey(n.Arg); ok {
+		return typeAnyBool, caps.with(attributeCapability(key, n.Value))
+	}
+	return typeAnyBool, caps
+}
+
+// tagType returns the type of the tags of the given entity type, or nil if the entity type has no tags.
+func (c *typechecker) tagType(t typeEntity) valueType {
+	var res valueType
+	for _, name := range t.names {
+		e, ok := c.schema.entities[name]
+		if !ok || e.tags == nil {
+			return nil
+		}
+		tt := valueTypeFromSchema(e.tags)
+		if res == nil {
+			res = tt
+			continue
+		}
+		if res, ok = leastUpperBound(res, tt, c.mode); !ok {
+			return nil
+		}
+	}
+	return res
+}
+
+func (c *typechecker) getTag(n ast.NodeTypeGetTag, caps capabilities) valueType {
+	t := c.expectEntity(n.Left, caps, "getTag")
+	c.expectString(n.Right, caps, "getTag")
+	e, ok := t.(typeEntity)
+	if !ok {
+		return typeError{}
+	}
+	tags := c.tagType(e)
+	if tags == nil {
+		return c.errorf("entity type %v does not declare tags", e)
+	}
+	key, ok := expressionKey(n.Left)
+	tag, isLiteral := stringLiteral(n.Right)
+	if _, guarded := caps[tagCapability(key, tag)]; !ok || !isLiteral || !guarded {
+		return c.errorf("unsafe access to tag of entity type %v; guard it with a `hasTag` check", e)
+	}
+	return tags
+}
+
+func (c *typechecker) hasTag(n ast.NodeTypeHasTag, caps capabilities) (valueType, capabilities) {
+	t := c.expectEntity(n.Left, caps, "hasTag")
+	c.expectString(n.Right, caps, "hasTag")
+	e, ok := t.(typeEntity)
+	if !ok {
+		return typeError{}, caps
+	}
+	if c.tagType(e) == nil {
+		return typeFalse, caps
+	}
+	key, ok := expressionKey(n.Left)
+	tag, isLiteral := stringLiteral(n.Right)
+	if ok && isLiteral {
+		return typeAnyBool, caps.with(tagCapability(key, tag))
+	}
+	return typeAnyBool, caps
+}
+
+func stringLiteral(n ast.IsNode) (types.String, bool) {
+	if v, ok := n.(ast.NodeValue); ok {
+		s, ok := v.Value.(types.String)
+		return s, ok
+	}
+	return "", false
+}
+
+func (c *typechecker) is(left ast.IsNode, entityType types.EntityType, caps capabilities) valueType {
+	t := c.expectEntity(left, caps, "is")
+	if !c.entityType(entityType) {
+		return typeError{}
+	}
+	e, ok := t.(typeEntity)
+	if !ok {
+		return t
+	}
+	switch {
+	case !slices.Contains(e.names, entityType):
+		return typeFalse
+	case len(e.names) == 1:
+		return typeTrue
+	}
+	return typeAnyBool
+}
+
+// in returns the type of `left in right`, which is False when the schema does not allow any entity of the left type
+// to be a member of any entity of the right type.
+func (c *typechecker) in(n ast.NodeTypeIn, caps capabilities) valueType {
+	lt := c.expectEntity(n.Left, caps, "in")
+	rt, _ := c.typeOf(n.Right, caps)
+	var rights []typeEntity
+	switch r := rt.(type) {
+	case typeEntity:
+		rights = []typeEntity{r}
+	case typeSet:
+		switch elem := r.element.(type) {
+		case typeEntity:
+			rights = []typeEntity{elem}
+		case nil, typeError:
+		default:
+			return c.errorf("in: expected an entity or a set of entities, got %v", rt)
+		}
+	case typeError:
+	default:
+		return c.errorf("in: expected an entity or a set of entities, got %v", rt)
+	}
+	l, ok := lt.(typeEntity)
+	if !ok || len(rights) == 0 {
+		return typeAnyBool
+	}
+	r := rights[0]
+	if l.literal != nil && r.literal != nil {
+		return typeBool{known: true, value: c.schema.actionIn(*l.literal, *r.literal, true)}
+	}
+	for _, ln := range l.names {
+		for _, rn := range r.names {
+			if c.schema.entityIn(ln, rn, true) {
+				return typeAnyBool
+			}
+		}
+	}
+	return typeFalse
+}
+
+func and(a, b valueType) valueType {
+	ab, aok := a.(typeBool)
+	bb, bok := b.(typeBool)
+	switch {
+	case !aok || !bok:
+		return typeError{}
+	case ab == typeFalse || bb == typeFalse:
+		return typeFalse
+	case ab == typeTrue && bb == typeTrue:
+		return typeTrue
+	}
+	return typeAnyBool
+}
+
+func (c *typechecker) and(n ast.NodeTypeAnd, caps capabilities) (valueType, capabilities) {
+	lt, lcaps := c.expectBool(n.Left, caps, "&&")
+	if lt == typeFalse {
+		return lt, caps
+	}
+	rt, rcaps := c.expectBool(n.Right, caps.union(lcaps), "&&")
+	if _, ok := lt.(typeError); ok {
+		return lt, caps
+	}
+	if _, ok := rt.(typeError); ok {
+		return rt, caps
+	}
+	return and(lt, rt), lcaps.union(rcaps)
+}
+
+func (c *typechecker) or(n ast.NodeTypeOr, caps capabilities) (valueType, capabilities) {
+	lt, lcaps := c.expectBool(n.Left, caps, "||")
+	if lt == typeTrue {
+		return lt, lcaps
+	}
+	rt, rcaps := c.expectBool(n.Right, caps, "||")
+	switch {
+	case lt == typeFalse:
+		return rt, rcaps
+	case rt == typeFalse:
+		return lt, lcaps
+	case rt == typeTrue:
+		return rt, rcaps
+	}
+	if _, ok := lt.(typeError); ok {
+		return lt, caps
+	}
+	if _, ok := rt.(typeError); ok {
+		return rt, caps
+	}
+	return typeAnyBool, lcaps.intersect(rcaps)
+}
+
+func (c *typechecker) ifThenElse(n ast.NodeTypeIfThenElse, caps capabilities) (valueType, capabilities) {
+	ct, ccaps := c.expectBool(n.If, caps, "if")
+	switch ct {
+	case typeTrue:
+		return c.typeOf(n.Then, caps.union(ccaps))
+	case typeFalse:
+		return c.typeOf(n.Else, caps)
+	}
+	tt, tcaps := c.typeOf(n.Then, caps.union(ccaps))
+	et, ecaps := c.typeOf(n.Else, caps)
+	lub, ok := leastUpperBound(tt, et, c.mode)
+	if !ok {
+		return c.errorf("the branches of an if expression must have compatible types, got %v and %v", tt, et), caps
+	}
+	return lub, ccaps.union(tcaps).intersect(ecaps)
+}
+
+func (c *typechecker) equals(left, right ast.IsNode, caps capabilities) valueType {
+	lt, _ := c.typeOf(left, caps)
+	rt, _ := c.typeOf(right, caps)
+	le, lok := lt.(typeEntity)
+	re, rok := rt.(typeEntity)
+	if lok && rok {
+		if le.literal != nil && re.literal != nil {
+			return typeBool{known: true, value: *le.literal == *re.literal}
+		}
+		for _, n := range le.names {
+			if slices.Contains(re.names, n) {
+				return typeAnyBool
+			}
+		}
+		return typeFalse
+	}
+	if _, ok := leastUpperBound(lt, rt, c.mode); !ok && c.mode == Strict {
+		return c.errorf("==: operands must have compatible types, got %v and %v", lt, rt)
+	}
+	return typeAnyBool
+}
+
+func (c *typechecker) compare(n ast.BinaryNode, op string, caps capabilities) valueType {
+	lt, _ := c.typeOf(n.Left, caps)
+	rt, _ := c.typeOf(n.Right, caps)
+	if _, ok := lt.(typeError); ok {
+		return typeAnyBool
+	}
+	if _, ok := rt.(typeError); ok {
+		return typeAnyBool
+	}
+	comparable := func(t valueType) bool {
+		switch t := t.(type) {
+		case typeLong:
+			return true
+		case typeExtension:
+			return t.name == "datetime" || t.name == "duration"
+		}
+		return false
+	}
+	if !comparable(lt) || lt != rt {
+		return c.errorf("%s: expected operands of type Long, datetime or duration, got %v and %v", op, lt, rt)
+	}
+	return typeAnyBool
+}
+
+func (c *typechecker) arithmetic(n ast.BinaryNode, op string, caps capabilities) valueType {
+	lt := c.expectLong(n.Left, caps, op)
+	rt := c.expectLong(n.Right, caps, op)
+	if _, ok := lt.(typeError); ok {
+		return lt
+	}
+	if _, ok := rt.(typeError); ok {
+		return rt
+	}
+	return typeLong{}
+}
+
+func (c *typechecker) contains(n ast.BinaryNode, caps capabilities) valueType {
+	st := c.expectSet(n.Left, caps, "contains")
+	et, _ := c.typeOf(n.Right, caps)
+	s, ok := st.(typeSet)
+	if !ok || s.element == nil {
+		return typeAnyBool
+	}
+	if _, ok := leastUpperBound(s.element, et, c.mode); !ok && c.mode == Strict {
+		return c.errorf("contains: expected an element of type %v, got %v", s.element, et)
+	}
+	return typeAnyBool
+}
+
+func (c *typechecker) containsSet(n ast.BinaryNode, op string, caps capabilities) valueType {
+	lt := c.expectSet(n.Left, caps, op)
+	rt := c.expectSet(n.Right, caps, op)
+	ls, lok := lt.(typeSet)
+	rs, rok := rt.(typeSet)
+	if !lok || !rok || ls.element == nil || rs.element == nil {
+		return typeAnyBool
+	}
+	if _, ok := leastUpperBound(ls.element, rs.element, c.mode); !ok && c.mode == Strict {
+		return c.errorf("%s: expected sets with compatible element types, got %v and %v", op, lt, rt)
+	}
+	return typeAnyBool
+}
+
+type extensionSignature struct {
+	args   []valueType
+	result valueType
+}
+
+var (
+	typeDecimal  = typeExtension{name: "decimal"}
+	typeIPAddr   = typeExtension{name: "ipaddr"}
+	typeDatetime = typeExtension{name: "datetime"}
+	typeDuration = typeExtension{name: "duration"}
+)
+
+var extensionSignatures = map[types.Path]extensionSignature{
+	"ip":       {args: []valueType{typeString{}}, result: typeIPAddr},
+	"decimal":  {args: []valueType{typeString{}}, result: typeDecimal},
+	"datetime": {args: []valueType{typeString{}}, result: typeDatetime},
+	"duration": {args: []valueType{typeString{}}, result: typeDuration},
+
+	"lessThan":           {args: []valueType{typeDecimal, typeDecimal}, result: typeAnyBool},
+	"lessThanOrEqual":    {args: []valueType{typeDecimal, typeDecimal}, result: typeAnyBool},
+	"greaterThan":        {args: []valueType{typeDecimal, typeDecimal}, result: typeAnyBool},
+	"greaterThanOrEqual": {args: []valueType{typeDecimal, typeDecimal}, result: typeAnyBool},
+
+	"isIpv4":      {args: []valueType{typeIPAddr}, result: typeAnyBool},
+	"isIpv6":      {args: []valueType{typeIPAddr}, result: typeAnyBool},
+	"isLoopback":  {args: []valueType{typeIPAddr}, result: typeAnyBool},
+	"isMulticast": {args: []valueType{typeIPAddr}, result: typeAnyBool},
+	"isInRange":   {args: []valueType{typeIPAddr, typeIPAddr}, result: typeAnyBool},
+
+	"toDate":        {args: []valueType{typeDatetime}, result: typeDatetime},
+	"toTime":        {args: []valueType{typeDatetime}, result: typeDuration},
+	"offset":        {args: []valueType{typeDatetime, typeDuration}, result: typeDatetime},
+	"durationSince": {args: []valueType{typeDatetime, typeDatetime}, result: typeDuration},
+
+	"toDays":         {args: []valueType{typeDuration}, result: typeLong{}},
+	"toHours":        {args: []valueType{typeDuration}, result: typeLong{}},
+	"toMinutes":      {args: []valueType{typeDuration}, result: typeLong{}},
+	"toSeconds":      {args: []valueType{typeDuration}, result: typeLong{}},
+	"toMilliseconds": {args: []valueType{typeDuration}, result: typeLong{}},
+}
+
+// extensionConstructors parse the literal argument of each extension type constructor.
+var extensionConstructors = map[types.Path]func(string) error{
+	"ip":       func(s string) error { _, err := types.ParseIPAddr(s); return err },
+	"decimal":  func(s string) error { _, err := types.ParseDecimal(s); return err },
+	"datetime": func(s string) error { _, err := types.ParseDatetime(s); return err },
+	"duration": func(s string) error { _, err := types.ParseDuration(s); return err },
+}
+
+func (c *typechecker) extensionCall(n ast.NodeTypeExtensionCall, caps capabilities) valueType {
+	sig, ok := extensionSignatures[n.Name]
+	if !ok {
+		return c.errorf("unknown extension function %v", n.Name)
+	}
+	if len(n.Args) != len(sig.args) {
+		return c.errorf("%v takes %d argument(s), but %d provided", n.Name, len(sig.args), len(n.Args))
+	}
+	failed := false
+	for i, a := range n.Args {
+		t, _ := c.typeOf(a, caps)
+		if _, ok := t.(typeError); ok {
+			failed = true
+			continue
+		}
+		if t != sig.args[i] {
+			c.errorf("%v: expected argument %d to be of type %v, got %v", n.Name, i+1, sig.args[i], t)
+			failed = true
+		}
+	}
+	if parse, ok := extensionConstructors[n.Name]; ok && !failed {
+		// In permissive mode a constructor may be applied to any string, at the risk of an error during evaluation.
+		s, ok := stringLiteral(n.Args[0])
+		if !ok && c.mode == Strict {
+			return c.errorf("%v: the argument must be a string literal", n.Name)
+		}
+		if err := parse(string(s)); ok && err != nil {
+			return c.errorf("%v: invalid argument %q: %v", n.Name, s, err)
+		}
+	}
+	if failed {
+		return typeError{}
+	}
+	return sig.result
+}
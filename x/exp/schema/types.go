@@ -0,0 +1,258 @@
+package schema
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/cedar-policy/cedar-go/types"
+	"github.com/cedar-policy/cedar-go/x/exp/schema/ast"
+)
+
+// valueType is the static type the validator infers for an expression.
+type valueType interface {
+	fmt.Stringer
+	isValueType()
+}
+
+// typeBool is the type of boolean expressions.  When known is true, the expression is known to always evaluate to
+// value, which lets the validator skip the parts of a policy that can never be evaluated.
+type typeBool struct {
+	known bool
+	value bool
+}
+
+var (
+	typeAnyBool = typeBool{}
+	typeTrue    = typeBool{known: true, value: true}
+	typeFalse   = typeBool{known: true, value: false}
+)
+
+func (typeBool) isValueType() { _ = 0 } // No-op statement injected for code coverage instrumentation
+
+func (t typeBool) String() string {
+	switch {
+	case !t.known:
+		return "Bool"
+	case t.value:
+		return "True"
+	}
+	return "False"
+}
+
+type typeLong struct{}
+
+func (typeLong) isValueType() { _ = 0 } // No-op statement injected for code coverage instrumentation
+
+func (typeLong) String() string { return "Long" }
+
+type typeString struct{}
+
+func (typeString) isValueType() { _ = 0 } // No-op statement injected for code coverage instrumentation
+
+func (typeString) String() string { return "String" }
+
+type typeExtension struct {
+	name types.Ident
+}
+
+func (typeExtension) isValueType() { _ = 0 } // No-op statement injected for code coverage instrumentation
+
+func (t typeExtension) String() string { return string(t.name) }
+
+// typeSet is the type of a set.  The element type is nil for the empty set literal.
+type typeSet struct {
+	element valueType
+}
+
+func (typeSet) isValueType() { _ = 0 } // No-op statement injected for code coverage instrumentation
+
+func (t typeSet) String() string {
+	if t.element == nil {
+		return "Set<?>"
+	}
+	return "Set<" + t.element.String() + ">"
+}
+
+type attributeType struct {
+	typ      valueType
+	required bool
+}
+
+// typeRecord is the type of a record.  An open record may have attributes besides those listed; open records arise
+// only in permissive mode, as the least upper bound of records with different attributes.
+type typeRecord struct {
+	attributes map[types.String]attributeType
+	open       bool
+}
+
+func (typeRecord) isValueType() { _ = 0 } // No-op statement injected for code coverage instrumentation
+
+func (t typeRecord) String() string {
+	keys := make([]string, 0, len(t.attributes))
+	for k := range t.attributes {
+		keys = append(keys, string(k))
+	}
+	slices.Sort(keys)
+	var sb strings.Builder
+	sb.WriteString("{")
+	for i, k := range keys {
+		if i > 0 {
+			sb.WriteString(",")
+		}
+		a := t.attributes[types.String(k)]
+		sb.WriteString(" " + k)
+		if !a.required {
+			sb.WriteString("?")
+		}
+		sb.WriteString(": " + a.typ.String())
+	}
+	if t.open {
+		if len(keys) > 0 {
+			sb.WriteString(",")
+		}
+		sb.WriteString(" ...")
+	}
+	sb.WriteString(" }")
+	return sb.String()
+}
+
+// typeEntity is the type of an entity reference.  The literal is set when the expression is known to refer to one
+// particular action, which allows action equality and hierarchy tests to be decided statically.
+type typeEntity struct {
+	names   []types.EntityType
+	literal *types.EntityUID
+}
+
+func (typeEntity) isValueType() { _ = 0 } // No-op statement injected for code coverage instrumentation
+
+func (t typeEntity) String() string {
+	names := make([]string, len(t.names))
+	for i, n := range t.names {
+		names[i] = string(n)
+	}
+	return strings.Join(names, " | ")
+}
+
+// typeError is the type of an expression which failed to typecheck.  It is compatible with every other type so that a
+// single mistake is reported only once.
+type typeError struct{}
+
+func (typeError) isValueType() { _ = 0 } // No-op statement injected for code coverage instrumentation
+
+func (typeError) String() string { return "error" }
+
+// valueTypeFromSchema converts a resolved schema type into the type of the values it describes.
+func valueTypeFromSchema(t ast.IsType) valueType {
+	switch t := t.(type) {
+	case ast.BooleanType:
+		return typeAnyBool
+	case ast.LongType:
+		return typeLong{}
+	case ast.StringType:
+		return typeString{}
+	case ast.ExtensionType:
+		return typeExtension{name: t.Name}
+	case ast.SetType:
+		return typeSet{element: valueTypeFromSchema(t.Element)}
+	case ast.RecordType:
+		return recordTypeFromSchema(t)
+	case ast.EntityTypeRef:
+		return typeEntity{names: []types.EntityType{types.EntityType(t.Name)}}
+	}
+	return typeError{}
+}
+
+func recordTypeFromSchema(r ast.RecordType) typeRecord {
+	res := typeRecord{attributes: make(map[types.String]attributeType, len(r.Attributes))}
+	for _, a := range r.Attributes {
+		res.attributes[a.Name] = attributeType{typ: valueTypeFromSchema(a.Type), required: !a.Optional}
+	}
+	return res
+}
+
+// leastUpperBound returns the most specific type which includes both a and b, or false if there is no such type.  The
+// types of the elements of a set, the branches of a conditional and the operands of an equality test must have a
+// least upper bound.  In strict mode, entity types must be identical and records must have identical attributes; in
+// permissive mode, different entity types form a union and different records form an open record of the attributes
+// they share.
+func leastUpperBound(a, b valueType, mode ValidationMode) (valueType, bool) {
+	if _, ok := a.(typeError); ok {
+		return a, true
+	}
+	if _, ok := b.(typeError); ok {
+		return b, true
+	}
+	switch a := a.(type) {
+	case typeBool:
+		if b, ok := b.(typeBool); ok {
+			if a == b {
+				return a, true
+			}
+			return typeAnyBool, true
+		}
+	case typeLong, typeString:
+		if a == b {
+			return a, true
+		}
+	case typeExtension:
+		if b, ok := b.(typeExtension); ok && a.name == b.name {
+			return a, true
+		}
+	case typeSet:
+		if b, ok := b.(typeSet); ok {
+			switch {
+			case a.element == nil:
+				return b, true
+			case b.element == nil:
+				return a, true
+			}
+			element, ok := leastUpperBound(a.element, b.element, mode)
+			return typeSet{element: element}, ok
+		}
+	case typeRecord:
+		if b, ok := b.(typeRecord); ok {
+			return recordUpperBound(a, b, mode)
+		}
+	case typeEntity:
+		b, ok := b.(typeEntity)
+		switch {
+		case !ok:
+		case slices.Equal(a.names, b.names):
+			if a.literal != nil && b.literal != nil && *a.literal == *b.literal {
+				return a, true
+			}
+			return typeEntity{names: a.names}, true
+		case mode == Permissive:
+			names := slices.Concat(a.names, b.names)
+			slices.Sort(names)
+			return typeEntity{names: slices.Compact(names)}, true
+		}
+	}
+	return nil, false
+}
+
+func recordUpperBound(a, b typeRecord, mode ValidationMode) (valueType, bool) {
+	sameKeys := !a.open && !b.open && len(a.attributes) == len(b.attributes)
+	res := typeRecord{attributes: make(map[types.String]attributeType, len(a.attributes)), open: !sameKeys}
+	for k, aa := range a.attributes {
+		ba, ok := b.attributes[k]
+		if !ok {
+			sameKeys = false
+			res.open = true
+			continue
+		}
+		if aa.required != ba.required && mode == Strict {
+			return nil, false
+		}
+		t, ok := leastUpperBound(aa.typ, ba.typ, mode)
+		if !ok {
+			return nil, false
+		}
+		res.attributes[k] = attributeType{typ: t, required: aa.required && ba.required}
+	}
+	if !sameKeys && mode == Strict {
+		return nil, false
+	}
+	return res, true
+}
@@ -0,0 +1,455 @@
+package schema
+
+import (
+	"fmt"
+	"slices"
+
+	"github.com/cedar-policy/cedar-go"
+	"github.com/cedar-policy/cedar-go/types"
+	"github.com/cedar-policy/cedar-go/x/exp/ast"
+)
+
+// A ValidationError details a problem found by Validate in a policy, identified by its PolicyID within a PolicySet.
+// The Position is that of the offending expression, or of the policy for a problem with its scope or one found in a
+// policy which was not parsed from Cedar text.
+type ValidationError struct {
+	PolicyID types.PolicyID `json:"policy"`
+	Position types.Position `json:"position"`
+	Message  string         `json:"message"`
+}
+
+func (e ValidationError) String() string {
+	return fmt.Sprintf("while validating policy `%v`: %v", e.PolicyID, e.Message)
+}
+
+// A ValidationMode selects how strictly Validate typechecks policies.
+type ValidationMode uint8
+
+const (
+	// Strict mode requires the types of the operands of `==`, `contains`, `containsAll` and `containsAny`, the
+	// elements of a set and the branches of a conditional to agree exactly, rejects empty set literals and requires
+	// the arguments of extension constructors to be literals.
+	Strict ValidationMode = iota
+	// Permissive mode allows values of different entity types and records with different attributes to be mixed and
+	// compared, and accepts policies which may fail to evaluate only because of a malformed extension value.
+	Permissive
+)
+
+func (m ValidationMode) String() string {
+	if m == Permissive {
+		return "permissive"
+	}
+	return "strict"
+}
+
+// A RequestEnvironment is one combination of principal type, action and resource type that a schema permits.
+type RequestEnvironment struct {
+	Principal types.EntityType `json:"principal"`
+	Action    types.EntityUID  `json:"action"`
+	Resource  types.EntityType `json:"resource"`
+}
+
+func (e RequestEnvironment) String() string {
+	return fmt.Sprintf("%v/%v/%v", e.Principal, e.Action, e.Resource)
+}
+
+// RequestEnvironments returns every request environment the schema permits: for each action with an appliesTo
+// declaration, every combination of its principal and resource types.  Environments are returned in the order the
+// schema declares them.
+func RequestEnvironments(s *Schema) ([]RequestEnvironment, error) {
+	rs, err := s.resolve()
+	if err != nil {
+		return nil, err
+	}
+	var res []RequestEnvironment
+	for _, env := range rs.environments() {
+		res = append(res, env.public())
+	}
+	return res, nil
+}
+
+// An EnvironmentStatus is the outcome of typechecking a policy in one request environment.
+type EnvironmentStatus uint8
+
+const (
+	// EnvironmentValid means that the policy typechecks and may apply to requests in the environment.
+	EnvironmentValid EnvironmentStatus = iota
+	// EnvironmentInvalid means that typechecking the policy found errors.
+	EnvironmentInvalid
+	// EnvironmentDead means that the policy typechecks, but its conditions can never be satisfied in the environment.
+	EnvironmentDead
+	// EnvironmentOutOfScope means that the policy scope excludes every request in the environment.
+	EnvironmentOutOfScope
+)
+
+var environmentStatusNames = []string{"valid", "invalid", "dead", "outOfScope"}
+
+func (s EnvironmentStatus) String() string {
+	if int(s) < len(environmentStatusNames) {
+		return environmentStatusNames[s]
+	}
+	return fmt.Sprintf("EnvironmentStatus(%d)", s)
+}
+
+func (s EnvironmentStatus) MarshalJSON() ([]byte, error) { return []byte(`"` + s.String() + `"`), nil }
+
+func (s *EnvironmentStatus) UnmarshalJSON(b []byte) error {
+	for i, name := range environmentStatusNames {
+		if string(b) == `"`+name+`"` {
+			*s = EnvironmentStatus(i)
+			return nil
+		}
+	}
+	return fmt.Errorf("unknown environment status %s", b)
+}
+
+// An EnvironmentResult is the outcome of typechecking a policy in one request environment.
+type EnvironmentResult struct {
+	Environment RequestEnvironment `json:"environment"`
+	Status      EnvironmentStatus  `json:"status"`
+	Errors      []string           `json:"errors,omitempty"`
+}
+
+// A PolicyResult holds the outcome of typechecking a policy in each of the request environments of the schema, in the
+// order returned by RequestEnvironments.  Environments is empty if the policy refers to entity types or actions which
+// the schema does not declare, because such a policy cannot be typechecked in any environment.
+type PolicyResult struct {
+	PolicyID     types.PolicyID      `json:"policy"`
+	Environments []EnvironmentResult `json:"environments,omitempty"`
+}
+
+// EnvironmentsWithStatus returns the request environments in which typechecking the policy had the given outcome.
+func (r PolicyResult) EnvironmentsWithStatus(status EnvironmentStatus) []RequestEnvironment {
+	var res []RequestEnvironment
+	for _, e := range r.Environments {
+		if e.Status == status {
+			res = append(res, e.Environment)
+		}
+	}
+	return res
+}
+
+// A ValidationResult holds the outcome of validating a PolicySet.  The policy set is valid if there are no errors.
+// Policies holds the outcome for each policy in each request environment, in PolicyID order.
+type ValidationResult struct {
+	Errors   []ValidationError `json:"errors,omitempty"`
+	Policies []PolicyResult    `json:"policies,omitempty"`
+}
+
+// Valid reports whether validation found no errors.
+func (r ValidationResult) Valid() bool {
+	return len(r.Errors) == 0
+}
+
+// Validate typechecks every policy in the PolicySet against the schema in strict mode.  Each policy is checked in every
+// request environment, i.e. every combination of principal type, action and resource type permitted by an action's
+// appliesTo declaration, that its scope matches.  Validation reports:
+//   - entity types and actions which the schema does not declare
+//   - policies whose scope matches no request environment
+//   - accesses to attributes which are not declared, and to optional attributes which are not guarded by `has`
+//   - accesses to tags which are not declared or not guarded by `hasTag`
+//   - operands and arguments of the wrong type
+//
+// Errors are returned in PolicyID order.  The returned error is non-nil only if the schema itself is invalid.
+func Validate(s *Schema, policies *cedar.PolicySet) (ValidationResult, error) {
+	return ValidateWithMode(s, policies, Strict)
+}
+
+// ValidateWithMode is like Validate, but typechecks in the given mode.
+func ValidateWithMode(s *Schema, policies *cedar.PolicySet, mode ValidationMode) (ValidationResult, error) {
+	return validate(s, policies, mode, -1)
+}
+
+// validate validates the policies in the given mode, and reports those whose entity dereference level exceeds
+// maxLevel unless it is negative.
+func validate(s *Schema, policies *cedar.PolicySet, mode ValidationMode, maxLevel int) (ValidationResult, error) {
+	rs, err := s.resolve()
+	if err != nil {
+		return ValidationResult{}, err
+	}
+	var res ValidationResult
+	for _, id := range sortedPolicyIDs(policies) {
+		p := (*ast.Policy)(policies.Get(id).AST())
+		errs, envs := rs.validatePolicy(p, mode)
+		if maxLevel >= 0 {
+			if level := rs.policyLevel(p); level > maxLevel {
+				errs = append(errs, ValidationError{Position: types.Position(p.Position), Message: fmt.Sprintf(
+					"the policy dereferences entities to level %v, exceeding the maximum level of %v", level, maxLevel)})
+			}
+		}
+		for _, e := range errs {
+			e.PolicyID = id
+			res.Errors = append(res.Errors, e)
+		}
+		res.Policies = append(res.Policies, PolicyResult{PolicyID: id, Environments: envs})
+	}
+	return res, nil
+}
+
+func sortedPolicyIDs(policies *cedar.PolicySet) []types.PolicyID {
+	var ids []types.PolicyID
+	for id := range policies.All() {
+		ids = append(ids, id)
+	}
+	slices.Sort(ids)
+	return ids
+}
+
+// validatePolicy returns all of the errors found in the policy, along with the outcome in each request environment.  An
+// error found in several request environments is reported once.
+func (s *resolvedSchema) validatePolicy(p *ast.Policy, mode ValidationMode) ([]ValidationError, []EnvironmentResult) {
+	if errs := s.checkReferences(p); len(errs) > 0 {
+		return errs, nil
+	}
+	var res []ValidationError
+	var envs []EnvironmentResult
+	found := false
+	for _, env := range s.environments() {
+		envRes := EnvironmentResult{Environment: env.public(), Status: EnvironmentOutOfScope}
+		if s.scopeMatches(p, env) {
+			found = true
+			errs, dead := s.typecheckPolicy(p, env, mode)
+			switch {
+			case len(errs) > 0:
+				envRes.Status = EnvironmentInvalid
+			case dead:
+				envRes.Status = EnvironmentDead
+			default:
+				envRes.Status = EnvironmentValid
+			}
+			for _, e := range errs {
+				envRes.Errors = append(envRes.Errors, e.Message)
+				if !slices.Contains(res, e) {
+					res = append(res, e)
+				}
+			}
+		}
+		envs = append(envs, envRes)
+	}
+	if !found {
+		res = []ValidationError{{Position: types.Position(p.Position), Message: "the policy scope does not match any " +
+			"combination of principal type, action and resource type permitted by the schema"}}
+	}
+	return res, envs
+}
+
+// checkReferences reports the entity types and actions anywhere in the policy which the schema does not declare,
+// including those in parts of the policy which can never be evaluated.
+func (s *resolvedSchema) checkReferences(p *ast.Policy) []ValidationError {
+	c := typechecker{schema: s, node: &nodePositions{position: types.Position(p.Position)}}
+	checkEntityScope := func(n ast.IsScopeNode) {
+		switch n := n.(type) {
+		case ast.ScopeTypeEq:
+			c.entityUID(n.Entity)
+		case ast.ScopeTypeIn:
+			c.entityUID(n.Entity)
+		case ast.ScopeTypeIs:
+			c.entityType(n.Type)
+		case ast.ScopeTypeIsIn:
+			c.entityType(n.Type)
+			c.entityUID(n.Entity)
+		}
+	}
+	checkEntityScope(p.Principal)
+	checkEntityScope(p.Resource)
+	var actions []types.EntityUID
+	switch n := p.Action.(type) {
+	case ast.ScopeTypeEq:
+		actions = []types.EntityUID{n.Entity}
+	case ast.ScopeTypeIn:
+		actions = []types.EntityUID{n.Entity}
+	case ast.ScopeTypeInSet:
+		actions = n.Entities
+	}
+	for _, a := range actions {
+		if _, ok := s.actions[a]; !ok {
+			c.errorf("unknown action %v", a)
+		}
+	}
+	for _, cond := range p.Conditions {
+		conditionPositions(p, cond).walk(func(np *nodePositions) {
+			c.node = np
+			switch n := np.node.(type) {
+			case ast.NodeValue:
+				walkValue(n.Value, func(uid types.EntityUID) { c.entityUID(uid) })
+			case ast.NodeTypeIs:
+				c.entityType(n.EntityType)
+			case ast.NodeTypeIsIn:
+				c.entityType(n.EntityType)
+			}
+		})
+	}
+	return c.errors
+}
+
+// walk calls f for n and each of its descendants.
+func walk(n ast.IsNode, f func(ast.IsNode)) {
+	f(n)
+	for _, c := range children(n) {
+		walk(c, f)
+	}
+}
+
+func binaryChildren(n ast.BinaryNode) []ast.IsNode {
+	return []ast.IsNode{n.Left, n.Right}
+}
+
+// children returns the operands of n, in evaluation order.
+func children(n ast.IsNode) []ast.IsNode {
+	switch n := n.(type) {
+	case ast.NodeTypeAccess:
+		return []ast.IsNode{n.Arg}
+	case ast.NodeTypeHas:
+		return []ast.IsNode{n.Arg}
+	case ast.NodeTypeLike:
+		return []ast.IsNode{n.Arg}
+	case ast.NodeTypeIs:
+		return []ast.IsNode{n.Left}
+	case ast.NodeTypeIsIn:
+		return []ast.IsNode{n.Left, n.Entity}
+	case ast.NodeTypeIfThenElse:
+		return []ast.IsNode{n.If, n.Then, n.Else}
+	case ast.NodeTypeExtensionCall:
+		return n.Args
+	case ast.NodeTypeRecord:
+		res := make([]ast.IsNode, len(n.Elements))
+		for i, e := range n.Elements {
+			res[i] = e.Value
+		}
+		return res
+	case ast.NodeTypeSet:
+		return n.Elements
+	case ast.NodeTypeNegate:
+		return []ast.IsNode{n.Arg}
+	case ast.NodeTypeNot:
+		return []ast.IsNode{n.Arg}
+	case ast.NodeTypeIsEmpty:
+		return []ast.IsNode{n.Arg}
+	case ast.NodeTypeGetTag:
+		return binaryChildren(n.BinaryNode)
+	case ast.NodeTypeHasTag:
+		return binaryChildren(n.BinaryNode)
+	case ast.NodeTypeAnd:
+		return binaryChildren(n.BinaryNode)
+	case ast.NodeTypeOr:
+		return binaryChildren(n.BinaryNode)
+	case ast.NodeTypeEquals:
+		return binaryChildren(n.BinaryNode)
+	case ast.NodeTypeNotEquals:
+		return binaryChildren(n.BinaryNode)
+	case ast.NodeTypeLessThan:
+		return binaryChildren(n.BinaryNode)
+	case ast.NodeTypeLessThanOrEqual:
+		return binaryChildren(n.BinaryNode)
+	case ast.NodeTypeGreaterThan:
+		return binaryChildren(n.BinaryNode)
+	case ast.NodeTypeGreaterThanOrEqual:
+		return binaryChildren(n.BinaryNode)
+	case ast.NodeTypeIn:
+		return binaryChildren(n.BinaryNode)
+	case ast.NodeTypeAdd:
+		return binaryChildren(n.BinaryNode)
+	case ast.NodeTypeSub:
+		return binaryChildren(n.BinaryNode)
+	case ast.NodeTypeMult:
+		return binaryChildren(n.BinaryNode)
+	case ast.NodeTypeContains:
+		return binaryChildren(n.BinaryNode)
+	case ast.NodeTypeContainsAll:
+		return binaryChildren(n.BinaryNode)
+	case ast.NodeTypeContainsAny:
+		return binaryChildren(n.BinaryNode)
+	}
+	return nil
+}
+
+// walkValue calls f for each entity reference within the value.
+func walkValue(v types.Value, f func(types.EntityUID)) {
+	switch v := v.(type) {
+	case types.EntityUID:
+		f(v)
+	case types.Set:
+		for e := range v.All() {
+			walkValue(e, f)
+		}
+	case types.Record:
+		for _, e := range v.All() {
+			walkValue(e, f)
+		}
+	}
+}
+
+// environments returns every request environment the schema permits, in declaration order.
+func (s *resolvedSchema) environments() []requestEnv {
+	var res []requestEnv
+	for _, uid := range s.actionOrder {
+		a := s.actions[uid]
+		if a.appliesTo == nil {
+			continue
+		}
+		context := recordTypeFromSchema(a.appliesTo.context)
+		for _, p := range a.appliesTo.principals {
+			for _, r := range a.appliesTo.resources {
+				res = append(res, requestEnv{principal: p, action: uid, resource: r, context: context})
+			}
+		}
+	}
+	return res
+}
+
+func (s *resolvedSchema) entityScopeMatches(n ast.IsScopeNode, t types.EntityType) bool {
+	switch n := n.(type) {
+	case ast.ScopeTypeEq:
+		return n.Entity.Type == t
+	case ast.ScopeTypeIn:
+		return s.entityIn(t, n.Entity.Type, true)
+	case ast.ScopeTypeIs:
+		return n.Type == t
+	case ast.ScopeTypeIsIn:
+		return n.Type == t && s.entityIn(t, n.Entity.Type, true)
+	}
+	return true
+}
+
+func (s *resolvedSchema) scopeMatches(p *ast.Policy, env requestEnv) bool {
+	if !s.entityScopeMatches(p.Principal, env.principal) || !s.entityScopeMatches(p.Resource, env.resource) {
+		return false
+	}
+	switch n := p.Action.(type) {
+	case ast.ScopeTypeEq:
+		return n.Entity == env.action
+	case ast.ScopeTypeIn:
+		return s.actionIn(env.action, n.Entity, true)
+	case ast.ScopeTypeInSet:
+		for _, e := range n.Entities {
+			if s.actionIn(env.action, e, true) {
+				return true
+			}
+		}
+		return false
+	}
+	return true
+}
+
+// typecheckPolicy typechecks the conditions of the policy in the given request environment, and reports whether they
+// can never be satisfied.  The conditions are checked in order as if they were joined by `&&`, so a condition which is
+// known to be false hides the conditions that follow it, and the capabilities established by one condition are
+// available to the next.
+func (s *resolvedSchema) typecheckPolicy(p *ast.Policy, env requestEnv, mode ValidationMode) ([]ValidationError, bool) {
+	c := typechecker{schema: s, mode: mode, env: env}
+	caps := capabilities{}
+	for _, cond := range p.Conditions {
+		positions := conditionPositions(p, cond)
+		c.node = &nodePositions{position: positions.position, children: []*nodePositions{positions}}
+		body := cond.Body
+		if cond.Condition == ast.ConditionUnless {
+			body = ast.NodeTypeNot{UnaryNode: ast.UnaryNode{Arg: body}}
+		}
+		t, condCaps := c.expectBool(body, caps, "condition")
+		if t == typeFalse {
+			return c.errors, true
+		}
+		caps = caps.union(condCaps)
+	}
+	return c.errors, false
+}
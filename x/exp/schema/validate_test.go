@@ -0,0 +1,433 @@
+package schema_test
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/cedar-policy/cedar-go"
+	"github.com/cedar-policy/cedar-go/ast"
+	"github.com/cedar-policy/cedar-go/internal/testutil"
+	"github.com/cedar-policy/cedar-go/types"
+	"github.com/cedar-policy/cedar-go/x/exp/schema"
+)
+
+const validateSchema = `entity User in [Group] = {
+  name: String,
+  age: Long,
+  manager?: User,
+  address: { city: String, zip?: String }
+} tags String;
+entity Group;
+entity Photo in [Album] = {
+  owner: User,
+  labels: Set<String>,
+  ip: ipaddr
+};
+entity Album;
+entity Color enum ["red", "green"];
+action view appliesTo {
+  principal: [User],
+  resource: [Photo],
+  context: { authenticated: Bool, now?: datetime }
+};
+action edit in [view] appliesTo {
+  principal: [User],
+  resource: [Photo, Album]
+};
+action admin;
+`
+
+func TestValidate(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name   string
+		policy string
+		want   []string
+	}{
+		{"trivial", `permit(principal, action, resource);`, nil},
+		{"scopeEq", `permit(principal == User::"alice", action == Action::"view", resource == Photo::"p");`, nil},
+		{"scopeIn", `permit(principal in Group::"g", action in Action::"view", resource in Album::"a");`, nil},
+		{"scopeIs", `permit(principal is User in Group::"g", action, resource is Album);`, nil},
+		{"attributes", `permit(principal, action == Action::"view", resource) when {
+			resource.owner == principal && principal.age > 21 && principal.address.city like "Lon*" &&
+			context.authenticated && resource.labels.contains("public") && resource.ip.isLoopback()
+		};`, nil},
+		{"guardedOptional", `permit(principal, action, resource) when {
+			principal has manager && principal.manager.name == "bob" &&
+			(principal.address has zip && principal.address.zip == "12345")
+		};`, nil},
+		{"guardedOptionalConditions", `permit(principal, action == Action::"view", resource) when {
+			context has now
+		} when {
+			context.now > datetime("2024-01-01")
+		};`, nil},
+		{"guardedTag", `permit(principal, action, resource) when {
+			principal.hasTag("team") && principal.getTag("team") == "cedar"
+		};`, nil},
+		{"ifThenElse", `permit(principal, action, resource) when {
+			if principal has manager then principal.manager.age > 40 else false
+		};`, nil},
+		{"enum", `permit(principal, action, resource) when { Color::"red" != Color::"green" };`, nil},
+		{"extensions", `permit(principal, action, resource) when {
+			decimal("1.23").lessThan(decimal("2.0")) && ip("10.0.0.1").isInRange(ip("10.0.0.0/8")) &&
+			duration("1h") > duration("1m")
+		};`, nil},
+		{"shortCircuit", `permit(principal, action, resource) when { false && principal.unknown };`, nil},
+		{"unknownEntityType", `permit(principal == Admin::"a", action, resource);`,
+			[]string{"unknown entity type Admin"}},
+		{"unknownEntityTypeUnreachable", `permit(principal, action, resource) when { false && Admin::"a" == principal };`,
+			[]string{"unknown entity type Admin"}},
+		{"unknownAction", `permit(principal, action == Action::"delete", resource);`,
+			[]string{`unknown action Action::"delete"`}},
+		{"unknownEnumValue", `permit(principal, action, resource) when { Color::"blue" == Color::"red" };`,
+			[]string{`Color::"blue" is not a declared value of enumerated entity type Color`}},
+		{"scopeMatchesNothing", `permit(principal is Photo, action, resource);`,
+			[]string{"the policy scope does not match any combination of principal type, action and resource type " +
+				"permitted by the schema"}},
+		{"actionWithoutAppliesTo", `permit(principal, action == Action::"admin", resource);`,
+			[]string{"the policy scope does not match any combination of principal type, action and resource type " +
+				"permitted by the schema"}},
+		{"unknownAttribute", `permit(principal, action, resource) when { principal.email == "a" };`,
+			[]string{"attribute `email` not found on entity type User"}},
+		{"unsafeOptional", `permit(principal, action, resource) when { principal.manager.age > 1 };`,
+			[]string{"unsafe access to optional attribute `manager` of entity type User; guard it with a `has` check"}},
+		{"unsafeOptionalUnlessNot", `permit(principal, action, resource) unless { principal has manager } when {
+			principal.manager.age > 1
+		};`,
+			[]string{"unsafe access to optional attribute `manager` of entity type User; guard it with a `has` check"}},
+		{"undeclaredTags", `permit(principal, action, resource is Photo) when { resource.getTag("a") == "b" };`,
+			[]string{"entity type Photo does not declare tags"}},
+		{"unsafeTag", `permit(principal, action, resource) when { principal.getTag("team") == "cedar" };`,
+			[]string{"unsafe access to tag of entity type User; guard it with a `hasTag` check"}},
+		{"conditionNotBool", `permit(principal, action, resource) when { principal.age };`,
+			[]string{"condition: expected Bool, got Long"}},
+		{"operandType", `permit(principal, action, resource) when { principal.name > 1 };`,
+			[]string{">: expected operands of type Long, datetime or duration, got String and Long"}},
+		{"incompatibleEquals", `permit(principal, action, resource) when { principal.age == "1" };`,
+			[]string{"==: operands must have compatible types, got Long and String"}},
+		{"emptySet", `permit(principal, action, resource) when { [].isEmpty() };`,
+			[]string{"empty set literals are not allowed because their element type cannot be determined"}},
+		{"heterogeneousSet", `permit(principal, action, resource) when { [1, "a"].contains(1) };`,
+			[]string{"set elements must have compatible types, got Long and String"}},
+		{"extensionArgument", `permit(principal, action, resource) when { ip("not an ip").isLoopback() };`,
+			[]string{`ip: invalid argument "not an ip": error parsing ip value: error parsing IP address not an ip`}},
+		{"contextAttributeForOneAction", `permit(principal, action, resource) when { context.authenticated };`,
+			[]string{"attribute `authenticated` not found on record"}},
+		{"incompatibleBranches", `permit(principal, action, resource) when {
+			(if principal.age > 1 then 1 else "a") == 1
+		};`,
+			[]string{"the branches of an if expression must have compatible types, got Long and String"}},
+	}
+	var s schema.Schema
+	testutil.OK(t, s.UnmarshalCedar([]byte(validateSchema)))
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			ps, err := cedar.NewPolicySetFromBytes("policy.cedar", []byte(tt.policy))
+			testutil.OK(t, err)
+			res, err := schema.Validate(&s, ps)
+			testutil.OK(t, err)
+			var got []string
+			for _, e := range res.Errors {
+				got = append(got, e.Message)
+			}
+			testutil.Equals(t, got, tt.want)
+			testutil.Equals(t, res.Valid(), len(tt.want) == 0)
+		})
+	}
+}
+
+func TestValidateErrorOrder(t *testing.T) {
+	t.Parallel()
+	var s schema.Schema
+	testutil.OK(t, s.UnmarshalCedar([]byte(validateSchema)))
+	ps, err := cedar.NewPolicySetFromBytes("policy.cedar", []byte(`
+permit(principal, action, resource) when { principal.a };
+permit(principal, action, resource);
+permit(principal, action, resource) when { principal.b };`))
+	testutil.OK(t, err)
+	res, err := schema.Validate(&s, ps)
+	testutil.OK(t, err)
+	testutil.Equals(t, res.Errors, []schema.ValidationError{
+		{
+			PolicyID: "policy0",
+			Position: types.Position{Filename: "policy.cedar", Offset: 44, Line: 2, Column: 44},
+			Message:  "attribute `a` not found on entity type User",
+		},
+		{
+			PolicyID: "policy2",
+			Position: types.Position{Filename: "policy.cedar", Offset: 139, Line: 4, Column: 44},
+			Message:  "attribute `b` not found on entity type User",
+		},
+	})
+	testutil.Equals(t, res.Errors[0].String(), "while validating policy `policy0`: attribute `a` not found on entity type User")
+	testutil.JSONMarshalsTo(t, res.Errors[0],
+		"{\"policy\":\"policy0\",\"position\":{\"filename\":\"policy.cedar\",\"offset\":44,\"line\":2,\"column\":44},"+
+			"\"message\":\"attribute `a` not found on entity type User\"}")
+}
+
+func TestValidateErrorPositions(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name   string
+		policy string
+		want   []string
+	}{
+		{"node", `permit(principal, action, resource) when { principal.a };`,
+			[]string{"1:44: attribute `a` not found on entity type User"}},
+		{"operand", `permit(principal, action, resource) when { principal.age + "x" > 1 };`,
+			[]string{"1:60: +: expected Long, got String"}},
+		{"condition", `permit(principal, action, resource) when { 1 };`,
+			[]string{"1:44: condition: expected Bool, got Long"}},
+		{"unless", `permit(principal, action, resource) unless { 1 };`,
+			[]string{"1:46: !: expected Bool, got Long"}},
+		{"isIn", `permit(principal, action, resource) when { principal is User in 1 };`,
+			[]string{"1:44: in: expected an entity or a set of entities, got Long"}},
+		{"reference", `permit(principal, action, resource) when { principal == Admin::"a" };`,
+			[]string{"1:57: unknown entity type Admin"}},
+		{"scope", `permit(principal == Admin::"a", action, resource);`,
+			[]string{"1:1: unknown entity type Admin"}},
+		{"repeated", `permit(principal, action, resource) when {
+  principal.a ||
+  principal.a
+};`,
+			[]string{
+				"2:3: attribute `a` not found on entity type User",
+				"3:3: attribute `a` not found on entity type User",
+			}},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			var s schema.Schema
+			testutil.OK(t, s.UnmarshalCedar([]byte(validateSchema)))
+			ps, err := cedar.NewPolicySetFromBytes("policy.cedar", []byte(tt.policy))
+			testutil.OK(t, err)
+			res, err := schema.Validate(&s, ps)
+			testutil.OK(t, err)
+			var got []string
+			for _, e := range res.Errors {
+				testutil.Equals(t, e.Position.Filename, "policy.cedar")
+				got = append(got, fmt.Sprintf("%d:%d: %s", e.Position.Line, e.Position.Column, e.Message))
+			}
+			testutil.Equals(t, got, tt.want)
+		})
+	}
+
+	t.Run("unparsed", func(t *testing.T) {
+		t.Parallel()
+		var s schema.Schema
+		testutil.OK(t, s.UnmarshalCedar([]byte(validateSchema)))
+		ps := cedar.NewPolicySet()
+		ps.Add("policy0", cedar.NewPolicyFromAST(ast.Permit().When(ast.Principal().Access("a"))))
+		res, err := schema.Validate(&s, ps)
+		testutil.OK(t, err)
+		testutil.Equals(t, res.Errors, []schema.ValidationError{
+			{PolicyID: "policy0", Message: "attribute `a` not found on entity type User"},
+		})
+	})
+}
+
+func TestValidateSchemaErrors(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name   string
+		schema string
+		want   string
+	}{
+		{"undeclaredEntityType", `entity User in [Group];`, "schema error at <input>:1:1: undeclared entity type Group"},
+		{"undeclaredType", `entity User = { a: Missing };`, "schema error at <input>:1:17: undeclared type Missing"},
+		{"undeclaredAction", `action view in [read];`,
+			`schema error at <input>:1:1: action Action::"view" is a member of undeclared action Action::"read"`},
+		{"actionCycle", `action a in [b]; action b in [a];`,
+			`schema error at <input>:1:1: action Action::"a" is a member of itself`},
+		{"commonTypeCycle", `type A = B; type B = A; entity User = { a: A };`,
+			"schema error at <input>:1:13: common type A refers to itself"},
+		{"reservedNamespace", `namespace __cedar { entity User; }`,
+			"schema error at <input>:1:1: namespace __cedar is reserved"},
+		{"entityCommonClash", `type User = Long; entity User;`,
+			"schema error at <input>:1:19: entity type User has the same name as a common type"},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			var s schema.Schema
+			testutil.OK(t, s.UnmarshalCedar([]byte(tt.schema)))
+			_, err := schema.Validate(&s, cedar.NewPolicySet())
+			testutil.Error(t, err)
+			testutil.Equals(t, err.Error(), tt.want)
+		})
+	}
+}
+
+func TestValidateNameResolution(t *testing.T) {
+	t.Parallel()
+	var s schema.Schema
+	testutil.OK(t, s.UnmarshalCedar([]byte(`type Name = Long;
+entity Group;
+namespace App {
+  type Name = String;
+  entity User in [Group] = { name: Name, global: __cedar::Long };
+  action view appliesTo { principal: User, resource: Group };
+}
+`)))
+	ps, err := cedar.NewPolicySetFromBytes("policy.cedar", []byte(`permit(principal, action == App::Action::"view", resource) when {
+	principal.name like "a*" && principal.global > 1 && principal in resource
+};`))
+	testutil.OK(t, err)
+	res, err := schema.Validate(&s, ps)
+	testutil.OK(t, err)
+	testutil.Equals(t, res.Errors, nil)
+}
+
+func TestValidatePermissive(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name       string
+		policy     string
+		strict     []string
+		permissive []string
+	}{
+		{"entityUnionSet", `permit(principal, action, resource is Photo) when { [principal, resource.owner, resource].contains(principal) };`,
+			[]string{"set elements must have compatible types, got User and Photo"},
+			nil},
+		{"entityUnionAttribute", `permit(principal, action, resource is Photo) when {
+			(if principal.age > 1 then principal else resource).name == "a"
+		};`,
+			[]string{"the branches of an if expression must have compatible types, got User and Photo"},
+			[]string{"attribute `name` not found on entity type Photo | User"}},
+		{"mismatchedEquals", `permit(principal, action, resource) when { principal.age == "1" };`,
+			[]string{"==: operands must have compatible types, got Long and String"},
+			nil},
+		{"mismatchedContains", `permit(principal, action, resource is Photo) when { resource.labels.contains(1) };`,
+			[]string{"contains: expected an element of type String, got Long"},
+			nil},
+		{"emptySet", `permit(principal, action, resource) when { [].isEmpty() };`,
+			[]string{"empty set literals are not allowed because their element type cannot be determined"},
+			nil},
+		{"nonLiteralExtension", `permit(principal, action, resource) when { ip(principal.name).isLoopback() };`,
+			[]string{"ip: the argument must be a string literal"},
+			nil},
+		{"recordWidth", `permit(principal, action, resource) when {
+			(if principal.age > 1 then {a: 1, b: "x"} else {a: 2}).a > 0
+		};`,
+			[]string{`the branches of an if expression must have compatible types, got { a: Long, b: String } and { a: Long }`},
+			nil},
+		{"recordWidthMissingAttribute", `permit(principal, action, resource) when {
+			(if principal.age > 1 then {a: 1, b: "x"} else {a: 2}).b == "x"
+		};`,
+			[]string{`the branches of an if expression must have compatible types, got { a: Long, b: String } and { a: Long }`},
+			[]string{"attribute `b` not found on record"}},
+		{"stillIncompatible", `permit(principal, action, resource) when { [1, "a"].contains(1) };`,
+			[]string{"set elements must have compatible types, got Long and String"},
+			[]string{"set elements must have compatible types, got Long and String"}},
+	}
+	var s schema.Schema
+	testutil.OK(t, s.UnmarshalCedar([]byte(validateSchema)))
+	messages := func(res schema.ValidationResult) []string {
+		var got []string
+		for _, e := range res.Errors {
+			got = append(got, e.Message)
+		}
+		return got
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			ps, err := cedar.NewPolicySetFromBytes("policy.cedar", []byte(tt.policy))
+			testutil.OK(t, err)
+			res, err := schema.ValidateWithMode(&s, ps, schema.Strict)
+			testutil.OK(t, err)
+			testutil.Equals(t, messages(res), tt.strict)
+			res, err = schema.ValidateWithMode(&s, ps, schema.Permissive)
+			testutil.OK(t, err)
+			testutil.Equals(t, messages(res), tt.permissive)
+		})
+	}
+}
+
+func TestRequestEnvironments(t *testing.T) {
+	t.Parallel()
+	var s schema.Schema
+	testutil.OK(t, s.UnmarshalCedar([]byte(validateSchema)))
+	envs, err := schema.RequestEnvironments(&s)
+	testutil.OK(t, err)
+	view := types.NewEntityUID("Action", "view")
+	edit := types.NewEntityUID("Action", "edit")
+	testutil.Equals(t, envs, []schema.RequestEnvironment{
+		{Principal: "User", Action: view, Resource: "Photo"},
+		{Principal: "User", Action: edit, Resource: "Photo"},
+		{Principal: "User", Action: edit, Resource: "Album"},
+	})
+	testutil.Equals(t, envs[0].String(), `User/Action::"view"/Photo`)
+
+	var bad schema.Schema
+	testutil.OK(t, bad.UnmarshalCedar([]byte(`entity User in [Group];`)))
+	_, err = schema.RequestEnvironments(&bad)
+	testutil.Error(t, err)
+}
+
+func TestValidateEnvironmentResults(t *testing.T) {
+	t.Parallel()
+	var s schema.Schema
+	testutil.OK(t, s.UnmarshalCedar([]byte(validateSchema)))
+	ps, err := cedar.NewPolicySetFromBytes("policy.cedar", []byte(`
+permit(principal, action, resource) when { resource is Photo };
+permit(principal, action, resource) when { context.authenticated };
+permit(principal, action == Action::"edit", resource is Album);
+permit(principal, action, resource is Folder);`))
+	testutil.OK(t, err)
+	res, err := schema.Validate(&s, ps)
+	testutil.OK(t, err)
+
+	view := types.NewEntityUID("Action", "view")
+	edit := types.NewEntityUID("Action", "edit")
+	viewPhoto := schema.RequestEnvironment{Principal: "User", Action: view, Resource: "Photo"}
+	editPhoto := schema.RequestEnvironment{Principal: "User", Action: edit, Resource: "Photo"}
+	editAlbum := schema.RequestEnvironment{Principal: "User", Action: edit, Resource: "Album"}
+	testutil.Equals(t, res.Policies, []schema.PolicyResult{
+		{PolicyID: "policy0", Environments: []schema.EnvironmentResult{
+			{Environment: viewPhoto, Status: schema.EnvironmentValid},
+			{Environment: editPhoto, Status: schema.EnvironmentValid},
+			{Environment: editAlbum, Status: schema.EnvironmentDead},
+		}},
+		{PolicyID: "policy1", Environments: []schema.EnvironmentResult{
+			{Environment: viewPhoto, Status: schema.EnvironmentValid},
+			{Environment: editPhoto, Status: schema.EnvironmentInvalid, Errors: []string{"attribute `authenticated` not found on record"}},
+			{Environment: editAlbum, Status: schema.EnvironmentInvalid, Errors: []string{"attribute `authenticated` not found on record"}},
+		}},
+		{PolicyID: "policy2", Environments: []schema.EnvironmentResult{
+			{Environment: viewPhoto, Status: schema.EnvironmentOutOfScope},
+			{Environment: editPhoto, Status: schema.EnvironmentOutOfScope},
+			{Environment: editAlbum, Status: schema.EnvironmentValid},
+		}},
+		{PolicyID: "policy3"},
+	})
+	testutil.Equals(t, res.Policies[0].EnvironmentsWithStatus(schema.EnvironmentValid),
+		[]schema.RequestEnvironment{viewPhoto, editPhoto})
+	testutil.Equals(t, len(res.Errors), 2)
+	testutil.JSONMarshalsTo(t, res.Policies[2].Environments[2],
+		`{"environment":{"principal":"User","action":{"__entity":{"type":"Action","id":"edit"}},"resource":"Album"},`+
+			`"status":"valid"}`)
+}
+
+func TestEnvironmentStatus(t *testing.T) {
+	t.Parallel()
+	for _, s := range []schema.EnvironmentStatus{
+		schema.EnvironmentValid, schema.EnvironmentInvalid, schema.EnvironmentDead, schema.EnvironmentOutOfScope,
+	} {
+		var got schema.EnvironmentStatus
+		testutil.OK(t, got.UnmarshalJSON(testutil.Must(s.MarshalJSON())))
+		testutil.Equals(t, got, s)
+	}
+	testutil.Equals(t, schema.EnvironmentDead.String(), "dead")
+	testutil.Equals(t, schema.EnvironmentStatus(42).String(), "EnvironmentStatus(42)")
+	var got schema.EnvironmentStatus
+	testutil.Error(t, got.UnmarshalJSON([]byte(`"alive"`)))
+	testutil.Equals(t, schema.Strict.String(), "strict")
+	testutil.Equals(t, schema.Permissive.String(), "permissive")
+}
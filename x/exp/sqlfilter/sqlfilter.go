@@ -0,0 +1,640 @@
+// Package sqlfilter translates the residual policies of a partial authorization, in which only the resource is unknown,
+// into a parameterized SQL predicate which selects the resources that the request would be allowed to access.  This
+// lets an application answer questions such as "which documents may this user view?" with a single database query
+// instead of authorizing each row.
+//
+// Entities are represented in the database by their IDs, so a Mapping describes where the ID, attributes and ancestors
+// of the resource are stored, and the entity types of those which are entities.  Attributes which are not mapped, and
+// expressions which have no SQL equivalent, produce an error wrapping ErrUnsupported rather than a predicate which may
+// disagree with Cedar.
+//
+// Cedar does not apply a policy whose evaluation fails, e.g. because the resource lacks an attribute, while SQL
+// evaluates a comparison with NULL as unknown.  The predicate agrees with Cedar for resources which have each mapped
+// attribute that the policies access without first testing for it with `has`.
+package sqlfilter
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+
+	"github.com/cedar-policy/cedar-go"
+	"github.com/cedar-policy/cedar-go/internal/parser"
+	"github.com/cedar-policy/cedar-go/types"
+	"github.com/cedar-policy/cedar-go/x/exp/ast"
+)
+
+// ErrUnsupported is returned, wrapped, when a residual policy cannot be translated to SQL.
+var ErrUnsupported = errors.New("unsupported by SQL translation")
+
+// A Mapping describes how the resources being filtered, all of one entity type, are stored in the database.
+type Mapping struct {
+	// Type is the entity type of the resources.
+	Type types.EntityType
+
+	// ID is the SQL expression for the ID of the resource, e.g. "documents.id".
+	ID string
+
+	// Attributes maps attributes of the resource to the SQL expressions for their values, e.g. "documents.owner_id".
+	// Attributes whose values are entities are stored as the IDs of the entities.
+	Attributes map[types.String]string
+
+	// EntityTypes maps attributes of the resource whose values, or for sets their elements, are entities to the entity
+	// type of those entities.  Comparing an entity with an attribute which is not mapped here is unsupported, as its
+	// ID alone does not identify the entity.
+	EntityTypes map[types.String]types.EntityType
+
+	// Sets maps attributes of the resource whose values are sets to SQL queries which select their elements, e.g.
+	// "SELECT tag FROM document_tags WHERE document_tags.document_id = documents.id".
+	Sets map[types.String]string
+
+	// Ancestors maps entity types to SQL queries which select the IDs of the ancestors of the resource of that type,
+	// e.g. from a closure table: "SELECT ancestor_id FROM folder_closure WHERE folder_closure.descendant_id =
+	// documents.folder_id".  The resource has no ancestors of the types which are not mapped.  It is required to
+	// translate `in`.
+	Ancestors map[types.EntityType]string
+
+	// Placeholder returns the placeholder for the nth parameter, counting from 1, e.g. "$1".  If nil, each placeholder
+	// is "?".
+	Placeholder func(n int) string
+}
+
+// A Filter is a parameterized SQL predicate.
+type Filter struct {
+	SQL  string
+	Args []any
+}
+
+const (
+	sqlTrue  = "1 = 1"
+	sqlFalse = "1 = 0"
+)
+
+// Where returns a SQL predicate which selects the resources that a partial authorization allows.  The response must
+// come from a request in which the resource, and nothing else, was Unknown.  The predicate is true for the resources
+// which satisfy some residual permit policy and no residual forbid policy.
+func Where(res cedar.PartialResponse, m Mapping) (Filter, error) {
+	if res.Decided {
+		if res.Decision == cedar.Allow {
+			return Filter{SQL: sqlTrue}, nil
+		}
+		return Filter{SQL: sqlFalse}, nil
+	}
+	t := translator{m: m}
+	var permits, forbids []string
+	for _, id := range slices.Sorted(maps.Keys(res.Residuals)) {
+		p := res.Residuals[id]
+		sql, err := t.policy((*ast.Policy)(p.AST()))
+		if err != nil {
+			return Filter{}, fmt.Errorf("policy %v: %w", id, err)
+		}
+		if p.Effect() == cedar.Permit {
+			permits = append(permits, sql)
+		} else {
+			forbids = append(forbids, sql)
+		}
+	}
+	sql := join(permits, " OR ", sqlFalse)
+	if len(forbids) > 0 {
+		sql = sql + " AND NOT " + join(forbids, " OR ", sqlFalse)
+	}
+	return Filter{SQL: sql, Args: t.args}, nil
+}
+
+// Policy returns a SQL predicate which selects the resources that satisfy the scope and conditions of a residual
+// policy, regardless of its effect.
+func Policy(p *ast.Policy, m Mapping) (Filter, error) {
+	t := translator{m: m}
+	sql, err := t.policy(p)
+	if err != nil {
+		return Filter{}, err
+	}
+	return Filter{SQL: sql, Args: t.args}, nil
+}
+
+// join returns the parenthesized conjunction or disjunction of the predicates, or empty if there are none.
+func join(predicates []string, op string, empty string) string {
+	if len(predicates) == 0 {
+		return empty
+	}
+	return "(" + strings.Join(predicates, op) + ")"
+}
+
+type translator struct {
+	m    Mapping
+	args []any
+}
+
+func (t *translator) policy(p *ast.Policy) (string, error) {
+	if _, ok := p.Principal.(ast.ScopeTypeAll); !ok {
+		return "", fmt.Errorf("%w: principal scope constraint", ErrUnsupported)
+	}
+	if _, ok := p.Action.(ast.ScopeTypeAll); !ok {
+		return "", fmt.Errorf("%w: action scope constraint", ErrUnsupported)
+	}
+	var predicates []string
+	scope, err := t.scope(p.Resource)
+	if err != nil {
+		return "", err
+	}
+	if scope != "" {
+		predicates = append(predicates, scope)
+	}
+	for _, c := range p.Conditions {
+		sql, err := t.predicate(c.Body)
+		if err != nil {
+			return "", err
+		}
+		if c.Condition == ast.ConditionUnless {
+			sql = "NOT (" + sql + ")"
+		}
+		predicates = append(predicates, sql)
+	}
+	return join(predicates, " AND ", sqlTrue), nil
+}
+
+// scope returns the predicate for a resource scope constraint, or empty if it has none.
+func (t *translator) scope(n ast.IsResourceScopeNode) (string, error) {
+	switch n := n.(type) {
+	case ast.ScopeTypeAll:
+		return "", nil
+	case ast.ScopeTypeEq:
+		return t.equal(n.Entity), nil
+	case ast.ScopeTypeIn:
+		return t.in([]types.EntityUID{n.Entity})
+	case ast.ScopeTypeIs:
+		return t.is(n.Type), nil
+	case ast.ScopeTypeIsIn:
+		return t.isIn(n.Type, []types.EntityUID{n.Entity})
+	default:
+		return "", fmt.Errorf("%w: resource scope constraint %T", ErrUnsupported, n)
+	}
+}
+
+// param adds a parameter holding v and returns its placeholder.
+func (t *translator) param(v types.Value) (string, error) {
+	switch v := v.(type) {
+	case types.Boolean:
+		t.args = append(t.args, bool(v))
+	case types.Long:
+		t.args = append(t.args, int64(v))
+	case types.String:
+		t.args = append(t.args, string(v))
+	case types.EntityUID:
+		t.args = append(t.args, string(v.ID))
+	default:
+		return "", fmt.Errorf("%w: value %s", ErrUnsupported, v.MarshalCedar())
+	}
+	if t.m.Placeholder == nil {
+		return "?", nil
+	}
+	return t.m.Placeholder(len(t.args)), nil
+}
+
+func (t *translator) mustParam(v types.Value) string {
+	p, _ := t.param(v)
+	return p
+}
+
+func isResource(n ast.IsNode) bool {
+	v, ok := n.(ast.NodeTypeVariable)
+	return ok && v.Name == "resource"
+}
+
+// resourceAttribute returns the attribute of the resource which n accesses.
+func resourceAttribute(n ast.IsNode) (types.String, bool) {
+	a, ok := n.(ast.NodeTypeAccess)
+	if !ok || !isResource(a.Arg) {
+		return "", false
+	}
+	return a.Value, true
+}
+
+func unsupported(n ast.IsNode) error {
+	var buf bytes.Buffer
+	parser.MarshalNode(n, &buf)
+	return fmt.Errorf("%w: %s", ErrUnsupported, buf.String())
+}
+
+// operand returns the SQL expression for a value: a parameter, the ID of the resource, or the column of one of its
+// attributes.
+func (t *translator) operand(n ast.IsNode) (string, error) {
+	switch n := n.(type) {
+	case ast.NodeValue:
+		return t.param(n.Value)
+	case ast.NodeTypeVariable:
+		if isResource(n) {
+			return t.m.ID, nil
+		}
+	case ast.NodeTypeAccess:
+		if attr, ok := resourceAttribute(n); ok {
+			return t.attribute(attr)
+		}
+	}
+	return "", unsupported(n)
+}
+
+func (t *translator) attribute(attr types.String) (string, error) {
+	col, ok := t.m.Attributes[attr]
+	if !ok {
+		return "", fmt.Errorf("%w: attribute %s of the resource is not mapped", ErrUnsupported, attr)
+	}
+	return col, nil
+}
+
+// set returns the SQL query which selects the elements of a set-valued attribute of the resource.
+func (t *translator) set(n ast.IsNode) (string, error) {
+	attr, ok := resourceAttribute(n)
+	if !ok {
+		return "", unsupported(n)
+	}
+	query, ok := t.m.Sets[attr]
+	if !ok {
+		return "", fmt.Errorf("%w: set attribute %s of the resource is not mapped", ErrUnsupported, attr)
+	}
+	return query, nil
+}
+
+func (t *translator) predicate(n ast.IsNode) (string, error) {
+	switch n := n.(type) {
+	case ast.NodeValue:
+		if b, ok := n.Value.(types.Boolean); ok {
+			if b {
+				return sqlTrue, nil
+			}
+			return sqlFalse, nil
+		}
+	case ast.NodeTypeAccess:
+		if attr, ok := resourceAttribute(n); ok {
+			col, err := t.attribute(attr)
+			if err != nil {
+				return "", err
+			}
+			return col + " = " + t.mustParam(types.True), nil
+		}
+	case ast.NodeTypeAnd:
+		return t.binary(n.BinaryNode, " AND ")
+	case ast.NodeTypeOr:
+		return t.binary(n.BinaryNode, " OR ")
+	case ast.NodeTypeNot:
+		arg, err := t.predicate(n.Arg)
+		if err != nil {
+			return "", err
+		}
+		return "NOT (" + arg + ")", nil
+	case ast.NodeTypeIfThenElse:
+		return t.ifThenElse(n)
+	case ast.NodeTypeEquals:
+		if e, ok := resourceEntity(n.BinaryNode); ok {
+			return t.equal(e), nil
+		}
+		return t.equality(n.BinaryNode, " = ", sqlFalse)
+	case ast.NodeTypeNotEquals:
+		if e, ok := resourceEntity(n.BinaryNode); ok {
+			return "NOT (" + t.equal(e) + ")", nil
+		}
+		return t.equality(n.BinaryNode, " <> ", sqlTrue)
+	case ast.NodeTypeLessThan:
+		return t.ordering(n, n.BinaryNode, " < ")
+	case ast.NodeTypeLessThanOrEqual:
+		return t.ordering(n, n.BinaryNode, " <= ")
+	case ast.NodeTypeGreaterThan:
+		return t.ordering(n, n.BinaryNode, " > ")
+	case ast.NodeTypeGreaterThanOrEqual:
+		return t.ordering(n, n.BinaryNode, " >= ")
+	case ast.NodeTypeHas:
+		if isResource(n.Arg) {
+			return t.has(n.Value)
+		}
+	case ast.NodeTypeIn:
+		if isResource(n.Left) {
+			if entities, ok := entityValues(n.Right); ok {
+				return t.in(entities)
+			}
+		}
+	case ast.NodeTypeIs:
+		if isResource(n.Left) {
+			return t.is(n.EntityType), nil
+		}
+	case ast.NodeTypeIsIn:
+		if isResource(n.Left) {
+			if entities, ok := entityValues(n.Entity); ok {
+				return t.isIn(n.EntityType, entities)
+			}
+		}
+	case ast.NodeTypeContains:
+		return t.contains(n)
+	case ast.NodeTypeContainsAny:
+		return t.containsSet(n.BinaryNode, " OR ", sqlFalse)
+	case ast.NodeTypeContainsAll:
+		return t.containsSet(n.BinaryNode, " AND ", sqlTrue)
+	case ast.NodeTypeLike:
+		if t.entityType(n.Arg) != "" {
+			return "", unsupported(n)
+		}
+		arg, err := t.operand(n.Arg)
+		if err != nil {
+			return "", err
+		}
+		return arg + " LIKE " + t.mustParam(likePattern(n.Value)) + ` ESCAPE '\'`, nil
+	}
+	return "", unsupported(n)
+}
+
+func (t *translator) binary(n ast.BinaryNode, op string) (string, error) {
+	left, err := t.predicate(n.Left)
+	if err != nil {
+		return "", err
+	}
+	right, err := t.predicate(n.Right)
+	if err != nil {
+		return "", err
+	}
+	return "(" + left + op + right + ")", nil
+}
+
+func (t *translator) ifThenElse(n ast.NodeTypeIfThenElse) (string, error) {
+	var parts [4]string
+	for i, c := range []ast.IsNode{n.If, n.Then, n.If, n.Else} {
+		sql, err := t.predicate(c)
+		if err != nil {
+			return "", err
+		}
+		parts[i] = sql
+	}
+	return "((" + parts[0] + " AND " + parts[1] + ") OR (NOT (" + parts[2] + ") AND " + parts[3] + "))", nil
+}
+
+// equality returns the predicate for the operands being equal, or not, which is the constant differ if they can't be
+// equal because their entity types differ.
+func (t *translator) equality(n ast.BinaryNode, op string, differ string) (string, error) {
+	ok, err := t.mayEqual(n.Left, n.Right)
+	if err != nil {
+		return "", err
+	}
+	if !ok {
+		return differ, nil
+	}
+	return t.comparison(n, op)
+}
+
+// ordering returns the predicate for an ordering of the operands, which is unsupported for entities, as Cedar does not
+// order them.
+func (t *translator) ordering(n ast.IsNode, b ast.BinaryNode, op string) (string, error) {
+	if t.entityType(b.Left) != "" || t.entityType(b.Right) != "" {
+		return "", unsupported(n)
+	}
+	return t.comparison(b, op)
+}
+
+func (t *translator) comparison(n ast.BinaryNode, op string) (string, error) {
+	left, err := t.operand(n.Left)
+	if err != nil {
+		return "", err
+	}
+	right, err := t.operand(n.Right)
+	if err != nil {
+		return "", err
+	}
+	return left + op + right, nil
+}
+
+// entityType returns the entity type of the value of an operand, or of its elements if it is a set-valued attribute of
+// the resource, or empty if it is not an entity or is an attribute whose entity type is not mapped.
+func (t *translator) entityType(n ast.IsNode) types.EntityType {
+	switch n := n.(type) {
+	case ast.NodeValue:
+		if e, ok := n.Value.(types.EntityUID); ok {
+			return e.Type
+		}
+	case ast.NodeTypeVariable:
+		if isResource(n) {
+			return t.m.Type
+		}
+	case ast.NodeTypeAccess:
+		if attr, ok := resourceAttribute(n); ok {
+			return t.m.EntityTypes[attr]
+		}
+	}
+	return ""
+}
+
+// mayEqual reports whether the values of two operands, or elements of set-valued attributes, may be equal, which they
+// can't if only one is an entity or they are entities of different types.  It is unsupported to compare an entity with
+// an attribute of the resource whose entity type is not mapped.
+func (t *translator) mayEqual(a, b ast.IsNode) (bool, error) {
+	at, bt := t.entityType(a), t.entityType(b)
+	if at == bt {
+		return true, nil
+	}
+	if at != "" && bt != "" {
+		return false, nil
+	}
+	other := a
+	if at != "" {
+		other = b
+	}
+	if attr, ok := resourceAttribute(other); ok {
+		return false, fmt.Errorf("%w: entity type of attribute %s of the resource is not mapped", ErrUnsupported, attr)
+	}
+	return false, nil
+}
+
+func (t *translator) has(attr types.String) (string, error) {
+	if _, ok := t.m.Sets[attr]; ok {
+		return sqlTrue, nil
+	}
+	col, err := t.attribute(attr)
+	if err != nil {
+		return "", err
+	}
+	return col + " IS NOT NULL", nil
+}
+
+// entityValues returns the entities of an entity or a set of entities.
+func entityValues(n ast.IsNode) ([]types.EntityUID, bool) {
+	v, ok := n.(ast.NodeValue)
+	if !ok {
+		return nil, false
+	}
+	switch v := v.Value.(type) {
+	case types.EntityUID:
+		return []types.EntityUID{v}, true
+	case types.Set:
+		var res []types.EntityUID
+		for _, e := range elements(v) {
+			uid, ok := e.(types.EntityUID)
+			if !ok {
+				return nil, false
+			}
+			res = append(res, uid)
+		}
+		return res, true
+	}
+	return nil, false
+}
+
+// elements returns the elements of a set in the order of their Cedar text, so that the parameters for them are in a
+// deterministic order.
+func elements(s types.Set) []types.Value {
+	res := slices.Collect(s.All())
+	slices.SortFunc(res, func(a, b types.Value) int { return strings.Compare(a.String(), b.String()) })
+	return res
+}
+
+// resourceEntity returns the entity which the resource is compared with, if n compares the two.
+func resourceEntity(n ast.BinaryNode) (types.EntityUID, bool) {
+	left, right := n.Left, n.Right
+	if isResource(right) {
+		left, right = right, left
+	}
+	v, ok := right.(ast.NodeValue)
+	if !ok || !isResource(left) {
+		return types.EntityUID{}, false
+	}
+	e, ok := v.Value.(types.EntityUID)
+	return e, ok
+}
+
+// equal returns the predicate for the resource being the entity.
+func (t *translator) equal(e types.EntityUID) string {
+	if e.Type != t.m.Type {
+		return sqlFalse
+	}
+	return t.m.ID + " = " + t.mustParam(e)
+}
+
+// in returns the predicate for the resource being, or being a descendant of, any of the entities.
+func (t *translator) in(entities []types.EntityUID) (string, error) {
+	if len(t.m.Ancestors) == 0 {
+		return "", fmt.Errorf("%w: in, without a mapping of the ancestors of the resource", ErrUnsupported)
+	}
+	var predicates []string
+	for _, e := range entities {
+		if e.Type == t.m.Type {
+			predicates = append(predicates, t.equal(e))
+		}
+		if query, ok := t.m.Ancestors[e.Type]; ok {
+			predicates = append(predicates, t.mustParam(e)+" IN ("+query+")")
+		}
+	}
+	return join(predicates, " OR ", sqlFalse), nil
+}
+
+func (t *translator) is(et types.EntityType) string {
+	if et != t.m.Type {
+		return sqlFalse
+	}
+	return sqlTrue
+}
+
+func (t *translator) isIn(et types.EntityType, entities []types.EntityUID) (string, error) {
+	if et != t.m.Type {
+		return sqlFalse, nil
+	}
+	return t.in(entities)
+}
+
+func (t *translator) contains(n ast.NodeTypeContains) (string, error) {
+	if v, ok := n.Left.(ast.NodeValue); ok {
+		set, ok := v.Value.(types.Set)
+		if !ok {
+			return "", unsupported(n)
+		}
+		right, err := t.operand(n.Right)
+		if err != nil {
+			return "", err
+		}
+		var params []string
+		for _, e := range elements(set) {
+			ok, err := t.mayEqual(ast.NodeValue{Value: e}, n.Right)
+			if err != nil {
+				return "", err
+			}
+			if !ok {
+				continue
+			}
+			p, err := t.param(e)
+			if err != nil {
+				return "", err
+			}
+			params = append(params, p)
+		}
+		if len(params) == 0 {
+			return sqlFalse, nil
+		}
+		return right + " IN (" + strings.Join(params, ", ") + ")", nil
+	}
+	query, err := t.set(n.Left)
+	if err != nil {
+		return "", err
+	}
+	ok, err := t.mayEqual(n.Left, n.Right)
+	if err != nil {
+		return "", err
+	}
+	if !ok {
+		return sqlFalse, nil
+	}
+	right, err := t.operand(n.Right)
+	if err != nil {
+		return "", err
+	}
+	return right + " IN (" + query + ")", nil
+}
+
+// containsSet returns the predicate for containsAny or containsAll, where the left operand is a set attribute of the
+// resource and the right operand is a set value.
+func (t *translator) containsSet(n ast.BinaryNode, op string, empty string) (string, error) {
+	query, err := t.set(n.Left)
+	if err != nil {
+		return "", err
+	}
+	v, ok := n.Right.(ast.NodeValue)
+	if !ok {
+		return "", unsupported(n.Right)
+	}
+	set, ok := v.Value.(types.Set)
+	if !ok {
+		return "", unsupported(n.Right)
+	}
+	var predicates []string
+	for _, e := range elements(set) {
+		ok, err := t.mayEqual(n.Left, ast.NodeValue{Value: e})
+		if err != nil {
+			return "", err
+		}
+		if !ok {
+			predicates = append(predicates, sqlFalse)
+			continue
+		}
+		p, err := t.param(e)
+		if err != nil {
+			return "", err
+		}
+		predicates = append(predicates, p+" IN ("+query+")")
+	}
+	return join(predicates, op, empty), nil
+}
+
+// likePattern returns the SQL LIKE pattern, using \ as the escape character, equivalent to a Cedar pattern.
+func likePattern(p types.Pattern) types.String {
+	var buf strings.Builder
+	for _, c := range p.Components() {
+		s, ok := c.(types.String)
+		if !ok {
+			buf.WriteRune('%')
+			continue
+		}
+		for _, r := range s {
+			if r == '%' || r == '_' || r == '\\' {
+				buf.WriteRune('\\')
+			}
+			buf.WriteRune(r)
+		}
+	}
+	return types.String(buf.String())
+}
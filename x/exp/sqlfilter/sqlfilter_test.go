@@ -0,0 +1,389 @@
+package sqlfilter_test
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/cedar-policy/cedar-go"
+	"github.com/cedar-policy/cedar-go/internal/testutil"
+	"github.com/cedar-policy/cedar-go/x/exp/ast"
+	"github.com/cedar-policy/cedar-go/x/exp/sqlfilter"
+)
+
+var documents = sqlfilter.Mapping{
+	Type: "Doc",
+	ID:   "docs.id",
+	Attributes: map[cedar.String]string{
+		"owner":  "docs.owner_id",
+		"parent": "docs.parent_id",
+		"public": "docs.public",
+		"size":   "docs.size",
+		"title":  "docs.title",
+	},
+	EntityTypes: map[cedar.String]cedar.EntityType{"owner": "User", "parent": "Doc", "editors": "User"},
+	Sets: map[cedar.String]string{
+		"editors": "SELECT user_id FROM doc_editors WHERE doc_editors.doc_id = docs.id",
+		"tags":    "SELECT tag FROM doc_tags WHERE doc_tags.doc_id = docs.id",
+	},
+	Ancestors: map[cedar.EntityType]string{
+		"Doc":    "SELECT ancestor_id FROM doc_ancestors WHERE doc_ancestors.doc_id = docs.id",
+		"Folder": "SELECT folder_id FROM doc_folders WHERE doc_folders.doc_id = docs.id",
+	},
+}
+
+const (
+	editors   = "(SELECT user_id FROM doc_editors WHERE doc_editors.doc_id = docs.id)"
+	tags      = "(SELECT tag FROM doc_tags WHERE doc_tags.doc_id = docs.id)"
+	ancestors = "(SELECT ancestor_id FROM doc_ancestors WHERE doc_ancestors.doc_id = docs.id)"
+	folders   = "(SELECT folder_id FROM doc_folders WHERE doc_folders.doc_id = docs.id)"
+)
+
+func partial(t *testing.T, policies string) cedar.PartialResponse {
+	ps, err := cedar.NewPolicySetFromBytes("policy.cedar", []byte(policies))
+	testutil.OK(t, err)
+	return cedar.AuthorizePartial(ps, nil, cedar.PartialRequest{
+		Principal: cedar.NewEntityUID("User", "alice"),
+		Action:    cedar.NewEntityUID("Action", "view"),
+		Resource:  cedar.Unknown(),
+	})
+}
+
+func TestWhere(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name     string
+		policies string
+		sql      string
+		args     []any
+	}{
+		{"allow", `permit(principal == User::"alice", action, resource);`, "1 = 1", nil},
+		{"deny", `permit(principal == User::"bob", action, resource);`, "1 = 0", nil},
+		{"owner",
+			`permit(principal, action, resource) when { resource.owner == principal };`,
+			"((docs.owner_id = ?))",
+			[]any{"alice"},
+		},
+		{"folder",
+			`permit(principal, action, resource in Folder::"shared");`,
+			"(((? IN " + folders + ")))",
+			[]any{"shared"},
+		},
+		{"inDoc",
+			`permit(principal, action, resource in Doc::"readme");`,
+			"(((docs.id = ? OR ? IN " + ancestors + ")))",
+			[]any{"readme", "readme"},
+		},
+		{"inSet",
+			`permit(principal, action, resource) when { resource in [Folder::"b", Folder::"a", Group::"c"] };`,
+			"(((? IN " + folders + " OR ? IN " + folders + ")))",
+			[]any{"a", "b"},
+		},
+		{"inUnmappedType",
+			`permit(principal, action, resource in Group::"x");`,
+			"((1 = 0))",
+			nil,
+		},
+		{"entityTypes",
+			`permit(principal, action, resource) when { resource.owner == Team::"a" || resource.owner != Team::"a" };
+			permit(principal, action, resource) when { resource.parent == resource && resource.owner != principal };`,
+			"(((1 = 0 OR 1 = 1)) OR ((docs.parent_id = docs.id AND docs.owner_id <> ?)))",
+			[]any{"alice"},
+		},
+		{"setLiteralEntityTypes",
+			`permit(principal, action, resource) when { [Team::"a", User::"a", 1].contains(resource.owner) || [Team::"a"].contains(resource.owner) };`,
+			"(((docs.owner_id IN (?) OR 1 = 0)))",
+			[]any{"a"},
+		},
+		{"setEntityTypes",
+			`permit(principal, action, resource) when { resource.editors.contains(principal) || resource.editors.contains(Team::"a") };
+			permit(principal, action, resource) when { resource.editors.containsAny([Team::"a", User::"b"]) && resource.editors.containsAll([Team::"a"]) };`,
+			"(((? IN " + editors + " OR 1 = 0)) OR (((1 = 0 OR ? IN " + editors + ") AND (1 = 0))))",
+			[]any{"alice", "b"},
+		},
+		{"eq",
+			`permit(principal, action, resource == Doc::"readme");
+			permit(principal, action, resource) when { User::"x" == resource || resource != Doc::"a" };`,
+			"((docs.id = ?) OR ((1 = 0 OR NOT (docs.id = ?))))",
+			[]any{"readme", "a"},
+		},
+		{"is",
+			`permit(principal, action, resource is Doc) when { resource.public };
+			permit(principal, action, resource is Folder in Folder::"a") when { resource.public };
+			permit(principal, action, resource) when { resource is Doc in Doc::"a" && !(resource is User) };`,
+			"((1 = 1 AND docs.public = ?) OR (1 = 0 AND docs.public = ?) OR (((docs.id = ? OR ? IN " + ancestors +
+				") AND NOT (1 = 0))))",
+			[]any{true, true, "a", "a"},
+		},
+		{"isIn",
+			`permit(principal, action, resource) when { resource is Folder in Folder::"a" };`,
+			"((1 = 0))",
+			nil,
+		},
+		{"tags",
+			`permit(principal, action, resource) when { resource.tags.contains("x") };
+			permit(principal, action, resource) when { resource.tags.containsAny(["a"]) || resource.tags.containsAll([]) };
+			permit(principal, action, resource) when { resource.tags.containsAll(["a", "a"]) || resource.tags.containsAny([]) };`,
+			"((? IN " + tags + ") OR (((? IN " + tags + ") OR 1 = 1)) OR (((? IN " + tags + ") OR 1 = 0)))",
+			[]any{"x", "a", "a"},
+		},
+		{"setLiteral",
+			`permit(principal, action, resource) when { [User::"bob", principal].contains(resource.owner) || [].contains(resource.owner) };`,
+			"(((docs.owner_id IN (?, ?) OR 1 = 0)))",
+			[]any{"alice", "bob"},
+		},
+		{"like",
+			`permit(principal, action, resource) when { resource.title like "a*b_%\*" };`,
+			`((docs.title LIKE ? ESCAPE '\'))`,
+			[]any{`a%b\_\%*`},
+		},
+		{"comparisons",
+			`permit(principal, action, resource) when { resource.size > 1 && resource.size >= 2 && resource.size < 3 && resource.size <= 4 && resource.size != 0 };`,
+			"((((((docs.size > ? AND docs.size >= ?) AND docs.size < ?) AND docs.size <= ?) AND docs.size <> ?)))",
+			[]any{int64(1), int64(2), int64(3), int64(4), int64(0)},
+		},
+		{"has",
+			`permit(principal, action, resource) when { resource has size && resource has tags };`,
+			"(((docs.size IS NOT NULL AND 1 = 1)))",
+			nil,
+		},
+		{"ifThenElse",
+			`permit(principal, action, resource) when { if resource.public then true else resource.owner == principal };`,
+			"((((docs.public = ? AND 1 = 1) OR (NOT (docs.public = ?) AND docs.owner_id = ?))))",
+			[]any{true, true, "alice"},
+		},
+		{"forbid",
+			`permit(principal, action, resource) when { resource.public };
+			forbid(principal, action, resource) unless { resource.owner == principal };`,
+			"((docs.public = ?)) AND NOT ((NOT (docs.owner_id = ?)))",
+			[]any{true, "alice"},
+		},
+		{"onlyForbid",
+			`permit(principal, action, resource);
+			forbid(principal, action, resource) when { resource.size > 10 };`,
+			"(1 = 1) AND NOT ((docs.size > ?))",
+			[]any{int64(10)},
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			filter, err := sqlfilter.Where(partial(t, tt.policies), documents)
+			testutil.OK(t, err)
+			testutil.Equals(t, filter.SQL, tt.sql)
+			testutil.Equals(t, filter.Args, tt.args)
+		})
+	}
+}
+
+func TestWhereErrors(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name     string
+		policies string
+		mapping  sqlfilter.Mapping
+		err      string
+	}{
+		{"attribute",
+			`permit(principal, action, resource) when { resource.missing == 1 };`,
+			documents,
+			"policy policy0: unsupported by SQL translation: attribute missing of the resource is not mapped",
+		},
+		{"booleanAttribute",
+			`permit(principal, action, resource) when { resource.missing };`,
+			documents,
+			"policy policy0: unsupported by SQL translation: attribute missing of the resource is not mapped",
+		},
+		{"hasAttribute",
+			`permit(principal, action, resource) when { resource has missing };`,
+			documents,
+			"policy policy0: unsupported by SQL translation: attribute missing of the resource is not mapped",
+		},
+		{"setAttribute",
+			`permit(principal, action, resource) when { resource.missing.contains(1) };`,
+			documents,
+			"policy policy0: unsupported by SQL translation: set attribute missing of the resource is not mapped",
+		},
+		{"arithmetic",
+			`permit(principal, action, resource) when { resource.size + 1 > 2 };`,
+			documents,
+			"policy policy0: unsupported by SQL translation: resource.size + 1",
+		},
+		{"arithmeticRight",
+			`permit(principal, action, resource) when { 2 < resource.size + 1 };`,
+			documents,
+			"policy policy0: unsupported by SQL translation: resource.size + 1",
+		},
+		{"value",
+			`permit(principal, action, resource) when { resource.size == decimal("1.5") };`,
+			documents,
+			`policy policy0: unsupported by SQL translation: value decimal("1.5")`,
+		},
+		{"record",
+			`permit(principal, action, resource) when { resource.size.x == 1 };`,
+			documents,
+			"policy policy0: unsupported by SQL translation: resource.size.x",
+		},
+		{"nonBoolean",
+			`permit(principal, action, resource) when { if resource.public then 1 else 2 };`,
+			documents,
+			"policy policy0: unsupported by SQL translation: 1",
+		},
+		{"ifCondition",
+			`permit(principal, action, resource) when { if resource.size + 1 then true else false };`,
+			documents,
+			"policy policy0: unsupported by SQL translation: resource.size + 1",
+		},
+		{"entityType",
+			`permit(principal, action, resource) when { resource.title == User::"a" };`,
+			documents,
+			"policy policy0: unsupported by SQL translation: entity type of attribute title of the resource is not mapped",
+		},
+		{"entityTypeRight",
+			`permit(principal, action, resource) when { principal != resource.title };`,
+			documents,
+			"policy policy0: unsupported by SQL translation: entity type of attribute title of the resource is not mapped",
+		},
+		{"setLiteralEntityType",
+			`permit(principal, action, resource) when { [User::"a"].contains(resource.title) };`,
+			documents,
+			"policy policy0: unsupported by SQL translation: entity type of attribute title of the resource is not mapped",
+		},
+		{"setEntityType",
+			`permit(principal, action, resource) when { resource.tags.contains(principal) };`,
+			documents,
+			"policy policy0: unsupported by SQL translation: entity type of attribute tags of the resource is not mapped",
+		},
+		{"setAnyEntityType",
+			`permit(principal, action, resource) when { resource.tags.containsAny([principal]) };`,
+			documents,
+			"policy policy0: unsupported by SQL translation: entity type of attribute tags of the resource is not mapped",
+		},
+		{"orderingEntity",
+			`permit(principal, action, resource) when { resource.owner < principal };`,
+			documents,
+			`policy policy0: unsupported by SQL translation: resource.owner < User::"alice"`,
+		},
+		{"likeEntity",
+			`permit(principal, action, resource) when { resource.owner like "a*" };`,
+			documents,
+			`policy policy0: unsupported by SQL translation: resource.owner like "a*"`,
+		},
+		{"in",
+			`permit(principal, action, resource in Folder::"a");`,
+			sqlfilter.Mapping{Type: "Doc", ID: "docs.id"},
+			"policy policy0: unsupported by SQL translation: in, without a mapping of the ancestors of the resource",
+		},
+		{"inAttribute",
+			`permit(principal, action, resource) when { resource.owner in Group::"a" };`,
+			documents,
+			`policy policy0: unsupported by SQL translation: resource.owner in Group::"a"`,
+		},
+		{"error",
+			`permit(principal, action, resource) when { resource.public && 1 < "a" };`,
+			documents,
+			`policy policy0: unsupported by SQL translation: __cedar::partialError("type error: expected comparable value, got string")`,
+		},
+		{"and",
+			`permit(principal, action, resource) when { resource.public && resource.size.x };`,
+			documents,
+			"policy policy0: unsupported by SQL translation: resource.size.x",
+		},
+		{"not",
+			`permit(principal, action, resource) when { !(resource.size + 1 == 2) };`,
+			documents,
+			"policy policy0: unsupported by SQL translation: resource.size + 1",
+		},
+		{"containsAnySet",
+			`permit(principal, action, resource) when { resource.tags.containsAny(resource.tags) };`,
+			documents,
+			"policy policy0: unsupported by SQL translation: resource.tags",
+		},
+		{"containsAnyLeft",
+			`permit(principal, action, resource) when { resource.owner.tags.containsAny([1]) };`,
+			documents,
+			"policy policy0: unsupported by SQL translation: resource.owner.tags",
+		},
+		{"containsAnyValue",
+			`permit(principal, action, resource) when { resource.tags.containsAny([decimal("1.5")]) };`,
+			documents,
+			`policy policy0: unsupported by SQL translation: value decimal("1.5")`,
+		},
+		{"containsRight",
+			`permit(principal, action, resource) when { resource.tags.contains(resource.tags.x) };`,
+			documents,
+			"policy policy0: unsupported by SQL translation: resource.tags.x",
+		},
+		{"containsLiteralRight",
+			`permit(principal, action, resource) when { [1].contains(resource.tags.x) };`,
+			documents,
+			"policy policy0: unsupported by SQL translation: resource.tags.x",
+		},
+		{"containsLiteralValue",
+			`permit(principal, action, resource) when { [decimal("1.5")].contains(resource.size) };`,
+			documents,
+			`policy policy0: unsupported by SQL translation: value decimal("1.5")`,
+		},
+		{"like",
+			`permit(principal, action, resource) when { resource.owner.name like "a" };`,
+			documents,
+			"policy policy0: unsupported by SQL translation: resource.owner.name",
+		},
+		{"forbid",
+			`permit(principal, action, resource);
+			forbid(principal, action, resource) when { resource.missing };`,
+			documents,
+			"policy policy1: unsupported by SQL translation: attribute missing of the resource is not mapped",
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			_, err := sqlfilter.Where(partial(t, tt.policies), tt.mapping)
+			testutil.ErrorIs(t, err, sqlfilter.ErrUnsupported)
+			testutil.Equals(t, err.Error(), tt.err)
+		})
+	}
+}
+
+func TestPolicy(t *testing.T) {
+	t.Parallel()
+	mapping := documents
+	mapping.Placeholder = func(n int) string { return fmt.Sprintf("$%d", n) }
+	filter, err := sqlfilter.Policy(ast.Forbid().
+		ResourceIsIn("Doc", cedar.NewEntityUID("Doc", "a")).
+		When(ast.Resource().Access("owner").Equal(ast.EntityUID("User", "alice"))), mapping)
+	testutil.OK(t, err)
+	testutil.Equals(t, filter.SQL, "((docs.id = $1 OR $2 IN "+ancestors+") AND docs.owner_id = $3)")
+	testutil.Equals(t, filter.Args, []any{"a", "a", "alice"})
+
+	filter, err = sqlfilter.Policy(ast.Permit().When(ast.Resource().Access("parent").Equal(ast.Resource())).When(ast.False()), documents)
+	testutil.OK(t, err)
+	testutil.Equals(t, filter.SQL, "(docs.parent_id = docs.id AND 1 = 0)")
+
+	for _, p := range []*ast.Policy{
+		ast.Permit().PrincipalEq(cedar.NewEntityUID("User", "alice")),
+		ast.Permit().ActionEq(cedar.NewEntityUID("Action", "view")),
+		ast.Permit().ResourceEqSlot(),
+		ast.Permit().ResourceIn(cedar.NewEntityUID("Folder", "a")).When(ast.True()).Unless(ast.Principal()),
+		ast.Permit().When(ast.Long(1)),
+		ast.Permit().When(ast.Context().Has("x")),
+		ast.Permit().When(ast.Context().In(ast.EntityUID("Folder", "a"))),
+		ast.Permit().When(ast.Resource().In(ast.Context())),
+		ast.Permit().When(ast.Resource().In(ast.Value(cedar.NewSet(cedar.Long(1))))),
+		ast.Permit().When(ast.Resource().In(ast.Long(1))),
+		ast.Permit().When(ast.Context().Is("Doc")),
+		ast.Permit().When(ast.Context().IsIn("Doc", ast.EntityUID("Folder", "a"))),
+		ast.Permit().When(ast.Resource().IsIn("Doc", ast.Context())),
+		ast.Permit().When(ast.Long(1).Contains(ast.Long(1))),
+		ast.Permit().When(ast.Resource().Access("tags").ContainsAll(ast.Long(1))),
+		ast.Permit().When(ast.Context().Equal(ast.Long(1))),
+		ast.Permit().When(ast.Context().Access("x").Equal(ast.Long(1))),
+		ast.Permit().When(ast.Long(1).And(ast.True())),
+		ast.Permit().When(ast.Context().Access("x")),
+	} {
+		_, err := sqlfilter.Policy(p, documents)
+		testutil.ErrorIs(t, err, sqlfilter.ErrUnsupported)
+	}
+}
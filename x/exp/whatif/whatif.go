@@ -0,0 +1,220 @@
+// Package whatif explains what would have to change for a denied request to be allowed, e.g. "allowed if principal in
+// Group::\"editors\"" or "allowed if context.mfa == true".  This lets an application tell a user which group to join,
+// or which step to take, in order to gain access.
+//
+// The caller chooses which parts of the request may change: the ancestors, attributes and tags of some entities, and
+// some attributes of the context.  The policies are partially evaluated with those parts unknown, and each residual
+// permit policy is reduced to the constraints and conditions which do not already hold, together with the negation of
+// each residual forbid policy which currently applies.
+//
+// The analysis considers each policy separately.  Meeting the requirements of an Alternative may make a forbid policy
+// which does not currently apply start to apply, and an Alternative's requirements may contradict one another.
+package whatif
+
+import (
+	"bytes"
+	"maps"
+	"slices"
+	"strings"
+
+	"github.com/cedar-policy/cedar-go"
+	"github.com/cedar-policy/cedar-go/internal/eval"
+	"github.com/cedar-policy/cedar-go/internal/parser"
+	"github.com/cedar-policy/cedar-go/types"
+	"github.com/cedar-policy/cedar-go/x/exp/ast"
+)
+
+// Changes lists the parts of a request which may differ from their current values.
+type Changes struct {
+	// Entities lists the entities whose ancestors, attributes and tags may change, e.g. the principal, to find the
+	// groups it could join.
+	Entities []types.EntityUID
+
+	// Context lists the attributes of the context which may change, or be added if the context does not have them.
+	Context []types.String
+}
+
+// A Requirement is a Cedar expression which does not currently evaluate to true, but must for the request to be
+// allowed.
+type Requirement struct {
+	Expression ast.IsNode
+}
+
+// String returns the Cedar text of the expression.
+func (r Requirement) String() string {
+	var buf bytes.Buffer
+	parser.MarshalNode(r.Expression, &buf)
+	return buf.String()
+}
+
+// An Alternative is a set of requirements which together would allow the request.
+type Alternative struct {
+	// Policies are the permit policies which would allow the request.
+	Policies []cedar.PolicyID
+
+	// Requirements must all hold.  It is empty if the request is already allowed.
+	Requirements []Requirement
+}
+
+// String returns the Cedar text of the conjunction of the requirements, or "true" if there are none.
+func (a Alternative) String() string {
+	if len(a.Requirements) == 0 {
+		return "true"
+	}
+	var s []string
+	for _, r := range a.Requirements {
+		s = append(s, r.String())
+	}
+	return strings.Join(s, " && ")
+}
+
+// Explain returns the minimal alternatives under which the request would be allowed, given the parts of it which may
+// change, in order of their number of requirements.  An alternative is left out if another one has a subset of its
+// requirements.  It returns nil if no change would allow the request, e.g. because a forbid policy applies regardless
+// of the parts which may change.
+func Explain(policies cedar.PolicyIterator, entities types.EntityGetter, req cedar.Request, changes Changes) []Alternative {
+	if entities == nil {
+		var zero types.EntityMap
+		entities = zero
+	}
+	context := req.Context.Map()
+	if context == nil {
+		context = types.RecordMap{}
+	}
+	for _, k := range changes.Context {
+		context[k] = cedar.Unknown()
+	}
+	res := cedar.AuthorizePartial(policies, entities, cedar.PartialRequest{
+		Principal:       req.Principal,
+		Action:          req.Action,
+		Resource:        req.Resource,
+		Context:         types.NewRecord(context),
+		UnknownEntities: changes.Entities,
+	})
+
+	env := eval.Env{
+		Entities:  entities,
+		Principal: req.Principal,
+		Action:    req.Action,
+		Resource:  req.Resource,
+		Context:   req.Context,
+	}
+	var permits []Alternative
+	var forbids []Requirement
+	for _, id := range slices.Sorted(maps.Keys(res.Residuals)) {
+		p := (*ast.Policy)(res.Residuals[id].AST())
+		parts := policyParts(p)
+		if p.Effect == ast.EffectPermit {
+			permits = append(permits, Alternative{Policies: []cedar.PolicyID{id}, Requirements: unmet(env, parts)})
+			continue
+		}
+		if len(parts) == 0 {
+			return nil
+		}
+		if len(unmet(env, parts)) == 0 {
+			forbids = append(forbids, Requirement{Expression: not(and(parts))})
+		}
+	}
+
+	var alts []Alternative
+	for _, a := range permits {
+		a.Requirements = dedupe(append(a.Requirements, forbids...))
+		alts = merge(alts, a)
+	}
+	var minimal []Alternative
+	for _, a := range alts {
+		if !slices.ContainsFunc(alts, func(b Alternative) bool { return isStrictSubset(b, a) }) {
+			minimal = append(minimal, a)
+		}
+	}
+	slices.SortStableFunc(minimal, func(a, b Alternative) int {
+		return len(a.Requirements) - len(b.Requirements)
+	})
+	return minimal
+}
+
+// policyParts returns the scope constraints and conditions of a policy as expressions which must all evaluate to true
+// for it to apply.
+func policyParts(p *ast.Policy) []ast.IsNode {
+	parts := eval.ScopeNodes(p)
+	for _, c := range p.Conditions {
+		if c.Condition == ast.ConditionWhen {
+			parts = append(parts, c.Body)
+		} else {
+			parts = append(parts, not(c.Body))
+		}
+	}
+	return parts
+}
+
+// unmet splits the expressions into their conjuncts and returns those which do not evaluate to true.
+func unmet(env eval.Env, nodes []ast.IsNode) []Requirement {
+	var res []Requirement
+	for _, n := range nodes {
+		if a, ok := n.(ast.NodeTypeAnd); ok {
+			res = append(res, unmet(env, []ast.IsNode{a.Left, a.Right})...)
+			continue
+		}
+		if r := eval.EvalNode(env, n); r.Err != nil || r.Value != types.True {
+			res = append(res, Requirement{Expression: n})
+		}
+	}
+	return res
+}
+
+// not returns the negation of an expression, removing a double negation.
+func not(n ast.IsNode) ast.IsNode {
+	if n, ok := n.(ast.NodeTypeNot); ok {
+		return n.Arg
+	}
+	return ast.NodeTypeNot{UnaryNode: ast.UnaryNode{Arg: n}}
+}
+
+// and returns the conjunction of one or more expressions.
+func and(nodes []ast.IsNode) ast.IsNode {
+	res := nodes[0]
+	for _, n := range nodes[1:] {
+		res = ast.NodeTypeAnd{BinaryNode: ast.BinaryNode{Left: res, Right: n}}
+	}
+	return res
+}
+
+// dedupe removes the requirements whose Cedar text repeats that of an earlier one.
+func dedupe(reqs []Requirement) []Requirement {
+	seen := map[string]bool{}
+	var res []Requirement
+	for _, r := range reqs {
+		if s := r.String(); !seen[s] {
+			seen[s] = true
+			res = append(res, r)
+		}
+	}
+	return res
+}
+
+// merge adds an alternative to a list, adding its policies to an alternative which has the same requirements if there
+// is one.
+func merge(alts []Alternative, a Alternative) []Alternative {
+	for i, b := range alts {
+		if len(b.Requirements) == len(a.Requirements) && isSubset(a, b) {
+			alts[i].Policies = append(alts[i].Policies, a.Policies...)
+			return alts
+		}
+	}
+	return append(alts, a)
+}
+
+// isSubset reports whether each requirement of a is a requirement of b.
+func isSubset(a, b Alternative) bool {
+	for _, r := range a.Requirements {
+		if !slices.ContainsFunc(b.Requirements, func(s Requirement) bool { return s.String() == r.String() }) {
+			return false
+		}
+	}
+	return true
+}
+
+// isStrictSubset reports whether a has fewer requirements than b and each of them is a requirement of b.
+func isStrictSubset(a, b Alternative) bool {
+	return len(a.Requirements) < len(b.Requirements) && isSubset(a, b)
+}
@@ -0,0 +1,159 @@
+package whatif_test
+
+import (
+	"testing"
+
+	"github.com/cedar-policy/cedar-go"
+	"github.com/cedar-policy/cedar-go/internal/testutil"
+	"github.com/cedar-policy/cedar-go/x/exp/ast"
+	"github.com/cedar-policy/cedar-go/x/exp/whatif"
+)
+
+func TestExplain(t *testing.T) {
+	t.Parallel()
+	alice := cedar.NewEntityUID("User", "alice")
+	doc := cedar.NewEntityUID("Doc", "plan")
+	entities := cedar.EntityMap{
+		alice: {UID: alice, Attributes: cedar.NewRecord(cedar.RecordMap{"level": cedar.Long(5)})},
+		doc:   {UID: doc, Attributes: cedar.NewRecord(cedar.RecordMap{"owner": alice})},
+	}
+	req := cedar.Request{
+		Principal: alice,
+		Action:    cedar.NewEntityUID("Action", "edit"),
+		Resource:  doc,
+		Context:   cedar.NewRecord(cedar.RecordMap{"mfa": cedar.False}),
+	}
+	principal := whatif.Changes{Entities: []cedar.EntityUID{alice}}
+	mfa := whatif.Changes{Context: []cedar.String{"mfa"}}
+	tests := []struct {
+		name     string
+		policies string
+		changes  whatif.Changes
+		out      []string
+	}{
+		{"group",
+			`permit(principal in Group::"editors", action == Action::"edit", resource);`,
+			principal,
+			[]string{`principal in Group::"editors"`},
+		},
+		{"context",
+			`permit(principal, action, resource) when { context.mfa == true };`,
+			mfa,
+			[]string{`context.mfa == true`},
+		},
+		{"contextRecord",
+			`permit(principal, action, resource) when { context == {"mfa": true} };`,
+			mfa,
+			[]string{`context == {"mfa":true}`},
+		},
+		{"forbidContextRecord",
+			`permit(principal, action, resource);
+forbid(principal, action, resource) when { context == {"mfa": false} };`,
+			mfa,
+			[]string{`!(context == {"mfa":false})`},
+		},
+		{"newContextAttribute",
+			`permit(principal, action, resource) when { context.ip like "10.*" };`,
+			whatif.Changes{Context: []cedar.String{"ip"}},
+			[]string{`context.ip like "10.*"`},
+		},
+		{"heldConjunctsLeftOut",
+			`permit(principal, action, resource) when { principal.level > 3 && principal.clearance == "top" && resource.owner == principal };`,
+			principal,
+			[]string{`principal.clearance == "top"`},
+		},
+		{"unless",
+			`permit(principal, action, resource) unless { principal in Group::"interns" };`,
+			principal,
+			[]string{`true`},
+		},
+		{"alreadyAllowed",
+			`permit(principal, action, resource);`,
+			principal,
+			[]string{`true`},
+		},
+		{"unchangeable",
+			`permit(principal, action, resource) when { context.mfa == true };`,
+			principal,
+			nil,
+		},
+		{"forbidApplies",
+			`permit(principal, action, resource);
+forbid(principal, action, resource) unless { context.mfa };`,
+			principal,
+			nil,
+		},
+		{"forbidNegated",
+			`permit(principal in Group::"editors", action, resource);
+forbid(principal, action, resource) unless { principal in Group::"staff" };`,
+			principal,
+			[]string{`principal in Group::"editors" && principal in Group::"staff"`},
+		},
+		{"forbidDoubleNegation",
+			`permit(principal, action, resource) when { context.mfa };
+forbid(principal == User::"alice", action, resource) when { !context.mfa };`,
+			mfa,
+			[]string{`context.mfa`},
+		},
+		{"forbidNegatedConjunction",
+			`permit(principal, action, resource) when { context.mfa };
+forbid(principal, action, resource) when { principal.level > 3 } when { !context.mfa };`,
+			whatif.Changes{Entities: []cedar.EntityUID{alice}, Context: []cedar.String{"mfa"}},
+			[]string{`context.mfa && !(principal.level > 3 && !context.mfa)`},
+		},
+		{"forbidNotApplying",
+			`permit(principal, action, resource) when { context.mfa };
+forbid(principal, action, resource) when { context.mfa && principal == User::"bob" };`,
+			mfa,
+			[]string{`context.mfa`},
+		},
+		{"merged",
+			`permit(principal in Group::"editors", action, resource);
+permit(principal, action, resource) when { principal in Group::"editors" };`,
+			principal,
+			[]string{`principal in Group::"editors"`},
+		},
+		{"supersetLeftOut",
+			`permit(principal in Group::"editors", action, resource) when { context.mfa };
+permit(principal, action, resource) when { context.mfa };
+permit(principal in Group::"admins", action, resource);`,
+			whatif.Changes{Entities: []cedar.EntityUID{alice}, Context: []cedar.String{"mfa"}},
+			[]string{`context.mfa`, `principal in Group::"admins"`},
+		},
+		{"errorIsUnmet",
+			`permit(principal, action, resource) when { principal.missing == 1 };`,
+			principal,
+			[]string{`principal.missing == 1`},
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			ps, err := cedar.NewPolicySetFromBytes("policy.cedar", []byte(tt.policies))
+			testutil.OK(t, err)
+			var out []string
+			for _, a := range whatif.Explain(ps, entities, req, tt.changes) {
+				out = append(out, a.String())
+			}
+			testutil.Equals(t, out, tt.out)
+		})
+	}
+}
+
+func TestExplainAlternative(t *testing.T) {
+	t.Parallel()
+	ps, err := cedar.NewPolicySetFromBytes("policy.cedar", []byte(`
+permit(principal in Group::"editors", action, resource);
+permit(principal, action, resource) when { principal in Group::"editors" };
+`))
+	testutil.OK(t, err)
+	alice := cedar.NewEntityUID("User", "alice")
+	alts := whatif.Explain(ps, nil, cedar.Request{Principal: alice}, whatif.Changes{Entities: []cedar.EntityUID{alice}})
+	testutil.Equals(t, alts, []whatif.Alternative{{
+		Policies: []cedar.PolicyID{"policy0", "policy1"},
+		Requirements: []whatif.Requirement{
+			{Expression: ast.Principal().In(ast.EntityUID("Group", "editors")).AsIsNode()},
+		},
+	}})
+}